@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"github.com/Jacobbrewer1/wolf/pkg/request"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// openAPISpec is the OpenAPI specification of the admin API.
+//
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// registerAPIRoutes registers the routes of the admin API on the router. Every route requires either the bearer
+// token, or a client certificate that was verified by the server.
+func (a *App) registerAPIRoutes(r *mux.Router, token string) {
+	r.Use(apiAuth(token), a.requireStarted)
+
+	r.HandleFunc("/openapi.yaml", middlewareHttp(getOpenAPISpec)).Methods(http.MethodGet)
+
+	r.HandleFunc("/guilds/{guild_id}/ticketing", middlewareHttp(a.getTicketingConfig())).Methods(http.MethodGet)
+	r.HandleFunc("/guilds/{guild_id}/ticketing", middlewareHttp(a.updateTicketingConfig())).Methods(http.MethodPut)
+
+	r.HandleFunc("/guilds/{guild_id}/tickets", middlewareHttp(a.listTickets())).Methods(http.MethodGet)
+	r.HandleFunc("/guilds/{guild_id}/tickets/{ticket_id}", middlewareHttp(a.getTicket())).Methods(http.MethodGet)
+	r.HandleFunc("/guilds/{guild_id}/tickets/{ticket_id}", middlewareHttp(a.forceDeleteTicket())).Methods(http.MethodDelete)
+	r.HandleFunc("/guilds/{guild_id}/tickets/{ticket_id}/history", middlewareHttp(a.getTicketHistory())).Methods(http.MethodGet)
+	r.HandleFunc("/guilds/{guild_id}/tickets/{ticket_id}/transcript", middlewareHttp(a.getTicketTranscript())).Methods(http.MethodGet)
+	r.HandleFunc("/guilds/{guild_id}/tickets/{ticket_id}/close", middlewareHttp(a.forceCloseTicket())).Methods(http.MethodPost)
+}
+
+// apiAuth only allows the requests that present the bearer token, or a client certificate that was verified by the
+// server.
+func apiAuth(token string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The request ID is set before the handler is reached, so it is in the response when it is rejected.
+			r = request.WithRequestID(w, r)
+
+			if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if token != "" && hasBearerToken(r, token) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+AppName+`"`)
+			request.RespondError(w, r, http.StatusUnauthorized, "Unauthorized")
+		})
+	}
+}
+
+// serverTLSConfig returns the TLS configuration of the monitoring server. Client certificates signed by the CA are
+// verified if presented, so the admin API can authenticate them, while the other endpoints stay open to clients
+// without a certificate.
+func serverTLSConfig(clientCAFile string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if clientCAFile == "" {
+		return cfg, nil
+	}
+
+	pem, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading client CA: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates found in client CA")
+	}
+
+	cfg.ClientCAs = pool
+	cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	return cfg, nil
+}
+
+// getOpenAPISpec returns the OpenAPI specification of the admin API.
+func getOpenAPISpec(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	if _, err := w.Write(openAPISpec); err != nil {
+		slog.Error("Error writing response", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// writeAPIInternalError logs the error and writes a generic message to the response, so the details are not leaked.
+func writeAPIInternalError(w http.ResponseWriter, r *http.Request, err error) {
+	logging.FromContext(r.Context()).Error("Error handling API request",
+		slog.String(logging.KeyError, err.Error()),
+		slog.String("path", r.URL.Path),
+	)
+	request.RespondInternalError(w, r)
+}
+
+// isNotFound returns true if the error is from a document or a Discord resource that does not exist.
+func isNotFound(err error) bool {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return true
+	}
+
+	restErr := new(discordgo.RESTError)
+	return errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound
+}
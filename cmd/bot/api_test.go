@@ -0,0 +1,668 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/request"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const testAPIToken = "secret"
+
+// fakeGuildDal is an in memory GuildDal. The updates are recorded, but not applied.
+type fakeGuildDal struct {
+	mut    sync.Mutex
+	guilds map[string]entities.Guild
+
+	// updates are the updates made to the guilds.
+	updates []*dataaccess.GuildUpdate
+}
+
+func (f *fakeGuildDal) SaveGuild(_ context.Context, guild *entities.Guild) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	f.guilds[guild.ID] = *guild
+	return nil
+}
+
+func (f *fakeGuildDal) UpdateGuild(_ context.Context, _ string, update *dataaccess.GuildUpdate) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	f.updates = append(f.updates, update)
+	return nil
+}
+
+func (f *fakeGuildDal) GetGuildByID(_ context.Context, id string) (*entities.Guild, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	guild, ok := f.guilds[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &guild, nil
+}
+
+// fakeTicketDal is an in memory TicketDal, keyed by the number of the ticket.
+type fakeTicketDal struct {
+	mut     sync.Mutex
+	tickets map[int]entities.Ticket
+
+	// saveErr is returned by SaveTicket, if it is set.
+	saveErr error
+}
+
+func (f *fakeTicketDal) SaveTicket(_ context.Context, ticket *entities.Ticket) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	if f.saveErr != nil {
+		return f.saveErr
+	}
+	f.tickets[ticket.ID] = *ticket
+	return nil
+}
+
+func (f *fakeTicketDal) GetTicket(_ context.Context, guildID string, channelID string) (*entities.Ticket, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	for _, ticket := range f.tickets {
+		if ticket.GuildID == guildID && ticket.ChannelID == channelID && !ticket.Deleted {
+			return &ticket, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (f *fakeTicketDal) GetLatestTicket(_ context.Context, _ string) (*entities.Ticket, error) {
+	return nil, mongo.ErrNoDocuments
+}
+
+func (f *fakeTicketDal) GetTicketByID(_ context.Context, guildID string, id int) (*entities.Ticket, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	ticket, ok := f.tickets[id]
+	if !ok || ticket.GuildID != guildID {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &ticket, nil
+}
+
+func (f *fakeTicketDal) ListTickets(_ context.Context, guildID string, filter *dataaccess.TicketFilter) ([]*entities.Ticket, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	tickets := make([]*entities.Ticket, 0)
+	for _, ticket := range f.tickets {
+		ticket := ticket
+		if ticket.GuildID != guildID ||
+			(filter.Status != "" && ticket.Status() != filter.Status) ||
+			(filter.UserID != "" && ticket.UserID != filter.UserID) ||
+			(filter.ClaimedBy != "" && ticket.ClaimedBy != filter.ClaimedBy) {
+			continue
+		}
+		tickets = append(tickets, &ticket)
+	}
+
+	sort.Slice(tickets, func(i, j int) bool {
+		return tickets[i].ID > tickets[j].ID
+	})
+	return tickets, nil
+}
+
+// fakeDiscord answers the requests made to the Discord API with canned responses, keyed by the method and the path
+// without the API prefix. The requests without a response get a 404.
+type fakeDiscord map[string]string
+
+func (f fakeDiscord) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := strings.TrimPrefix(req.URL.Path, "/api/v"+discordgo.APIVersion)
+
+	status := http.StatusOK
+	body, ok := f[req.Method+" "+path]
+	if !ok {
+		status = http.StatusNotFound
+		body = `{"message":"Unknown Channel","code":10003}`
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// fakeScheduledActionDal is an in memory ScheduledActionDal.
+type fakeScheduledActionDal struct {
+	mut     sync.Mutex
+	actions []entities.ScheduledAction
+
+	// scheduleErr is returned by ScheduleAction, if it is set.
+	scheduleErr error
+}
+
+func (f *fakeScheduledActionDal) ScheduleAction(_ context.Context, action *entities.ScheduledAction) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	if f.scheduleErr != nil {
+		return f.scheduleErr
+	}
+	action.ID = primitive.NewObjectID()
+	f.actions = append(f.actions, *action)
+	return nil
+}
+
+func (f *fakeScheduledActionDal) SaveScheduledAction(_ context.Context, action *entities.ScheduledAction) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	for i := range f.actions {
+		if f.actions[i].ID == action.ID {
+			f.actions[i] = *action
+		}
+	}
+	return nil
+}
+
+func (f *fakeScheduledActionDal) DueScheduledActions(_ context.Context, at time.Time, _ int64) ([]*entities.ScheduledAction, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	due := make([]*entities.ScheduledAction, 0)
+	for _, action := range f.actions {
+		if action.Status == entities.ScheduledActionPending && !time.Time(action.RunAt).After(at) {
+			cp := action
+			due = append(due, &cp)
+		}
+	}
+	return due, nil
+}
+
+func (f *fakeScheduledActionDal) CancelScheduledActions(_ context.Context, guildID, targetID string, actionType entities.ScheduledActionType, except ...primitive.ObjectID) (int64, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	var n int64
+	for i, action := range f.actions {
+		if action.GuildID == guildID && action.TargetID == targetID && action.Type == actionType && action.Status == entities.ScheduledActionPending && !slices.Contains(except, action.ID) {
+			f.actions[i].Status = entities.ScheduledActionCancelled
+			n++
+		}
+	}
+	return n, nil
+}
+
+// newTestAPI creates the admin API with in memory DALs and a fake Discord API.
+func newTestAPI(t *testing.T, discord fakeDiscord) (http.Handler, *fakeGuildDal, *fakeTicketDal) {
+	t.Helper()
+
+	guilds := &fakeGuildDal{
+		guilds: map[string]entities.Guild{
+			"100": {
+				ID: "100",
+				Ticketing: entities.TicketingConfig{
+					Enabled:                 true,
+					ChannelID:               "200",
+					RoleID:                  "300",
+					OpenMessageID:           "400",
+					ClosedTicketsCategoryID: "500",
+				},
+			},
+		},
+	}
+
+	tickets := &fakeTicketDal{
+		tickets: map[int]entities.Ticket{
+			1: {ID: 1, GuildID: "100", ChannelID: "601", UserID: "701", Username: "alice"},
+			2: {ID: 2, GuildID: "100", ChannelID: "602", UserID: "702", Username: "bob", ClaimedBy: "703"},
+			3: {ID: 3, GuildID: "100", ChannelID: "603", UserID: "701", Username: "alice", ClaimedBy: "703", ClosedBy: "701"},
+		},
+	}
+	tickets.tickets[1] = withHistory(tickets.tickets[1], entities.TicketEventCreated, "701")
+
+	prevGuilds, prevTickets, prevActions := dataaccess.GuildDB, dataaccess.TicketDB, dataaccess.ScheduledActionDB
+	dataaccess.GuildDB, dataaccess.TicketDB, dataaccess.ScheduledActionDB = guilds, tickets, new(fakeScheduledActionDal)
+	t.Cleanup(func() {
+		dataaccess.GuildDB, dataaccess.TicketDB, dataaccess.ScheduledActionDB = prevGuilds, prevTickets, prevActions
+	})
+
+	s, err := discordgo.New("Bot token")
+	require.NoError(t, err)
+	s.Client = &http.Client{Transport: discord}
+
+	a := &App{
+		Logger:  slog.Default(),
+		r:       mux.NewRouter(),
+		s:       s,
+		startup: newStartupState(),
+	}
+	a.startup.complete()
+	a.registerAPIRoutes(a.r.PathPrefix(PathAPI).Subrouter(), testAPIToken)
+	return a.r, guilds, tickets
+}
+
+func withHistory(ticket entities.Ticket, eventType entities.TicketEventType, userID string) entities.Ticket {
+	ticket.AddEvent(eventType, userID, entities.TicketEventSourceDiscord)
+	return ticket
+}
+
+// doAPIRequest makes an authenticated request to the admin API.
+func doAPIRequest(handler http.Handler, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+
+	req := httptest.NewRequest(method, PathAPI+path, reader)
+	req.Header.Set("Authorization", "Bearer "+testAPIToken)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAPI_Auth(t *testing.T) {
+	handler, _, _ := newTestAPI(t, fakeDiscord{})
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{name: "no token", header: "", want: http.StatusUnauthorized},
+		{name: "wrong token", header: "Bearer wrong", want: http.StatusUnauthorized},
+		{name: "wrong scheme", header: "Basic " + testAPIToken, want: http.StatusUnauthorized},
+		{name: "valid token", header: "Bearer " + testAPIToken, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, PathAPI+"/guilds/100/ticketing", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.want, rec.Code)
+			require.NotEmpty(t, rec.Header().Get(request.HeaderRequestID))
+			if tt.want == http.StatusUnauthorized {
+				require.Contains(t, rec.Header().Get("WWW-Authenticate"), "Bearer")
+
+				got := new(request.ErrorResponse)
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(got))
+				require.Equal(t, "unauthorized", got.Code)
+				require.Equal(t, rec.Header().Get(request.HeaderRequestID), got.RequestID)
+			}
+		})
+	}
+}
+
+func TestAPI_GetTicketingConfig(t *testing.T) {
+	handler, _, _ := newTestAPI(t, fakeDiscord{})
+
+	rec := doAPIRequest(handler, http.MethodGet, "/guilds/100/ticketing", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	got := new(apiTicketingConfig)
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(got))
+	require.True(t, got.Enabled)
+	require.Equal(t, "200", got.ChannelID)
+	require.Equal(t, "400", got.OpenMessageID)
+
+	rec = doAPIRequest(handler, http.MethodGet, "/guilds/100/ticketing", "", "Accept", "application/xml")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/xml", rec.Header().Get("Content-Type"))
+
+	got = new(apiTicketingConfig)
+	require.NoError(t, xml.NewDecoder(rec.Body).Decode(got))
+	require.Equal(t, "300", got.RoleID)
+
+	rec = doAPIRequest(handler, http.MethodGet, "/guilds/999/ticketing", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestAPI_UpdateTicketingConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		guildID     string
+		body        string
+		contentType string
+		want        int
+	}{
+		{
+			name:    "update",
+			guildID: "100",
+			body:    `{"enabled":true,"channel_id":"201","role_id":"301","open_message_id":"999"}`,
+			want:    http.StatusOK,
+		},
+		{
+			name:    "create",
+			guildID: "101",
+			body:    `{"enabled":false}`,
+			want:    http.StatusOK,
+		},
+		{
+			name:        "xml",
+			guildID:     "100",
+			body:        `<ticketing><enabled>true</enabled><channel_id>201</channel_id><role_id>301</role_id></ticketing>`,
+			contentType: "application/xml",
+			want:        http.StatusOK,
+		},
+		{
+			name:    "enabled without channel",
+			guildID: "100",
+			body:    `{"enabled":true,"role_id":"301"}`,
+			want:    http.StatusBadRequest,
+		},
+		{
+			name:    "invalid ID",
+			guildID: "100",
+			body:    `{"channel_id":"general"}`,
+			want:    http.StatusBadRequest,
+		},
+		{
+			name:    "unknown field",
+			guildID: "100",
+			body:    `{"enable":true}`,
+			want:    http.StatusBadRequest,
+		},
+		{
+			name:        "unsupported content type",
+			guildID:     "100",
+			body:        `enabled=true`,
+			contentType: "application/x-www-form-urlencoded",
+			want:        http.StatusUnsupportedMediaType,
+		},
+		{
+			name:    "invalid guild",
+			guildID: "guild",
+			body:    `{}`,
+			want:    http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, guilds, _ := newTestAPI(t, fakeDiscord{})
+
+			var headers []string
+			if tt.contentType != "" {
+				headers = []string{"Content-Type", tt.contentType}
+			}
+
+			rec := doAPIRequest(handler, http.MethodPut, "/guilds/"+tt.guildID+"/ticketing", tt.body, headers...)
+			require.Equal(t, tt.want, rec.Code, rec.Body.String())
+			if tt.want != http.StatusOK {
+				return
+			}
+
+			guild, err := guilds.GetGuildByID(context.Background(), tt.guildID)
+			require.NoError(t, err)
+			if tt.guildID == "100" {
+				require.Equal(t, "201", guild.Ticketing.ChannelID)
+				require.Equal(t, "301", guild.Ticketing.RoleID)
+				require.Equal(t, "400", guild.Ticketing.OpenMessageID, "the open message is managed by the bot")
+			}
+		})
+	}
+}
+
+func TestAPI_ListTickets(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  int
+		ids   []int
+	}{
+		{name: "all", query: "", want: http.StatusOK, ids: []int{3, 2, 1}},
+		{name: "open", query: "?status=open", want: http.StatusOK, ids: []int{1}},
+		{name: "claimed", query: "?status=claimed", want: http.StatusOK, ids: []int{2}},
+		{name: "closed by user", query: "?status=closed&user_id=701", want: http.StatusOK, ids: []int{3}},
+		{name: "claimed by", query: "?claimed_by=703", want: http.StatusOK, ids: []int{3, 2}},
+		{name: "invalid status", query: "?status=archived", want: http.StatusBadRequest},
+		{name: "open and claimed by", query: "?status=open&claimed_by=703", want: http.StatusBadRequest},
+		{name: "limit too large", query: "?limit=101", want: http.StatusBadRequest},
+		{name: "negative offset", query: "?offset=-1", want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _, _ := newTestAPI(t, fakeDiscord{})
+
+			rec := doAPIRequest(handler, http.MethodGet, "/guilds/100/tickets"+tt.query, "")
+			require.Equal(t, tt.want, rec.Code, rec.Body.String())
+			if tt.want != http.StatusOK {
+				return
+			}
+
+			got := new(apiTickets)
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(got))
+			require.EqualValues(t, defaultTicketPageSize, got.Limit)
+
+			ids := make([]int, 0, len(got.Tickets))
+			for _, ticket := range got.Tickets {
+				ids = append(ids, ticket.ID)
+			}
+			require.Equal(t, tt.ids, ids)
+		})
+	}
+}
+
+func TestAPI_GetTicket(t *testing.T) {
+	handler, _, _ := newTestAPI(t, fakeDiscord{})
+
+	rec := doAPIRequest(handler, http.MethodGet, "/guilds/100/tickets/2", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	got := new(apiTicket)
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(got))
+	require.Equal(t, 2, got.ID)
+	require.Equal(t, entities.TicketStatusClaimed, got.Status)
+	require.Equal(t, "703", got.ClaimedBy)
+
+	rec = doAPIRequest(handler, http.MethodGet, "/guilds/100/tickets/9", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doAPIRequest(handler, http.MethodGet, "/guilds/100/tickets/abc", "")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestAPI_GetTicketHistory(t *testing.T) {
+	handler, _, _ := newTestAPI(t, fakeDiscord{})
+
+	rec := doAPIRequest(handler, http.MethodGet, "/guilds/100/tickets/1/history", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	got := new(apiTicketHistory)
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(got))
+	require.Equal(t, 1, got.TicketID)
+	require.Len(t, got.Events, 1)
+	require.Equal(t, entities.TicketEventCreated, got.Events[0].Type)
+	require.Equal(t, "701", got.Events[0].UserID)
+	require.Equal(t, entities.TicketEventSourceDiscord, got.Events[0].Source)
+}
+
+func TestAPI_GetTicketTranscript(t *testing.T) {
+	// Discord returns the messages newest first.
+	handler, _, _ := newTestAPI(t, fakeDiscord{
+		"GET /channels/601/messages": `[
+			{"id":"2","content":"How can we help?","timestamp":"2024-01-01T10:01:00Z","author":{"id":"703","username":"carol"}},
+			{"id":"1","content":"Hello","timestamp":"2024-01-01T10:00:00Z","author":{"id":"701","username":"alice"},
+			 "attachments":[{"id":"9","url":"https://cdn.example.com/a.png"}]}
+		]`,
+	})
+
+	rec := doAPIRequest(handler, http.MethodGet, "/guilds/100/tickets/1/transcript", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	got := new(apiTranscript)
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(got))
+	require.False(t, got.Truncated)
+	require.Len(t, got.Messages, 2)
+	require.Equal(t, "Hello", got.Messages[0].Content)
+	require.Equal(t, "alice", got.Messages[0].Author)
+	require.Equal(t, []string{"https://cdn.example.com/a.png"}, got.Messages[0].Attachments)
+	require.Equal(t, "How can we help?", got.Messages[1].Content)
+
+	rec = doAPIRequest(handler, http.MethodGet, "/guilds/100/tickets/1/transcript", "", "Accept", "text/plain")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "text/plain", rec.Header().Get("Content-Type"))
+	require.Contains(t, rec.Body.String(), "alice: Hello\n\tattachment: https://cdn.example.com/a.png\n")
+
+	// The channel of the second ticket no longer exists.
+	rec = doAPIRequest(handler, http.MethodGet, "/guilds/100/tickets/2/transcript", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestAPI_CloseTicket(t *testing.T) {
+	tests := []struct {
+		name     string
+		ticketID string
+		body     string
+		want     int
+	}{
+		{name: "close", ticketID: "2", body: `{"user_id":"703"}`, want: http.StatusOK},
+		{name: "user required", ticketID: "2", body: ``, want: http.StatusBadRequest},
+		{name: "invalid user", ticketID: "2", body: `{"user_id":"carol"}`, want: http.StatusBadRequest},
+		{name: "already closed", ticketID: "3", body: `{"user_id":"703"}`, want: http.StatusConflict},
+		{name: "not found", ticketID: "9", body: `{"user_id":"703"}`, want: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _, tickets := newTestAPI(t, fakeDiscord{
+				"GET /channels/602":   `{"id":"602","parent_id":"510","position":1}`,
+				"GET /channels/603":   `{"id":"603","parent_id":"500","position":1}`,
+				"GET /channels/500":   `{"id":"500","type":4}`,
+				"PATCH /channels/602": `{"id":"602","parent_id":"500"}`,
+			})
+
+			rec := doAPIRequest(handler, http.MethodPost, "/guilds/100/tickets/"+tt.ticketID+"/close", tt.body)
+			require.Equal(t, tt.want, rec.Code, rec.Body.String())
+			if tt.want != http.StatusOK {
+				return
+			}
+
+			got := new(apiTicket)
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(got))
+			require.Equal(t, entities.TicketStatusClosed, got.Status)
+
+			ticket, err := tickets.GetTicketByID(context.Background(), "100", 2)
+			require.NoError(t, err)
+			require.Equal(t, "703", ticket.ClosedBy)
+			require.Len(t, ticket.History, 1)
+			require.Equal(t, entities.TicketEventClosed, ticket.History[0].Type)
+			require.Equal(t, entities.TicketEventSourceAPI, ticket.History[0].Source)
+		})
+	}
+}
+
+func TestAPI_DeleteTicket(t *testing.T) {
+	handler, _, tickets := newTestAPI(t, fakeDiscord{})
+
+	rec := doAPIRequest(handler, http.MethodDelete, "/guilds/100/tickets/1", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	ticket, err := tickets.GetTicketByID(context.Background(), "100", 1)
+	require.NoError(t, err)
+	require.True(t, ticket.Deleted)
+	require.Equal(t, entities.TicketStatusDeleted, ticket.Status())
+	require.Equal(t, entities.TicketEventDeleted, ticket.History[len(ticket.History)-1].Type)
+
+	// The channel is deleted later by the scheduled action worker.
+	actions := dataaccess.ScheduledActionDB.(*fakeScheduledActionDal).actions
+	require.Len(t, actions, 1)
+	require.Equal(t, entities.ScheduledActionDeleteChannel, actions[0].Type)
+	require.Equal(t, "601", actions[0].TargetID)
+	require.Equal(t, entities.ScheduledActionPending, actions[0].Status)
+	require.WithinDuration(t, time.Now().Add(ticketChannelDeleteDelay), time.Time(actions[0].RunAt), 5*time.Second)
+
+	rec = doAPIRequest(handler, http.MethodDelete, "/guilds/100/tickets/1", "")
+	require.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestAPI_DeleteTicket_Errors(t *testing.T) {
+	t.Run("scheduling fails", func(t *testing.T) {
+		handler, _, tickets := newTestAPI(t, fakeDiscord{})
+		actions := dataaccess.ScheduledActionDB.(*fakeScheduledActionDal)
+		actions.scheduleErr = errors.New("schedule failed")
+
+		rec := doAPIRequest(handler, http.MethodDelete, "/guilds/100/tickets/1", "")
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+		// The ticket is still open, so the delete can be retried.
+		ticket, err := tickets.GetTicketByID(context.Background(), "100", 1)
+		require.NoError(t, err)
+		require.False(t, ticket.Deleted)
+
+		actions.scheduleErr = nil
+		rec = doAPIRequest(handler, http.MethodDelete, "/guilds/100/tickets/1", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		require.Len(t, actions.actions, 1)
+	})
+
+	t.Run("saving fails", func(t *testing.T) {
+		handler, _, tickets := newTestAPI(t, fakeDiscord{})
+		tickets.saveErr = errors.New("save failed")
+
+		rec := doAPIRequest(handler, http.MethodDelete, "/guilds/100/tickets/1", "")
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+		// The channel of the open ticket is not deleted.
+		actions := dataaccess.ScheduledActionDB.(*fakeScheduledActionDal).actions
+		require.Len(t, actions, 1)
+		require.Equal(t, entities.ScheduledActionCancelled, actions[0].Status)
+	})
+}
+
+func TestAPI_RequireStarted(t *testing.T) {
+	a := &App{
+		Logger:  slog.Default(),
+		r:       mux.NewRouter(),
+		startup: newStartupState(),
+	}
+	a.registerAPIRoutes(a.r.PathPrefix(PathAPI).Subrouter(), testAPIToken)
+
+	rec := doAPIRequest(a.r, http.MethodGet, "/guilds/100/ticketing", "")
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Equal(t, "5", rec.Header().Get("Retry-After"))
+
+	// The requests are authenticated before the startup is checked.
+	req := httptest.NewRequest(http.MethodGet, PathAPI+"/guilds/100/ticketing", nil)
+	rec = httptest.NewRecorder()
+	a.r.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,602 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"github.com/Jacobbrewer1/wolf/pkg/request"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// defaultTicketPageSize is the number of tickets listed when no limit is given.
+	defaultTicketPageSize = 50
+
+	// maxTicketPageSize is the maximum number of tickets listed at once.
+	maxTicketPageSize = 100
+
+	// maxTranscriptMessages is the maximum number of messages in a transcript.
+	maxTranscriptMessages = 1000
+
+	// transcriptPageSize is the number of messages fetched from Discord at once, which is the maximum Discord allows.
+	transcriptPageSize = 100
+)
+
+// apiTicketingConfig is the ticketing configuration of a guild.
+type apiTicketingConfig struct {
+	XMLName xml.Name `json:"-" xml:"ticketing"`
+
+	// Enabled is whether ticketing is enabled.
+	Enabled bool `json:"enabled" xml:"enabled"`
+
+	// ChannelID is the ID of the channel that ticketing is enabled in.
+	ChannelID string `json:"channel_id" xml:"channel_id"`
+
+	// RoleID is the ID of the role that handles tickets.
+	RoleID string `json:"role_id" xml:"role_id"`
+
+	// OpenMessageID is the ID of the open ticket message. This is managed by the bot, and ignored on updates.
+	OpenMessageID string `json:"open_message_id,omitempty" xml:"open_message_id,omitempty"`
+
+	// CreatedTicketsCategoryID is the ID of the category that created tickets are put in.
+	CreatedTicketsCategoryID string `json:"created_tickets_category_id" xml:"created_tickets_category_id"`
+
+	// ClaimedTicketsCategoryID is the ID of the category that claimed tickets are put in.
+	ClaimedTicketsCategoryID string `json:"claimed_tickets_category_id" xml:"claimed_tickets_category_id"`
+
+	// ClosedTicketsCategoryID is the ID of the category that closed tickets are put in.
+	ClosedTicketsCategoryID string `json:"closed_tickets_category_id" xml:"closed_tickets_category_id"`
+}
+
+// newAPITicketingConfig creates the API representation of the ticketing configuration.
+func newAPITicketingConfig(cfg *entities.TicketingConfig) *apiTicketingConfig {
+	return &apiTicketingConfig{
+		Enabled:                  cfg.Enabled,
+		ChannelID:                cfg.ChannelID,
+		RoleID:                   cfg.RoleID,
+		OpenMessageID:            cfg.OpenMessageID,
+		CreatedTicketsCategoryID: cfg.CreatedTicketsCategoryID,
+		ClaimedTicketsCategoryID: cfg.ClaimedTicketsCategoryID,
+		ClosedTicketsCategoryID:  cfg.ClosedTicketsCategoryID,
+	}
+}
+
+// validate ensures that the IDs are Discord IDs, and that an enabled configuration has a channel and a role.
+func (c *apiTicketingConfig) validate() error {
+	ids := map[string]string{
+		"channel_id":                  c.ChannelID,
+		"role_id":                     c.RoleID,
+		"created_tickets_category_id": c.CreatedTicketsCategoryID,
+		"claimed_tickets_category_id": c.ClaimedTicketsCategoryID,
+		"closed_tickets_category_id":  c.ClosedTicketsCategoryID,
+	}
+	for field, id := range ids {
+		if id != "" && !isSnowflake(id) {
+			return fmt.Errorf("%s is not a valid ID", field)
+		}
+	}
+
+	if c.Enabled && (c.ChannelID == "" || c.RoleID == "") {
+		return errors.New("channel_id and role_id are required when ticketing is enabled")
+	}
+	return nil
+}
+
+// apiTicket is a ticket.
+type apiTicket struct {
+	XMLName xml.Name `json:"-" xml:"ticket"`
+
+	// ID is the number of the ticket.
+	ID int `json:"id" xml:"id"`
+
+	// GuildID is the ID of the guild that the ticket is in.
+	GuildID string `json:"guild_id" xml:"guild_id"`
+
+	// ChannelID is the ID of the channel of the ticket.
+	ChannelID string `json:"channel_id" xml:"channel_id"`
+
+	// Name is the name of the ticket.
+	Name string `json:"name" xml:"name"`
+
+	// Status is the status of the ticket.
+	Status entities.TicketStatus `json:"status" xml:"status"`
+
+	// UserID is the ID of the user that created the ticket.
+	UserID string `json:"user_id" xml:"user_id"`
+
+	// Username is the username of the user that created the ticket.
+	Username string `json:"username" xml:"username"`
+
+	// ClaimedBy is the ID of the user that claimed the ticket.
+	ClaimedBy string `json:"claimed_by,omitempty" xml:"claimed_by,omitempty"`
+
+	// ClosedBy is the ID of the user that closed the ticket.
+	ClosedBy string `json:"closed_by,omitempty" xml:"closed_by,omitempty"`
+
+	// CreatedAt is the time that the ticket was created.
+	CreatedAt string `json:"created_at" xml:"created_at"`
+}
+
+// newAPITicket creates the API representation of the ticket.
+func newAPITicket(ticket *entities.Ticket) *apiTicket {
+	return &apiTicket{
+		ID:        ticket.ID,
+		GuildID:   ticket.GuildID,
+		ChannelID: ticket.ChannelID,
+		Name:      ticket.Name(),
+		Status:    ticket.Status(),
+		UserID:    ticket.UserID,
+		Username:  ticket.Username,
+		ClaimedBy: ticket.ClaimedBy,
+		ClosedBy:  ticket.ClosedBy,
+		CreatedAt: ticket.CreatedAt.String(),
+	}
+}
+
+// apiTickets is a page of tickets.
+type apiTickets struct {
+	XMLName xml.Name `json:"-" xml:"tickets"`
+
+	// Tickets are the tickets, newest first.
+	Tickets []*apiTicket `json:"tickets" xml:"ticket"`
+
+	// Limit is the maximum number of tickets in the page.
+	Limit int64 `json:"limit" xml:"limit,attr"`
+
+	// Offset is the number of tickets skipped before the page.
+	Offset int64 `json:"offset" xml:"offset,attr"`
+}
+
+// apiTicketEvent is a change made to a ticket.
+type apiTicketEvent struct {
+	// Type is the type of change.
+	Type entities.TicketEventType `json:"type" xml:"type"`
+
+	// UserID is the ID of the user that made the change.
+	UserID string `json:"user_id,omitempty" xml:"user_id,omitempty"`
+
+	// Source is where the change was made from.
+	Source entities.TicketEventSource `json:"source" xml:"source"`
+
+	// At is the time that the change was made.
+	At string `json:"at" xml:"at"`
+}
+
+// apiTicketHistory is the history of a ticket.
+type apiTicketHistory struct {
+	XMLName xml.Name `json:"-" xml:"history"`
+
+	// TicketID is the number of the ticket.
+	TicketID int `json:"ticket_id" xml:"ticket_id,attr"`
+
+	// Events are the changes made to the ticket, oldest first.
+	Events []*apiTicketEvent `json:"events" xml:"event"`
+}
+
+// apiTranscriptMessage is a message in the transcript of a ticket.
+type apiTranscriptMessage struct {
+	// ID is the ID of the message.
+	ID string `json:"id" xml:"id,attr"`
+
+	// AuthorID is the ID of the author of the message.
+	AuthorID string `json:"author_id" xml:"author_id"`
+
+	// Author is the username of the author of the message.
+	Author string `json:"author" xml:"author"`
+
+	// Content is the content of the message.
+	Content string `json:"content" xml:"content"`
+
+	// Attachments are the URLs of the attachments of the message.
+	Attachments []string `json:"attachments,omitempty" xml:"attachment,omitempty"`
+
+	// Timestamp is the time that the message was sent.
+	Timestamp string `json:"timestamp" xml:"timestamp"`
+}
+
+// apiTranscript is the transcript of a ticket.
+type apiTranscript struct {
+	XMLName xml.Name `json:"-" xml:"transcript"`
+
+	// TicketID is the number of the ticket.
+	TicketID int `json:"ticket_id" xml:"ticket_id,attr"`
+
+	// Messages are the messages of the ticket channel, oldest first.
+	Messages []*apiTranscriptMessage `json:"messages" xml:"message"`
+
+	// Truncated is whether the oldest messages were left out because the channel has too many messages.
+	Truncated bool `json:"truncated" xml:"truncated,attr"`
+
+	// ticketName is the name of the ticket, used in the plain text transcript.
+	ticketName string
+}
+
+// apiTicketAction is the request to close or delete a ticket.
+type apiTicketAction struct {
+	XMLName xml.Name `json:"-" xml:"action"`
+
+	// UserID is the ID of the user the change is attributed to.
+	UserID string `json:"user_id" xml:"user_id"`
+}
+
+// getTicketingConfig returns the ticketing configuration of the guild.
+func (a *App) getTicketingConfig() Controller {
+	return func(w http.ResponseWriter, r *http.Request) {
+		guild, err := dataaccess.GuildDB.GetGuildByID(r.Context(), mux.Vars(r)["guild_id"])
+		if isNotFound(err) {
+			request.RespondError(w, r, http.StatusNotFound, "Guild not found")
+			return
+		} else if err != nil {
+			writeAPIInternalError(w, r, err)
+			return
+		}
+
+		request.Respond(w, r, http.StatusOK, newAPITicketingConfig(&guild.Ticketing))
+	}
+}
+
+// updateTicketingConfig replaces the ticketing configuration of the guild.
+func (a *App) updateTicketingConfig() Controller {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		guildID := mux.Vars(r)["guild_id"]
+		if !isSnowflake(guildID) {
+			request.RespondError(w, r, http.StatusBadRequest, "Invalid guild ID")
+			return
+		}
+
+		cfg := new(apiTicketingConfig)
+		if err := request.Decode(w, r, cfg); err != nil {
+			request.RespondDecodeError(w, r, err)
+			return
+		}
+
+		if err := cfg.validate(); err != nil {
+			request.RespondError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		guild, err := dataaccess.GuildDB.GetGuildByID(ctx, guildID)
+		if isNotFound(err) {
+			guild = &entities.Guild{ID: guildID}
+		} else if err != nil {
+			writeAPIInternalError(w, r, err)
+			return
+		}
+
+		guild.Ticketing = entities.TicketingConfig{
+			Enabled:                  cfg.Enabled,
+			ChannelID:                cfg.ChannelID,
+			RoleID:                   cfg.RoleID,
+			OpenMessageID:            guild.Ticketing.OpenMessageID,
+			CreatedTicketsCategoryID: cfg.CreatedTicketsCategoryID,
+			ClaimedTicketsCategoryID: cfg.ClaimedTicketsCategoryID,
+			ClosedTicketsCategoryID:  cfg.ClosedTicketsCategoryID,
+		}
+
+		if err := dataaccess.GuildDB.SaveGuild(ctx, guild); err != nil {
+			writeAPIInternalError(w, r, err)
+			return
+		}
+
+		a.Info("Ticketing configuration updated with the API", slog.String(logging.KeyGuildID, guildID))
+		request.Respond(w, r, http.StatusOK, newAPITicketingConfig(&guild.Ticketing))
+	}
+}
+
+// listTickets lists the tickets of the guild, filtered by the query parameters.
+func (a *App) listTickets() Controller {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		filter := &dataaccess.TicketFilter{
+			Status:    entities.TicketStatus(query.Get("status")),
+			UserID:    query.Get("user_id"),
+			ClaimedBy: query.Get("claimed_by"),
+			Limit:     defaultTicketPageSize,
+		}
+
+		switch filter.Status {
+		case "", entities.TicketStatusOpen, entities.TicketStatusClaimed, entities.TicketStatusClosed, entities.TicketStatusDeleted:
+		default:
+			request.RespondError(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid status %q", filter.Status))
+			return
+		}
+
+		if filter.Status == entities.TicketStatusOpen && filter.ClaimedBy != "" {
+			request.RespondError(w, r, http.StatusBadRequest, "Open tickets are not claimed")
+			return
+		}
+
+		if str := query.Get("limit"); str != "" {
+			limit, err := strconv.ParseInt(str, 10, 64)
+			if err != nil || limit < 1 || limit > maxTicketPageSize {
+				request.RespondError(w, r, http.StatusBadRequest, fmt.Sprintf("The limit must be between 1 and %d", maxTicketPageSize))
+				return
+			}
+			filter.Limit = limit
+		}
+
+		if str := query.Get("offset"); str != "" {
+			offset, err := strconv.ParseInt(str, 10, 64)
+			if err != nil || offset < 0 {
+				request.RespondError(w, r, http.StatusBadRequest, "The offset must not be negative")
+				return
+			}
+			filter.Offset = offset
+		}
+
+		tickets, err := dataaccess.TicketDB.ListTickets(r.Context(), mux.Vars(r)["guild_id"], filter)
+		if err != nil {
+			writeAPIInternalError(w, r, err)
+			return
+		}
+
+		resp := &apiTickets{
+			Tickets: make([]*apiTicket, 0, len(tickets)),
+			Limit:   filter.Limit,
+			Offset:  filter.Offset,
+		}
+		for _, ticket := range tickets {
+			resp.Tickets = append(resp.Tickets, newAPITicket(ticket))
+		}
+
+		request.Respond(w, r, http.StatusOK, resp)
+	}
+}
+
+// getTicket returns the ticket.
+func (a *App) getTicket() Controller {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ticket, ok := apiTicketFromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		request.Respond(w, r, http.StatusOK, newAPITicket(ticket))
+	}
+}
+
+// getTicketHistory returns the changes made to the ticket.
+func (a *App) getTicketHistory() Controller {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ticket, ok := apiTicketFromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		resp := &apiTicketHistory{
+			TicketID: ticket.ID,
+			Events:   make([]*apiTicketEvent, 0, len(ticket.History)),
+		}
+		for _, event := range ticket.History {
+			resp.Events = append(resp.Events, &apiTicketEvent{
+				Type:   event.Type,
+				UserID: event.UserID,
+				Source: event.Source,
+				At:     event.At.String(),
+			})
+		}
+
+		request.Respond(w, r, http.StatusOK, resp)
+	}
+}
+
+// getTicketTranscript returns the messages of the ticket channel. The transcript is only available until the
+// channel is deleted.
+func (a *App) getTicketTranscript() Controller {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ticket, ok := apiTicketFromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		transcript, err := a.fetchTranscript(r, ticket)
+		if isNotFound(err) {
+			request.RespondError(w, r, http.StatusNotFound, "The transcript is no longer available")
+			return
+		} else if err != nil {
+			writeAPIInternalError(w, r, err)
+			return
+		}
+
+		request.Respond(w, r, http.StatusOK, transcript)
+	}
+}
+
+// fetchTranscript fetches the messages of the ticket channel from Discord, oldest first.
+func (a *App) fetchTranscript(r *http.Request, ticket *entities.Ticket) (*apiTranscript, error) {
+	transcript := &apiTranscript{
+		ticketName: ticket.Name(),
+		TicketID:   ticket.ID,
+		Messages:   make([]*apiTranscriptMessage, 0),
+	}
+
+	// The messages are fetched newest first, a page at a time.
+	beforeID := ""
+	for {
+		msgs, err := a.Session().ChannelMessages(ticket.ChannelID, transcriptPageSize, beforeID, "", "", discordgo.WithContext(r.Context()))
+		if err != nil {
+			return nil, fmt.Errorf("error getting messages: %w", err)
+		}
+
+		for _, msg := range msgs {
+			transcript.Messages = append(transcript.Messages, newAPITranscriptMessage(msg))
+		}
+
+		if len(msgs) < transcriptPageSize {
+			break
+		} else if len(transcript.Messages) >= maxTranscriptMessages {
+			transcript.Truncated = true
+			break
+		}
+		beforeID = msgs[len(msgs)-1].ID
+	}
+
+	// Reverse the messages so the oldest is first.
+	for i, j := 0, len(transcript.Messages)-1; i < j; i, j = i+1, j-1 {
+		transcript.Messages[i], transcript.Messages[j] = transcript.Messages[j], transcript.Messages[i]
+	}
+
+	return transcript, nil
+}
+
+// newAPITranscriptMessage creates the API representation of the message.
+func newAPITranscriptMessage(msg *discordgo.Message) *apiTranscriptMessage {
+	m := &apiTranscriptMessage{
+		ID:        msg.ID,
+		Content:   msg.Content,
+		Timestamp: msg.Timestamp.UTC().Format(time.RFC3339),
+	}
+
+	if msg.Author != nil {
+		m.AuthorID = msg.Author.ID
+		m.Author = msg.Author.Username
+	}
+
+	for _, attachment := range msg.Attachments {
+		m.Attachments = append(m.Attachments, attachment.URL)
+	}
+	return m
+}
+
+// String returns the transcript as plain text, one line per message.
+func (t *apiTranscript) String() string {
+	sb := new(strings.Builder)
+	fmt.Fprintf(sb, "Transcript of ticket %s", t.ticketName)
+	for _, msg := range t.Messages {
+		fmt.Fprintf(sb, "\n[%s] %s: %s", msg.Timestamp, msg.Author, msg.Content)
+		for _, url := range msg.Attachments {
+			fmt.Fprintf(sb, "\n\tattachment: %s", url)
+		}
+	}
+	return sb.String()
+}
+
+// forceCloseTicket closes the ticket, in the same way as the close button.
+func (a *App) forceCloseTicket() Controller {
+	return func(w http.ResponseWriter, r *http.Request) {
+		action, ok := decodeTicketAction(w, r)
+		if !ok {
+			return
+		}
+
+		if action.UserID == "" {
+			// A closed ticket is recognised by who closed it.
+			request.RespondError(w, r, http.StatusBadRequest, "user_id is required")
+			return
+		}
+
+		ticket, ok := apiTicketFromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		err := closeTicket(r.Context(), a.Logger, a, ticket, action.UserID, entities.TicketEventSourceAPI)
+		if !writeTicketActionError(w, r, err) {
+			return
+		}
+
+		a.Info("Ticket closed with the API", slog.String(logging.KeyGuildID, ticket.GuildID), slog.Int("ticket", ticket.ID))
+		request.Respond(w, r, http.StatusOK, newAPITicket(ticket))
+	}
+}
+
+// forceDeleteTicket deletes the ticket, in the same way as the delete confirmation button.
+func (a *App) forceDeleteTicket() Controller {
+	return func(w http.ResponseWriter, r *http.Request) {
+		action, ok := decodeTicketAction(w, r)
+		if !ok {
+			return
+		}
+
+		ticket, ok := apiTicketFromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		err := deleteTicket(r.Context(), a.Logger, a, ticket, action.UserID, entities.TicketEventSourceAPI)
+		if !writeTicketActionError(w, r, err) {
+			return
+		}
+
+		a.Info("Ticket deleted with the API", slog.String(logging.KeyGuildID, ticket.GuildID), slog.Int("ticket", ticket.ID))
+		request.Respond(w, r, http.StatusOK, newAPITicket(ticket))
+	}
+}
+
+// decodeTicketAction decodes the optional body of a request to close or delete a ticket. If the body is invalid, the
+// error is written to the response and false is returned.
+func decodeTicketAction(w http.ResponseWriter, r *http.Request) (*apiTicketAction, bool) {
+	action := new(apiTicketAction)
+	if err := request.Decode(w, r, action); err != nil && !errors.Is(err, request.ErrEmptyBody) {
+		request.RespondDecodeError(w, r, err)
+		return nil, false
+	}
+
+	if action.UserID != "" && !isSnowflake(action.UserID) {
+		request.RespondError(w, r, http.StatusBadRequest, "user_id is not a valid ID")
+		return nil, false
+	}
+	return action, true
+}
+
+// writeTicketActionError writes the error of closing or deleting a ticket to the response. It returns true if there
+// was no error.
+func writeTicketActionError(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errTicketAlreadyClosed):
+		request.RespondError(w, r, http.StatusConflict, "The ticket is already closed")
+	case errors.Is(err, errTicketDeleted):
+		request.RespondError(w, r, http.StatusConflict, "The ticket has been deleted")
+	case isNotFound(err):
+		request.RespondError(w, r, http.StatusNotFound, "The ticket channel no longer exists")
+	default:
+		writeAPIInternalError(w, r, err)
+	}
+	return false
+}
+
+// apiTicketFromRequest gets the ticket identified by the path of the request. If the ticket cannot be found, the
+// error is written to the response and false is returned.
+func apiTicketFromRequest(w http.ResponseWriter, r *http.Request) (*entities.Ticket, bool) {
+	vars := mux.Vars(r)
+
+	id, err := strconv.Atoi(vars["ticket_id"])
+	if err != nil || id < 1 {
+		request.RespondError(w, r, http.StatusBadRequest, "Invalid ticket ID")
+		return nil, false
+	}
+
+	ticket, err := dataaccess.TicketDB.GetTicketByID(r.Context(), vars["guild_id"], id)
+	if isNotFound(err) {
+		request.RespondError(w, r, http.StatusNotFound, "Ticket not found")
+		return nil, false
+	} else if err != nil {
+		writeAPIInternalError(w, r, err)
+		return nil, false
+	}
+	return ticket, true
+}
+
+// isSnowflake returns true if the string is a Discord ID.
+func isSnowflake(id string) bool {
+	if id == "" {
+		return false
+	}
+
+	_, err := strconv.ParseUint(id, 10, 64)
+	return err == nil
+}
@@ -1,20 +1,68 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/antiraid"
+	"github.com/Jacobbrewer1/wolf/pkg/automod"
+	"github.com/Jacobbrewer1/wolf/pkg/commands"
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess"
+	"github.com/Jacobbrewer1/wolf/pkg/leader"
 	"github.com/Jacobbrewer1/wolf/pkg/logging"
 	"github.com/Jacobbrewer1/wolf/pkg/request"
+	"github.com/Jacobbrewer1/wolf/pkg/tracing"
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
+const (
+	// componentCommands is the name of the command router in the logs.
+	componentCommands = "commands"
+
+	// componentLeader is the name of the leader election in the logs.
+	componentLeader = "leader"
+
+	// componentScheduler is the name of the scheduled action worker in the logs.
+	componentScheduler = "scheduler"
+
+	// componentEventLog is the name of the server event logging in the logs.
+	componentEventLog = "event_log"
+
+	// componentAutomod is the name of the automod in the logs.
+	componentAutomod = "automod"
+
+	// componentRaid is the name of the anti-raid protection in the logs.
+	componentRaid = "raid"
+
+	// componentReactionRoles is the name of the reaction roles in the logs.
+	componentReactionRoles = "reaction_roles"
+
+	// componentWelcome is the name of the welcome and goodbye messages in the logs.
+	componentWelcome = "welcome"
+
+	// componentVerification is the name of the member verification in the logs.
+	componentVerification = "verification"
+
+	// componentRolePersistence is the name of the role persistence in the logs.
+	componentRolePersistence = "role_persistence"
+)
+
+// shutdownTimeout is how long the monitoring server has to finish the requests in flight on shutdown.
+const shutdownTimeout = 5 * time.Second
+
 // IApp is the interface for the application.
 type IApp interface {
 	// Session returns the discord session.
@@ -31,55 +79,145 @@ type App struct {
 	// svr is the server for the application.
 	svr *http.Server
 
-	// s is the discord session.
+	// s is the discord session of the first shard run by this instance. It is used for the REST API.
 	s *discordgo.Session
 
-	// eventNotifier is the channel for notifying of events.
-	eventNotifier chan any
+	// shards are the discord sessions of the shards run by this instance.
+	shards []*discordgo.Session
+
+	// elector elects the instance that runs the singletons, such as registering the global slash commands.
+	elector *leader.Elector
+
+	// router routes the interactions to the commands and components.
+	router *commands.Router
+
+	// registry keeps the registered slash commands up to date.
+	registry *commands.Registry
+
+	// ctx is the base context of the application. The contexts of the interactions and http requests are derived
+	// from it, and it is cancelled on shutdown.
+	ctx context.Context
+
+	// cancel cancels the base context of the application.
+	cancel context.CancelFunc
+
+	// tp is the tracer provider. It is flushed on shutdown.
+	tp *sdktrace.TracerProvider
+
+	// logLevels are the log levels, which can be changed at runtime through the monitoring server.
+	logLevels *logging.Levels
+
+	// startup tracks the progress of the startup for the startup probe.
+	startup *startupState
+
+	// gateway tracks the connections of the shards to the gateway for the liveness and readiness probes.
+	gateway *gatewayState
+
+	// events sends the server events to the log channels of the guilds.
+	events *eventLog
+
+	// automod evaluates the automod rules of the guilds against their messages.
+	automod *automod.Engine
+
+	// raids detects raids from the members joining the guilds.
+	raids *antiraid.Detector
 }
 
 // NewApp creates a new instance of App.
-func NewApp(l *slog.Logger, r *mux.Router) *App {
+func NewApp(l *slog.Logger, r *mux.Router, logCfg *logging.Config) *App {
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return &App{
 		Logger: l,
 		r:      r,
+		router: commands.NewRouter(
+			commands.WithContext(ctx),
+			commands.WithLogger(l.With(slog.String(logging.KeyComponent, componentCommands))),
+			commands.WithErrorHook(countInteractionError),
+		),
+		ctx:       ctx,
+		cancel:    cancel,
+		logLevels: logCfg.Levels(),
+		startup:   newStartupState(),
+		gateway:   newGatewayState(),
+		automod:   automod.NewEngine(),
+		raids:     antiraid.NewDetector(antiraid.DefaultGuilds),
 	}
 }
 
 func (a *App) Run() error {
+	// Start the monitoring server first, so the probes can report the progress of the startup.
+	a.runServer()
+
+	// Start tracing before anything that creates spans.
+	a.startup.setStage("starting tracing")
+	tp, err := tracing.NewProvider(a.ctx, AppName, TracingExporter, os.Stdout)
+	if err != nil {
+		return fmt.Errorf("error creating tracer provider: %w", err)
+	}
+	a.tp = tp
+
+	// Connect to the database after the probes are served, as it can be the slowest part of the startup.
+	a.startup.setStage("connecting to MongoDB")
+	if err := connectMongo(); err != nil {
+		return fmt.Errorf("error connecting to MongoDB: %w", err)
+	}
+
+	// Apply the database migrations.
+	if RunMigrationsOnStartup {
+		a.startup.setStage("running migrations")
+		if err := runMigrations(a.ctx); err != nil {
+			return fmt.Errorf("error running migrations: %w", err)
+		}
+	}
+
+	// Invalidate the cache when other instances make changes.
+	if CacheChangeStreams {
+		a.watchCacheInvalidations()
+	}
+
+	// Register the commands and components.
+	a.startup.setStage("registering routes")
+	if err := a.registerRoutes(); err != nil {
+		return fmt.Errorf("error registering routes: %w", err)
+	}
+
 	// Register bot.
+	a.startup.setStage("creating shards")
 	if err := a.RegisterBot(); err != nil {
 		return fmt.Errorf("error registering bot: %w", err)
 	}
 
-	a.s.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
-		a.Info(fmt.Sprintf("Logged in as %s#%s", r.User.Username, r.User.Discriminator))
-	})
-
-	// Register slash commands.
-	if err := a.registerSlashCommands(); err != nil {
-		return fmt.Errorf("error registering slash commands: %w", err)
+	for _, shard := range a.shards {
+		shard.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
+			a.Info(fmt.Sprintf("Logged in as %s#%s", r.User.Username, r.User.Discriminator), slog.Int("shard", s.ShardID))
+		})
 	}
 
+	// Send the server events to the log channels in batches.
+	a.events = newEventLog(a.With(slog.String(logging.KeyComponent, componentEventLog)), a.s)
+	go a.events.run(a.ctx)
+
 	if err := a.RegisterDiscordHandlers(); err != nil {
 		return fmt.Errorf("error registering discord handlers: %w", err)
 	}
 
-	// Start event listener.
-	go a.eventListener()
+	// Run the singletons on the elected instance.
+	a.elector = leader.NewElector(a.With(slog.String(logging.KeyComponent, componentLeader)), dataaccess.LeaseDB, leaderLeaseName, instanceID(), leaderLeaseTTL)
+	go a.elector.Run(a.ctx, a.lead)
 
-	// Open websocket.
-	if err := a.s.Open(); err != nil {
+	// Open websockets.
+	if err := a.openShards(); err != nil {
 		return fmt.Errorf("error opening connection to Discord: %w", err)
 	}
 
+	a.startup.complete()
 	a.Info("Bot is now running.")
 
-	a.runServer()
-
 	// Register listerner for shutdown signal.
 	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
+	// Orchestrators stop the container with SIGTERM, so it is handled the same as an interrupt.
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
 	// Process shutdown signal.
 	for sig := range c {
@@ -93,40 +231,109 @@ func (a *App) Run() error {
 }
 
 func (a *App) ShutdownHook() error {
+	// Only the leader unregisters the slash commands, checked before the leadership is given up.
+	isLeader := a.elector != nil && a.elector.IsLeader()
+
+	// Cancel any interactions and http requests that are still being handled.
+	a.cancel()
+
+	// Wait for the leader lease to be released before exiting, so another instance can take over without waiting for
+	// the lease to expire.
+	if a.elector != nil {
+		select {
+		case <-a.elector.Done():
+		case <-time.After(shutdownTimeout):
+			a.Warn("Timed out waiting for the leader lease to be released")
+		}
+	}
+
 	// Reset the total number of guilds to 0.
-	TotalDiscordGuilds.Set(0)
+	for _, shard := range a.shards {
+		TotalDiscordGuilds.WithLabelValues(shardLabel(shard)).Set(0)
+	}
+
+	// Stop the monitoring server.
+	if a.svr != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := a.svr.Shutdown(ctx); err != nil {
+			a.Error("Error shutting down monitoring server", slog.String(logging.KeyError, err.Error()))
+		}
+	}
 
-	// Unregister slash commands.
-	if err := a.unregisterSlashCommands(); err != nil {
-		return fmt.Errorf("error unregistering slash commands: %w", err)
+	// Only unregister the slash commands when configured to, otherwise users are left without commands during a
+	// deployment.
+	if UnregisterCommandsOnShutdown && isLeader {
+		if err := a.unregisterSlashCommands(); err != nil {
+			return fmt.Errorf("error unregistering slash commands: %w", err)
+		}
 	}
 
-	// Close the connection to Discord.
-	if err := a.s.Close(); err != nil {
-		return fmt.Errorf("error closing connection to Discord: %w", err)
+	// Close the connections to Discord.
+	for _, shard := range a.shards {
+		if err := shard.Close(); err != nil {
+			return fmt.Errorf("error closing connection to Discord for shard %d: %w", shard.ShardID, err)
+		}
+	}
+
+	// Export the spans that are still buffered.
+	if a.tp != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := a.tp.Shutdown(ctx); err != nil {
+			return fmt.Errorf("error shutting down tracer provider: %w", err)
+		}
 	}
 	return nil
 }
 
 func (a *App) RegisterBot() error {
-	// Default the number of guilds to 0.
-	TotalDiscordGuilds.Set(0)
-
 	dg, err := discordgo.New("Bot " + BotToken)
 	if err != nil {
 		return fmt.Errorf("error creating Discord session: %w", err)
 	}
 
-	dg.Identify.Intents = discordgo.MakeIntent(discordgo.IntentsAll)
+	shardCount, shardIDs, err := resolveShards(dg)
+	if err != nil {
+		return fmt.Errorf("error resolving shards: %w", err)
+	}
+
+	for i, id := range shardIDs {
+		shard := dg
+		if i > 0 {
+			shard, err = discordgo.New("Bot " + BotToken)
+			if err != nil {
+				return fmt.Errorf("error creating Discord session: %w", err)
+			}
+		}
+
+		shard.ShardID = id
+		shard.ShardCount = shardCount
+		shard.Identify.Intents = discordgo.MakeIntent(discordgo.IntentsAll)
 
-	if a.eventNotifier == nil {
-		// Create event notifier. This is used to runServer events. It is buffered to prevent blocking.
-		a.eventNotifier = make(chan any, 100)
+		// Trace the REST calls as children of the interaction that made them.
+		shard.Client.Transport = tracing.NewTransport(shard.Client.Transport)
+
+		// Create event notifier. This is used to count the events. It is buffered to prevent blocking.
+		notifier := make(chan any, 100)
+		shard.SetEventNotifier(notifier)
+		go a.eventListener(shard, notifier)
+
+		// Follow the connection to the gateway for the probes.
+		a.gateway.track(shard)
+
+		// Default the number of guilds to 0.
+		TotalDiscordGuilds.WithLabelValues(shardLabel(shard)).Set(0)
+
+		a.shards = append(a.shards, shard)
 	}
 
-	dg.SetEventNotifier(a.eventNotifier)
+	a.Info("Running shards", slog.Int("shard_count", shardCount), slog.Any("shard_ids", shardIDs))
 
 	a.s = dg
+	a.registry = commands.NewRegistry(a.Logger, ApplicationId, a.router.Definitions()...)
 	return nil
 }
 
@@ -134,23 +341,98 @@ func (a *App) runServer() {
 	a.svr = &http.Server{
 		Addr:    ":" + MonitoringPort,
 		Handler: a.r,
+		BaseContext: func(net.Listener) context.Context {
+			// The requests are cancelled on shutdown.
+			return a.ctx
+		},
 	}
 
-	a.r.HandleFunc(PathMetrics, promhttp.Handler().ServeHTTP).Methods(http.MethodGet)
-	a.r.HandleFunc(PathHealth, middlewareHttp(a.healthCheck())).Methods(http.MethodGet)
-
+	// Every routed request is counted, and limited per client when configured.
+	a.r.Use(observeRequests)
 	a.r.NotFoundHandler = request.NotFoundHandler()
 	a.r.MethodNotAllowedHandler = request.MethodNotAllowedHandler()
+	if HTTPRateLimit > 0 {
+		rateLimit := request.RateLimit(request.NewRateLimiter(
+			request.WithRate(HTTPRateLimit),
+			request.WithBurst(HTTPRateLimitBurst),
+		))
+		a.r.Use(rateLimit)
+
+		// The unrouted requests are not passed through the middleware, so they are limited separately.
+		a.r.NotFoundHandler = rateLimit(a.r.NotFoundHandler)
+		a.r.MethodNotAllowedHandler = rateLimit(a.r.MethodNotAllowedHandler)
+	}
+
+	// The unrouted requests are not passed through the middleware, so they are counted separately.
+	a.r.NotFoundHandler = observeRequests(a.r.NotFoundHandler)
+	a.r.MethodNotAllowedHandler = observeRequests(a.r.MethodNotAllowedHandler)
+
+	// The probes are always open, so the orchestrator can reach them.
+	a.r.HandleFunc(PathHealth, middlewareHttp(a.readinessCheck())).Methods(http.MethodGet)
+	a.r.HandleFunc(PathLiveness, middlewareHttp(a.livenessCheck())).Methods(http.MethodGet)
+	a.r.HandleFunc(PathReadiness, middlewareHttp(a.readinessCheck())).Methods(http.MethodGet)
+	a.r.HandleFunc(PathStartup, middlewareHttp(a.startupCheck())).Methods(http.MethodGet)
+
+	// The captcha images are open, so they can be shown to the members being verified.
+	a.r.Handle(PathVerify+"/{challenge_id}/"+verificationCaptchaFile, a.requireStarted(middlewareHttp(getCaptchaImage))).
+		Methods(http.MethodGet)
+
+	// The metrics and admin routes require the monitoring credentials when configured.
+	protected := a.r.NewRoute().Subrouter()
+	protected.Use(monitoringAuth(MonitoringUsername, MonitoringPassword, MonitoringToken))
+	protected.Handle(PathMetrics, promhttp.Handler()).Methods(http.MethodGet)
+	protected.HandleFunc(PathLogLevel, middlewareHttp(a.getLogLevels())).Methods(http.MethodGet)
+	protected.HandleFunc(PathLogLevel, middlewareHttp(a.setLogLevel())).Methods(http.MethodPut)
+	if DebugEndpoints {
+		registerDebugRoutes(protected.PathPrefix(PathDebug).Subrouter())
+	}
+
+	// The admin API is only served when it can be authenticated.
+	if APIToken != "" || APIClientCAFile != "" {
+		a.registerAPIRoutes(a.r.PathPrefix(PathAPI).Subrouter(), APIToken)
+	}
+
+	if TLSCertFile != "" {
+		tlsConfig, err := serverTLSConfig(APIClientCAFile)
+		if err != nil {
+			a.Error("Error configuring TLS", slog.String(logging.KeyError, err.Error()))
+			a.Warn("Monitoring server will not be available")
+			return
+		}
+		a.svr.TLSConfig = tlsConfig
+	}
 
 	go func() {
 		slog.Info("Starting monitoring server")
-		if err := a.svr.ListenAndServe(); err != nil {
+
+		var err error
+		if TLSCertFile != "" {
+			err = a.svr.ListenAndServeTLS(TLSCertFile, TLSKeyFile)
+		} else {
+			err = a.svr.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			a.Error("Error starting monitoring server", slog.String(logging.KeyError, err.Error()))
 			a.Warn("Monitoring server will not be available")
 		}
 	}()
 }
 
+// watchCacheInvalidations watches for changes to the cached guilds and tickets until the application shuts down. The
+// watches are restarted when they fail, and the caches are bypassed until they are running.
+func (a *App) watchCacheInvalidations() {
+	for _, dal := range []any{dataaccess.GuildDB, dataaccess.TicketDB} {
+		w, ok := dal.(interface {
+			Watch(ctx context.Context)
+		})
+		if !ok {
+			continue
+		}
+
+		go w.Watch(a.ctx)
+	}
+}
+
 func (a *App) GetJoinedGuilds() ([]*discordgo.UserGuild, error) {
 	guilds, err := a.s.UserGuilds(0, "", "")
 	if err != nil {
@@ -160,91 +442,185 @@ func (a *App) GetJoinedGuilds() ([]*discordgo.UserGuild, error) {
 }
 
 func (a *App) RegisterDiscordHandlers() error {
-	// Bot joined guild.
-	a.s.AddHandler(a.guildJoinedHandler())
+	// Each shard only receives the events of its own guilds.
+	for _, shard := range a.shards {
+		// Bot joined guild.
+		shard.AddHandler(a.guildJoinedHandler())
+
+		// Bot left guild.
+		shard.AddHandler(a.guildLeaveHandler())
+
+		// Interaction create handler.
+		shard.AddHandler(interactionHandler(a.router))
+
+		// Server event logging.
+		shard.AddHandler(a.messageCreateLogHandler())
+		shard.AddHandler(a.messageUpdateLogHandler())
+		shard.AddHandler(a.messageDeleteLogHandler())
+		shard.AddHandler(a.messageDeleteBulkLogHandler())
+		shard.AddHandler(a.memberAddLogHandler())
+		shard.AddHandler(a.memberRemoveLogHandler())
+		shard.AddHandler(a.memberUpdateLogHandler())
+		shard.AddHandler(a.banAddLogHandler())
+		shard.AddHandler(a.banRemoveLogHandler())
+		shard.AddHandler(a.channelCreateLogHandler())
+		shard.AddHandler(a.channelDeleteLogHandler())
+		shard.AddHandler(a.inviteCreateLogHandler())
+
+		// Automod.
+		shard.AddHandler(a.automodHandler())
+
+		// Anti-raid protection.
+		shard.AddHandler(a.raidJoinHandler())
+
+		// Reaction roles.
+		shard.AddHandler(a.reactionRoleAddHandler())
+		shard.AddHandler(a.reactionRoleRemoveHandler())
+		shard.AddHandler(a.reactionRoleMessageDeleteHandler())
+		shard.AddHandler(a.reactionRoleMessageDeleteBulkHandler())
+		shard.AddHandler(a.reactionRoleReconcileHandler())
+
+		// Welcome and goodbye messages.
+		shard.AddHandler(a.welcomeHandler())
+		shard.AddHandler(a.goodbyeHandler())
+
+		// Member verification.
+		shard.AddHandler(a.verificationJoinHandler())
+
+		// Role persistence.
+		shard.AddHandler(a.rolePersistenceReconcileHandler())
+		shard.AddHandler(a.rolePersistenceUpdateHandler())
+		shard.AddHandler(a.rolePersistenceLeaveHandler())
+		shard.AddHandler(a.rolePersistenceJoinHandler())
+	}
+	return nil
+}
 
-	// Bot left guild.
-	a.s.AddHandler(a.guildLeaveHandler())
+// registerRoutes registers the slash commands and message components with the router.
+func (a *App) registerRoutes() error {
+	// Slash commands.
+	for _, cmd := range []*commands.Command{
+		setupCmd,
+		ticketCmd,
+		modCmd,
+		caseCmd,
+		automodCmd,
+		raidCmd,
+		rolesCmd,
+		reactionRolesCmd,
+	} {
+		if err := a.router.AddCommand(cmd); err != nil {
+			return fmt.Errorf("error adding command: %w", err)
+		}
+	}
 
-	// Interaction create handler.
-	a.s.AddHandler(interactionHandler(a,
-		// Slash Controllers
-		map[string]commandController{
-			setupCmd.Name: setupCmdController,
-		},
-		// Button Controllers
-		map[string]commandProcessor{
-			OpenTicketButtonID:         createTicket,
-			ClaimTicketButtonID:        claimTicketHandler,
-			CloseTicketButtonID:        closeTicketHandler,
-			ReopenTicketButtonID:       reopenTicketHandler,
-			DeleteTicketButtonID:       deleteTicketHandler,
-			DeleteConfirmationButtonID: deleteTicketConfirmationHandler,
-		}))
+	// Buttons, select menus and modals.
+	for _, comp := range []*commands.Component{
+		{CustomID: OpenTicketButtonID, Ephemeral: true, Handler: createTicket},
+		{CustomID: ClaimTicketButtonID, Guards: []commands.Guard{ticketRoleGuard}, Handler: claimTicketHandler},
+		{CustomID: CloseTicketButtonID, Guards: []commands.Guard{ticketRoleGuard}, Handler: closeTicketHandler},
+		{CustomID: ReopenTicketButtonID, Handler: reopenTicketHandler},
+		{CustomID: DeleteTicketButtonID, Guards: []commands.Guard{ticketRoleGuard}, Ephemeral: true, Handler: deleteTicketHandler},
+		{CustomID: DeleteConfirmationButtonID, Handler: deleteTicketConfirmationHandler},
+		{CustomID: RolePanelButtonID, Prefix: true, Ephemeral: true, Handler: rolePanelButtonHandler},
+		{CustomID: RolePanelSelectID, Prefix: true, Ephemeral: true, Handler: rolePanelSelectHandler},
+		{CustomID: VerifyButtonID, Ephemeral: true, Handler: a.verifyButtonHandler},
+		{CustomID: VerifyAnswerButtonID, Prefix: true, Ephemeral: true, Handler: a.verifyAnswerButtonHandler},
+		{CustomID: VerifyModalID, Prefix: true, Ephemeral: true, Handler: a.verifyModalHandler},
+	} {
+		if err := a.router.AddComponent(comp); err != nil {
+			return fmt.Errorf("error adding component: %w", err)
+		}
+	}
 	return nil
 }
 
-func (a *App) eventListener() {
-	for e := range a.eventNotifier {
+// eventListener counts the events received by the shard.
+func (a *App) eventListener(shard *discordgo.Session, notifier <-chan any) {
+	label := shardLabel(shard)
+
+	for e := range notifier {
 		switch t := e.(type) {
 		case *discordgo.Event:
 			if t.Type != "" {
-				TotalDiscordEvents.WithLabelValues(t.Type).Inc()
+				TotalDiscordEvents.WithLabelValues(label, t.Type).Inc()
 			} else {
 				// If there is no type, then use the operation name.
-				TotalDiscordEvents.WithLabelValues(strings.ToUpper(t.Operation.String())).Inc()
+				TotalDiscordEvents.WithLabelValues(label, strings.ToUpper(t.Operation.String())).Inc()
 			}
 		default:
 			a.Error("Unknown event type", slog.String("type", fmt.Sprintf("%T", e)))
-			TotalDiscordEvents.WithLabelValues("UNKNOWN").Inc()
+			TotalDiscordEvents.WithLabelValues(label, "UNKNOWN").Inc()
 		}
 	}
 }
 
+// registerSlashCommands registers the global slash commands, this is only run by the leader. Guild scoped commands
+// are registered by the shard of each guild as it is joined, and the global commands left from the global scope are
+// removed.
 func (a *App) registerSlashCommands() error {
-	// Get all guilds the bot is in.
-	guilds, err := a.GetJoinedGuilds()
-	if err != nil {
-		return fmt.Errorf("error getting guilds: %w", err)
+	if CommandScope != CommandScopeGlobal {
+		if _, err := a.registry.Prune(a.s, commands.GlobalScope); err != nil {
+			return fmt.Errorf("error removing global commands: %w", err)
+		}
+		return nil
 	}
 
-	// Register slash commands for each guild.
-	for _, g := range guilds {
-		// Register the setup command.
-		if _, err := a.Session().ApplicationCommandCreate(ApplicationId, g.ID, setupCmd); err != nil {
-			return fmt.Errorf("error creating setup command for guild %s: %w", g.ID, err)
-		}
+	if _, err := a.registry.Sync(a.s, commands.GlobalScope); err != nil {
+		return fmt.Errorf("error registering global commands: %w", err)
+	}
+	return nil
+}
 
-		// Register the ticket command.
-		if _, err := a.Session().ApplicationCommandCreate(ApplicationId, g.ID, ticketCmd); err != nil {
-			return fmt.Errorf("error creating ticket command for guild %s: %w", g.ID, err)
+// registerGuildSlashCommands registers the slash commands in the guild when the command scope is guild and the guild
+// is a development guild. Otherwise, the commands left in the guild from an earlier scope are removed, so they are not
+// shown alongside the global commands.
+func (a *App) registerGuildSlashCommands(guildID string) error {
+	if CommandScope != CommandScopeGuild || !isDevGuild(guildID) {
+		if _, err := a.registry.Prune(a.s, guildID); err != nil {
+			return fmt.Errorf("error removing commands from guild %s: %w", guildID, err)
 		}
+		return nil
+	}
+
+	if _, err := a.registry.Sync(a.s, guildID); err != nil {
+		return fmt.Errorf("error registering commands for guild %s: %w", guildID, err)
 	}
 	return nil
 }
 
+// unregisterSlashCommands removes the slash commands from wherever they were registered.
 func (a *App) unregisterSlashCommands() error {
+	if CommandScope == CommandScopeGlobal {
+		if err := a.registry.Clear(a.s, commands.GlobalScope); err != nil {
+			return fmt.Errorf("error unregistering global commands: %w", err)
+		}
+		return nil
+	}
+
 	// Get all guilds the bot is in.
 	guilds, err := a.GetJoinedGuilds()
 	if err != nil {
 		return fmt.Errorf("error getting guilds: %w", err)
 	}
 
-	// Delete slash commands for each guild.
-	for _, guild := range guilds {
-		// Delete the setup command.
-		if err := a.s.ApplicationCommandDelete(ApplicationId, guild.ID, setupCmd.ID); err != nil {
-			return fmt.Errorf("error deleting setup command for guild %s: %w", guild.ID, err)
+	for _, g := range guilds {
+		if !isDevGuild(g.ID) {
+			continue
 		}
 
-		// Delete the ticket command.
-		if err := a.s.ApplicationCommandDelete(ApplicationId, guild.ID, ticketCmd.ID); err != nil {
-			return fmt.Errorf("error deleting command for guild %s: %w", guild.ID, err)
+		if err := a.registry.Clear(a.s, g.ID); err != nil {
+			return fmt.Errorf("error unregistering commands for guild %s: %w", g.ID, err)
 		}
 	}
 	return nil
 }
 
+// isDevGuild returns true if the commands should be registered in the guild when the command scope is guild.
+func isDevGuild(guildID string) bool {
+	return slices.Contains(DevGuildIDs, guildID)
+}
+
 func (a *App) Session() *discordgo.Session {
 	return a.s
 }
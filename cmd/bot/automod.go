@@ -0,0 +1,505 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/automod"
+	"github.com/Jacobbrewer1/wolf/pkg/commands"
+	"github.com/Jacobbrewer1/wolf/pkg/custom"
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+)
+
+const (
+	// AutomodCmdName is the command for the automod configuration.
+	AutomodCmdName = "automod"
+
+	// EnableAutomodRuleCmdName is the sub command for enabling an automod rule.
+	EnableAutomodRuleCmdName = "enable"
+
+	// DisableAutomodRuleCmdName is the sub command for disabling an automod rule.
+	DisableAutomodRuleCmdName = "disable"
+
+	// ExemptAutomodCmdName is the sub command for exempting a role or channel from the automod rules.
+	ExemptAutomodCmdName = "exempt"
+
+	// ShowAutomodCmdName is the sub command for showing the automod rules.
+	ShowAutomodCmdName = "show"
+)
+
+const (
+	// automodRuleAll is the option value that exempts from every automod rule at once.
+	automodRuleAll = "all"
+
+	// automodValuesLength is the maximum length of the values of a rule shown in the automod rules.
+	automodValuesLength = 200
+)
+
+var (
+	// automodCmd is the command for the automod configuration.
+	automodCmd = &commands.Command{
+		Name:        AutomodCmdName,
+		Description: "This is the command for the automod rules.",
+		Permissions: discordgo.PermissionManageServer,
+		GuildOnly:   true,
+		Ephemeral:   true,
+		Subcommands: []*commands.Command{
+			{
+				Name:        EnableAutomodRuleCmdName,
+				Description: "This enables an automod rule, or changes its settings.",
+				Options:     new(enableAutomodRuleOptions),
+				Handler:     enableAutomodRuleHandler,
+			},
+			{
+				Name:        DisableAutomodRuleCmdName,
+				Description: "This disables an automod rule.",
+				Options:     new(disableAutomodRuleOptions),
+				Handler:     disableAutomodRuleHandler,
+			},
+			{
+				Name:        ExemptAutomodCmdName,
+				Description: "This exempts a role or channel from an automod rule.",
+				Options:     new(exemptAutomodOptions),
+				Handler:     exemptAutomodHandler,
+			},
+			{
+				Name:        ShowAutomodCmdName,
+				Description: "This shows the automod rules.",
+				Handler:     showAutomodHandler,
+			},
+		},
+	}
+)
+
+// enableAutomodRuleOptions are the options for the enable automod rule command.
+type enableAutomodRuleOptions struct {
+	// Rule is the type of the rule.
+	Rule string `option:"rule" description:"This is the rule to enable." required:"true" choices:"rate,duplicate,mentions,invites,domains,words,regex"`
+
+	// Delete is whether the messages that break the rule are deleted.
+	Delete bool `option:"delete" description:"This is whether the messages that break the rule are deleted."`
+
+	// Warn is whether the members that break the rule are warned.
+	Warn bool `option:"warn" description:"This is whether the members that break the rule are warned."`
+
+	// Timeout is how long the members that break the rule are timed out for, such as 10m or 1h.
+	Timeout string `option:"timeout" description:"This is how long the members that break the rule are timed out for, such as 10m or 1h."`
+
+	// Threshold is the number of messages, duplicate messages or mentions that breaks the rule.
+	Threshold int `option:"threshold" description:"This is the number of messages, duplicates or mentions that breaks the rule." min:"1"`
+
+	// Window is how far back the messages are counted, such as 10s.
+	Window string `option:"window" description:"This is how far back the messages are counted, such as 10s."`
+
+	// Values are the comma separated domains or words, or the regular expression, that are blocked.
+	Values string `option:"values" description:"These are the comma separated domains or words, or the regular expression, to block."`
+
+	// timeout is the parsed Timeout.
+	timeout time.Duration
+
+	// window is the parsed Window.
+	window time.Duration
+}
+
+// Validate parses the durations of the rule.
+func (o *enableAutomodRuleOptions) Validate() error {
+	if o.Timeout != "" {
+		d, err := parseModerationDuration(o.Timeout)
+		if err != nil {
+			return err
+		}
+		o.timeout = d
+	}
+
+	if o.Window != "" {
+		d, err := parseModerationDuration(o.Window)
+		if err != nil {
+			return err
+		}
+		o.window = d
+	}
+	return nil
+}
+
+// rule returns the rule configured by the options. The exemptions of the existing rule are kept.
+func (o *enableAutomodRuleOptions) rule(existing *entities.AutomodRule) *entities.AutomodRule {
+	t := entities.AutomodRuleType(o.Rule)
+
+	rule := &entities.AutomodRule{
+		Threshold:       o.Threshold,
+		Window:          o.window,
+		TimeoutDuration: o.timeout,
+	}
+	if existing != nil {
+		rule.ExemptRoleIDs = existing.ExemptRoleIDs
+		rule.ExemptChannelIDs = existing.ExemptChannelIDs
+	}
+
+	if o.Delete {
+		rule.Actions = append(rule.Actions, entities.AutomodActionDelete)
+	}
+	if o.Warn {
+		rule.Actions = append(rule.Actions, entities.AutomodActionWarn)
+	}
+	if o.timeout > 0 {
+		rule.Actions = append(rule.Actions, entities.AutomodActionTimeout)
+	}
+	if len(rule.Actions) == 0 {
+		rule.Actions = append(rule.Actions, entities.AutomodActionLog)
+	}
+
+	// A regular expression can contain commas, so it is kept whole. Several can be combined with |.
+	if t == entities.AutomodRuleRegex && o.Values != "" {
+		rule.Values = []string{o.Values}
+	} else {
+		for _, value := range strings.Split(o.Values, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				rule.Values = append(rule.Values, value)
+			}
+		}
+	}
+
+	automod.ApplyDefaults(t, rule)
+	return rule
+}
+
+// disableAutomodRuleOptions are the options for the disable automod rule command.
+type disableAutomodRuleOptions struct {
+	// Rule is the type of the rule.
+	Rule string `option:"rule" description:"This is the rule to disable." required:"true" choices:"rate,duplicate,mentions,invites,domains,words,regex"`
+}
+
+// exemptAutomodOptions are the options for the exempt automod command.
+type exemptAutomodOptions struct {
+	// Rule is the type of the rule, or all to exempt from every rule.
+	Rule string `option:"rule" description:"This is the rule to exempt from." required:"true" choices:"all,rate,duplicate,mentions,invites,domains,words,regex"`
+
+	// Role is the role whose members are exempt.
+	Role *discordgo.Role `option:"role" description:"This is the role whose members are exempt."`
+
+	// Channel is the channel that is exempt.
+	Channel *discordgo.Channel `option:"channel" description:"This is the channel that is exempt."`
+
+	// Remove is whether the exemption is removed instead.
+	Remove bool `option:"remove" description:"This is whether the exemption is removed instead."`
+}
+
+// Validate checks that a role or channel was given.
+func (o *exemptAutomodOptions) Validate() error {
+	if o.Role == nil && o.Channel == nil {
+		return commands.NewUserError("Give a role or a channel to exempt.")
+	}
+	return nil
+}
+
+// enableAutomodRuleHandler is the handler for the enable automod rule command.
+func enableAutomodRuleHandler(c *commands.Context) error {
+	ctx := c.Context()
+	opts := c.Options().(*enableAutomodRuleOptions)
+	t := entities.AutomodRuleType(opts.Rule)
+
+	guild, err := getGuildConfig(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+
+	rule := opts.rule(guild.Automod.Rule(t))
+	if err := automod.ValidateRule(t, rule); err != nil {
+		return commands.NewUserError("The %s rule cannot be enabled: %s.", t, err.Error())
+	}
+
+	// Save the guild.
+	update := &dataaccess.GuildUpdate{Set: map[string]any{automodRulePath(t): rule}}
+	if err := dataaccess.GuildDB.UpdateGuild(ctx, c.GuildID, update); err != nil {
+		return fmt.Errorf("error saving guild: %w", err)
+	}
+
+	if err := c.RespondEphemeral(fmt.Sprintf("The %s rule has been enabled: %s", t, describeAutomodRule(rule))); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// disableAutomodRuleHandler is the handler for the disable automod rule command.
+func disableAutomodRuleHandler(c *commands.Context) error {
+	ctx := c.Context()
+	opts := c.Options().(*disableAutomodRuleOptions)
+	t := entities.AutomodRuleType(opts.Rule)
+
+	guild, err := getGuildConfig(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+
+	if guild.Automod.Rule(t) == nil {
+		return commands.NewUserError("The %s rule is not enabled.", t)
+	}
+
+	// Save the guild.
+	update := &dataaccess.GuildUpdate{Unset: []string{automodRulePath(t)}}
+	if err := dataaccess.GuildDB.UpdateGuild(ctx, c.GuildID, update); err != nil {
+		return fmt.Errorf("error saving guild: %w", err)
+	}
+
+	if err := c.RespondEphemeral(fmt.Sprintf("The %s rule has been disabled.", t)); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// exemptAutomodHandler is the handler for the exempt automod command.
+func exemptAutomodHandler(c *commands.Context) error {
+	ctx := c.Context()
+	opts := c.Options().(*exemptAutomodOptions)
+
+	guild, err := getGuildConfig(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+
+	types := []entities.AutomodRuleType{entities.AutomodRuleType(opts.Rule)}
+	if opts.Rule == automodRuleAll {
+		types = entities.AutomodRuleTypes
+	}
+
+	ids := make(map[string]any)
+	for _, t := range types {
+		if guild.Automod.Rule(t) == nil {
+			continue
+		}
+
+		if opts.Role != nil {
+			ids[automodRulePath(t)+".exempt_role_ids"] = opts.Role.ID
+		}
+		if opts.Channel != nil {
+			ids[automodRulePath(t)+".exempt_channel_ids"] = opts.Channel.ID
+		}
+	}
+
+	if len(ids) == 0 {
+		return commands.NewUserError("The %s rule is not enabled.", opts.Rule)
+	}
+
+	// Save the guild.
+	if err := dataaccess.GuildDB.UpdateGuild(ctx, c.GuildID, toggleIDs(opts.Remove, ids)); err != nil {
+		return fmt.Errorf("error saving guild: %w", err)
+	}
+
+	subjects := make([]string, 0, 2)
+	if opts.Role != nil {
+		subjects = append(subjects, fmt.Sprintf("<@&%s>", opts.Role.ID))
+	}
+	if opts.Channel != nil {
+		subjects = append(subjects, fmt.Sprintf("<#%s>", opts.Channel.ID))
+	}
+
+	msg := fmt.Sprintf("%s are now exempt from the %s automod rules.", strings.Join(subjects, " and "), opts.Rule)
+	if opts.Remove {
+		msg = fmt.Sprintf("%s are no longer exempt from the %s automod rules.", strings.Join(subjects, " and "), opts.Rule)
+	}
+	if err := c.RespondEphemeral(msg); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// automodRulePath returns the path of the rule of the type in the guild.
+func automodRulePath(t entities.AutomodRuleType) string {
+	return "automod.rules." + string(t)
+}
+
+// toggleIDs returns the update that adds the IDs to the array fields of the guild, keyed by the path of the fields,
+// or removes them if remove is set.
+func toggleIDs(remove bool, ids map[string]any) *dataaccess.GuildUpdate {
+	if remove {
+		return &dataaccess.GuildUpdate{Pull: ids}
+	}
+	return &dataaccess.GuildUpdate{AddToSet: ids}
+}
+
+// showAutomodHandler is the handler for the show automod command.
+func showAutomodHandler(c *commands.Context) error {
+	guild, err := getGuildConfig(c.Context(), c.GuildID)
+	if err != nil {
+		return err
+	}
+
+	fields := make([]*discordgo.MessageEmbedField, 0, len(entities.AutomodRuleTypes))
+	for _, t := range entities.AutomodRuleTypes {
+		rule := guild.Automod.Rule(t)
+		if rule == nil {
+			continue
+		}
+
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  string(t),
+			Value: truncate(describeAutomodRule(rule), eventLogFieldLength),
+		})
+	}
+
+	if len(fields) == 0 {
+		if err := c.RespondEphemeral("No automod rules are enabled."); err != nil {
+			return fmt.Errorf("error responding to interaction: %w", err)
+		}
+		return nil
+	}
+
+	err = c.Respond(&discordgo.InteractionResponseData{
+		Flags: discordgo.MessageFlagsEphemeral,
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:  "Automod Rules",
+				Fields: fields,
+				Color:  0x0099ff,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// describeAutomodRule returns a description of the settings of the rule.
+func describeAutomodRule(rule *entities.AutomodRule) string {
+	actions := make([]string, 0, len(rule.Actions))
+	for _, action := range rule.Actions {
+		if action == entities.AutomodActionTimeout {
+			actions = append(actions, fmt.Sprintf("%s (%s)", action, custom.FormatDuration(rule.TimeoutDuration)))
+			continue
+		}
+		actions = append(actions, string(action))
+	}
+
+	parts := []string{"actions: " + strings.Join(actions, ", ")}
+	if rule.Threshold > 0 {
+		parts = append(parts, fmt.Sprintf("threshold: %d", rule.Threshold))
+	}
+	if rule.Window > 0 {
+		parts = append(parts, "window: "+custom.FormatDuration(rule.Window))
+	}
+	if len(rule.Values) > 0 {
+		parts = append(parts, "values: "+truncate(strings.Join(rule.Values, ", "), automodValuesLength))
+	}
+	if len(rule.ExemptRoleIDs) > 0 {
+		parts = append(parts, "exempt roles: "+roleMentions(rule.ExemptRoleIDs))
+	}
+	if len(rule.ExemptChannelIDs) > 0 {
+		channels := make([]string, 0, len(rule.ExemptChannelIDs))
+		for _, id := range rule.ExemptChannelIDs {
+			channels = append(channels, fmt.Sprintf("<#%s>", id))
+		}
+		parts = append(parts, "exempt channels: "+strings.Join(channels, " "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// automodHandler evaluates the automod rules of the guild against each message, and enforces the rules it breaks.
+func (a *App) automodHandler() func(s *discordgo.Session, m *discordgo.MessageCreate) {
+	return func(s *discordgo.Session, m *discordgo.MessageCreate) {
+		if m.GuildID == "" || m.Author == nil || m.Author.Bot {
+			return
+		}
+
+		l := a.With(
+			slog.String(logging.KeyComponent, componentAutomod),
+			slog.String("guild_id", m.GuildID),
+			slog.String("user_id", m.Author.ID),
+		)
+
+		guild, err := getGuildConfig(a.ctx, m.GuildID)
+		if err != nil {
+			l.Error("Error getting automod configuration", slog.String(logging.KeyError, err.Error()))
+			return
+		}
+
+		hits := a.automod.Evaluate(&guild.Automod, m)
+		if len(hits) == 0 {
+			return
+		}
+
+		enforceAutomod(a.ctx, l, s, guild, m, hits)
+	}
+}
+
+// enforceAutomod takes the actions of the rules broken by the message, and records each broken rule as a case. The
+// message is deleted at most once, however many rules want it deleted.
+func enforceAutomod(ctx context.Context, l *slog.Logger, s *discordgo.Session, guild *entities.Guild, m *discordgo.MessageCreate, hits []*automod.Hit) {
+	deleted := false
+	for _, hit := range hits {
+		AutomodHits.WithLabelValues(string(hit.Type)).Inc()
+
+		reason := fmt.Sprintf("Automod %s rule: %s", hit.Type, hit.Reason)
+		hl := l.With(slog.String("rule", string(hit.Type)))
+
+		if hit.Rule.HasAction(entities.AutomodActionDelete) && !deleted {
+			err := s.ChannelMessageDelete(m.ChannelID, m.ID, discordgo.WithContext(ctx), discordgo.WithAuditLogReason(reason))
+			if err != nil && !isNotFound(err) {
+				hl.Warn("Error deleting message that broke automod rule", slog.String(logging.KeyError, err.Error()))
+			}
+			deleted = true
+		}
+
+		caseType := entities.CaseTypeAutomod
+		var duration time.Duration
+		switch {
+		case hit.Rule.HasAction(entities.AutomodActionTimeout):
+			until := time.Now().Add(hit.Rule.TimeoutDuration)
+			err := s.GuildMemberTimeout(m.GuildID, m.Author.ID, &until, discordgo.WithContext(ctx), discordgo.WithAuditLogReason(reason))
+			if err != nil {
+				// The rule was still broken, so it is recorded without the timeout.
+				hl.Warn("Error timing out member that broke automod rule", slog.String(logging.KeyError, err.Error()))
+				break
+			}
+			caseType = entities.CaseTypeTimeout
+			duration = hit.Rule.TimeoutDuration
+		case hit.Rule.HasAction(entities.AutomodActionWarn):
+			caseType = entities.CaseTypeWarn
+		}
+
+		if err := recordAutomodCase(ctx, hl, s, guild, m.Author.ID, caseType, duration, reason); err != nil {
+			hl.Error("Error recording automod case", slog.String(logging.KeyError, err.Error()))
+		}
+	}
+}
+
+// recordAutomodCase records the automod action against the member as a case, taken by the bot, and posts the case
+// to the mod-log channel of the guild.
+func recordAutomodCase(ctx context.Context, l *slog.Logger, s *discordgo.Session, guild *entities.Guild, targetID string, caseType entities.CaseType, duration time.Duration, reason string) error {
+	now := time.Now().UTC()
+	guildCase := &entities.Case{
+		GuildID:     guild.ID,
+		Type:        caseType,
+		ModeratorID: s.State.User.ID,
+		TargetID:    targetID,
+		Reason:      reason,
+		Duration:    duration,
+		CreatedAt:   custom.Datetime(now),
+	}
+	if duration > 0 {
+		guildCase.ExpiresAt = custom.Datetime(now.Add(duration))
+	}
+
+	// The member is only told about the actions taken against them.
+	if guild.Moderation.DMTargets && caseType != entities.CaseTypeAutomod {
+		notifyCaseTarget(ctx, l, s, guildCase)
+	}
+
+	if err := dataaccess.CaseDB.CreateCase(ctx, guildCase); err != nil {
+		return fmt.Errorf("error creating case: %w", err)
+	}
+	ModerationActions.WithLabelValues(string(caseType)).Inc()
+
+	postCaseLog(ctx, l, s, guild, guildCase)
+	return nil
+}
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Jacobbrewer1/wolf/pkg/dataaccess"
 	"github.com/Jacobbrewer1/wolf/pkg/dataaccess/connection"
 	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"github.com/Jacobbrewer1/wolf/pkg/request"
+	"github.com/Jacobbrewer1/wolf/pkg/tracing"
 )
 
 const (
@@ -24,6 +32,87 @@ const (
 
 	// EnvMonitoringPort is the environment variable for the monitoring port.
 	EnvMonitoringPort = `MONITORING_PORT`
+
+	// EnvCommandScope is the environment variable for where the slash commands are registered.
+	EnvCommandScope = `COMMAND_SCOPE`
+
+	// EnvDevGuildIDs is the environment variable for the comma separated guilds to register commands in.
+	EnvDevGuildIDs = `DEV_GUILD_IDS`
+
+	// EnvUnregisterCommands is the environment variable for unregistering the slash commands on shutdown.
+	EnvUnregisterCommands = `UNREGISTER_COMMANDS_ON_SHUTDOWN`
+
+	// EnvRunMigrations is the environment variable for applying the database migrations on startup.
+	EnvRunMigrations = `RUN_MIGRATIONS_ON_STARTUP`
+
+	// EnvCacheSize is the environment variable for the number of guilds and tickets that are cached.
+	EnvCacheSize = `CACHE_SIZE`
+
+	// EnvCacheTTL is the environment variable for how long the guilds and tickets are cached for.
+	EnvCacheTTL = `CACHE_TTL`
+
+	// EnvCacheChangeStreams is the environment variable for invalidating the cache with Mongo change streams.
+	EnvCacheChangeStreams = `CACHE_CHANGE_STREAMS`
+
+	// EnvShardCount is the environment variable for the total number of shards.
+	EnvShardCount = `SHARD_COUNT`
+
+	// EnvShardIDs is the environment variable for the comma separated shards run by this instance.
+	EnvShardIDs = `SHARD_IDS`
+
+	// EnvTracingExporter is the environment variable for where the traces are exported to.
+	EnvTracingExporter = `TRACING_EXPORTER`
+
+	// EnvAPIToken is the environment variable for the bearer token of the admin API.
+	EnvAPIToken = `API_TOKEN`
+
+	// EnvAPIClientCA is the environment variable for the CA certificates that sign the client certificates accepted
+	// by the admin API.
+	EnvAPIClientCA = `API_CLIENT_CA_FILE`
+
+	// EnvTLSCert is the environment variable for the certificate of the monitoring server.
+	EnvTLSCert = `TLS_CERT_FILE`
+
+	// EnvTLSKey is the environment variable for the private key of the monitoring server.
+	EnvTLSKey = `TLS_KEY_FILE`
+
+	// EnvMonitoringUsername is the environment variable for the basic auth username of the monitoring routes.
+	EnvMonitoringUsername = `MONITORING_USERNAME`
+
+	// EnvMonitoringPassword is the environment variable for the basic auth password of the monitoring routes.
+	EnvMonitoringPassword = `MONITORING_PASSWORD`
+
+	// EnvMonitoringToken is the environment variable for the bearer token of the monitoring routes.
+	EnvMonitoringToken = `MONITORING_TOKEN`
+
+	// EnvDebugEndpoints is the environment variable for serving the pprof and expvar routes.
+	EnvDebugEndpoints = `DEBUG_ENDPOINTS`
+
+	// EnvHTTPRateLimit is the environment variable for the number of requests per second allowed from each client
+	// of the monitoring server.
+	EnvHTTPRateLimit = `HTTP_RATE_LIMIT`
+
+	// EnvHTTPRateLimitBurst is the environment variable for the number of requests allowed at once from each client
+	// of the monitoring server.
+	EnvHTTPRateLimitBurst = `HTTP_RATE_LIMIT_BURST`
+
+	// EnvMessageCacheSize is the environment variable for the number of messages cached for the event logs.
+	EnvMessageCacheSize = `MESSAGE_CACHE_SIZE`
+
+	// EnvMessageCacheTTL is the environment variable for how long the messages are cached for the event logs.
+	EnvMessageCacheTTL = `MESSAGE_CACHE_TTL`
+
+	// EnvPublicURL is the environment variable for the URL that the monitoring server can be reached at by the members.
+	EnvPublicURL = `PUBLIC_URL`
+)
+
+const (
+	// CommandScopeGlobal registers the slash commands globally, for every guild the bot is in.
+	CommandScopeGlobal = "global"
+
+	// CommandScopeGuild registers the slash commands per guild. This is used for development guilds as the changes
+	// are visible immediately.
+	CommandScopeGuild = "guild"
 )
 
 var (
@@ -38,6 +127,84 @@ var (
 
 	// MonitoringPort is the port for the monitoring server.
 	MonitoringPort string
+
+	// CommandScope is where the slash commands are registered.
+	CommandScope string
+
+	// DevGuildIDs are the guilds to register the slash commands in when the command scope is guild. They are required
+	// when the command scope is guild.
+	DevGuildIDs []string
+
+	// UnregisterCommandsOnShutdown is whether to remove the slash commands when the bot shuts down.
+	UnregisterCommandsOnShutdown bool
+
+	// RunMigrationsOnStartup is whether to apply the database migrations on startup. When disabled, the migrations
+	// are applied with the migrate subcommand.
+	RunMigrationsOnStartup = true
+
+	// CacheSize is the number of guilds, and separately tickets, that are cached.
+	CacheSize = 1000
+
+	// CacheTTL is how long the guilds and tickets are cached for.
+	CacheTTL = 5 * time.Minute
+
+	// CacheChangeStreams is whether to invalidate the cache when the guilds and tickets are changed by other
+	// instances. This requires MongoDB to run as a replica set, as the cache is bypassed while the changes cannot be
+	// watched.
+	CacheChangeStreams bool
+
+	// ShardCount is the total number of shards. If zero, the number of shards recommended by Discord is used.
+	ShardCount int
+
+	// ShardIDs are the shards run by this instance. If empty, every shard is run.
+	ShardIDs []int
+
+	// TracingExporter is where the traces are exported to. The OTLP exporter is configured with the standard
+	// OTEL_EXPORTER_OTLP_* environment variables.
+	TracingExporter = tracing.ExporterNone
+
+	// APIToken is the bearer token of the admin API. The admin API is disabled if neither a token nor a client CA is
+	// configured.
+	APIToken string
+
+	// APIClientCAFile is the file of the CA certificates that sign the client certificates accepted by the admin API.
+	// This requires the monitoring server to be served with TLS.
+	APIClientCAFile string
+
+	// TLSCertFile is the certificate of the monitoring server. If empty, the monitoring server is served without TLS.
+	TLSCertFile string
+
+	// TLSKeyFile is the private key of the monitoring server.
+	TLSKeyFile string
+
+	// MonitoringUsername and MonitoringPassword are the basic auth credentials of the metrics and admin routes. The
+	// routes are open if neither these nor a token are configured.
+	MonitoringUsername, MonitoringPassword string
+
+	// MonitoringToken is the bearer token of the metrics and admin routes.
+	MonitoringToken string
+
+	// DebugEndpoints is whether the pprof and expvar routes are served under /debug.
+	DebugEndpoints bool
+
+	// HTTPRateLimit is the number of requests per second allowed from each client of the monitoring server. If zero,
+	// the requests are not limited.
+	HTTPRateLimit float64 = request.DefaultRate
+
+	// HTTPRateLimitBurst is the number of requests allowed at once from each client of the monitoring server.
+	HTTPRateLimitBurst = request.DefaultBurst
+
+	// MessageCacheSize is the number of messages cached, so the event logs can show the content of edited and deleted
+	// messages.
+	MessageCacheSize = 10000
+
+	// MessageCacheTTL is how long the messages are cached for the event logs.
+	MessageCacheTTL = 24 * time.Hour
+
+	// PublicURL is the URL that the monitoring server can be reached at by the members, such as
+	// https://wolf.example.com. When set, the captcha images are linked from the monitoring server rather than
+	// attached to the challenges.
+	PublicURL string
 )
 
 func parseConfig() {
@@ -65,13 +232,234 @@ func parseConfig() {
 		slog.Info("No monitoring port provided in environment, defaulting to 8080", slog.String("key", EnvMonitoringPort))
 	}
 
+	switch envCommandScope := os.Getenv(EnvCommandScope); envCommandScope {
+	case CommandScopeGlobal, CommandScopeGuild:
+		slog.Debug("Found command scope in environment", slog.String("key", EnvCommandScope))
+		CommandScope = envCommandScope
+	case "":
+		// Default to global if not provided.
+		CommandScope = CommandScopeGlobal
+		slog.Info("No command scope provided in environment, defaulting to global", slog.String("key", EnvCommandScope))
+	default:
+		slog.Error("Invalid command scope provided in environment",
+			slog.String("key", EnvCommandScope),
+			slog.String(logging.KeyError, "must be one of "+CommandScopeGlobal+" or "+CommandScopeGuild),
+		)
+		os.Exit(1)
+	}
+
+	if envDevGuildIDs := os.Getenv(EnvDevGuildIDs); envDevGuildIDs != "" {
+		slog.Debug("Found dev guild IDs in environment", slog.String("key", EnvDevGuildIDs))
+		for _, id := range strings.Split(envDevGuildIDs, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				DevGuildIDs = append(DevGuildIDs, id)
+			}
+		}
+	}
+
+	// Without the development guilds, the guild scoped commands would be registered in every guild.
+	if CommandScope == CommandScopeGuild && len(DevGuildIDs) == 0 {
+		slog.Error("No dev guild IDs provided in environment for the guild command scope", slog.String("key", EnvDevGuildIDs))
+		os.Exit(1)
+	}
+
+	if envUnregister := os.Getenv(EnvUnregisterCommands); envUnregister != "" {
+		unregister, err := strconv.ParseBool(envUnregister)
+		if err != nil {
+			slog.Error("Invalid value for unregistering commands on shutdown",
+				slog.String("key", EnvUnregisterCommands),
+				slog.String(logging.KeyError, err.Error()),
+			)
+			os.Exit(1)
+		}
+		UnregisterCommandsOnShutdown = unregister
+	}
+
+	if envRunMigrations := os.Getenv(EnvRunMigrations); envRunMigrations != "" {
+		run, err := strconv.ParseBool(envRunMigrations)
+		if err != nil {
+			slog.Error("Invalid value for running migrations on startup",
+				slog.String("key", EnvRunMigrations),
+				slog.String(logging.KeyError, err.Error()),
+			)
+			os.Exit(1)
+		}
+		RunMigrationsOnStartup = run
+	}
+
+	if envCacheSize := os.Getenv(EnvCacheSize); envCacheSize != "" {
+		size, err := strconv.Atoi(envCacheSize)
+		if err != nil || size < 1 {
+			slog.Error("Invalid value for cache size", slog.String("key", EnvCacheSize))
+			os.Exit(1)
+		}
+		CacheSize = size
+	}
+
+	if envCacheTTL := os.Getenv(EnvCacheTTL); envCacheTTL != "" {
+		ttl, err := time.ParseDuration(envCacheTTL)
+		if err != nil {
+			slog.Error("Invalid value for cache TTL",
+				slog.String("key", EnvCacheTTL),
+				slog.String(logging.KeyError, err.Error()),
+			)
+			os.Exit(1)
+		}
+		CacheTTL = ttl
+	}
+
+	if envChangeStreams := os.Getenv(EnvCacheChangeStreams); envChangeStreams != "" {
+		changeStreams, err := strconv.ParseBool(envChangeStreams)
+		if err != nil {
+			slog.Error("Invalid value for cache change streams",
+				slog.String("key", EnvCacheChangeStreams),
+				slog.String(logging.KeyError, err.Error()),
+			)
+			os.Exit(1)
+		}
+		CacheChangeStreams = changeStreams
+	}
+
+	if envShardCount := os.Getenv(EnvShardCount); envShardCount != "" {
+		count, err := strconv.Atoi(envShardCount)
+		if err != nil || count < 1 {
+			slog.Error("Invalid value for shard count", slog.String("key", EnvShardCount))
+			os.Exit(1)
+		}
+		ShardCount = count
+	}
+
+	if envShardIDs := os.Getenv(EnvShardIDs); envShardIDs != "" {
+		for _, str := range strings.Split(envShardIDs, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(str))
+			if err != nil {
+				slog.Error("Invalid value for shard IDs",
+					slog.String("key", EnvShardIDs),
+					slog.String(logging.KeyError, err.Error()),
+				)
+				os.Exit(1)
+			}
+			ShardIDs = append(ShardIDs, id)
+		}
+
+		if ShardCount == 0 {
+			// The shards of the other instances are unknown without the total.
+			slog.Error("The shard count must be provided with the shard IDs", slog.String("key", EnvShardCount))
+			os.Exit(1)
+		}
+	}
+
+	if envExporter := os.Getenv(EnvTracingExporter); envExporter != "" {
+		switch envExporter {
+		case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+			TracingExporter = envExporter
+		default:
+			slog.Error("Invalid value for tracing exporter", slog.String("key", EnvTracingExporter))
+			os.Exit(1)
+		}
+	}
+
+	if envAPIToken := os.Getenv(EnvAPIToken); envAPIToken != "" {
+		slog.Debug("Found API token in environment", slog.String("key", EnvAPIToken))
+		APIToken = envAPIToken
+	}
+
+	APIClientCAFile = os.Getenv(EnvAPIClientCA)
+	TLSCertFile = os.Getenv(EnvTLSCert)
+	TLSKeyFile = os.Getenv(EnvTLSKey)
+
+	if (TLSCertFile == "") != (TLSKeyFile == "") {
+		slog.Error("The TLS certificate and key must be provided together",
+			slog.String("cert_key", EnvTLSCert),
+			slog.String("key_key", EnvTLSKey),
+		)
+		os.Exit(1)
+	}
+
+	if APIClientCAFile != "" && TLSCertFile == "" {
+		// Client certificates can only be verified over TLS.
+		slog.Error("The TLS certificate must be provided with the API client CA", slog.String("key", EnvTLSCert))
+		os.Exit(1)
+	}
+
+	MonitoringUsername = os.Getenv(EnvMonitoringUsername)
+	MonitoringPassword = os.Getenv(EnvMonitoringPassword)
+	MonitoringToken = os.Getenv(EnvMonitoringToken)
+
+	if (MonitoringUsername == "") != (MonitoringPassword == "") {
+		slog.Error("The monitoring username and password must be provided together",
+			slog.String("username_key", EnvMonitoringUsername),
+			slog.String("password_key", EnvMonitoringPassword),
+		)
+		os.Exit(1)
+	}
+
+	if envDebug := os.Getenv(EnvDebugEndpoints); envDebug != "" {
+		debug, err := strconv.ParseBool(envDebug)
+		if err != nil {
+			slog.Error("Invalid value for debug endpoints",
+				slog.String("key", EnvDebugEndpoints),
+				slog.String(logging.KeyError, err.Error()),
+			)
+			os.Exit(1)
+		}
+		DebugEndpoints = debug
+	}
+
+	if envRateLimit := os.Getenv(EnvHTTPRateLimit); envRateLimit != "" {
+		limit, err := strconv.ParseFloat(envRateLimit, 64)
+		if err != nil || limit < 0 {
+			slog.Error("Invalid value for HTTP rate limit", slog.String("key", EnvHTTPRateLimit))
+			os.Exit(1)
+		}
+		HTTPRateLimit = limit
+	}
+
+	if envBurst := os.Getenv(EnvHTTPRateLimitBurst); envBurst != "" {
+		burst, err := strconv.Atoi(envBurst)
+		if err != nil || burst < 1 {
+			slog.Error("Invalid value for HTTP rate limit burst", slog.String("key", EnvHTTPRateLimitBurst))
+			os.Exit(1)
+		}
+		HTTPRateLimitBurst = burst
+	}
+
+	if envMessageCacheSize := os.Getenv(EnvMessageCacheSize); envMessageCacheSize != "" {
+		size, err := strconv.Atoi(envMessageCacheSize)
+		if err != nil || size < 1 {
+			slog.Error("Invalid value for message cache size", slog.String("key", EnvMessageCacheSize))
+			os.Exit(1)
+		}
+		MessageCacheSize = size
+	}
+
+	if envMessageCacheTTL := os.Getenv(EnvMessageCacheTTL); envMessageCacheTTL != "" {
+		ttl, err := time.ParseDuration(envMessageCacheTTL)
+		if err != nil {
+			slog.Error("Invalid value for message cache TTL",
+				slog.String("key", EnvMessageCacheTTL),
+				slog.String(logging.KeyError, err.Error()),
+			)
+			os.Exit(1)
+		}
+		MessageCacheTTL = ttl
+	}
+
+	if envPublicURL := os.Getenv(EnvPublicURL); envPublicURL != "" {
+		u, err := url.Parse(envPublicURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			slog.Error("Invalid value for public URL", slog.String("key", EnvPublicURL))
+			os.Exit(1)
+		}
+		PublicURL = strings.TrimSuffix(envPublicURL, "/")
+	}
+
 	if BotToken != "" &&
 		ApplicationId != "" &&
 		MongoUri != "" {
 
 		// All required environment variables have been provided.
 		slog.Debug("All required environment variables have been provided")
-		connectMongo()
 		return
 	}
 
@@ -79,19 +467,28 @@ func parseConfig() {
 	os.Exit(1)
 }
 
-func connectMongo() {
+// connectMongo connects to MongoDB and creates the data access layers.
+func connectMongo() error {
 	mongoConn := new(connection.MongoDB)
 	mongoConn.ConnectionString = MongoUri
 
 	db, err := mongoConn.Connect()
 	if err != nil {
-		slog.Error("Error connecting to mongo", slog.String(logging.KeyError, err.Error()))
-		os.Exit(1)
+		return fmt.Errorf("error connecting to mongo: %w", err)
 	} else if db == nil {
-		slog.Error("MongoDB came back nil", slog.String(logging.KeyError, "MongoDB came back nil"))
-		os.Exit(1)
+		return errors.New("MongoDB came back nil")
 	}
 
 	dataaccess.MongoDB = db
+	dataaccess.GuildDB = dataaccess.NewCachedGuildDal(dataaccess.NewGuildDal(), CacheSize, CacheTTL)
+	dataaccess.TicketDB = dataaccess.NewCachedTicketDal(dataaccess.NewTicketDal(), CacheSize, CacheTTL)
+	dataaccess.CaseDB = dataaccess.NewCaseDal()
+	dataaccess.ScheduledActionDB = dataaccess.NewScheduledActionDal()
+	dataaccess.RaidDB = dataaccess.NewRaidDal()
+	dataaccess.RolePanelDB = dataaccess.NewRolePanelDal()
+	dataaccess.VerificationDB = dataaccess.NewVerificationDal()
+	dataaccess.MemberSnapshotDB = dataaccess.NewMemberSnapshotDal()
+	dataaccess.LeaseDB = dataaccess.NewLeaseDal()
 	slog.Debug("Connected to MongoDB", slog.String("key", EnvMongoUri))
+	return nil
 }
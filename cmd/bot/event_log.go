@@ -0,0 +1,603 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/batch"
+	"github.com/Jacobbrewer1/wolf/pkg/cache"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+)
+
+const (
+	// eventLogMaxEmbeds is the maximum number of embeds sent in a single message.
+	eventLogMaxEmbeds = 10
+
+	// eventLogMaxEmbedsLength is the maximum total length of the embeds sent in a single message.
+	eventLogMaxEmbedsLength = 6000
+
+	// eventLogFieldLength is the maximum length of the content shown in an embed field.
+	eventLogFieldLength = 1024
+
+	// eventLogDescriptionLength is the maximum length of the description of an embed.
+	eventLogDescriptionLength = 4096
+
+	// eventLogBulkLineLength is the maximum length of the content of each message listed in a bulk delete.
+	eventLogBulkLineLength = 200
+
+	// eventLogNotCached is shown in place of the content of a message that was not cached.
+	eventLogNotCached = "*The message was not cached.*"
+)
+
+// eventLogColors are the colors of the event log embeds, by the type of the event.
+var eventLogColors = map[entities.LogType]int{
+	entities.LogTypeMessageEdit:       0x0099ff,
+	entities.LogTypeMessageDelete:     0xff0000,
+	entities.LogTypeMessageBulkDelete: 0xff0000,
+	entities.LogTypeMemberJoin:        0x00ff00,
+	entities.LogTypeMemberLeave:       0xff6600,
+	entities.LogTypeMemberBan:         0xff0000,
+	entities.LogTypeMemberNickname:    0x0099ff,
+	entities.LogTypeMemberRoles:       0x0099ff,
+	entities.LogTypeChannelCreate:     0x00ff00,
+	entities.LogTypeChannelDelete:     0xff6600,
+	entities.LogTypeInviteCreate:      0x00ff00,
+	entities.LogTypeVerification:      0x0099ff,
+}
+
+// cachedMessage is a message kept so the event logs can show its content once it is edited or deleted.
+type cachedMessage struct {
+	// AuthorID is the ID of the author of the message.
+	AuthorID string
+
+	// Content is the content of the message.
+	Content string
+
+	// Attachments is the number of attachments on the message.
+	Attachments int
+}
+
+// eventLog sends the server events to the log channels configured by each guild.
+type eventLog struct {
+	// l is the logger.
+	l *slog.Logger
+
+	// s is the discord session used to send the logs.
+	s *discordgo.Session
+
+	// messages are the recent messages of the guilds that log edits or deletes, by the message ID.
+	messages *cache.Cache[string, *cachedMessage]
+
+	// batcher groups the embeds by the channel they are sent to, so bursts of events are sent in fewer messages.
+	batcher *batch.Batcher[*discordgo.MessageEmbed]
+}
+
+// newEventLog creates a new eventLog that sends the logs with the session.
+func newEventLog(l *slog.Logger, s *discordgo.Session) *eventLog {
+	e := &eventLog{
+		l:        l,
+		s:        s,
+		messages: cache.New[string, *cachedMessage](MessageCacheSize, MessageCacheTTL),
+	}
+	e.batcher = batch.New(e.send, batch.WithMaxItems(eventLogMaxEmbeds))
+	return e
+}
+
+// run sends the queued logs until the context is cancelled.
+func (e *eventLog) run(ctx context.Context) {
+	e.batcher.Run(ctx)
+}
+
+// config gets the logging configuration of the guild.
+func (e *eventLog) config(ctx context.Context, guildID string) (*entities.LoggingConfig, error) {
+	guild, err := getGuildConfig(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	return &guild.Logging, nil
+}
+
+// channel returns the ID of the channel that the type of event is logged to by the guild, or an empty string if it is
+// not logged.
+func (e *eventLog) channel(ctx context.Context, guildID string, t entities.LogType) string {
+	cfg, err := e.config(ctx, guildID)
+	if err != nil {
+		e.l.Error("Error getting logging configuration",
+			slog.String("guild_id", guildID),
+			slog.String(logging.KeyError, err.Error()),
+		)
+		return ""
+	}
+	return cfg.Channel(t)
+}
+
+// log queues the embed to be sent to the channel that the type of event is logged to by the guild.
+func (e *eventLog) log(ctx context.Context, guildID string, t entities.LogType, embed *discordgo.MessageEmbed) {
+	channelID := e.channel(ctx, guildID, t)
+	if channelID == "" {
+		return
+	}
+
+	embed.Color = eventLogColors[t]
+	embed.Timestamp = time.Now().UTC().Format(time.RFC3339)
+
+	if !e.batcher.Add(channelID, embed) {
+		LoggedEvents.WithLabelValues(string(t), "dropped").Inc()
+		e.l.Warn("Event log is full, dropping event",
+			slog.String("guild_id", guildID),
+			slog.String("channel_id", channelID),
+			slog.String("type", string(t)),
+		)
+		return
+	}
+	LoggedEvents.WithLabelValues(string(t), "queued").Inc()
+}
+
+// send sends the embeds to the channel, splitting them so each message stays within the limits of Discord.
+func (e *eventLog) send(ctx context.Context, channelID string, embeds []*discordgo.MessageEmbed) {
+	for len(embeds) > 0 {
+		n, length := 0, 0
+		for n < len(embeds) {
+			length += embedLength(embeds[n])
+			if n > 0 && length > eventLogMaxEmbedsLength {
+				break
+			}
+			n++
+		}
+
+		if _, err := e.s.ChannelMessageSendEmbeds(channelID, embeds[:n], discordgo.WithContext(ctx)); err != nil {
+			e.l.Error("Error sending event log",
+				slog.String("channel_id", channelID),
+				slog.Int("embeds", n),
+				slog.String(logging.KeyError, err.Error()),
+			)
+		}
+		embeds = embeds[n:]
+	}
+}
+
+// embedLength returns the length of the embed, as counted towards the limits of Discord.
+func embedLength(embed *discordgo.MessageEmbed) int {
+	length := len([]rune(embed.Title)) + len([]rune(embed.Description))
+	for _, field := range embed.Fields {
+		length += len([]rune(field.Name)) + len([]rune(field.Value))
+	}
+	if embed.Footer != nil {
+		length += len([]rune(embed.Footer.Text))
+	}
+	if embed.Author != nil {
+		length += len([]rune(embed.Author.Name))
+	}
+	return length
+}
+
+// logsMessages returns true if the guild logs message edits or deletes, so its messages need to be cached.
+func (e *eventLog) logsMessages(ctx context.Context, guildID string) bool {
+	cfg, err := e.config(ctx, guildID)
+	if err != nil {
+		e.l.Error("Error getting logging configuration",
+			slog.String("guild_id", guildID),
+			slog.String(logging.KeyError, err.Error()),
+		)
+		return false
+	}
+	return cfg.Channel(entities.LogTypeMessageEdit) != "" ||
+		cfg.Channel(entities.LogTypeMessageDelete) != "" ||
+		cfg.Channel(entities.LogTypeMessageBulkDelete) != ""
+}
+
+// messageContent returns the content of the cached message to show in an embed.
+func messageContent(msg *cachedMessage, n int) string {
+	if msg == nil {
+		return eventLogNotCached
+	}
+
+	content := msg.Content
+	if msg.Attachments > 0 {
+		content = strings.TrimSpace(fmt.Sprintf("%s\n*%d attachment(s)*", content, msg.Attachments))
+	}
+	if content == "" {
+		return "*No content.*"
+	}
+	return truncate(content, n)
+}
+
+// userField returns an embed field showing the user.
+func userField(name string, user *discordgo.User) *discordgo.MessageEmbedField {
+	return &discordgo.MessageEmbedField{
+		Name:   name,
+		Value:  fmt.Sprintf("%s (%s)", user.Mention(), user.ID),
+		Inline: true,
+	}
+}
+
+// messageCreateLogHandler caches the messages of the guilds that log edits or deletes.
+func (a *App) messageCreateLogHandler() func(s *discordgo.Session, m *discordgo.MessageCreate) {
+	return func(s *discordgo.Session, m *discordgo.MessageCreate) {
+		if m.GuildID == "" || m.Author == nil || m.Author.Bot {
+			return
+		}
+
+		if !a.events.logsMessages(a.ctx, m.GuildID) {
+			return
+		}
+
+		a.events.messages.Set(m.ID, &cachedMessage{
+			AuthorID:    m.Author.ID,
+			Content:     m.Content,
+			Attachments: len(m.Attachments),
+		})
+	}
+}
+
+// messageUpdateLogHandler logs the edits to messages.
+func (a *App) messageUpdateLogHandler() func(s *discordgo.Session, m *discordgo.MessageUpdate) {
+	return func(s *discordgo.Session, m *discordgo.MessageUpdate) {
+		// Updates without an author are embeds being added to the message, not edits.
+		if m.GuildID == "" || m.Author == nil || m.Author.Bot {
+			return
+		}
+
+		before, _ := a.events.messages.Get(m.ID)
+		after := &cachedMessage{
+			AuthorID:    m.Author.ID,
+			Content:     m.Content,
+			Attachments: len(m.Attachments),
+		}
+		if before != nil && *before == *after {
+			return
+		}
+		if before != nil || a.events.logsMessages(a.ctx, m.GuildID) {
+			a.events.messages.Set(m.ID, after)
+		}
+
+		a.events.log(a.ctx, m.GuildID, entities.LogTypeMessageEdit, &discordgo.MessageEmbed{
+			Title:       "Message Edited",
+			Description: fmt.Sprintf("[Jump to message](https://discord.com/channels/%s/%s/%s)", m.GuildID, m.ChannelID, m.ID),
+			Fields: []*discordgo.MessageEmbedField{
+				userField("Author", m.Author),
+				{
+					Name:   "Channel",
+					Value:  fmt.Sprintf("<#%s>", m.ChannelID),
+					Inline: true,
+				},
+				{
+					Name:  "Before",
+					Value: messageContent(before, eventLogFieldLength),
+				},
+				{
+					Name:  "After",
+					Value: messageContent(after, eventLogFieldLength),
+				},
+			},
+		})
+	}
+}
+
+// messageDeleteLogHandler logs the deleted messages.
+func (a *App) messageDeleteLogHandler() func(s *discordgo.Session, m *discordgo.MessageDelete) {
+	return func(s *discordgo.Session, m *discordgo.MessageDelete) {
+		if m.GuildID == "" {
+			return
+		}
+
+		msg, _ := a.events.messages.Get(m.ID)
+		a.events.messages.Delete(m.ID)
+
+		author := "Unknown"
+		if msg != nil {
+			author = fmt.Sprintf("<@%s> (%s)", msg.AuthorID, msg.AuthorID)
+		}
+
+		a.events.log(a.ctx, m.GuildID, entities.LogTypeMessageDelete, &discordgo.MessageEmbed{
+			Title: "Message Deleted",
+			Fields: []*discordgo.MessageEmbedField{
+				{
+					Name:   "Author",
+					Value:  author,
+					Inline: true,
+				},
+				{
+					Name:   "Channel",
+					Value:  fmt.Sprintf("<#%s>", m.ChannelID),
+					Inline: true,
+				},
+				{
+					Name:  "Content",
+					Value: messageContent(msg, eventLogFieldLength),
+				},
+			},
+			Footer: &discordgo.MessageEmbedFooter{
+				Text: fmt.Sprintf("Message ID: %s", m.ID),
+			},
+		})
+	}
+}
+
+// messageDeleteBulkLogHandler logs the messages deleted at once.
+func (a *App) messageDeleteBulkLogHandler() func(s *discordgo.Session, m *discordgo.MessageDeleteBulk) {
+	return func(s *discordgo.Session, m *discordgo.MessageDeleteBulk) {
+		if m.GuildID == "" {
+			return
+		}
+
+		// The cached messages are listed for as long as they fit in the description.
+		lines := make([]string, 0, len(m.Messages))
+		length := 0
+		for _, id := range m.Messages {
+			msg, ok := a.events.messages.Get(id)
+			if !ok {
+				continue
+			}
+			a.events.messages.Delete(id)
+
+			line := fmt.Sprintf("<@%s>: %s", msg.AuthorID, messageContent(msg, eventLogBulkLineLength))
+			if length+len([]rune(line))+1 > eventLogDescriptionLength {
+				continue
+			}
+			lines = append(lines, line)
+			length += len([]rune(line)) + 1
+		}
+
+		description := strings.Join(lines, "\n")
+		if description == "" {
+			description = "*None of the messages were cached.*"
+		}
+
+		a.events.log(a.ctx, m.GuildID, entities.LogTypeMessageBulkDelete, &discordgo.MessageEmbed{
+			Title:       fmt.Sprintf("%d Messages Deleted", len(m.Messages)),
+			Description: description,
+			Fields: []*discordgo.MessageEmbedField{
+				{
+					Name:  "Channel",
+					Value: fmt.Sprintf("<#%s>", m.ChannelID),
+				},
+			},
+		})
+	}
+}
+
+// memberAddLogHandler logs the members joining.
+func (a *App) memberAddLogHandler() func(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+	return func(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+		if m.Member == nil || m.User == nil {
+			return
+		}
+
+		fields := []*discordgo.MessageEmbedField{userField("Member", m.User)}
+		if created, err := discordgo.SnowflakeTimestamp(m.User.ID); err == nil {
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:   "Account Created",
+				Value:  fmt.Sprintf("<t:%d:R>", created.Unix()),
+				Inline: true,
+			})
+		}
+
+		a.events.log(a.ctx, m.GuildID, entities.LogTypeMemberJoin, &discordgo.MessageEmbed{
+			Title:  "Member Joined",
+			Fields: fields,
+		})
+	}
+}
+
+// memberRemoveLogHandler logs the members leaving.
+func (a *App) memberRemoveLogHandler() func(s *discordgo.Session, m *discordgo.GuildMemberRemove) {
+	return func(s *discordgo.Session, m *discordgo.GuildMemberRemove) {
+		if m.Member == nil || m.User == nil {
+			return
+		}
+
+		a.events.log(a.ctx, m.GuildID, entities.LogTypeMemberLeave, &discordgo.MessageEmbed{
+			Title:  "Member Left",
+			Fields: []*discordgo.MessageEmbedField{userField("Member", m.User)},
+		})
+	}
+}
+
+// banAddLogHandler logs the users being banned.
+func (a *App) banAddLogHandler() func(s *discordgo.Session, b *discordgo.GuildBanAdd) {
+	return func(s *discordgo.Session, b *discordgo.GuildBanAdd) {
+		if b.User == nil {
+			return
+		}
+
+		a.events.log(a.ctx, b.GuildID, entities.LogTypeMemberBan, &discordgo.MessageEmbed{
+			Title:  "Member Banned",
+			Fields: []*discordgo.MessageEmbedField{userField("User", b.User)},
+		})
+	}
+}
+
+// banRemoveLogHandler logs the bans being removed.
+func (a *App) banRemoveLogHandler() func(s *discordgo.Session, b *discordgo.GuildBanRemove) {
+	return func(s *discordgo.Session, b *discordgo.GuildBanRemove) {
+		if b.User == nil {
+			return
+		}
+
+		a.events.log(a.ctx, b.GuildID, entities.LogTypeMemberBan, &discordgo.MessageEmbed{
+			Title:  "Member Unbanned",
+			Fields: []*discordgo.MessageEmbedField{userField("User", b.User)},
+		})
+	}
+}
+
+// memberUpdateLogHandler logs the changes to the nicknames and roles of the members. The changes are only known for
+// the members that were in the state before the update.
+func (a *App) memberUpdateLogHandler() func(s *discordgo.Session, m *discordgo.GuildMemberUpdate) {
+	return func(s *discordgo.Session, m *discordgo.GuildMemberUpdate) {
+		if m.Member == nil || m.User == nil || m.BeforeUpdate == nil {
+			return
+		}
+
+		if m.BeforeUpdate.Nick != m.Nick {
+			a.events.log(a.ctx, m.GuildID, entities.LogTypeMemberNickname, &discordgo.MessageEmbed{
+				Title: "Nickname Changed",
+				Fields: []*discordgo.MessageEmbedField{
+					userField("Member", m.User),
+					{
+						Name:  "Before",
+						Value: nicknameOrNone(m.BeforeUpdate.Nick),
+					},
+					{
+						Name:  "After",
+						Value: nicknameOrNone(m.Nick),
+					},
+				},
+			})
+		}
+
+		added := roleDifference(m.Roles, m.BeforeUpdate.Roles)
+		removed := roleDifference(m.BeforeUpdate.Roles, m.Roles)
+		if len(added) == 0 && len(removed) == 0 {
+			return
+		}
+
+		fields := []*discordgo.MessageEmbedField{userField("Member", m.User)}
+		if len(added) > 0 {
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:  "Added",
+				Value: truncate(roleMentions(added), eventLogFieldLength),
+			})
+		}
+		if len(removed) > 0 {
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:  "Removed",
+				Value: truncate(roleMentions(removed), eventLogFieldLength),
+			})
+		}
+
+		a.events.log(a.ctx, m.GuildID, entities.LogTypeMemberRoles, &discordgo.MessageEmbed{
+			Title:  "Roles Changed",
+			Fields: fields,
+		})
+	}
+}
+
+// nicknameOrNone returns the nickname, or a placeholder if the member has no nickname.
+func nicknameOrNone(nick string) string {
+	if nick == "" {
+		return "*None*"
+	}
+	return truncate(nick, eventLogFieldLength)
+}
+
+// roleDifference returns the roles that are in a but not in b.
+func roleDifference(a, b []string) []string {
+	diff := make([]string, 0)
+	for _, role := range a {
+		if !slices.Contains(b, role) {
+			diff = append(diff, role)
+		}
+	}
+	return diff
+}
+
+// roleMentions returns the mentions of the roles.
+func roleMentions(roleIDs []string) string {
+	mentions := make([]string, 0, len(roleIDs))
+	for _, id := range roleIDs {
+		mentions = append(mentions, fmt.Sprintf("<@&%s>", id))
+	}
+	return strings.Join(mentions, " ")
+}
+
+// channelCreateLogHandler logs the channels being created.
+func (a *App) channelCreateLogHandler() func(s *discordgo.Session, c *discordgo.ChannelCreate) {
+	return func(s *discordgo.Session, c *discordgo.ChannelCreate) {
+		if c.Channel == nil || c.GuildID == "" {
+			return
+		}
+
+		a.events.log(a.ctx, c.GuildID, entities.LogTypeChannelCreate, &discordgo.MessageEmbed{
+			Title: "Channel Created",
+			Fields: []*discordgo.MessageEmbedField{
+				{
+					Name:   "Channel",
+					Value:  fmt.Sprintf("<#%s> (%s)", c.ID, c.Name),
+					Inline: true,
+				},
+			},
+			Footer: &discordgo.MessageEmbedFooter{
+				Text: fmt.Sprintf("Channel ID: %s", c.ID),
+			},
+		})
+	}
+}
+
+// channelDeleteLogHandler logs the channels being deleted.
+func (a *App) channelDeleteLogHandler() func(s *discordgo.Session, c *discordgo.ChannelDelete) {
+	return func(s *discordgo.Session, c *discordgo.ChannelDelete) {
+		if c.Channel == nil || c.GuildID == "" {
+			return
+		}
+
+		a.events.log(a.ctx, c.GuildID, entities.LogTypeChannelDelete, &discordgo.MessageEmbed{
+			Title: "Channel Deleted",
+			Fields: []*discordgo.MessageEmbedField{
+				{
+					Name:   "Channel",
+					Value:  fmt.Sprintf("#%s", c.Name),
+					Inline: true,
+				},
+			},
+			Footer: &discordgo.MessageEmbedFooter{
+				Text: fmt.Sprintf("Channel ID: %s", c.ID),
+			},
+		})
+	}
+}
+
+// inviteCreateLogHandler logs the invites being created.
+func (a *App) inviteCreateLogHandler() func(s *discordgo.Session, i *discordgo.InviteCreate) {
+	return func(s *discordgo.Session, i *discordgo.InviteCreate) {
+		if i.Invite == nil || i.GuildID == "" {
+			return
+		}
+
+		fields := make([]*discordgo.MessageEmbedField, 0, 4)
+		if i.Inviter != nil {
+			fields = append(fields, userField("Inviter", i.Inviter))
+		}
+
+		maxUses := "Unlimited"
+		if i.MaxUses > 0 {
+			maxUses = fmt.Sprintf("%d", i.MaxUses)
+		}
+
+		expires := "Never"
+		if i.MaxAge > 0 {
+			expires = fmt.Sprintf("<t:%d:R>", i.CreatedAt.Add(time.Duration(i.MaxAge)*time.Second).Unix())
+		}
+
+		fields = append(fields,
+			&discordgo.MessageEmbedField{
+				Name:   "Channel",
+				Value:  fmt.Sprintf("<#%s>", i.ChannelID),
+				Inline: true,
+			},
+			&discordgo.MessageEmbedField{
+				Name:   "Max Uses",
+				Value:  maxUses,
+				Inline: true,
+			},
+			&discordgo.MessageEmbedField{
+				Name:   "Expires",
+				Value:  expires,
+				Inline: true,
+			},
+		)
+
+		a.events.log(a.ctx, i.GuildID, entities.LogTypeInviteCreate, &discordgo.MessageEmbed{
+			Title:       "Invite Created",
+			Description: fmt.Sprintf("discord.gg/%s", i.Code),
+			Fields:      fields,
+		})
+	}
+}
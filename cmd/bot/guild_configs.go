@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// getGuildConfig gets the configuration of the guild, or a new configuration if it has none. The guilds without a
+// configuration are cached by the guild data access layer, so the gateway events of the guilds that are not configured
+// do not query the database each time.
+func getGuildConfig(ctx context.Context, guildID string) (*entities.Guild, error) {
+	guild, err := dataaccess.GuildDB.GetGuildByID(ctx, guildID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("error getting guild: %w", err)
+	}
+
+	if guild == nil {
+		guild = &entities.Guild{
+			ID: guildID,
+		}
+	}
+	return guild, nil
+}
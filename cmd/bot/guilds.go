@@ -9,27 +9,25 @@ import (
 )
 
 func (a *App) guildJoinedHandler() func(s *discordgo.Session, g *discordgo.GuildCreate) {
-	return func(_ *discordgo.Session, g *discordgo.GuildCreate) {
+	return func(s *discordgo.Session, g *discordgo.GuildCreate) {
 		slog.Info(fmt.Sprintf("Joined guild %s", g.Name))
 
-		if err := a.registerSlashCommands(); err != nil {
+		// Only the guild that was joined is touched.
+		if err := a.registerGuildSlashCommands(g.ID); err != nil {
 			slog.Error("Error registering slash commands", slog.String(logging.KeyError, err.Error()))
 		}
 
-		// Increment the total number of guilds.
-		TotalDiscordGuilds.Inc()
+		// Increment the total number of guilds on the shard.
+		TotalDiscordGuilds.WithLabelValues(shardLabel(s)).Inc()
 	}
 }
 
 func (a *App) guildLeaveHandler() func(s *discordgo.Session, g *discordgo.GuildDelete) {
-	return func(_ *discordgo.Session, g *discordgo.GuildDelete) {
+	return func(s *discordgo.Session, g *discordgo.GuildDelete) {
+		// The commands are not unregistered as the bot no longer has access to the guild.
 		slog.Info(fmt.Sprintf("Left guild %s", g.Name))
 
-		if err := a.unregisterSlashCommands(); err != nil {
-			slog.Error("Error unregistering slash commands", slog.String(logging.KeyError, err.Error()))
-		}
-
-		// Decrement the total number of guilds.
-		TotalDiscordGuilds.Dec()
+		// Decrement the total number of guilds on the shard.
+		TotalDiscordGuilds.WithLabelValues(shardLabel(s)).Dec()
 	}
 }
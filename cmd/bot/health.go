@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess"
+	dbMonitoring "github.com/Jacobbrewer1/wolf/pkg/dataaccess/monitoring"
+	"github.com/Jacobbrewer1/wolf/pkg/request"
+	"github.com/alexliesenfeld/health"
+)
+
+const (
+	// probeLiveness is the name of the liveness probe, which fails when the application must be restarted.
+	probeLiveness = "liveness"
+
+	// probeReadiness is the name of the readiness probe, which fails when the application cannot serve.
+	probeReadiness = "readiness"
+
+	// probeStartup is the name of the startup probe, which fails until the application has started.
+	probeStartup = "startup"
+
+	// gatewayMaxDisconnected is how long a shard can be disconnected from the gateway before the application is
+	// restarted. The shards reconnect by themselves, so this is only reached when a reconnect is stuck.
+	gatewayMaxDisconnected = 5 * time.Minute
+
+	// startupRetryAfter is the Retry-After given to the requests that are rejected during startup.
+	startupRetryAfter = 5 * time.Second
+)
+
+// startupState tracks the progress of the startup, which is reported by the startup probe.
+type startupState struct {
+	mut sync.RWMutex
+
+	// stage is what the application is currently doing.
+	stage string
+
+	// done is whether the startup has completed.
+	done bool
+}
+
+// newStartupState creates a new startupState.
+func newStartupState() *startupState {
+	return &startupState{
+		stage: "initialising",
+	}
+}
+
+// setStage records what the application is currently doing.
+func (s *startupState) setStage(stage string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.stage = stage
+}
+
+// complete records that the startup has completed.
+func (s *startupState) complete() {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.stage = ""
+	s.done = true
+}
+
+// isDone returns true if the startup has completed.
+func (s *startupState) isDone() bool {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	return s.done
+}
+
+// check returns an error with the current stage until the startup has completed.
+func (s *startupState) check(_ context.Context) error {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	if !s.done {
+		return fmt.Errorf("starting: %s", s.stage)
+	}
+	return nil
+}
+
+// shardConnection is the connection of a shard to the gateway.
+type shardConnection struct {
+	// connected is whether the shard is connected.
+	connected bool
+
+	// since is when the shard connected or disconnected.
+	since time.Time
+}
+
+// gatewayState tracks the connections of the shards to the gateway from their connect and disconnect events.
+type gatewayState struct {
+	mut    sync.RWMutex
+	shards map[int]*shardConnection
+}
+
+// newGatewayState creates a new gatewayState.
+func newGatewayState() *gatewayState {
+	return &gatewayState{
+		shards: make(map[int]*shardConnection),
+	}
+}
+
+// track follows the connection of the shard. The shard is disconnected until it first connects.
+func (g *gatewayState) track(shard *discordgo.Session) {
+	g.set(shard.ShardID, false)
+
+	shard.AddHandler(func(s *discordgo.Session, _ *discordgo.Connect) {
+		g.set(s.ShardID, true)
+	})
+	shard.AddHandler(func(s *discordgo.Session, _ *discordgo.Disconnect) {
+		g.set(s.ShardID, false)
+	})
+}
+
+// set records that the shard has connected or disconnected.
+func (g *gatewayState) set(shardID int, connected bool) {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+
+	if conn, ok := g.shards[shardID]; ok && conn.connected == connected {
+		return
+	}
+
+	g.shards[shardID] = &shardConnection{
+		connected: connected,
+		since:     time.Now(),
+	}
+
+	value := 0.0
+	if connected {
+		value = 1
+	}
+	GatewayConnected.WithLabelValues(strconv.Itoa(shardID)).Set(value)
+}
+
+// check returns an error listing the shards that have been disconnected for longer than maxDisconnected.
+func (g *gatewayState) check(maxDisconnected time.Duration) error {
+	g.mut.RLock()
+	defer g.mut.RUnlock()
+
+	if len(g.shards) == 0 {
+		return errors.New("no shards are running")
+	}
+
+	disconnected := make([]int, 0)
+	for id, conn := range g.shards {
+		if !conn.connected && time.Since(conn.since) >= maxDisconnected {
+			disconnected = append(disconnected, id)
+		}
+	}
+
+	if len(disconnected) == 0 {
+		return nil
+	}
+
+	sort.Ints(disconnected)
+	ids := make([]string, 0, len(disconnected))
+	for _, id := range disconnected {
+		ids = append(ids, strconv.Itoa(id))
+	}
+	return fmt.Errorf("shards disconnected from the gateway: %s", strings.Join(ids, ", "))
+}
+
+// livenessCheck returns the handler of the liveness probe. It only fails when a shard cannot reconnect to the gateway,
+// which a restart fixes. The dependencies, such as MongoDB, are not checked, as a restart does not fix them.
+func (a *App) livenessCheck() Controller {
+	return newProbe(probeLiveness,
+		health.WithCheck(health.Check{
+			Name: "gateway",
+			Check: func(ctx context.Context) error {
+				// The shards are not connected until the startup has completed, which the startup probe covers.
+				if !a.startup.isDone() {
+					return nil
+				}
+				return a.gateway.check(gatewayMaxDisconnected)
+			},
+		}),
+	)
+}
+
+// readinessCheck returns the handler of the readiness probe. It fails while the application has not started, a
+// shard is disconnected from the gateway, or MongoDB cannot be reached.
+func (a *App) readinessCheck() Controller {
+	return newProbe(probeReadiness,
+		health.WithCheck(health.Check{
+			Name:  "startup",
+			Check: a.startup.check,
+		}),
+		health.WithCheck(health.Check{
+			Name: "gateway",
+			Check: func(ctx context.Context) error {
+				return a.gateway.check(0)
+			},
+		}),
+		health.WithCheck(health.Check{
+			Name:    "mongodb",
+			Check:   pingMongo,
+			Timeout: 2 * time.Second,
+		}),
+	)
+}
+
+// startupCheck returns the handler of the startup probe. It reports what the application is doing until it has
+// started.
+func (a *App) startupCheck() Controller {
+	return newProbe(probeStartup,
+		health.WithCheck(health.Check{
+			Name:  "startup",
+			Check: a.startup.check,
+		}),
+	)
+}
+
+// pingMongo checks that MongoDB can be reached.
+func pingMongo(ctx context.Context) (err error) {
+	// Record the latency and the result of the check.
+	observe := dbMonitoring.ObserveQuery("health_check", "ping", "-", "-")
+	defer func() {
+		observe(err)
+	}()
+
+	if dataaccess.MongoDB == nil {
+		return errors.New("not connected to MongoDB")
+	}
+
+	if err = dataaccess.MongoDB.Ping(ctx, nil); err != nil {
+		return fmt.Errorf("failed to ping MongoDB: %w", err)
+	}
+	return nil
+}
+
+// newProbe creates the handler of a probe with the checks. The result of each check is exported as a gauge, and the
+// changes of status are logged.
+func newProbe(probe string, checks ...health.CheckerOption) Controller {
+	opts := []health.CheckerOption{
+		// Set a TTL of 1 second for the results of the checks.
+		health.WithCacheDuration(1 * time.Second),
+
+		// Set a timeout of 2 seconds for the checks.
+		health.WithTimeout(2 * time.Second),
+
+		health.WithInterceptors(observeCheck(probe)),
+
+		health.WithStatusListener(func(ctx context.Context, state health.CheckerState) {
+			slog.Info("Health status changed",
+				slog.String("probe", probe),
+				slog.String("state", string(state.Status)),
+			)
+		}),
+	}
+
+	checker := health.NewChecker(append(opts, checks...)...)
+	return Controller(health.NewHandler(checker))
+}
+
+// observeCheck sets the gauge of each check of the probe to its latest result.
+func observeCheck(probe string) health.Interceptor {
+	return func(next health.InterceptorFunc) health.InterceptorFunc {
+		return func(ctx context.Context, name string, state health.CheckState) health.CheckState {
+			state = next(ctx, name, state)
+
+			value := 0.0
+			if state.Result == nil {
+				value = 1
+			}
+			HealthCheckStatus.WithLabelValues(probe, name).Set(value)
+			return state
+		}
+	}
+}
+
+// requireStarted rejects the requests with a 503 until the startup has completed, for the routes that need the
+// Discord session or the database.
+func (a *App) requireStarted(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.startup.isDone() {
+			r = request.WithRequestID(w, r)
+			w.Header().Set("Retry-After", strconv.Itoa(int(startupRetryAfter.Seconds())))
+			request.RespondError(w, r, http.StatusServiceUnavailable, "The application is starting")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartupState(t *testing.T) {
+	s := newStartupState()
+	require.False(t, s.isDone())
+	require.EqualError(t, s.check(context.Background()), "starting: initialising")
+
+	s.setStage("connecting to MongoDB")
+	require.False(t, s.isDone())
+	require.EqualError(t, s.check(context.Background()), "starting: connecting to MongoDB")
+
+	s.complete()
+	require.True(t, s.isDone())
+	require.NoError(t, s.check(context.Background()))
+}
+
+func TestGatewayState(t *testing.T) {
+	g := newGatewayState()
+	require.EqualError(t, g.check(0), "no shards are running")
+
+	// A shard is disconnected until it first connects.
+	g.set(0, false)
+	g.set(1, false)
+	require.EqualError(t, g.check(0), "shards disconnected from the gateway: 0, 1")
+	require.NoError(t, g.check(time.Hour))
+	require.Equal(t, 0.0, testutil.ToFloat64(GatewayConnected.WithLabelValues("0")))
+
+	g.set(0, true)
+	require.EqualError(t, g.check(0), "shards disconnected from the gateway: 1")
+	require.Equal(t, 1.0, testutil.ToFloat64(GatewayConnected.WithLabelValues("0")))
+
+	g.set(1, true)
+	require.NoError(t, g.check(0))
+
+	// A shard that disconnects is only reported once it has been disconnected for long enough.
+	g.set(1, false)
+	require.EqualError(t, g.check(0), "shards disconnected from the gateway: 1")
+	require.NoError(t, g.check(time.Hour))
+	require.Equal(t, 0.0, testutil.ToFloat64(GatewayConnected.WithLabelValues("1")))
+}
+
+func TestGatewayState_RepeatedEvent(t *testing.T) {
+	g := newGatewayState()
+	g.set(0, false)
+	g.shards[0].since = time.Now().Add(-time.Hour)
+
+	// A repeated disconnect does not reset how long the shard has been disconnected.
+	g.set(0, false)
+	require.Error(t, g.check(time.Minute))
+}
+
+func TestProbes(t *testing.T) {
+	tests := []struct {
+		name       string
+		started    bool
+		connected  bool
+		probe      func(a *App) Controller
+		probeName  string
+		check      string
+		wantStatus int
+	}{
+		{
+			name:       "startup while starting",
+			probe:      (*App).startupCheck,
+			probeName:  probeStartup,
+			check:      "startup",
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "startup once started",
+			started:    true,
+			probe:      (*App).startupCheck,
+			probeName:  probeStartup,
+			check:      "startup",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "liveness while starting",
+			probe:      (*App).livenessCheck,
+			probeName:  probeLiveness,
+			check:      "gateway",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "liveness with a stuck shard",
+			started:    true,
+			probe:      (*App).livenessCheck,
+			probeName:  probeLiveness,
+			check:      "gateway",
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "liveness with a connected shard",
+			started:    true,
+			connected:  true,
+			probe:      (*App).livenessCheck,
+			probeName:  probeLiveness,
+			check:      "gateway",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "readiness while starting",
+			connected:  true,
+			probe:      (*App).readinessCheck,
+			probeName:  probeReadiness,
+			check:      "startup",
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "readiness without MongoDB",
+			started:    true,
+			connected:  true,
+			probe:      (*App).readinessCheck,
+			probeName:  probeReadiness,
+			check:      "mongodb",
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &App{
+				startup: newStartupState(),
+				gateway: newGatewayState(),
+			}
+			if tt.started {
+				a.startup.complete()
+			}
+			a.gateway.set(0, tt.connected)
+			if !tt.connected {
+				a.gateway.shards[0].since = time.Now().Add(-gatewayMaxDisconnected)
+			}
+
+			probe := tt.probe(a)
+			rec := httptest.NewRecorder()
+			probe(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+
+			// The result of the check is exported.
+			want := 0.0
+			if tt.wantStatus == http.StatusOK {
+				want = 1
+			}
+			require.Equal(t, want, testutil.ToFloat64(HealthCheckStatus.WithLabelValues(tt.probeName, tt.check)))
+		})
+	}
+}
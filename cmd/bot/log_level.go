@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/xml"
+	"log/slog"
+	"net/http"
+	"sort"
+
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"github.com/Jacobbrewer1/wolf/pkg/request"
+)
+
+// logLevels is the response of the log level endpoint.
+type logLevels struct {
+	// Level is the level of the loggers that are not overridden.
+	Level string `json:"level" xml:"level"`
+
+	// Loggers are the levels of the loggers that are overridden, keyed by the value of their dal or component
+	// attribute.
+	Loggers map[string]string `json:"loggers"`
+}
+
+// MarshalXML encodes the log levels as XML, with an element for each overridden logger as maps cannot be encoded.
+func (l *logLevels) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	type loggerLevel struct {
+		Name  string `xml:"name,attr"`
+		Level string `xml:",chardata"`
+	}
+
+	v := struct {
+		XMLName xml.Name      `xml:"log_levels"`
+		Level   string        `xml:"level"`
+		Loggers []loggerLevel `xml:"logger"`
+	}{
+		Level: l.Level,
+	}
+
+	for name, level := range l.Loggers {
+		v.Loggers = append(v.Loggers, loggerLevel{Name: name, Level: level})
+	}
+	sort.Slice(v.Loggers, func(i, j int) bool {
+		return v.Loggers[i].Name < v.Loggers[j].Name
+	})
+
+	return e.Encode(v)
+}
+
+// setLogLevelRequest is the request to change a log level.
+type setLogLevelRequest struct {
+	// Logger is the name of the logger to override. If empty, the level of the loggers that are not overridden is set.
+	Logger string `json:"logger" xml:"logger"`
+
+	// Level is the level to set. If empty, the override of the logger is removed.
+	Level string `json:"level" xml:"level"`
+}
+
+// getLogLevels returns the current log levels.
+func (a *App) getLogLevels() Controller {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a.writeLogLevels(w, r)
+	}
+}
+
+// setLogLevel changes a log level without restarting the application.
+func (a *App) setLogLevel() Controller {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := new(setLogLevelRequest)
+		if err := request.Decode(w, r, req); err != nil {
+			request.RespondDecodeError(w, r, err)
+			return
+		}
+
+		if req.Level == "" {
+			if req.Logger == "" {
+				request.RespondError(w, r, http.StatusBadRequest, "A level is required")
+				return
+			}
+
+			a.logLevels.ResetLoggerLevel(req.Logger)
+			a.Info("Log level override removed", slog.String("logger", req.Logger))
+			a.writeLogLevels(w, r)
+			return
+		}
+
+		level, err := logging.ParseLevel(req.Level)
+		if err != nil {
+			request.RespondError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if req.Logger == "" {
+			a.logLevels.SetLevel(level)
+		} else {
+			a.logLevels.SetLoggerLevel(req.Logger, level)
+		}
+
+		a.Info("Log level changed", slog.String("logger", req.Logger), slog.String("level", level.String()))
+		a.writeLogLevels(w, r)
+	}
+}
+
+// writeLogLevels writes the current log levels to the response.
+func (a *App) writeLogLevels(w http.ResponseWriter, r *http.Request) {
+	resp := &logLevels{
+		Level:   a.logLevels.Level().String(),
+		Loggers: make(map[string]string),
+	}
+	for name, level := range a.logLevels.Overrides() {
+		resp.Loggers[name] = level.String()
+	}
+
+	request.Respond(w, r, http.StatusOK, resp)
+}
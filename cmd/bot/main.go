@@ -13,6 +13,12 @@ func main() {
 	if err != nil {
 		log.Fatalln(err)
 	}
+
+	if len(os.Args) > 1 && os.Args[1] == migrateCommand {
+		migrate()
+		return
+	}
+
 	parseConfig()
 	a.Info("Starting application")
 	if err := a.Run(); err != nil {
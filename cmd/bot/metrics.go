@@ -14,7 +14,7 @@ var (
 			Name: fmt.Sprintf("%s_total_discord_events", AppName),
 			Help: "Total number of events",
 		},
-		[]string{"event"},
+		[]string{"shard", "event"},
 	)
 
 	// HttpTotalRequests is the total number of http requests.
@@ -35,11 +35,13 @@ var (
 		[]string{"path", "method", "status_code"},
 	)
 
-	TotalDiscordGuilds = promauto.NewGauge(
+	// TotalDiscordGuilds is the total number of discord guilds, by the shard they are on.
+	TotalDiscordGuilds = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: fmt.Sprintf("%s_total_discord_guilds", AppName),
 			Help: "Total number of discord guilds",
 		},
+		[]string{"shard"},
 	)
 
 	DiscordCommandDuration = promauto.NewHistogramVec(
@@ -47,6 +49,148 @@ var (
 			Name: fmt.Sprintf("%s_discord_command_duration", AppName),
 			Help: "Duration of the discord command",
 		},
-		[]string{"command"},
+		[]string{"shard", "command"},
+	)
+
+	// DiscordInteractionErrors is the total number of errors handling discord interactions.
+	DiscordInteractionErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_discord_interaction_errors", AppName),
+			Help: "Total number of errors handling discord interactions",
+		},
+		[]string{"shard", "command", "class"},
+	)
+
+	// HealthCheckStatus is the latest result of each health check, by the probe it is part of. It is 1 when the check
+	// passes and 0 when it fails.
+	HealthCheckStatus = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: fmt.Sprintf("%s_health_check_status", AppName),
+			Help: "Latest result of the health check, 1 when it passes and 0 when it fails",
+		},
+		[]string{"probe", "check"},
+	)
+
+	// GatewayConnected is whether each shard is connected to the Discord gateway.
+	GatewayConnected = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: fmt.Sprintf("%s_gateway_connected", AppName),
+			Help: "Whether the shard is connected to the Discord gateway",
+		},
+		[]string{"shard"},
+	)
+
+	// IsLeader is whether this instance is the leader, which runs the singletons.
+	IsLeader = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: fmt.Sprintf("%s_is_leader", AppName),
+			Help: "Whether this instance is the leader",
+		},
+	)
+
+	// ModerationActions is the total number of moderation actions taken, by the type of the action.
+	ModerationActions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_moderation_actions", AppName),
+			Help: "Total number of moderation actions taken",
+		},
+		[]string{"action"},
+	)
+
+	// ScheduledActionsRun is the total number of scheduled actions run, by the type of the action and its status
+	// after it was run.
+	ScheduledActionsRun = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_scheduled_actions_run", AppName),
+			Help: "Total number of scheduled actions run",
+		},
+		[]string{"type", "status"},
+	)
+
+	// LoggedEvents is the total number of server events logged, by the type of the event and whether it was queued
+	// or dropped.
+	LoggedEvents = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_logged_events", AppName),
+			Help: "Total number of server events logged",
+		},
+		[]string{"type", "status"},
+	)
+
+	// AutomodHits is the total number of messages that broke an automod rule, by the type of the rule.
+	AutomodHits = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_automod_hits", AppName),
+			Help: "Total number of messages that broke an automod rule",
+		},
+		[]string{"rule"},
+	)
+
+	// Raids is the total number of times raid mode was entered, by whether it was started automatically or manually.
+	Raids = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_raids", AppName),
+			Help: "Total number of times raid mode was entered",
+		},
+		[]string{"trigger"},
+	)
+
+	// RaidJoinActions is the total number of actions taken against members that joined in raid mode, by the action.
+	RaidJoinActions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_raid_join_actions", AppName),
+			Help: "Total number of actions taken against members that joined in raid mode",
+		},
+		[]string{"action"},
+	)
+
+	// RolePanelRoleChanges is the total number of roles given and taken with the role panels, by whether the role was
+	// added or removed.
+	RolePanelRoleChanges = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_role_panel_role_changes", AppName),
+			Help: "Total number of roles given and taken with the role panels",
+		},
+		[]string{"action"},
+	)
+
+	// ReactionRoleChanges is the total number of roles given and taken by reactions, by whether the role was added or
+	// removed.
+	ReactionRoleChanges = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_reaction_role_changes", AppName),
+			Help: "Total number of roles given and taken by reactions",
+		},
+		[]string{"action"},
+	)
+
+	// WelcomeMessages is the total number of welcome, goodbye and direct messages sent to the members that join and
+	// leave, by the type of the message and whether it was sent.
+	WelcomeMessages = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_welcome_messages", AppName),
+			Help: "Total number of welcome, goodbye and direct messages sent to the members that join and leave",
+		},
+		[]string{"type", "status"},
+	)
+
+	// VerificationAttempts is the total number of verification attempts, by the challenge and whether the member
+	// passed, failed or was kicked for not being verified in time.
+	VerificationAttempts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_verification_attempts", AppName),
+			Help: "Total number of verification attempts",
+		},
+		[]string{"mode", "result"},
+	)
+
+	// RolePersistenceRestores is the total number of members that rejoined and had their roles or nickname restored,
+	// by what was restored.
+	RolePersistenceRestores = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_role_persistence_restores", AppName),
+			Help: "Total number of members that rejoined and had their roles or nickname restored",
+		},
+		[]string{"type"},
 	)
 )
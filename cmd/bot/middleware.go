@@ -1,7 +1,7 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -9,40 +9,58 @@ import (
 	"time"
 
 	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/commands"
 	"github.com/Jacobbrewer1/wolf/pkg/logging"
-	"github.com/Jacobbrewer1/wolf/pkg/messages"
 	"github.com/Jacobbrewer1/wolf/pkg/request"
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-// commandController is the handler for slash commands.
-type commandController func(a IApp, i *discordgo.InteractionCreate) (commandProcessor, error)
+const (
+	// httpRequestTimeout is how long a http request has to be handled before its context is cancelled.
+	httpRequestTimeout = 10 * time.Second
 
-// commandProcessor is the processor for slash commands.
-type commandProcessor func(a IApp, i *discordgo.InteractionCreate) error
+	// pathUnrouted is the path in the metrics of the requests that did not match a route.
+	pathUnrouted = "unrouted"
+)
 
 type Controller func(w http.ResponseWriter, r *http.Request)
 
 func middlewareHttp(handler Controller) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		now := time.Now().UTC()
 		cw := request.NewClientWriter(w)
 
+		// Tag the request with an ID, which is returned in the response and in the errors, and logged with it.
+		r = request.WithRequestID(cw, r)
+		l := logging.FromContext(r.Context()).With(slog.String(logging.KeyRequestID, request.RequestID(r)))
+		r = r.WithContext(logging.WithLogger(r.Context(), l))
+
 		// Recover from any panics that occur in the handler.
 		defer func() {
 			if rec := recover(); rec != nil {
-				slog.Error("Panic in handler",
-					slog.String(logging.KeyError, rec.(error).Error()),
-					slog.String("stack", string(debug.Stack())),
+				l.Error("Panic in handler",
+					slog.String(logging.KeyError, fmt.Sprint(rec)),
+					slog.String(logging.KeyStack, string(debug.Stack())),
 				)
-				cw.WriteHeader(http.StatusInternalServerError)
-				if err := json.NewEncoder(cw).Encode(request.NewMessage(messages.ErrInternalServerError)); err != nil {
-					slog.Error("Error encoding response", slog.String(logging.KeyError, err.Error()))
-				}
+				request.RespondInternalError(cw, r)
 			}
 		}()
 
+		// Give the request a deadline, the context is also cancelled on shutdown.
+		ctx, cancel := context.WithTimeout(r.Context(), httpRequestTimeout)
+		defer cancel()
+
+		handler(cw, r.WithContext(ctx))
+	}
+}
+
+// observeRequests records the metrics of every request, including the requests that are rejected by the other
+// middleware before they reach their handler.
+func observeRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now().UTC()
+		cw := request.NewClientWriter(w)
+
 		var path string
 		route := mux.CurrentRoute(r)
 		if route != nil { // The route may be nil if the request is not routed.
@@ -54,7 +72,8 @@ func middlewareHttp(handler Controller) http.HandlerFunc {
 				path = r.URL.Path // If the route does not define a path, use the URL path.
 			}
 		} else {
-			path = r.URL.Path // If the route is nil, use the URL path.
+			// The request did not match a route, so its path is not used, as any path can be requested.
+			path = pathUnrouted
 		}
 
 		defer func() {
@@ -63,111 +82,29 @@ func middlewareHttp(handler Controller) http.HandlerFunc {
 			HttpRequestDuration.WithLabelValues(path, r.Method, fmt.Sprintf("%d", cw.StatusCode())).Observe(time.Since(now).Seconds())
 		}()
 
-		handler(cw, r)
-	}
+		next.ServeHTTP(cw, r)
+	})
 }
 
-// interactionHandler is the handler for interactions.
-func interactionHandler(
-	a IApp,
-	slashControllers map[string]commandController,
-	buttonControllers map[string]commandProcessor,
-) func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+// interactionHandler is the handler for interactions. The interactions are routed to the commands and components
+// registered with the router, which also recovers panics, logs errors with the context of the interaction and responds
+// to the user when an error occurs.
+func interactionHandler(router *commands.Router) func(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	return func(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		// Process the latency for the interaction.
-		t := prometheus.NewTimer(DiscordCommandDuration.WithLabelValues(i.Type.String()))
+		t := prometheus.NewTimer(DiscordCommandDuration.WithLabelValues(shardLabel(s), i.Type.String()))
 		defer t.ObserveDuration()
 
-		switch i.Type {
-		// Slash commands.
-		case discordgo.InteractionApplicationCommand:
-			slashCommandHandler(a, slashControllers)(s, i)
-		// Button interactions.
-		case discordgo.InteractionMessageComponent:
-			buttonHandler(a, buttonControllers)(s, i)
-		// Unknown interaction type.
-		default:
-			slog.Error(fmt.Sprintf("Unknown interaction type %d", i.Type),
-				slog.Int("type", int(i.Type)))
-
-			if err := respondEphemeralError(a, i); err != nil {
-				slog.Error("Error responding to interaction", slog.String(logging.KeyError, err.Error()))
-				return
-			}
-		}
+		// The error has already been logged and responded to by the router.
+		_ = router.Handle(s, i)
 	}
 }
 
-// slashCommandHandler is the handler for slash commands.
-func slashCommandHandler(a IApp, controllers map[string]commandController) func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	return func(_ *discordgo.Session, i *discordgo.InteractionCreate) {
-		slog.Debug("Handling interaction " + i.ApplicationCommandData().Name)
-		if i.Type != discordgo.InteractionApplicationCommand {
-			return
-		}
-
-		if controller, ok := controllers[i.ApplicationCommandData().Name]; ok {
-			processor, err := controller(a, i)
-			if err != nil {
-				slog.Error(fmt.Sprintf("Error getting processor for command %s", i.ApplicationCommandData().Name),
-					slog.String(logging.KeyError, err.Error()))
-
-				if err := respondEphemeralError(a, i); err != nil {
-					slog.Error("Error responding to interaction", slog.String(logging.KeyError, err.Error()))
-					return
-				}
-				return
-			}
-
-			if err := processor(a, i); err != nil {
-				slog.Error(fmt.Sprintf("Error processing command %s", i.ApplicationCommandData().Name),
-					slog.String(logging.KeyError, err.Error()))
-
-				if err := respondEphemeralError(a, i); err != nil {
-					slog.Error("Error responding to interaction", slog.String(logging.KeyError, err.Error()))
-					return
-				}
-				return
-			}
-		} else {
-			slog.Error(fmt.Sprintf("No controller found for command %s", i.ApplicationCommandData().Name),
-				slog.String("command", i.ApplicationCommandData().Name))
-
-			if err := respondEphemeralError(a, i); err != nil {
-				slog.Error("Error responding to interaction", slog.String(logging.KeyError, err.Error()))
-				return
-			}
-		}
-	}
-}
-
-// buttonHandler is the handler for button interactions.
-func buttonHandler(a IApp, controllers map[string]commandProcessor) func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	return func(_ *discordgo.Session, i *discordgo.InteractionCreate) {
-		slog.Debug("Handling interaction " + i.MessageComponentData().CustomID)
-		if i.Type != discordgo.InteractionMessageComponent {
-			return
-		}
-
-		if processor, ok := controllers[i.MessageComponentData().CustomID]; ok {
-			if err := processor(a, i); err != nil {
-				slog.Error(fmt.Sprintf("Error processing command %s", i.MessageComponentData().CustomID),
-					slog.String(logging.KeyError, err.Error()))
-
-				if err := respondEphemeralError(a, i); err != nil {
-					slog.Error("Error responding to interaction", slog.String(logging.KeyError, err.Error()))
-					return
-				}
-				return
-			}
-		} else {
-			slog.Error(fmt.Sprintf("No controller found for command %s", i.MessageComponentData().CustomID),
-				slog.String("command", i.MessageComponentData().CustomID))
-
-			if err := respondEphemeralError(a, i); err != nil {
-				slog.Error("Error responding to interaction", slog.String(logging.KeyError, err.Error()))
-				return
-			}
-		}
+// countInteractionError counts the errors handling interactions by command and error class.
+func countInteractionError(c *commands.Context, class commands.ErrorClass, _ error) {
+	command := c.Command()
+	if command == "" {
+		command = string(commands.ErrorClassUnknown)
 	}
+	DiscordInteractionErrors.WithLabelValues(shardLabel(c.Session()), command, string(class)).Inc()
 }
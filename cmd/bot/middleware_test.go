@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Jacobbrewer1/wolf/pkg/request"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveRequests(t *testing.T) {
+	r := mux.NewRouter()
+	r.Use(observeRequests)
+	r.NotFoundHandler = observeRequests(request.NotFoundHandler())
+	r.MethodNotAllowedHandler = observeRequests(request.MethodNotAllowedHandler())
+	r.HandleFunc("/things/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods(http.MethodGet)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		label  string
+		status string
+	}{
+		{
+			name:   "routed",
+			method: http.MethodGet,
+			path:   "/things/1",
+			label:  "/things/{id}",
+			status: "204",
+		},
+		{
+			name:   "not found",
+			method: http.MethodGet,
+			path:   "/unknown/1",
+			label:  pathUnrouted,
+			status: "404",
+		},
+		{
+			name:   "method not allowed",
+			method: http.MethodPost,
+			path:   "/things/1",
+			label:  pathUnrouted,
+			status: "405",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := HttpTotalRequests.WithLabelValues(tt.label, tt.method, tt.status)
+			before := testutil.ToFloat64(counter)
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+			require.Equal(t, before+1, testutil.ToFloat64(counter))
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+)
+
+// migrateCommand is the command line subcommand that applies the database migrations and exits.
+const migrateCommand = "migrate"
+
+// runMigrations applies the database migrations that have not been applied yet.
+func runMigrations(ctx context.Context) error {
+	migrator, err := dataaccess.NewMigrator(dataaccess.Migrations...)
+	if err != nil {
+		return fmt.Errorf("error creating migrator: %w", err)
+	}
+
+	version, err := migrator.Migrate(ctx)
+	if err != nil {
+		return fmt.Errorf("error migrating database: %w", err)
+	}
+
+	slog.Info("Database schema is up to date", slog.Int("version", version))
+	return nil
+}
+
+// migrate runs the migrate subcommand. Only the MongoDB configuration is required.
+func migrate() {
+	MongoUri = os.Getenv(EnvMongoUri)
+	if MongoUri == "" {
+		slog.Error("No MongoDB URI provided in environment", slog.String("key", EnvMongoUri))
+		os.Exit(1)
+	}
+
+	if err := connectMongo(); err != nil {
+		slog.Error("Error connecting to MongoDB", slog.String(logging.KeyError, err.Error()))
+		os.Exit(1)
+	}
+
+	if err := runMigrations(context.Background()); err != nil {
+		slog.Error("Error running migrations", slog.String(logging.KeyError, err.Error()))
+		os.Exit(1)
+	}
+}
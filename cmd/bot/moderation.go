@@ -0,0 +1,1040 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/commands"
+	"github.com/Jacobbrewer1/wolf/pkg/custom"
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// ModCmdName is the command for moderating members.
+	ModCmdName = "mod"
+
+	// WarnCmdName is the sub command for warning a member.
+	WarnCmdName = "warn"
+
+	// TimeoutCmdName is the sub command for timing out a member.
+	TimeoutCmdName = "timeout"
+
+	// KickCmdName is the sub command for kicking a member.
+	KickCmdName = "kick"
+
+	// BanCmdName is the sub command for banning a user.
+	BanCmdName = "ban"
+
+	// UnbanCmdName is the sub command for removing the ban of a user.
+	UnbanCmdName = "unban"
+
+	// SoftbanCmdName is the sub command for softbanning a member.
+	SoftbanCmdName = "softban"
+
+	// MuteCmdName is the sub command for giving a member the mute role.
+	MuteCmdName = "mute"
+
+	// UnmuteCmdName is the sub command for removing the mute role from a member.
+	UnmuteCmdName = "unmute"
+
+	// CaseCmdName is the command for the moderation cases.
+	CaseCmdName = "case"
+
+	// ViewCaseCmdName is the sub command for viewing a case.
+	ViewCaseCmdName = "view"
+
+	// EditCaseReasonCmdName is the sub command for changing the reason of a case.
+	EditCaseReasonCmdName = "edit-reason"
+
+	// ListCasesCmdName is the sub command for listing the cases against a user.
+	ListCasesCmdName = "list"
+)
+
+const (
+	// defaultCaseReason is the reason of a case when the moderator does not give one.
+	defaultCaseReason = "No reason given"
+
+	// maxTimeout is the longest that Discord allows a member to be timed out for.
+	maxTimeout = 28 * 24 * time.Hour
+
+	// caseListLimit is the maximum number of cases listed against a user.
+	caseListLimit = 15
+
+	// caseListReasonLength is the maximum length of the reasons in the list of cases, so the list fits in an embed.
+	caseListReasonLength = 100
+
+	// caseErrorSchedule is recorded on a case when its expiry could not be scheduled.
+	caseErrorSchedule = "The expiry could not be scheduled, please try again."
+
+	// caseErrorForbidden is recorded on a case when the bot is not allowed to take its action.
+	caseErrorForbidden = "I do not have permission to do this."
+
+	// caseErrorApply is recorded on a case when Discord could not take its action.
+	caseErrorApply = "Discord could not take the action, please try again."
+)
+
+// caseColors are the colors of the case embeds, by the type of the case.
+var caseColors = map[entities.CaseType]int{
+	entities.CaseTypeWarn:    0xffcc00,
+	entities.CaseTypeTimeout: 0xff9900,
+	entities.CaseTypeKick:    0xff6600,
+	entities.CaseTypeBan:     0xff0000,
+	entities.CaseTypeUnban:   0x00ff00,
+	entities.CaseTypeSoftban: 0xff3300,
+	entities.CaseTypeMute:    0xff9900,
+	entities.CaseTypeUnmute:  0x00ff00,
+	entities.CaseTypeAutomod: 0xffcc00,
+}
+
+var (
+	// modCmd is the command for moderating members. Each sub command requires the Discord permission that the
+	// action needs, so the command is not restricted as a whole.
+	modCmd = &commands.Command{
+		Name:        ModCmdName,
+		Description: "This is the command for moderating members.",
+		GuildOnly:   true,
+		Ephemeral:   true,
+		Subcommands: []*commands.Command{
+			{
+				Name:        WarnCmdName,
+				Description: "This warns a member.",
+				Permissions: discordgo.PermissionModerateMembers,
+				Options:     new(warnOptions),
+				Handler:     warnHandler,
+			},
+			{
+				Name:        TimeoutCmdName,
+				Description: "This stops a member from talking for a while.",
+				Permissions: discordgo.PermissionModerateMembers,
+				Options:     new(timeoutOptions),
+				Handler:     timeoutHandler,
+			},
+			{
+				Name:        KickCmdName,
+				Description: "This kicks a member from the server.",
+				Permissions: discordgo.PermissionKickMembers,
+				Options:     new(moderationOptions),
+				Handler:     kickHandler,
+			},
+			{
+				Name:        BanCmdName,
+				Description: "This bans a user from the server, for a while if a duration is given.",
+				Permissions: discordgo.PermissionBanMembers,
+				Options:     new(banOptions),
+				Handler:     banHandler,
+			},
+			{
+				Name:        UnbanCmdName,
+				Description: "This removes the ban of a user.",
+				Permissions: discordgo.PermissionBanMembers,
+				Options:     new(moderationOptions),
+				Handler:     unbanHandler,
+			},
+			{
+				Name:        SoftbanCmdName,
+				Description: "This kicks a member from the server and deletes their recent messages.",
+				Permissions: discordgo.PermissionBanMembers,
+				Options:     new(softbanOptions),
+				Handler:     softbanHandler,
+			},
+			{
+				Name:        MuteCmdName,
+				Description: "This gives a member the mute role, for a while if a duration is given.",
+				Permissions: discordgo.PermissionModerateMembers,
+				Options:     new(muteOptions),
+				Handler:     muteHandler,
+			},
+			{
+				Name:        UnmuteCmdName,
+				Description: "This removes the mute role from a member.",
+				Permissions: discordgo.PermissionModerateMembers,
+				Options:     new(moderationOptions),
+				Handler:     unmuteHandler,
+			},
+		},
+	}
+
+	// caseCmd is the command for the moderation cases.
+	caseCmd = &commands.Command{
+		Name:        CaseCmdName,
+		Description: "This is the command for the moderation cases.",
+		Permissions: discordgo.PermissionModerateMembers,
+		GuildOnly:   true,
+		Ephemeral:   true,
+		Subcommands: []*commands.Command{
+			{
+				Name:        ViewCaseCmdName,
+				Description: "This shows a case.",
+				Options:     new(caseOptions),
+				Handler:     viewCaseHandler,
+			},
+			{
+				Name:        EditCaseReasonCmdName,
+				Description: "This changes the reason of a case.",
+				Options:     new(editCaseReasonOptions),
+				Handler:     editCaseReasonHandler,
+			},
+			{
+				Name:        ListCasesCmdName,
+				Description: "This lists the latest cases against a user.",
+				Options:     new(listCasesOptions),
+				Handler:     listCasesHandler,
+			},
+		},
+	}
+)
+
+// moderationOptions are the options of the moderation commands that only take a user and a reason.
+type moderationOptions struct {
+	// User is the user to take the action against.
+	User *discordgo.User `option:"user" description:"This is the user to take the action against." required:"true"`
+
+	// Reason is the reason for the action.
+	Reason string `option:"reason" description:"This is the reason for the action."`
+}
+
+// warnOptions are the options for the warn command.
+type warnOptions struct {
+	// User is the member to warn.
+	User *discordgo.User `option:"user" description:"This is the member to warn." required:"true"`
+
+	// Reason is the reason for the warning.
+	Reason string `option:"reason" description:"This is the reason for the warning." required:"true"`
+}
+
+// timeoutOptions are the options for the timeout command.
+type timeoutOptions struct {
+	// User is the member to time out.
+	User *discordgo.User `option:"user" description:"This is the member to time out." required:"true"`
+
+	// Duration is how long to time the member out for, such as 10m, 1h or 7d.
+	Duration string `option:"duration" description:"This is how long to time the member out for, such as 10m, 1h or 7d." required:"true"`
+
+	// Reason is the reason for the timeout.
+	Reason string `option:"reason" description:"This is the reason for the timeout."`
+
+	// duration is the parsed Duration.
+	duration time.Duration
+}
+
+// Validate parses the duration of the timeout.
+func (o *timeoutOptions) Validate() error {
+	d, err := parseModerationDuration(o.Duration)
+	if err != nil {
+		return err
+	}
+	if d > maxTimeout {
+		return commands.NewUserError("A member can be timed out for at most %s.", custom.FormatDuration(maxTimeout))
+	}
+	o.duration = d
+	return nil
+}
+
+// banOptions are the options for the ban command.
+type banOptions struct {
+	// User is the user to ban.
+	User *discordgo.User `option:"user" description:"This is the user to ban." required:"true"`
+
+	// Duration is how long to ban the user for, such as 1d or 2w. If empty, the ban does not expire.
+	Duration string `option:"duration" description:"This is how long to ban the user for, such as 1d or 2w. The ban is permanent if not given."`
+
+	// Reason is the reason for the ban.
+	Reason string `option:"reason" description:"This is the reason for the ban."`
+
+	// DeleteDays is the number of days of messages from the user to delete.
+	DeleteDays int `option:"delete_days" description:"This is the number of days of messages from the user to delete." min:"0" max:"7"`
+
+	// duration is the parsed Duration.
+	duration time.Duration
+}
+
+// Validate parses the duration of the ban.
+func (o *banOptions) Validate() error {
+	if o.Duration == "" {
+		return nil
+	}
+
+	d, err := parseModerationDuration(o.Duration)
+	if err != nil {
+		return err
+	}
+	o.duration = d
+	return nil
+}
+
+// softbanOptions are the options for the softban command.
+type softbanOptions struct {
+	// User is the member to softban.
+	User *discordgo.User `option:"user" description:"This is the member to softban." required:"true"`
+
+	// Reason is the reason for the softban.
+	Reason string `option:"reason" description:"This is the reason for the softban."`
+
+	// DeleteDays is the number of days of messages from the member to delete.
+	DeleteDays int `option:"delete_days" description:"This is the number of days of messages from the member to delete." min:"0" max:"7"`
+}
+
+// muteOptions are the options for the mute command.
+type muteOptions struct {
+	// User is the member to mute.
+	User *discordgo.User `option:"user" description:"This is the member to mute." required:"true"`
+
+	// Duration is how long to mute the member for, such as 2h or 1d. If empty, the mute does not expire.
+	Duration string `option:"duration" description:"This is how long to mute the member for, such as 2h or 1d. The mute is permanent if not given."`
+
+	// Reason is the reason for the mute.
+	Reason string `option:"reason" description:"This is the reason for the mute."`
+
+	// duration is the parsed Duration.
+	duration time.Duration
+}
+
+// Validate parses the duration of the mute.
+func (o *muteOptions) Validate() error {
+	if o.Duration == "" {
+		return nil
+	}
+
+	d, err := parseModerationDuration(o.Duration)
+	if err != nil {
+		return err
+	}
+	o.duration = d
+	return nil
+}
+
+// parseModerationDuration parses the duration of a moderation action, returning a user error if it is not valid.
+func parseModerationDuration(s string) (time.Duration, error) {
+	d, err := custom.ParseDuration(s)
+	if err != nil {
+		return 0, commands.NewUserError("%q is not a valid duration. Use a duration such as 10m, 1h or 7d.", s)
+	}
+	return d, nil
+}
+
+// caseOptions are the options for the commands that take a case.
+type caseOptions struct {
+	// Number is the number of the case.
+	Number int `option:"number" description:"This is the number of the case." required:"true" min:"1"`
+}
+
+// editCaseReasonOptions are the options for the edit reason command.
+type editCaseReasonOptions struct {
+	// Number is the number of the case.
+	Number int `option:"number" description:"This is the number of the case." required:"true" min:"1"`
+
+	// Reason is the new reason of the case.
+	Reason string `option:"reason" description:"This is the new reason of the case." required:"true"`
+}
+
+// listCasesOptions are the options for the list cases command.
+type listCasesOptions struct {
+	// User is the user to list the cases against.
+	User *discordgo.User `option:"user" description:"This is the user to list the cases against." required:"true"`
+}
+
+// moderationAction is a moderation action that is taken against a user and recorded as a case.
+type moderationAction struct {
+	// caseType is the type of the case that records the action.
+	caseType entities.CaseType
+
+	// target is the user that the action is taken against.
+	target *discordgo.User
+
+	// reason is the reason for the action.
+	reason string
+
+	// duration is how long the action lasts, if it expires.
+	duration time.Duration
+
+	// requireMember is whether the target must be a member of the guild.
+	requireMember bool
+
+	// muteRole is whether the action needs the mute role of the guild, which is set as the roleID.
+	muteRole bool
+
+	// roleID is the ID of the role that the action gives or removes.
+	roleID string
+
+	// reversal is the action that is scheduled to reverse the action when it expires. Actions with a reversal expire
+	// after their duration, if it is not zero.
+	reversal entities.ScheduledActionType
+
+	// cancels is the type of the pending reversals against the target that are cancelled by the action, as the action
+	// replaces them or reverses them early.
+	cancels entities.ScheduledActionType
+
+	// notifyBefore is whether the target is sent a direct message before the action is applied, as they can no
+	// longer be messaged after they have left the guild.
+	notifyBefore bool
+
+	// check checks that the action can be taken, before the case is recorded. It returns a user error when the
+	// action would do nothing. It is nil for actions that can always be taken.
+	check func(ctx context.Context, s *discordgo.Session, guildID string) error
+
+	// apply applies the action. It is nil for actions that are only recorded.
+	apply func(ctx context.Context, s *discordgo.Session, guildID string) error
+}
+
+// warnHandler is the handler for the warn command.
+func warnHandler(c *commands.Context) error {
+	opts := c.Options().(*warnOptions)
+
+	return moderate(c, &moderationAction{
+		caseType:      entities.CaseTypeWarn,
+		target:        opts.User,
+		reason:        opts.Reason,
+		requireMember: true,
+	})
+}
+
+// timeoutHandler is the handler for the timeout command.
+func timeoutHandler(c *commands.Context) error {
+	opts := c.Options().(*timeoutOptions)
+
+	return moderate(c, &moderationAction{
+		caseType:      entities.CaseTypeTimeout,
+		target:        opts.User,
+		reason:        opts.Reason,
+		duration:      opts.duration,
+		requireMember: true,
+		apply: func(ctx context.Context, s *discordgo.Session, guildID string) error {
+			until := time.Now().Add(opts.duration)
+			return s.GuildMemberTimeout(guildID, opts.User.ID, &until, discordgo.WithContext(ctx), discordgo.WithAuditLogReason(caseReason(opts.Reason)))
+		},
+	})
+}
+
+// kickHandler is the handler for the kick command.
+func kickHandler(c *commands.Context) error {
+	opts := c.Options().(*moderationOptions)
+
+	return moderate(c, &moderationAction{
+		caseType:      entities.CaseTypeKick,
+		target:        opts.User,
+		reason:        opts.Reason,
+		requireMember: true,
+		notifyBefore:  true,
+		apply: func(ctx context.Context, s *discordgo.Session, guildID string) error {
+			return s.GuildMemberDeleteWithReason(guildID, opts.User.ID, caseReason(opts.Reason), discordgo.WithContext(ctx))
+		},
+	})
+}
+
+// banHandler is the handler for the ban command.
+func banHandler(c *commands.Context) error {
+	opts := c.Options().(*banOptions)
+
+	return moderate(c, &moderationAction{
+		caseType:     entities.CaseTypeBan,
+		target:       opts.User,
+		reason:       opts.Reason,
+		duration:     opts.duration,
+		notifyBefore: true,
+		reversal:     entities.ScheduledActionUnban,
+		cancels:      entities.ScheduledActionUnban,
+		apply: func(ctx context.Context, s *discordgo.Session, guildID string) error {
+			return s.GuildBanCreateWithReason(guildID, opts.User.ID, caseReason(opts.Reason), opts.DeleteDays, discordgo.WithContext(ctx))
+		},
+	})
+}
+
+// unbanHandler is the handler for the unban command.
+func unbanHandler(c *commands.Context) error {
+	opts := c.Options().(*moderationOptions)
+
+	return moderate(c, &moderationAction{
+		caseType: entities.CaseTypeUnban,
+		target:   opts.User,
+		reason:   opts.Reason,
+		cancels:  entities.ScheduledActionUnban,
+		check: func(ctx context.Context, s *discordgo.Session, guildID string) error {
+			if _, err := s.GuildBan(guildID, opts.User.ID, discordgo.WithContext(ctx)); isNotFound(err) {
+				return commands.NewUserError("<@%s> is not banned.", opts.User.ID)
+			} else if err != nil {
+				return fmt.Errorf("error getting ban: %w", err)
+			}
+			return nil
+		},
+		apply: func(ctx context.Context, s *discordgo.Session, guildID string) error {
+			return s.GuildBanDelete(guildID, opts.User.ID, discordgo.WithContext(ctx), discordgo.WithAuditLogReason(caseReason(opts.Reason)))
+		},
+	})
+}
+
+// softbanHandler is the handler for the softban command. The member is banned to delete their recent messages, and
+// the ban is removed straight away so they can rejoin.
+func softbanHandler(c *commands.Context) error {
+	opts := c.Options().(*softbanOptions)
+
+	deleteDays := opts.DeleteDays
+	if deleteDays == 0 {
+		deleteDays = 1
+	}
+
+	return moderate(c, &moderationAction{
+		caseType:      entities.CaseTypeSoftban,
+		target:        opts.User,
+		reason:        opts.Reason,
+		requireMember: true,
+		notifyBefore:  true,
+		apply: func(ctx context.Context, s *discordgo.Session, guildID string) error {
+			reason := caseReason(opts.Reason)
+			if err := s.GuildBanCreateWithReason(guildID, opts.User.ID, reason, deleteDays, discordgo.WithContext(ctx)); err != nil {
+				return err
+			}
+			if err := s.GuildBanDelete(guildID, opts.User.ID, discordgo.WithContext(ctx), discordgo.WithAuditLogReason(reason)); err != nil {
+				return fmt.Errorf("error removing softban: %w", err)
+			}
+			return nil
+		},
+	})
+}
+
+// muteHandler is the handler for the mute command.
+func muteHandler(c *commands.Context) error {
+	opts := c.Options().(*muteOptions)
+
+	action := &moderationAction{
+		caseType:      entities.CaseTypeMute,
+		target:        opts.User,
+		reason:        opts.Reason,
+		duration:      opts.duration,
+		requireMember: true,
+		muteRole:      true,
+		reversal:      entities.ScheduledActionUnmute,
+		cancels:       entities.ScheduledActionUnmute,
+	}
+	action.apply = func(ctx context.Context, s *discordgo.Session, guildID string) error {
+		return s.GuildMemberRoleAdd(guildID, opts.User.ID, action.roleID, discordgo.WithContext(ctx), discordgo.WithAuditLogReason(caseReason(opts.Reason)))
+	}
+
+	return moderate(c, action)
+}
+
+// unmuteHandler is the handler for the unmute command.
+func unmuteHandler(c *commands.Context) error {
+	opts := c.Options().(*moderationOptions)
+
+	action := &moderationAction{
+		caseType:      entities.CaseTypeUnmute,
+		target:        opts.User,
+		reason:        opts.Reason,
+		requireMember: true,
+		muteRole:      true,
+		cancels:       entities.ScheduledActionUnmute,
+	}
+	action.apply = func(ctx context.Context, s *discordgo.Session, guildID string) error {
+		return s.GuildMemberRoleRemove(guildID, opts.User.ID, action.roleID, discordgo.WithContext(ctx), discordgo.WithAuditLogReason(caseReason(opts.Reason)))
+	}
+
+	return moderate(c, action)
+}
+
+// moderate takes the moderation action, records it as a case and posts the case to the mod-log channel of the guild.
+// If the action expires, its reversal is scheduled against the case.
+func moderate(c *commands.Context, action *moderationAction) error {
+	ctx := c.Context()
+	l := c.Logger().With(slog.String("action", string(action.caseType)), slog.String("target_id", action.target.ID))
+
+	// Get the guild configuration.
+	guild, err := dataaccess.GuildDB.GetGuildByID(ctx, c.GuildID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("error getting guild configuration: %w", err)
+	}
+	if guild == nil {
+		guild = &entities.Guild{ID: c.GuildID}
+	}
+
+	if action.muteRole {
+		if guild.Moderation.MuteRoleID == "" {
+			return commands.NewUserError("No mute role has been set for this server. Set one with `/%s %s`.", setupCmdName, moderationCmdName)
+		}
+		action.roleID = guild.Moderation.MuteRoleID
+	}
+
+	if err := checkModerationTarget(ctx, c, action.target, action.requireMember); err != nil {
+		return err
+	}
+
+	if action.check != nil {
+		if err := action.check(ctx, c.Session(), c.GuildID); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().UTC()
+	guildCase := &entities.Case{
+		GuildID:     c.GuildID,
+		Type:        action.caseType,
+		ModeratorID: c.Member.User.ID,
+		TargetID:    action.target.ID,
+		Reason:      caseReason(action.reason),
+		Duration:    action.duration,
+		CreatedAt:   custom.Datetime(now),
+	}
+	if action.duration > 0 {
+		guildCase.ExpiresAt = custom.Datetime(now.Add(action.duration))
+	}
+
+	// The case is recorded and its reversal scheduled before the action is taken, so an action is never left in place
+	// without a case or an expiry.
+	if err := dataaccess.CaseDB.CreateCase(ctx, guildCase); err != nil {
+		l.Error("Error creating case", slog.String(logging.KeyError, err.Error()))
+		return commands.NewUserError("<@%s> has not been %s, as the case could not be recorded. Please try again.", action.target.ID, caseTypeVerb(action.caseType))
+	}
+
+	var reversal *entities.ScheduledAction
+	if action.reversal != "" && action.duration > 0 {
+		reversal = &entities.ScheduledAction{
+			Type:     action.reversal,
+			GuildID:  c.GuildID,
+			TargetID: action.target.ID,
+			RoleID:   action.roleID,
+			CaseID:   guildCase.ID,
+		}
+		if err := scheduleAction(ctx, reversal, time.Time(guildCase.ExpiresAt)); err != nil {
+			l.Error("Error scheduling reversal", slog.Int("case", guildCase.ID), slog.String(logging.KeyError, err.Error()))
+			return failCase(ctx, l, guildCase, caseErrorSchedule, action.target.ID)
+		}
+	}
+
+	notify := guild.Moderation.DMTargets && action.caseType != entities.CaseTypeUnban && action.caseType != entities.CaseTypeUnmute
+	if notify && action.notifyBefore {
+		notifyCaseTarget(ctx, l, c.Session(), guildCase)
+	}
+
+	if action.apply != nil {
+		if err := action.apply(ctx, c.Session(), c.GuildID); err != nil {
+			l.Error("Error applying action", slog.Int("case", guildCase.ID), slog.String(logging.KeyError, err.Error()))
+
+			// The reversal must not run for an action that was never taken.
+			if reversal != nil {
+				reversal.Status = entities.ScheduledActionCancelled
+				reversal.CompletedAt = custom.Datetime(time.Now().UTC())
+				if err := dataaccess.ScheduledActionDB.SaveScheduledAction(ctx, reversal); err != nil {
+					l.Error("Error cancelling reversal", slog.Int("case", guildCase.ID), slog.String(logging.KeyError, err.Error()))
+				}
+			}
+
+			return failCase(ctx, l, guildCase, applyErrorReason(err), action.target.ID)
+		}
+	}
+	ModerationActions.WithLabelValues(string(action.caseType)).Inc()
+
+	if notify && !action.notifyBefore {
+		notifyCaseTarget(ctx, l, c.Session(), guildCase)
+	}
+
+	// An earlier reversal would undo this action, or has been made redundant by it. The action has been taken, so a
+	// failure is only logged.
+	if action.cancels != "" {
+		var except []primitive.ObjectID
+		if reversal != nil {
+			except = append(except, reversal.ID)
+		}
+		if _, err := dataaccess.ScheduledActionDB.CancelScheduledActions(ctx, c.GuildID, action.target.ID, action.cancels, except...); err != nil {
+			l.Error("Error cancelling earlier scheduled actions", slog.String(logging.KeyError, err.Error()))
+		}
+	}
+
+	postCaseLog(ctx, l, c.Session(), guild, guildCase)
+
+	msg := fmt.Sprintf("Case #%d: <@%s> has been %s", guildCase.ID, action.target.ID, caseTypeVerb(action.caseType))
+	if action.duration > 0 {
+		msg += " for " + custom.FormatDuration(action.duration)
+	}
+	if err := c.RespondEphemeral(msg + "."); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// failCase records on the case that its action could not be applied, and returns the error shown to the moderator. The
+// case number has already been given out, so the case is kept rather than deleted.
+func failCase(ctx context.Context, l *slog.Logger, guildCase *entities.Case, reason string, targetID string) error {
+	guildCase.ApplyError = reason
+	if err := dataaccess.CaseDB.SaveCase(ctx, guildCase); err != nil {
+		l.Error("Error saving failed case", slog.Int("case", guildCase.ID), slog.String(logging.KeyError, err.Error()))
+	}
+
+	return commands.NewUserError("Case #%d was recorded, but <@%s> has not been %s. %s The case has been marked as not applied.",
+		guildCase.ID, targetID, caseTypeVerb(guildCase.Type), reason)
+}
+
+// applyErrorReason returns why the action could not be applied, as it is recorded on the case. The error from Discord
+// is only logged, as its text is not meant for the moderators.
+func applyErrorReason(err error) string {
+	restErr := new(discordgo.RESTError)
+	if errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == http.StatusForbidden {
+		return caseErrorForbidden
+	}
+	return caseErrorApply
+}
+
+// checkModerationTarget checks that the member can take an action against the target.
+func checkModerationTarget(ctx context.Context, c *commands.Context, target *discordgo.User, requireMember bool) error {
+	guild, err := c.Session().Guild(c.GuildID, discordgo.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("error getting guild: %w", err)
+	}
+
+	member, err := c.Session().GuildMember(c.GuildID, target.ID, discordgo.WithContext(ctx))
+	if err != nil {
+		if !isNotFound(err) {
+			return fmt.Errorf("error getting target member: %w", err)
+		}
+		member = nil
+	}
+
+	bot, err := c.Session().GuildMember(c.GuildID, c.Session().State.User.ID, discordgo.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("error getting bot member: %w", err)
+	}
+
+	return moderationTargetError(guild, c.Member, bot, target, member, requireMember)
+}
+
+// moderationTargetError returns the error shown to the moderator if they cannot take an action against the target. The
+// highest role of the target must be below the highest roles of both the moderator and the bot, unless the moderator
+// owns the guild. The member is nil for a target that is not in the guild. Such a target has no roles, so it is only
+// rejected if the action requires a member.
+func moderationTargetError(guild *discordgo.Guild, moderator, bot *discordgo.Member, target *discordgo.User, member *discordgo.Member, requireMember bool) error {
+	switch {
+	case target.ID == moderator.User.ID:
+		return commands.NewUserError("You cannot moderate yourself.")
+	case target.ID == bot.User.ID:
+		return commands.NewUserError("I cannot moderate myself.")
+	case target.ID == guild.OwnerID:
+		return commands.NewUserError("The owner of the server cannot be moderated.")
+	}
+
+	if member == nil {
+		if requireMember {
+			return commands.NewUserError("<@%s> is not a member of this server.", target.ID)
+		}
+		return nil
+	}
+
+	targetPosition := highestRolePosition(guild.Roles, member.Roles)
+
+	if moderator.User.ID != guild.OwnerID && highestRolePosition(guild.Roles, moderator.Roles) <= targetPosition {
+		return commands.NewUserError("You cannot moderate <@%s>, as their highest role is not below yours.", target.ID)
+	}
+
+	if highestRolePosition(guild.Roles, bot.Roles) <= targetPosition {
+		return commands.NewUserError("I cannot moderate <@%s>, as their highest role is not below mine.", target.ID)
+	}
+
+	return nil
+}
+
+// highestRolePosition returns the position of the highest of the roles. A member without roles only has the
+// @everyone role, which is at position 0.
+func highestRolePosition(guildRoles []*discordgo.Role, roleIDs []string) int {
+	highest := 0
+	for _, role := range guildRoles {
+		if role.Position > highest && slices.Contains(roleIDs, role.ID) {
+			highest = role.Position
+		}
+	}
+	return highest
+}
+
+// notifyCaseTarget sends the target of the case a direct message about it. The action is still taken if the message
+// cannot be sent, as users can turn off direct messages from servers.
+func notifyCaseTarget(ctx context.Context, l *slog.Logger, s *discordgo.Session, guildCase *entities.Case) {
+	guildName := "the server"
+	if guild, err := s.Guild(guildCase.GuildID, discordgo.WithContext(ctx)); err == nil {
+		guildName = fmt.Sprintf("**%s**", guild.Name)
+	}
+
+	preposition := "from"
+	switch guildCase.Type {
+	case entities.CaseTypeWarn, entities.CaseTypeTimeout, entities.CaseTypeMute:
+		preposition = "in"
+	}
+
+	msg := fmt.Sprintf("You have been %s %s %s.", caseTypeVerb(guildCase.Type), preposition, guildName)
+	if guildCase.Duration > 0 {
+		msg += fmt.Sprintf("\nDuration: %s", custom.FormatDuration(guildCase.Duration))
+	}
+	msg += fmt.Sprintf("\nReason: %s", guildCase.Reason)
+
+	channel, err := s.UserChannelCreate(guildCase.TargetID, discordgo.WithContext(ctx))
+	if err == nil {
+		_, err = s.ChannelMessageSend(channel.ID, msg, discordgo.WithContext(ctx))
+	}
+	if err != nil {
+		l.Warn("Error sending direct message to moderation target", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// postCaseLog posts the case to the mod-log channel of the guild, if one is set, and saves the message on the case.
+// The action has already been taken, so errors are logged rather than returned.
+func postCaseLog(ctx context.Context, l *slog.Logger, s *discordgo.Session, guild *entities.Guild, guildCase *entities.Case) {
+	if guild.Moderation.LogChannelID == "" {
+		return
+	}
+
+	msg, err := s.ChannelMessageSendEmbed(guild.Moderation.LogChannelID, caseEmbed(guildCase), discordgo.WithContext(ctx))
+	if err != nil {
+		l.Warn("Error posting case to mod-log channel", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	guildCase.LogChannelID = msg.ChannelID
+	guildCase.LogMessageID = msg.ID
+	if err := dataaccess.CaseDB.SaveCase(ctx, guildCase); err != nil {
+		l.Warn("Error saving case log message", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// updateCaseLog updates the post of the case in the mod-log channel, if it was posted. Errors are logged, as the case
+// has already been saved.
+func updateCaseLog(ctx context.Context, l *slog.Logger, s *discordgo.Session, guildCase *entities.Case) {
+	if guildCase.LogMessageID == "" {
+		return
+	}
+
+	_, err := s.ChannelMessageEditEmbed(guildCase.LogChannelID, guildCase.LogMessageID, caseEmbed(guildCase), discordgo.WithContext(ctx))
+	if err != nil {
+		l.Warn("Error updating case in mod-log channel",
+			slog.Int("case", guildCase.ID),
+			slog.String(logging.KeyError, err.Error()),
+		)
+	}
+}
+
+// caseEmbed returns the embed that shows the case.
+func caseEmbed(guildCase *entities.Case) *discordgo.MessageEmbed {
+	fields := []*discordgo.MessageEmbedField{
+		{
+			Name:   "User",
+			Value:  fmt.Sprintf("<@%s> (%s)", guildCase.TargetID, guildCase.TargetID),
+			Inline: true,
+		},
+		{
+			Name:   "Moderator",
+			Value:  fmt.Sprintf("<@%s>", guildCase.ModeratorID),
+			Inline: true,
+		},
+	}
+
+	if guildCase.Duration > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Duration",
+			Value:  custom.FormatDuration(guildCase.Duration),
+			Inline: true,
+		})
+	}
+
+	switch {
+	case guildCase.ApplyError != "":
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Not applied",
+			Value:  truncate(guildCase.ApplyError, caseListReasonLength),
+			Inline: true,
+		})
+	case guildCase.ReversalError != "":
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Expiry failed",
+			Value:  truncate(guildCase.ReversalError, caseListReasonLength),
+			Inline: true,
+		})
+	case !time.Time(guildCase.ReversedAt).IsZero():
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Expired",
+			Value:  fmt.Sprintf("<t:%d:f>", time.Time(guildCase.ReversedAt).Unix()),
+			Inline: true,
+		})
+	case !time.Time(guildCase.ExpiresAt).IsZero():
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Expires",
+			Value:  fmt.Sprintf("<t:%d:R>", time.Time(guildCase.ExpiresAt).Unix()),
+			Inline: true,
+		})
+	}
+
+	fields = append(fields, &discordgo.MessageEmbedField{
+		Name:  "Reason",
+		Value: guildCase.Reason,
+	})
+
+	return &discordgo.MessageEmbed{
+		Title:     fmt.Sprintf("Case #%d | %s", guildCase.ID, caseTypeTitle(guildCase.Type)),
+		Color:     caseColors[guildCase.Type],
+		Fields:    fields,
+		Timestamp: time.Time(guildCase.CreatedAt).Format(time.RFC3339),
+	}
+}
+
+// caseReason returns the reason, or the default reason if it is empty.
+func caseReason(reason string) string {
+	if strings.TrimSpace(reason) == "" {
+		return defaultCaseReason
+	}
+	return reason
+}
+
+// caseTypeTitle returns the type of the case as a title, such as "Softban".
+func caseTypeTitle(t entities.CaseType) string {
+	s := string(t)
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// caseTypeVerb returns the past tense of the action of the case, such as "timed out".
+func caseTypeVerb(t entities.CaseType) string {
+	switch t {
+	case entities.CaseTypeWarn:
+		return "warned"
+	case entities.CaseTypeTimeout:
+		return "timed out"
+	case entities.CaseTypeKick:
+		return "kicked"
+	case entities.CaseTypeBan:
+		return "banned"
+	case entities.CaseTypeUnban:
+		return "unbanned"
+	case entities.CaseTypeSoftban:
+		return "softbanned"
+	case entities.CaseTypeMute:
+		return "muted"
+	case entities.CaseTypeUnmute:
+		return "unmuted"
+	case entities.CaseTypeAutomod:
+		return "flagged by automod"
+	default:
+		return string(t)
+	}
+}
+
+// truncate shortens the text to at most n characters, marking where it was cut.
+func truncate(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// getCase gets the case of the guild, returning a user error if it does not exist.
+func getCase(ctx context.Context, guildID string, number int) (*entities.Case, error) {
+	guildCase, err := dataaccess.CaseDB.GetCase(ctx, guildID, number)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, commands.NewUserError("Case #%d does not exist.", number)
+	} else if err != nil {
+		return nil, fmt.Errorf("error getting case: %w", err)
+	}
+	return guildCase, nil
+}
+
+// viewCaseHandler is the handler for the view case command.
+func viewCaseHandler(c *commands.Context) error {
+	opts := c.Options().(*caseOptions)
+
+	guildCase, err := getCase(c.Context(), c.GuildID, opts.Number)
+	if err != nil {
+		return err
+	}
+
+	err = c.Respond(&discordgo.InteractionResponseData{
+		Flags:  discordgo.MessageFlagsEphemeral,
+		Embeds: []*discordgo.MessageEmbed{caseEmbed(guildCase)},
+	})
+	if err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// editCaseReasonHandler is the handler for the edit reason command. The post of the case in the mod-log channel is
+// updated with the new reason.
+func editCaseReasonHandler(c *commands.Context) error {
+	ctx := c.Context()
+	opts := c.Options().(*editCaseReasonOptions)
+
+	guildCase, err := getCase(ctx, c.GuildID, opts.Number)
+	if err != nil {
+		return err
+	}
+
+	guildCase.Reason = caseReason(opts.Reason)
+	if err := dataaccess.CaseDB.SaveCase(ctx, guildCase); err != nil {
+		return fmt.Errorf("error saving case: %w", err)
+	}
+
+	updateCaseLog(ctx, c.Logger(), c.Session(), guildCase)
+
+	if err := c.RespondEphemeral(fmt.Sprintf("The reason of case #%d has been updated.", guildCase.ID)); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// listCasesHandler is the handler for the list cases command.
+func listCasesHandler(c *commands.Context) error {
+	opts := c.Options().(*listCasesOptions)
+
+	cases, err := dataaccess.CaseDB.ListCases(c.Context(), c.GuildID, opts.User.ID, caseListLimit)
+	if err != nil {
+		return fmt.Errorf("error listing cases: %w", err)
+	}
+
+	if len(cases) == 0 {
+		if err := c.RespondEphemeral(fmt.Sprintf("There are no cases against <@%s>.", opts.User.ID)); err != nil {
+			return fmt.Errorf("error responding to interaction: %w", err)
+		}
+		return nil
+	}
+
+	// The cases are listed oldest first, so they read as a history.
+	sort.Slice(cases, func(i, j int) bool {
+		return cases[i].ID < cases[j].ID
+	})
+
+	lines := make([]string, 0, len(cases))
+	for _, guildCase := range cases {
+		lines = append(lines, fmt.Sprintf("**#%d** %s <t:%d:d> by <@%s>: %s",
+			guildCase.ID,
+			caseTypeTitle(guildCase.Type),
+			time.Time(guildCase.CreatedAt).Unix(),
+			guildCase.ModeratorID,
+			truncate(guildCase.Reason, caseListReasonLength),
+		))
+	}
+
+	err = c.Respond(&discordgo.InteractionResponseData{
+		Flags: discordgo.MessageFlagsEphemeral,
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       fmt.Sprintf("Latest cases against %s", opts.User.Username),
+				Description: strings.Join(lines, "\n"),
+				Color:       0x0099ff,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/commands"
+	"github.com/stretchr/testify/require"
+)
+
+// testMember creates a member of the test guild with the roles.
+func testMember(id string, roleIDs ...string) *discordgo.Member {
+	return &discordgo.Member{
+		User:  &discordgo.User{ID: id},
+		Roles: roleIDs,
+	}
+}
+
+func TestHighestRolePosition(t *testing.T) {
+	guildRoles := []*discordgo.Role{
+		{ID: "everyone", Position: 0},
+		{ID: "member", Position: 1},
+		{ID: "mod", Position: 5},
+		{ID: "admin", Position: 10},
+	}
+
+	tests := []struct {
+		name    string
+		roleIDs []string
+		want    int
+	}{
+		{
+			name:    "no roles",
+			roleIDs: nil,
+			want:    0,
+		},
+		{
+			name:    "one role",
+			roleIDs: []string{"member"},
+			want:    1,
+		},
+		{
+			name:    "highest of many",
+			roleIDs: []string{"member", "admin", "mod"},
+			want:    10,
+		},
+		{
+			name:    "unknown role",
+			roleIDs: []string{"deleted"},
+			want:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, highestRolePosition(guildRoles, tt.roleIDs))
+		})
+	}
+}
+
+func TestModerationTargetError(t *testing.T) {
+	guild := &discordgo.Guild{
+		ID:      "guild",
+		OwnerID: "owner",
+		Roles: []*discordgo.Role{
+			{ID: "member", Position: 1},
+			{ID: "mod", Position: 5},
+			{ID: "bot", Position: 8},
+			{ID: "admin", Position: 10},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		moderator     *discordgo.Member
+		bot           *discordgo.Member
+		target        string
+		member        *discordgo.Member
+		requireMember bool
+		wantErr       string
+	}{
+		{
+			name:      "member below moderator and bot",
+			moderator: testMember("moderator", "mod"),
+			bot:       testMember("bot", "bot"),
+			target:    "target",
+			member:    testMember("target", "member"),
+		},
+		{
+			name:      "self",
+			moderator: testMember("moderator", "mod"),
+			bot:       testMember("bot", "bot"),
+			target:    "moderator",
+			member:    testMember("moderator", "mod"),
+			wantErr:   "You cannot moderate yourself.",
+		},
+		{
+			name:      "bot",
+			moderator: testMember("moderator", "admin"),
+			bot:       testMember("bot", "bot"),
+			target:    "bot",
+			member:    testMember("bot", "bot"),
+			wantErr:   "I cannot moderate myself.",
+		},
+		{
+			name:      "owner",
+			moderator: testMember("moderator", "admin"),
+			bot:       testMember("bot", "bot"),
+			target:    "owner",
+			member:    testMember("owner"),
+			wantErr:   "The owner of the server cannot be moderated.",
+		},
+		{
+			name:      "equal role to moderator",
+			moderator: testMember("moderator", "mod"),
+			bot:       testMember("bot", "bot"),
+			target:    "target",
+			member:    testMember("target", "mod"),
+			wantErr:   "You cannot moderate <@target>, as their highest role is not below yours.",
+		},
+		{
+			name:      "owner moderating an equal role",
+			moderator: testMember("owner"),
+			bot:       testMember("bot", "bot"),
+			target:    "target",
+			member:    testMember("target", "mod"),
+		},
+		{
+			name:      "equal role to bot",
+			moderator: testMember("moderator", "admin"),
+			bot:       testMember("bot", "bot"),
+			target:    "target",
+			member:    testMember("target", "bot"),
+			wantErr:   "I cannot moderate <@target>, as their highest role is not below mine.",
+		},
+		{
+			name:      "role above bot",
+			moderator: testMember("owner"),
+			bot:       testMember("bot", "bot"),
+			target:    "target",
+			member:    testMember("target", "admin"),
+			wantErr:   "I cannot moderate <@target>, as their highest role is not below mine.",
+		},
+		{
+			name:      "non-member",
+			moderator: testMember("moderator", "mod"),
+			bot:       testMember("bot", "bot"),
+			target:    "target",
+		},
+		{
+			name:          "non-member when a member is required",
+			moderator:     testMember("moderator", "mod"),
+			bot:           testMember("bot", "bot"),
+			target:        "target",
+			requireMember: true,
+			wantErr:       "<@target> is not a member of this server.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := moderationTargetError(guild, tt.moderator, tt.bot, &discordgo.User{ID: tt.target}, tt.member, tt.requireMember)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			userErr := new(commands.UserError)
+			require.ErrorAs(t, err, &userErr)
+			require.Equal(t, tt.wantErr, userErr.Message)
+		})
+	}
+}
+
+func TestApplyErrorReason(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "forbidden",
+			err:  &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusForbidden}, ResponseBody: []byte(`{"message":"Missing Permissions"}`)},
+			want: caseErrorForbidden,
+		},
+		{
+			name: "server error",
+			err:  &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusInternalServerError}, ResponseBody: []byte(`{"message":"internal"}`)},
+			want: caseErrorApply,
+		},
+		{
+			name: "other error",
+			err:  errors.New("connection reset"),
+			want: caseErrorApply,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, applyErrorReason(fmt.Errorf("error applying ban: %w", tt.err)))
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"crypto/subtle"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/Jacobbrewer1/wolf/pkg/request"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// PathDebug is the path prefix of the pprof and expvar routes.
+	PathDebug = "/debug"
+)
+
+// monitoringAuth only allows the requests that present the basic auth credentials or the bearer token, when either is
+// configured. The routes are open otherwise.
+func monitoringAuth(username, password, token string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if username == "" && token == "" {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if username != "" {
+				if user, pass, ok := r.BasicAuth(); ok &&
+					secureCompare(user, username) &&
+					secureCompare(pass, password) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			if token != "" && hasBearerToken(r, token) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			r = request.WithRequestID(w, r)
+			if username != "" {
+				w.Header().Add("WWW-Authenticate", `Basic realm="`+AppName+`"`)
+			}
+			if token != "" {
+				w.Header().Add("WWW-Authenticate", `Bearer realm="`+AppName+`"`)
+			}
+			request.RespondError(w, r, http.StatusUnauthorized, "Unauthorized")
+		})
+	}
+}
+
+// hasBearerToken returns true if the request presents the bearer token.
+func hasBearerToken(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && secureCompare(got, token)
+}
+
+// secureCompare compares the strings in constant time, so the secrets cannot be guessed from the response time.
+func secureCompare(got, want string) bool {
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+// registerDebugRoutes registers the pprof and expvar routes on the router.
+func registerDebugRoutes(r *mux.Router) {
+	r.HandleFunc("/pprof/cmdline", pprof.Cmdline).Methods(http.MethodGet)
+	r.HandleFunc("/pprof/profile", pprof.Profile).Methods(http.MethodGet)
+	r.HandleFunc("/pprof/symbol", pprof.Symbol).Methods(http.MethodGet, http.MethodPost)
+	r.HandleFunc("/pprof/trace", pprof.Trace).Methods(http.MethodGet)
+
+	// The index also serves the named profiles, such as heap and goroutine.
+	r.PathPrefix("/pprof/").HandlerFunc(pprof.Index).Methods(http.MethodGet)
+
+	r.Handle("/vars", expvar.Handler()).Methods(http.MethodGet)
+}
@@ -4,6 +4,24 @@ const (
 	// PathMetrics is the path for the metrics endpoint.
 	PathMetrics = "/metrics"
 
-	// PathHealth is the path for the health endpoint.
+	// PathHealth is the path for the health endpoint. It is kept for the existing probes, and reports readiness.
 	PathHealth = "/health"
+
+	// PathLiveness is the path for the liveness probe, which fails when the application must be restarted.
+	PathLiveness = "/livez"
+
+	// PathReadiness is the path for the readiness probe, which fails when the application cannot serve.
+	PathReadiness = "/readyz"
+
+	// PathStartup is the path for the startup probe, which fails until the application has started.
+	PathStartup = "/startupz"
+
+	// PathLogLevel is the path for viewing and changing the log levels.
+	PathLogLevel = "/log/level"
+
+	// PathAPI is the path prefix of the admin API.
+	PathAPI = "/api/v1"
+
+	// PathVerify is the path prefix of the public verification routes, which serve the captcha images.
+	PathVerify = "/verify"
 )
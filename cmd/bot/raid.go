@@ -0,0 +1,621 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/antiraid"
+	"github.com/Jacobbrewer1/wolf/pkg/commands"
+	"github.com/Jacobbrewer1/wolf/pkg/custom"
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// RaidCmdName is the command for raid mode.
+	RaidCmdName = "raid"
+
+	// StartRaidCmdName is the sub command for entering raid mode.
+	StartRaidCmdName = "start"
+
+	// EndRaidCmdName is the sub command for leaving raid mode.
+	EndRaidCmdName = "end"
+
+	// RaidStatusCmdName is the sub command for showing whether the server is in raid mode.
+	RaidStatusCmdName = "status"
+
+	// LockRaidChannelCmdName is the sub command for choosing the channels locked in raid mode.
+	LockRaidChannelCmdName = "lock"
+)
+
+const (
+	// raidLockPermissions are the permissions denied to the @everyone role in the locked channels.
+	raidLockPermissions = discordgo.PermissionSendMessages | discordgo.PermissionSendMessagesInThreads
+
+	// raidJoinReason is the audit log reason of the actions taken against the members that join in raid mode.
+	raidJoinReason = "Joined during raid mode"
+)
+
+// raidVerificationLevels are the verification levels that a guild can be raised to in raid mode, by their name.
+var raidVerificationLevels = map[string]discordgo.VerificationLevel{
+	"low":       discordgo.VerificationLevelLow,
+	"medium":    discordgo.VerificationLevelMedium,
+	"high":      discordgo.VerificationLevelHigh,
+	"very_high": discordgo.VerificationLevelVeryHigh,
+}
+
+var (
+	// raidCmd is the command for raid mode.
+	raidCmd = &commands.Command{
+		Name:        RaidCmdName,
+		Description: "This is the command for raid mode.",
+		Permissions: discordgo.PermissionManageServer,
+		GuildOnly:   true,
+		Ephemeral:   true,
+		Subcommands: []*commands.Command{
+			{
+				Name:        StartRaidCmdName,
+				Description: "This puts the server in raid mode.",
+				Options:     new(startRaidOptions),
+				Handler:     startRaidHandler,
+			},
+			{
+				Name:        EndRaidCmdName,
+				Description: "This takes the server out of raid mode.",
+				Handler:     endRaidHandler,
+			},
+			{
+				Name:        RaidStatusCmdName,
+				Description: "This shows whether the server is in raid mode.",
+				Handler:     raidStatusHandler,
+			},
+			{
+				Name:        LockRaidChannelCmdName,
+				Description: "This chooses a channel that members cannot talk in during raid mode.",
+				Options:     new(lockRaidChannelOptions),
+				Handler:     lockRaidChannelHandler,
+			},
+		},
+	}
+)
+
+// startRaidOptions are the options for the start raid command.
+type startRaidOptions struct {
+	// Reason is why raid mode is entered.
+	Reason string `option:"reason" description:"This is why the server is put in raid mode."`
+}
+
+// lockRaidChannelOptions are the options for the lock raid channel command.
+type lockRaidChannelOptions struct {
+	// Channel is the channel locked in raid mode.
+	Channel *discordgo.Channel `option:"channel" description:"This is the channel that members cannot talk in during raid mode." required:"true" channel_types:"text,news,forum"`
+
+	// Remove is whether the channel is no longer locked instead.
+	Remove bool `option:"remove" description:"This is whether the channel is no longer locked in raid mode instead."`
+}
+
+// raidSettings returns the settings the guild detects raids with.
+func raidSettings(cfg *entities.RaidConfig) antiraid.Settings {
+	return antiraid.Settings{
+		Threshold:     cfg.JoinThreshold,
+		Window:        cfg.JoinWindow,
+		MaxAccountAge: cfg.MaxAccountAge,
+	}
+}
+
+// getRaid gets the raid mode of the guild, or nil if the guild has never been in raid mode.
+func getRaid(ctx context.Context, guildID string) (*entities.Raid, error) {
+	raid, err := dataaccess.RaidDB.GetRaid(ctx, guildID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error getting raid: %w", err)
+	}
+	return raid, nil
+}
+
+// raidJoinHandler detects raids from the members joining, and takes the raid action against the members that join in
+// raid mode.
+func (a *App) raidJoinHandler() func(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+	return func(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+		if m.Member == nil || m.User == nil || m.User.Bot {
+			return
+		}
+
+		l := a.With(
+			slog.String(logging.KeyComponent, componentRaid),
+			slog.String("guild_id", m.GuildID),
+		)
+
+		guild, err := getGuildConfig(a.ctx, m.GuildID)
+		if err != nil {
+			l.Error("Error getting anti-raid configuration", slog.String(logging.KeyError, err.Error()))
+			return
+		}
+		if !guild.Raid.Enabled {
+			return
+		}
+
+		raid, err := getRaid(a.ctx, m.GuildID)
+		if err != nil {
+			l.Error("Error getting raid mode", slog.String(logging.KeyError, err.Error()))
+			return
+		}
+		if raid != nil && raid.Active {
+			actionRaidJoiner(a.ctx, l, s, guild, m.User.ID)
+			return
+		}
+
+		created, err := discordgo.SnowflakeTimestamp(m.User.ID)
+		if err != nil {
+			l.Warn("Error getting account creation time", slog.String(logging.KeyError, err.Error()))
+			return
+		}
+
+		joinedAt := m.JoinedAt
+		if joinedAt.IsZero() {
+			joinedAt = time.Now()
+		}
+
+		joiners := a.raids.Join(m.GuildID, m.User.ID, created, joinedAt, raidSettings(&guild.Raid))
+		if joiners == nil {
+			return
+		}
+
+		reason := fmt.Sprintf("%d suspicious accounts joined within %s", len(joiners), custom.FormatDuration(guild.Raid.JoinWindow))
+		if _, err := startRaid(a.ctx, l, s, guild, reason, "", joiners); err != nil {
+			l.Error("Error starting raid mode", slog.String(logging.KeyError, err.Error()))
+		}
+	}
+}
+
+// startRaid puts the guild in raid mode. The verification level of the guild is raised, the channels are locked, the
+// raid action is taken against the members whose joins started it, and the mod-log channel is alerted. Raid mode is
+// stored before anything is changed, and its end is scheduled before that, so it is still left if the bot is restarted.
+func startRaid(ctx context.Context, l *slog.Logger, s *discordgo.Session, guild *entities.Guild, reason, startedBy string, joinerIDs []string) (*entities.Raid, error) {
+	existing, err := getRaid(ctx, guild.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.Active {
+		return nil, commands.NewUserError("The server is already in raid mode.")
+	}
+
+	now := time.Now().UTC()
+	raid := &entities.Raid{
+		GuildID:   guild.ID,
+		Active:    true,
+		Reason:    reason,
+		StartedBy: startedBy,
+		StartedAt: custom.Datetime(now),
+		EndsAt:    custom.Datetime(now.Add(guild.Raid.CoolDown)),
+	}
+
+	// Leave raid mode after the cool-down. The end is scheduled before raid mode is stored, so the guild is never left
+	// in raid mode without an end.
+	if _, err := dataaccess.ScheduledActionDB.CancelScheduledActions(ctx, guild.ID, guild.ID, entities.ScheduledActionEndRaid); err != nil {
+		return nil, fmt.Errorf("error cancelling scheduled end of raid mode: %w", err)
+	}
+	end := &entities.ScheduledAction{
+		Type:     entities.ScheduledActionEndRaid,
+		GuildID:  guild.ID,
+		TargetID: guild.ID,
+	}
+	if err := scheduleAction(ctx, end, time.Time(raid.EndsAt)); err != nil {
+		return nil, fmt.Errorf("error scheduling end of raid mode: %w", err)
+	}
+
+	if err := dataaccess.RaidDB.SaveRaid(ctx, raid); err != nil {
+		// Raid mode was not entered, so it must not be ended.
+		end.Status = entities.ScheduledActionCancelled
+		end.CompletedAt = custom.Datetime(time.Now().UTC())
+		if err := dataaccess.ScheduledActionDB.SaveScheduledAction(context.WithoutCancel(ctx), end); err != nil {
+			l.Error("Error cancelling scheduled end of raid mode", slog.String(logging.KeyError, err.Error()))
+		}
+		return nil, fmt.Errorf("error saving raid: %w", err)
+	}
+
+	trigger := "automatic"
+	if startedBy != "" {
+		trigger = "manual"
+	}
+	Raids.WithLabelValues(trigger).Inc()
+
+	problems := make([]string, 0)
+
+	// Raise the verification level, unless it is already as high.
+	if dg, err := s.Guild(guild.ID, discordgo.WithContext(ctx)); err != nil {
+		l.Warn("Error getting guild for raid mode", slog.String(logging.KeyError, err.Error()))
+		problems = append(problems, "The verification level could not be raised.")
+	} else if level := discordgo.VerificationLevel(guild.Raid.VerificationLevel); dg.VerificationLevel < level {
+		_, err := s.GuildEdit(guild.ID, &discordgo.GuildParams{VerificationLevel: &level}, discordgo.WithContext(ctx), discordgo.WithAuditLogReason("Raid mode"))
+		if err != nil {
+			l.Warn("Error raising verification level for raid mode", slog.String(logging.KeyError, err.Error()))
+			problems = append(problems, "The verification level could not be raised.")
+		} else {
+			raid.VerificationRaised = true
+			raid.PreviousVerificationLevel = int(dg.VerificationLevel)
+		}
+	}
+
+	// Lock the channels, remembering their permissions so they can be restored.
+	for _, channelID := range guild.Raid.LockChannelIDs {
+		locked, err := lockRaidChannel(ctx, s, guild.ID, channelID)
+		if err != nil {
+			l.Warn("Error locking channel for raid mode", slog.String("channel_id", channelID), slog.String(logging.KeyError, err.Error()))
+			problems = append(problems, fmt.Sprintf("<#%s> could not be locked.", channelID))
+			continue
+		}
+		if locked != nil {
+			raid.LockedChannels = append(raid.LockedChannels, locked)
+		}
+	}
+
+	// Raid mode has been entered and its end scheduled, so a failure to record the changes is only reported.
+	if err := dataaccess.RaidDB.SaveRaid(ctx, raid); err != nil {
+		l.Error("Error saving raid changes", slog.String(logging.KeyError, err.Error()))
+		problems = append(problems, "The changes could not be recorded, so they will not be undone when raid mode ends.")
+	}
+
+	for _, userID := range joinerIDs {
+		actionRaidJoiner(ctx, l, s, guild, userID)
+	}
+
+	description := reason
+	if startedBy != "" {
+		description = fmt.Sprintf("Started by <@%s>: %s", startedBy, reason)
+	}
+	if len(problems) > 0 {
+		description += "\n\n" + strings.Join(problems, "\n")
+	}
+	alertRaid(ctx, l, s, guild, &discordgo.MessageEmbed{
+		Title:       "Raid Mode Started",
+		Description: description,
+		Color:       0xff0000,
+		Fields:      raidFields(guild, raid),
+		Timestamp:   now.Format(time.RFC3339),
+	})
+
+	return raid, nil
+}
+
+// lockRaidChannel stops the @everyone role sending messages in the channel. It returns nil if the channel was already
+// locked, so it is left locked when raid mode is left.
+func lockRaidChannel(ctx context.Context, s *discordgo.Session, guildID, channelID string) (*entities.LockedChannel, error) {
+	channel, err := s.Channel(channelID, discordgo.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting channel: %w", err)
+	}
+
+	// The ID of the @everyone role is the ID of the guild.
+	locked := &entities.LockedChannel{ChannelID: channelID}
+	for _, overwrite := range channel.PermissionOverwrites {
+		if overwrite.ID == guildID && overwrite.Type == discordgo.PermissionOverwriteTypeRole {
+			locked.HadOverwrite = true
+			locked.Allow = overwrite.Allow
+			locked.Deny = overwrite.Deny
+		}
+	}
+	if locked.Deny&raidLockPermissions == raidLockPermissions {
+		return nil, nil
+	}
+
+	err = s.ChannelPermissionSet(channelID, guildID, discordgo.PermissionOverwriteTypeRole,
+		locked.Allow&^raidLockPermissions,
+		locked.Deny|raidLockPermissions,
+		discordgo.WithContext(ctx),
+		discordgo.WithAuditLogReason("Raid mode"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error setting channel permissions: %w", err)
+	}
+	return locked, nil
+}
+
+// unlockRaidChannel restores the permissions that the @everyone role had in the channel before it was locked.
+func unlockRaidChannel(ctx context.Context, s *discordgo.Session, guildID string, locked *entities.LockedChannel) error {
+	var err error
+	if locked.HadOverwrite {
+		err = s.ChannelPermissionSet(locked.ChannelID, guildID, discordgo.PermissionOverwriteTypeRole, locked.Allow, locked.Deny,
+			discordgo.WithContext(ctx),
+			discordgo.WithAuditLogReason("Raid mode ended"),
+		)
+	} else {
+		err = s.ChannelPermissionDelete(locked.ChannelID, guildID, discordgo.WithContext(ctx), discordgo.WithAuditLogReason("Raid mode ended"))
+	}
+
+	// A channel that was deleted has nothing to restore.
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("error restoring channel permissions: %w", err)
+	}
+	return nil
+}
+
+// endRaid takes the guild out of raid mode, restoring the verification level and the channels. If anything cannot be
+// restored, the guild is left in raid mode with only what is left to restore, so it can be tried again.
+func endRaid(ctx context.Context, l *slog.Logger, s *discordgo.Session, guild *entities.Guild, raid *entities.Raid, endedBy string) error {
+	if !raid.Active {
+		return nil
+	}
+
+	errs := make([]error, 0)
+
+	if raid.VerificationRaised {
+		level := discordgo.VerificationLevel(raid.PreviousVerificationLevel)
+		_, err := s.GuildEdit(guild.ID, &discordgo.GuildParams{VerificationLevel: &level}, discordgo.WithContext(ctx), discordgo.WithAuditLogReason("Raid mode ended"))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error restoring verification level: %w", err))
+		} else {
+			raid.VerificationRaised = false
+		}
+	}
+
+	remaining := make([]*entities.LockedChannel, 0)
+	for _, locked := range raid.LockedChannels {
+		if err := unlockRaidChannel(ctx, s, guild.ID, locked); err != nil {
+			errs = append(errs, fmt.Errorf("channel %s: %w", locked.ChannelID, err))
+			remaining = append(remaining, locked)
+		}
+	}
+	raid.LockedChannels = remaining
+
+	if len(errs) == 0 {
+		raid.Active = false
+		raid.EndedAt = custom.Datetime(time.Now().UTC())
+	}
+
+	if err := dataaccess.RaidDB.SaveRaid(ctx, raid); err != nil {
+		return fmt.Errorf("error saving raid: %w", err)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	// A manual end replaces the end after the cool-down.
+	if endedBy != "" {
+		if _, err := dataaccess.ScheduledActionDB.CancelScheduledActions(ctx, guild.ID, guild.ID, entities.ScheduledActionEndRaid); err != nil {
+			l.Warn("Error cancelling scheduled end of raid mode", slog.String(logging.KeyError, err.Error()))
+		}
+	}
+
+	description := "The cool-down has passed."
+	if endedBy != "" {
+		description = fmt.Sprintf("Ended by <@%s>.", endedBy)
+	}
+	alertRaid(ctx, l, s, guild, &discordgo.MessageEmbed{
+		Title:       "Raid Mode Ended",
+		Description: description,
+		Color:       0x00ff00,
+		Timestamp:   time.Time(raid.EndedAt).Format(time.RFC3339),
+	})
+	return nil
+}
+
+// actionRaidJoiner takes the raid action of the guild against the member that joined. Errors are logged, so the
+// action is still taken against the other members.
+func actionRaidJoiner(ctx context.Context, l *slog.Logger, s *discordgo.Session, guild *entities.Guild, userID string) {
+	var err error
+	switch guild.Raid.Action {
+	case entities.RaidActionKick:
+		err = s.GuildMemberDeleteWithReason(guild.ID, userID, raidJoinReason, discordgo.WithContext(ctx))
+	case entities.RaidActionQuarantine:
+		if guild.Raid.QuarantineRoleID == "" {
+			return
+		}
+		err = s.GuildMemberRoleAdd(guild.ID, userID, guild.Raid.QuarantineRoleID, discordgo.WithContext(ctx), discordgo.WithAuditLogReason(raidJoinReason))
+	default:
+		return
+	}
+
+	if err != nil && !isNotFound(err) {
+		l.Warn("Error taking raid action against member",
+			slog.String("action", string(guild.Raid.Action)),
+			slog.String("user_id", userID),
+			slog.String(logging.KeyError, err.Error()),
+		)
+		return
+	}
+	RaidJoinActions.WithLabelValues(string(guild.Raid.Action)).Inc()
+}
+
+// alertRaid posts the embed to the mod-log channel of the guild, if one is set.
+func alertRaid(ctx context.Context, l *slog.Logger, s *discordgo.Session, guild *entities.Guild, embed *discordgo.MessageEmbed) {
+	if guild.Moderation.LogChannelID == "" {
+		return
+	}
+
+	if _, err := s.ChannelMessageSendEmbed(guild.Moderation.LogChannelID, embed, discordgo.WithContext(ctx)); err != nil {
+		l.Warn("Error posting raid alert to mod-log channel", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// raidFields returns the embed fields describing the raid mode.
+func raidFields(guild *entities.Guild, raid *entities.Raid) []*discordgo.MessageEmbedField {
+	fields := []*discordgo.MessageEmbedField{
+		{
+			Name:   "Ends",
+			Value:  fmt.Sprintf("<t:%d:R>", time.Time(raid.EndsAt).Unix()),
+			Inline: true,
+		},
+		{
+			Name:   "New members",
+			Value:  raidActionDescription(guild),
+			Inline: true,
+		},
+	}
+
+	if len(raid.LockedChannels) > 0 {
+		channels := make([]string, 0, len(raid.LockedChannels))
+		for _, locked := range raid.LockedChannels {
+			channels = append(channels, fmt.Sprintf("<#%s>", locked.ChannelID))
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  "Locked channels",
+			Value: truncate(strings.Join(channels, " "), eventLogFieldLength),
+		})
+	}
+	return fields
+}
+
+// raidActionDescription describes the action taken against the members that join in raid mode.
+func raidActionDescription(guild *entities.Guild) string {
+	switch guild.Raid.Action {
+	case entities.RaidActionKick:
+		return "Kicked"
+	case entities.RaidActionQuarantine:
+		return fmt.Sprintf("Given <@&%s>", guild.Raid.QuarantineRoleID)
+	default:
+		return "No action"
+	}
+}
+
+// startRaidHandler is the handler for the start raid command.
+func startRaidHandler(c *commands.Context) error {
+	ctx := c.Context()
+	opts := c.Options().(*startRaidOptions)
+
+	guild, err := getGuildConfig(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+	if !guild.Raid.Enabled {
+		return commands.NewUserError("Anti-raid protection is not enabled for this server. Enable it with `/%s %s`.", setupCmdName, raidSetupCmdName)
+	}
+
+	l := c.Logger().With(slog.String(logging.KeyComponent, componentRaid))
+	if _, err := startRaid(ctx, l, c.Session(), guild, caseReason(opts.Reason), c.Member.User.ID, nil); err != nil {
+		return fmt.Errorf("error starting raid mode: %w", err)
+	}
+
+	msg := fmt.Sprintf("The server is in raid mode, and will leave it after %s.", custom.FormatDuration(guild.Raid.CoolDown))
+	if err := c.RespondEphemeral(msg); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// endRaidHandler is the handler for the end raid command.
+func endRaidHandler(c *commands.Context) error {
+	ctx := c.Context()
+
+	guild, err := getGuildConfig(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+
+	raid, err := getRaid(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+	if raid == nil || !raid.Active {
+		return commands.NewUserError("The server is not in raid mode.")
+	}
+
+	l := c.Logger().With(slog.String(logging.KeyComponent, componentRaid))
+	if err := endRaid(ctx, l, c.Session(), guild, raid, c.Member.User.ID); err != nil {
+		return fmt.Errorf("error ending raid mode: %w", err)
+	}
+
+	if err := c.RespondEphemeral("The server is no longer in raid mode."); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// raidStatusHandler is the handler for the raid status command.
+func raidStatusHandler(c *commands.Context) error {
+	ctx := c.Context()
+
+	guild, err := getGuildConfig(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+
+	raid, err := getRaid(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+	if raid == nil || !raid.Active {
+		msg := "The server is not in raid mode."
+		if !guild.Raid.Enabled {
+			msg += " Anti-raid protection is not enabled."
+		}
+		if err := c.RespondEphemeral(msg); err != nil {
+			return fmt.Errorf("error responding to interaction: %w", err)
+		}
+		return nil
+	}
+
+	err = c.Respond(&discordgo.InteractionResponseData{
+		Flags: discordgo.MessageFlagsEphemeral,
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       "Raid Mode",
+				Description: raid.Reason,
+				Color:       0xff0000,
+				Fields:      raidFields(guild, raid),
+				Timestamp:   time.Time(raid.StartedAt).Format(time.RFC3339),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// lockRaidChannelHandler is the handler for the lock raid channel command. The channel is locked from the next time
+// raid mode is entered.
+func lockRaidChannelHandler(c *commands.Context) error {
+	ctx := c.Context()
+	opts := c.Options().(*lockRaidChannelOptions)
+
+	// Save the guild.
+	update := toggleIDs(opts.Remove, map[string]any{"raid.lock_channel_ids": opts.Channel.ID})
+	if err := dataaccess.GuildDB.UpdateGuild(ctx, c.GuildID, update); err != nil {
+		return fmt.Errorf("error saving guild: %w", err)
+	}
+
+	msg := fmt.Sprintf("<#%s> will be locked in raid mode.", opts.Channel.ID)
+	if opts.Remove {
+		msg = fmt.Sprintf("<#%s> will no longer be locked in raid mode.", opts.Channel.ID)
+	}
+	if err := c.RespondEphemeral(msg); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// runEndRaid takes the guild out of raid mode when its cool-down has passed.
+func (a *App) runEndRaid(ctx context.Context, action *entities.ScheduledAction) error {
+	guild, err := getGuildConfig(ctx, action.GuildID)
+	if err != nil {
+		return err
+	}
+
+	raid, err := getRaid(ctx, action.GuildID)
+	if err != nil {
+		return err
+	}
+
+	// Raid mode was already left manually.
+	if raid == nil || !raid.Active {
+		return nil
+	}
+
+	a.raids.Reset(action.GuildID)
+
+	l := a.With(slog.String(logging.KeyComponent, componentRaid), slog.String("guild_id", action.GuildID))
+	return endRaid(ctx, l, a.Session(), guild, raid, "")
+}
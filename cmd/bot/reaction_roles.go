@@ -0,0 +1,615 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/commands"
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"github.com/Jacobbrewer1/wolf/pkg/reactionrole"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// ReactionRolesCmdName is the command for the reaction roles.
+	ReactionRolesCmdName = "reactionroles"
+
+	// AddReactionRoleCmdName is the sub command for adding a reaction role to a message.
+	AddReactionRoleCmdName = "add"
+
+	// RemoveReactionRoleCmdName is the sub command for removing the reaction roles from a message.
+	RemoveReactionRoleCmdName = "remove"
+
+	// ListReactionRolesCmdName is the sub command for listing the reaction roles.
+	ListReactionRolesCmdName = "list"
+)
+
+const (
+	// reactionRoleMessagesLimit is the most messages of a guild that can have reaction roles.
+	reactionRoleMessagesLimit = 100
+
+	// reactionRoleReconcileLimit is the most reactions of each emoji that are reconciled when a guild becomes
+	// available.
+	reactionRoleReconcileLimit = 1000
+
+	// reactionRolePageSize is the number of reactions fetched at a time when reconciling.
+	reactionRolePageSize = 100
+
+	// reactionRoleReason is the audit log reason of the roles changed by reactions.
+	reactionRoleReason = "Reaction role"
+)
+
+var (
+	// messageLinkPattern matches the IDs of the channel and the message in a message link.
+	messageLinkPattern = regexp.MustCompile(`channels/\d+/(\d+)/(\d+)`)
+
+	// messageIDPattern matches the ID of a message.
+	messageIDPattern = regexp.MustCompile(`^\d{17,20}$`)
+)
+
+var (
+	// reactionRolesCmd is the command for the reaction roles.
+	reactionRolesCmd = &commands.Command{
+		Name:        ReactionRolesCmdName,
+		Description: "This is the command for the roles given and taken by reacting to messages.",
+		Permissions: discordgo.PermissionManageRoles,
+		GuildOnly:   true,
+		Ephemeral:   true,
+		Subcommands: []*commands.Command{
+			{
+				Name:        AddReactionRoleCmdName,
+				Description: "This makes reacting to a message with an emoji give or take a role.",
+				Options:     new(addReactionRoleOptions),
+				Handler:     addReactionRoleHandler,
+			},
+			{
+				Name:        RemoveReactionRoleCmdName,
+				Description: "This removes a reaction role, or every reaction role, from a message.",
+				Options:     new(removeReactionRoleOptions),
+				Handler:     removeReactionRoleHandler,
+			},
+			{
+				Name:        ListReactionRolesCmdName,
+				Description: "This lists the reaction roles.",
+				Handler:     listReactionRolesHandler,
+			},
+		},
+	}
+)
+
+// addReactionRoleOptions are the options for the add reaction role command.
+type addReactionRoleOptions struct {
+	// Message is the link or ID of the message.
+	Message string `option:"message" description:"This is the link to the message, or its ID if it is in this channel." required:"true"`
+
+	// Emoji is the emoji of the reaction.
+	Emoji string `option:"emoji" description:"This is the emoji that members react with." required:"true"`
+
+	// Role is the role given or taken by the reaction.
+	Role *discordgo.Role `option:"role" description:"This is the role given or taken by the reaction." required:"true"`
+
+	// Mode is how the reactions to the message change the roles.
+	Mode string `option:"mode" description:"This is how the reactions to the message change the roles." choices:"normal,unique,verify,drop"`
+
+	// channelID is the ID of the channel parsed from Message. It is empty if only the ID of the message was given.
+	channelID string
+
+	// messageID is the ID of the message parsed from Message.
+	messageID string
+
+	// emoji is Emoji in the form used by the API.
+	emoji string
+}
+
+// Validate parses the message and the emoji.
+func (o *addReactionRoleOptions) Validate() error {
+	channelID, messageID, err := parseMessageReference(o.Message)
+	if err != nil {
+		return err
+	}
+	o.channelID = channelID
+	o.messageID = messageID
+
+	emoji, err := reactionrole.ParseEmoji(o.Emoji)
+	if err != nil {
+		return commands.NewUserError("`%s` is not an emoji.", o.Emoji)
+	}
+	o.emoji = emoji
+	return nil
+}
+
+// removeReactionRoleOptions are the options for the remove reaction role command.
+type removeReactionRoleOptions struct {
+	// Message is the link or ID of the message.
+	Message string `option:"message" description:"This is the link to the message, or its ID if it is in this channel." required:"true"`
+
+	// Emoji is the emoji of the reaction role to remove. If empty, every reaction role of the message is removed.
+	Emoji string `option:"emoji" description:"This is the emoji of the reaction role to remove, or every one if not given."`
+
+	// messageID is the ID of the message parsed from Message.
+	messageID string
+
+	// emoji is Emoji in the form used by the API.
+	emoji string
+}
+
+// Validate parses the message and the emoji.
+func (o *removeReactionRoleOptions) Validate() error {
+	_, messageID, err := parseMessageReference(o.Message)
+	if err != nil {
+		return err
+	}
+	o.messageID = messageID
+
+	if o.Emoji != "" {
+		emoji, err := reactionrole.ParseEmoji(o.Emoji)
+		if err != nil {
+			return commands.NewUserError("`%s` is not an emoji.", o.Emoji)
+		}
+		o.emoji = emoji
+	}
+	return nil
+}
+
+// parseMessageReference returns the IDs of the channel and the message from a message link, or the ID of the message.
+// The channel is empty if only the ID of the message was given.
+func parseMessageReference(s string) (string, string, error) {
+	s = strings.TrimSpace(s)
+	if match := messageLinkPattern.FindStringSubmatch(s); match != nil {
+		return match[1], match[2], nil
+	}
+
+	if messageIDPattern.MatchString(s) {
+		return "", s, nil
+	}
+	return "", "", commands.NewUserError("`%s` is not a message link or ID.", s)
+}
+
+// messageLink returns the link to the message.
+func messageLink(guildID, channelID, messageID string) string {
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, channelID, messageID)
+}
+
+// emojiMention returns the emoji, in the form used by the API, as it is written in a message.
+func emojiMention(emoji string) string {
+	if strings.Contains(emoji, ":") {
+		return "<:" + emoji + ">"
+	}
+	return emoji
+}
+
+// addReactionRoleHandler is the handler for the add reaction role command. The bot reacts to the message with the
+// emoji, so members only have to press the reaction.
+func addReactionRoleHandler(c *commands.Context) error {
+	ctx := c.Context()
+	opts := c.Options().(*addReactionRoleOptions)
+
+	if _, err := assignableRoles(ctx, c, []string{opts.Role.ID}); err != nil {
+		return err
+	}
+
+	channelID := opts.channelID
+	if channelID == "" {
+		channelID = c.ChannelID
+	}
+
+	channel, err := c.Session().Channel(channelID, discordgo.WithContext(ctx))
+	if isNotFound(err) || (err == nil && channel.GuildID != c.GuildID) {
+		return commands.NewUserError("I cannot find that message in this server.")
+	} else if err != nil {
+		return fmt.Errorf("error getting channel: %w", err)
+	}
+
+	if _, err := c.Session().ChannelMessage(channelID, opts.messageID, discordgo.WithContext(ctx)); isNotFound(err) {
+		return commands.NewUserError("I cannot find that message in this server.")
+	} else if err != nil {
+		return fmt.Errorf("error getting message: %w", err)
+	}
+
+	guild, err := getGuildConfig(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+
+	msg := guild.ReactionRoles.Message(opts.messageID)
+	if msg == nil {
+		if len(guild.ReactionRoles.Messages) >= reactionRoleMessagesLimit {
+			return commands.NewUserError("A server can have reaction roles on at most %d messages.", reactionRoleMessagesLimit)
+		}
+
+		msg = &entities.ReactionRoleMessage{
+			ChannelID: channelID,
+			MessageID: opts.messageID,
+			Mode:      entities.ReactionRoleModeNormal,
+		}
+		guild.ReactionRoles.Messages = append(guild.ReactionRoles.Messages, msg)
+	}
+	if opts.Mode != "" {
+		msg.Mode = entities.ReactionRoleMode(opts.Mode)
+	}
+
+	if role := reactionrole.Find(msg, opts.emoji); role != nil {
+		role.RoleID = opts.Role.ID
+	} else {
+		if len(msg.Roles) >= reactionrole.MaxRolesPerMessage {
+			return commands.NewUserError("A message can have at most %d reaction roles.", reactionrole.MaxRolesPerMessage)
+		}
+		msg.Roles = append(msg.Roles, &entities.ReactionRole{
+			Emoji:  opts.emoji,
+			RoleID: opts.Role.ID,
+		})
+	}
+
+	if err := c.Session().MessageReactionAdd(channelID, opts.messageID, opts.emoji, discordgo.WithContext(ctx)); err != nil {
+		restErr := new(discordgo.RESTError)
+		if errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == http.StatusBadRequest {
+			return commands.NewUserError("I cannot react with %s. Custom emojis must be from a server I am in.", opts.Emoji)
+		}
+		return fmt.Errorf("error adding reaction: %w", err)
+	}
+
+	if err := dataaccess.GuildDB.SaveGuild(ctx, guild); err != nil {
+		return fmt.Errorf("error saving guild: %w", err)
+	}
+
+	err = c.RespondEphemeral(fmt.Sprintf("Reacting with %s to [this message](%s) now changes <@&%s> in %s mode.",
+		emojiMention(opts.emoji),
+		messageLink(c.GuildID, channelID, opts.messageID),
+		opts.Role.ID,
+		msg.Mode,
+	))
+	if err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// removeReactionRoleHandler is the handler for the remove reaction role command. The reactions of the bot are removed
+// too, any reactions by the members are left.
+func removeReactionRoleHandler(c *commands.Context) error {
+	ctx := c.Context()
+	opts := c.Options().(*removeReactionRoleOptions)
+
+	guild, err := getGuildConfig(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+
+	msg := guild.ReactionRoles.Message(opts.messageID)
+	if msg == nil {
+		return commands.NewUserError("That message has no reaction roles.")
+	}
+
+	removed := msg.Roles
+	if opts.emoji != "" {
+		role := reactionrole.Find(msg, opts.emoji)
+		if role == nil {
+			return commands.NewUserError("That message has no reaction role for %s.", opts.Emoji)
+		}
+
+		removed = []*entities.ReactionRole{role}
+		kept := make([]*entities.ReactionRole, 0, len(msg.Roles)-1)
+		for _, r := range msg.Roles {
+			if r != role {
+				kept = append(kept, r)
+			}
+		}
+		msg.Roles = kept
+	}
+
+	if opts.emoji == "" || len(msg.Roles) == 0 {
+		guild.ReactionRoles.RemoveMessage(msg.MessageID)
+	}
+
+	if err := dataaccess.GuildDB.SaveGuild(ctx, guild); err != nil {
+		return fmt.Errorf("error saving guild: %w", err)
+	}
+
+	for _, role := range removed {
+		err := c.Session().MessageReactionRemove(msg.ChannelID, msg.MessageID, role.Emoji, "@me", discordgo.WithContext(ctx))
+		if err != nil && !isNotFound(err) {
+			c.Logger().Error("Error removing reaction", slog.String(logging.KeyError, err.Error()))
+		}
+	}
+
+	if err := c.RespondEphemeral(fmt.Sprintf("Removed %d reaction roles from [this message](%s).",
+		len(removed), messageLink(c.GuildID, msg.ChannelID, msg.MessageID))); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// listReactionRolesHandler is the handler for the list reaction roles command.
+func listReactionRolesHandler(c *commands.Context) error {
+	guild, err := getGuildConfig(c.Context(), c.GuildID)
+	if err != nil {
+		return err
+	}
+
+	if len(guild.ReactionRoles.Messages) == 0 {
+		if err := c.RespondEphemeral("There are no reaction roles."); err != nil {
+			return fmt.Errorf("error responding to interaction: %w", err)
+		}
+		return nil
+	}
+
+	lines := make([]string, 0, len(guild.ReactionRoles.Messages))
+	for _, msg := range guild.ReactionRoles.Messages {
+		roles := make([]string, 0, len(msg.Roles))
+		for _, role := range msg.Roles {
+			roles = append(roles, fmt.Sprintf("%s <@&%s>", emojiMention(role.Emoji), role.RoleID))
+		}
+
+		lines = append(lines, fmt.Sprintf("[Message](%s) in <#%s> (%s): %s",
+			messageLink(c.GuildID, msg.ChannelID, msg.MessageID),
+			msg.ChannelID,
+			msg.Mode,
+			strings.Join(roles, ", "),
+		))
+	}
+
+	err = c.Respond(&discordgo.InteractionResponseData{
+		Flags: discordgo.MessageFlagsEphemeral,
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       "Reaction roles",
+				Description: truncate(strings.Join(lines, "\n"), eventLogDescriptionLength),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// reactionRoleAddHandler changes the roles of the members that react to the reaction role messages.
+func (a *App) reactionRoleAddHandler() func(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	return func(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+		if r.MessageReaction == nil || r.GuildID == "" || r.UserID == s.State.User.ID {
+			return
+		}
+		if r.Member == nil || (r.Member.User != nil && r.Member.User.Bot) {
+			return
+		}
+
+		l := a.With(
+			slog.String(logging.KeyComponent, componentReactionRoles),
+			slog.String("guild_id", r.GuildID),
+		)
+
+		guild, err := getGuildConfig(a.ctx, r.GuildID)
+		if err != nil {
+			l.Error("Error getting reaction role configuration", slog.String(logging.KeyError, err.Error()))
+			return
+		}
+
+		msg := guild.ReactionRoles.Message(r.MessageID)
+		if msg == nil {
+			return
+		}
+
+		change := reactionrole.React(msg, r.Emoji.APIName(), r.Member.Roles)
+		if change == nil {
+			return
+		}
+		applyReactionRoleChange(a.ctx, l, s, r.GuildID, msg, r.UserID, change)
+	}
+}
+
+// reactionRoleRemoveHandler changes the roles of the members that remove their reactions to the reaction role
+// messages.
+func (a *App) reactionRoleRemoveHandler() func(s *discordgo.Session, r *discordgo.MessageReactionRemove) {
+	return func(s *discordgo.Session, r *discordgo.MessageReactionRemove) {
+		if r.MessageReaction == nil || r.GuildID == "" || r.UserID == s.State.User.ID {
+			return
+		}
+
+		l := a.With(
+			slog.String(logging.KeyComponent, componentReactionRoles),
+			slog.String("guild_id", r.GuildID),
+		)
+
+		guild, err := getGuildConfig(a.ctx, r.GuildID)
+		if err != nil {
+			l.Error("Error getting reaction role configuration", slog.String(logging.KeyError, err.Error()))
+			return
+		}
+
+		msg := guild.ReactionRoles.Message(r.MessageID)
+		if msg == nil {
+			return
+		}
+
+		change := reactionrole.Unreact(msg, r.Emoji.APIName())
+		if change == nil {
+			return
+		}
+		applyReactionRoleChange(a.ctx, l, s, r.GuildID, msg, r.UserID, change)
+	}
+}
+
+// reactionRoleMessageDeleteHandler removes the reaction roles of the messages that are deleted.
+func (a *App) reactionRoleMessageDeleteHandler() func(s *discordgo.Session, m *discordgo.MessageDelete) {
+	return func(s *discordgo.Session, m *discordgo.MessageDelete) {
+		if m.Message == nil || m.GuildID == "" {
+			return
+		}
+		a.removeReactionRoleMessages(m.GuildID, m.ID)
+	}
+}
+
+// reactionRoleMessageDeleteBulkHandler removes the reaction roles of the messages that are deleted in bulk.
+func (a *App) reactionRoleMessageDeleteBulkHandler() func(s *discordgo.Session, m *discordgo.MessageDeleteBulk) {
+	return func(s *discordgo.Session, m *discordgo.MessageDeleteBulk) {
+		if m.GuildID == "" {
+			return
+		}
+		a.removeReactionRoleMessages(m.GuildID, m.Messages...)
+	}
+}
+
+// removeReactionRoleMessages removes the reaction roles of the messages of the guild. The cached configuration is
+// checked first, so deleting a message without reaction roles does not write to the database.
+func (a *App) removeReactionRoleMessages(guildID string, messageIDs ...string) {
+	l := a.With(
+		slog.String(logging.KeyComponent, componentReactionRoles),
+		slog.String("guild_id", guildID),
+	)
+
+	guild, err := getGuildConfig(a.ctx, guildID)
+	if err != nil {
+		l.Error("Error getting reaction role configuration", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	found := false
+	for _, id := range messageIDs {
+		found = found || guild.ReactionRoles.Message(id) != nil
+	}
+	if !found {
+		return
+	}
+
+	// The messages are pulled, so the changes made to the configuration since it was cached are kept.
+	err = dataaccess.GuildDB.UpdateGuild(a.ctx, guildID, &dataaccess.GuildUpdate{
+		Pull: map[string]any{
+			"reaction_roles.messages": bson.M{"message_id": bson.M{"$in": messageIDs}},
+		},
+	})
+	if err != nil {
+		l.Error("Error saving reaction role configuration", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+	l.Info("Removed reaction roles of deleted messages", slog.Any("message_ids", messageIDs))
+}
+
+// reactionRoleReconcileHandler reconciles the reaction roles of the guilds as they become available, which includes
+// every guild of the shard when it connects. The reactions added while the bot was offline are applied.
+func (a *App) reactionRoleReconcileHandler() func(s *discordgo.Session, g *discordgo.GuildCreate) {
+	return func(s *discordgo.Session, g *discordgo.GuildCreate) {
+		if g.Guild == nil || g.Unavailable {
+			return
+		}
+
+		l := a.With(
+			slog.String(logging.KeyComponent, componentReactionRoles),
+			slog.String("guild_id", g.ID),
+		)
+
+		guild, err := getGuildConfig(a.ctx, g.ID)
+		if err != nil {
+			l.Error("Error getting reaction role configuration", slog.String(logging.KeyError, err.Error()))
+			return
+		}
+
+		var deleted []string
+		for _, msg := range guild.ReactionRoles.Messages {
+			exists, err := reconcileReactionRoles(a.ctx, l, s, g.ID, msg)
+			if err != nil {
+				l.Error("Error reconciling reaction roles",
+					slog.String("message_id", msg.MessageID),
+					slog.String(logging.KeyError, err.Error()),
+				)
+				continue
+			}
+			if !exists {
+				deleted = append(deleted, msg.MessageID)
+			}
+		}
+
+		// The messages deleted while the bot was offline are cleaned up.
+		if len(deleted) > 0 {
+			a.removeReactionRoleMessages(g.ID, deleted...)
+		}
+	}
+}
+
+// reconcileReactionRoles applies the reactions to the message that are missing from the roles of the members. It
+// returns false if the message no longer exists.
+func reconcileReactionRoles(ctx context.Context, l *slog.Logger, s *discordgo.Session, guildID string, msg *entities.ReactionRoleMessage) (bool, error) {
+	for _, role := range msg.Roles {
+		after := ""
+		for fetched := 0; fetched < reactionRoleReconcileLimit; {
+			users, err := s.MessageReactions(msg.ChannelID, msg.MessageID, role.Emoji, reactionRolePageSize, "", after, discordgo.WithContext(ctx))
+			if isNotFound(err) {
+				// The emoji may have been deleted, rather than the message.
+				if _, err := s.ChannelMessage(msg.ChannelID, msg.MessageID, discordgo.WithContext(ctx)); isNotFound(err) {
+					return false, nil
+				}
+				break
+			} else if err != nil {
+				return true, fmt.Errorf("error getting reactions: %w", err)
+			}
+
+			for _, user := range users {
+				if user.Bot {
+					continue
+				}
+
+				member, err := s.State.Member(guildID, user.ID)
+				if err != nil {
+					member, err = s.GuildMember(guildID, user.ID, discordgo.WithContext(ctx))
+					if isNotFound(err) {
+						// The member has left the guild.
+						continue
+					} else if err != nil {
+						return true, fmt.Errorf("error getting member: %w", err)
+					}
+				}
+
+				if change := reactionrole.Reconcile(msg, role.Emoji, member.Roles); change != nil {
+					applyReactionRoleChange(ctx, l, s, guildID, msg, user.ID, change)
+				}
+			}
+
+			fetched += len(users)
+			if len(users) < reactionRolePageSize {
+				break
+			}
+			after = users[len(users)-1].ID
+		}
+	}
+	return true, nil
+}
+
+// applyReactionRoleChange gives and takes the roles of the member, and removes the reactions of the member that no
+// longer match their roles. Any failure is logged, so the rest of the change is still applied.
+func applyReactionRoleChange(ctx context.Context, l *slog.Logger, s *discordgo.Session, guildID string, msg *entities.ReactionRoleMessage, userID string, change *reactionrole.Change) {
+	l = l.With(slog.String("message_id", msg.MessageID), slog.String("user_id", userID))
+	reason := discordgo.WithAuditLogReason(reactionRoleReason)
+
+	for _, roleID := range change.Remove {
+		if err := s.GuildMemberRoleRemove(guildID, userID, roleID, discordgo.WithContext(ctx), reason); err != nil {
+			l.Error("Error removing reaction role", slog.String("role_id", roleID), slog.String(logging.KeyError, err.Error()))
+			continue
+		}
+		ReactionRoleChanges.WithLabelValues("remove").Inc()
+	}
+
+	for _, roleID := range change.Add {
+		if err := s.GuildMemberRoleAdd(guildID, userID, roleID, discordgo.WithContext(ctx), reason); err != nil {
+			l.Error("Error adding reaction role", slog.String("role_id", roleID), slog.String(logging.KeyError, err.Error()))
+			continue
+		}
+		ReactionRoleChanges.WithLabelValues("add").Inc()
+	}
+
+	for _, emoji := range change.RemoveReactions {
+		err := s.MessageReactionRemove(msg.ChannelID, msg.MessageID, emoji, userID, discordgo.WithContext(ctx))
+		if err != nil && !isNotFound(err) {
+			l.Error("Error removing reaction", slog.String(logging.KeyError, err.Error()))
+		}
+	}
+}
@@ -0,0 +1,546 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/commands"
+	"github.com/Jacobbrewer1/wolf/pkg/custom"
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"github.com/Jacobbrewer1/wolf/pkg/rolepanel"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// RolesCmdName is the command for the self-assignable roles.
+	RolesCmdName = "roles"
+
+	// RolePanelCmdName is the sub command group for the role panels.
+	RolePanelCmdName = "panel"
+
+	// CreateRolePanelCmdName is the sub command for creating a role panel.
+	CreateRolePanelCmdName = "create"
+
+	// DeleteRolePanelCmdName is the sub command for deleting a role panel.
+	DeleteRolePanelCmdName = "delete"
+
+	// ListRolePanelsCmdName is the sub command for listing the role panels.
+	ListRolePanelsCmdName = "list"
+)
+
+const (
+	// RolePanelButtonID is the prefix of the custom IDs of the role panel buttons. The number of the panel and the ID of
+	// the role are encoded after it.
+	RolePanelButtonID = "role_panel_button"
+
+	// RolePanelSelectID is the prefix of the custom IDs of the role panel select menus. The number of the panel is
+	// encoded after it.
+	RolePanelSelectID = "role_panel_select"
+)
+
+// rolePanelDefaultTitle is the title of a panel that was not given one.
+const rolePanelDefaultTitle = "Choose your roles"
+
+// roleIDPattern matches the IDs of the roles in a list of role mentions or IDs.
+var roleIDPattern = regexp.MustCompile(`\d{17,20}`)
+
+var (
+	// rolesCmd is the command for the self-assignable roles.
+	rolesCmd = &commands.Command{
+		Name:        RolesCmdName,
+		Description: "This is the command for the self-assignable roles.",
+		Permissions: discordgo.PermissionManageRoles,
+		GuildOnly:   true,
+		Ephemeral:   true,
+		Subcommands: []*commands.Command{
+			{
+				Name:        RolePanelCmdName,
+				Description: "This is the command for the panels that members choose their roles from.",
+				Subcommands: []*commands.Command{
+					{
+						Name:        CreateRolePanelCmdName,
+						Description: "This posts a panel that members choose their roles from.",
+						Options:     new(createRolePanelOptions),
+						Handler:     createRolePanelHandler,
+					},
+					{
+						Name:        DeleteRolePanelCmdName,
+						Description: "This deletes a role panel.",
+						Options:     new(rolePanelOptions),
+						Handler:     deleteRolePanelHandler,
+					},
+					{
+						Name:        ListRolePanelsCmdName,
+						Description: "This lists the role panels.",
+						Handler:     listRolePanelsHandler,
+					},
+				},
+			},
+		},
+	}
+)
+
+// createRolePanelOptions are the options for the create role panel command.
+type createRolePanelOptions struct {
+	// Channel is the channel the panel is posted in.
+	Channel *discordgo.Channel `option:"channel" description:"This is the channel the panel is posted in." required:"true" channel_types:"text,news"`
+
+	// Roles are the mentions or IDs of the roles of the panel.
+	Roles string `option:"roles" description:"These are the roles that can be chosen, as mentions or IDs." required:"true"`
+
+	// Style is whether the roles are chosen with buttons or a select menu.
+	Style string `option:"style" description:"This is whether the roles are chosen with buttons or a select menu." choices:"buttons,select"`
+
+	// Title is the title of the panel.
+	Title string `option:"title" description:"This is the title of the panel."`
+
+	// Description is the description of the panel.
+	Description string `option:"description" description:"This is the description of the panel."`
+
+	// SingleChoice is whether a member can only have one of the roles.
+	SingleChoice bool `option:"single_choice" description:"This is whether a member can only have one of the roles."`
+
+	// MaxSelections is the most of the roles a member can have.
+	MaxSelections int `option:"max_selections" description:"This is the most of the roles a member can have." min:"1" max:"25"`
+
+	// RequiredRole is the role a member must have to choose the roles.
+	RequiredRole *discordgo.Role `option:"required_role" description:"This is the role a member must have to choose the roles."`
+
+	// Quiet is whether the members are not sent a confirmation of the roles that were changed.
+	Quiet bool `option:"quiet" description:"This is whether the members are not told which roles were changed."`
+
+	// roleIDs are the IDs parsed from Roles.
+	roleIDs []string
+}
+
+// Validate parses the roles of the panel.
+func (o *createRolePanelOptions) Validate() error {
+	for _, id := range roleIDPattern.FindAllString(o.Roles, -1) {
+		if !slices.Contains(o.roleIDs, id) {
+			o.roleIDs = append(o.roleIDs, id)
+		}
+	}
+
+	switch {
+	case len(o.roleIDs) == 0:
+		return commands.NewUserError("Mention at least one role for the panel.")
+	case len(o.roleIDs) > rolepanel.MaxRoles:
+		return commands.NewUserError("A panel can have at most %d roles.", rolepanel.MaxRoles)
+	case o.SingleChoice && o.MaxSelections > 1:
+		return commands.NewUserError("A single choice panel cannot have more than one selection.")
+	}
+	return nil
+}
+
+// rolePanelOptions are the options of the role panel commands that only take a panel.
+type rolePanelOptions struct {
+	// Number is the number of the panel.
+	Number int `option:"number" description:"This is the number of the panel." required:"true" min:"1"`
+}
+
+// getRolePanel gets the role panel of the guild, returning a user error if it does not exist.
+func getRolePanel(ctx context.Context, guildID string, number int) (*entities.RolePanel, error) {
+	panel, err := dataaccess.RolePanelDB.GetRolePanel(ctx, guildID, number)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, commands.NewUserError("Role panel #%d does not exist.", number)
+	} else if err != nil {
+		return nil, fmt.Errorf("error getting role panel: %w", err)
+	}
+	return panel, nil
+}
+
+// createRolePanelHandler is the handler for the create role panel command. The panel is stored before it is posted,
+// so its number can be encoded in the custom IDs of the components.
+func createRolePanelHandler(c *commands.Context) error {
+	ctx := c.Context()
+	opts := c.Options().(*createRolePanelOptions)
+
+	roles, err := panelRoles(ctx, c, opts.roleIDs)
+	if err != nil {
+		return err
+	}
+
+	panel := &entities.RolePanel{
+		GuildID:       c.GuildID,
+		ChannelID:     opts.Channel.ID,
+		Title:         opts.Title,
+		Description:   opts.Description,
+		Style:         entities.RolePanelStyleButtons,
+		Roles:         roles,
+		SingleChoice:  opts.SingleChoice,
+		MaxSelections: opts.MaxSelections,
+		Quiet:         opts.Quiet,
+		CreatedBy:     c.Member.User.ID,
+		CreatedAt:     custom.Datetime(time.Now().UTC()),
+	}
+	if panel.Title == "" {
+		panel.Title = rolePanelDefaultTitle
+	}
+	if opts.Style != "" {
+		panel.Style = entities.RolePanelStyle(opts.Style)
+	}
+	if opts.RequiredRole != nil {
+		panel.RequiredRoleIDs = []string{opts.RequiredRole.ID}
+	}
+
+	if err := dataaccess.RolePanelDB.CreateRolePanel(ctx, panel); err != nil {
+		return fmt.Errorf("error creating role panel: %w", err)
+	}
+
+	msg, err := c.Session().ChannelMessageSendComplex(panel.ChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{rolePanelEmbed(panel)},
+		Components: rolePanelComponents(panel),
+	}, discordgo.WithContext(ctx))
+	if err != nil {
+		// The panel was never posted, so it is not kept.
+		if delErr := dataaccess.RolePanelDB.DeleteRolePanel(ctx, panel.GuildID, panel.ID); delErr != nil {
+			c.Logger().Error("Error deleting unposted role panel", slog.String(logging.KeyError, delErr.Error()))
+		}
+		return fmt.Errorf("error sending role panel: %w", err)
+	}
+
+	panel.MessageID = msg.ID
+	if err := dataaccess.RolePanelDB.SaveRolePanel(ctx, panel); err != nil {
+		return fmt.Errorf("error saving role panel: %w", err)
+	}
+
+	if err := c.RespondEphemeral(fmt.Sprintf("Role panel #%d has been posted in <#%s>.", panel.ID, panel.ChannelID)); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// panelRoles returns the roles of a new panel.
+func panelRoles(ctx context.Context, c *commands.Context, roleIDs []string) ([]*entities.RolePanelRole, error) {
+	guildRoles, err := assignableRoles(ctx, c, roleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]*entities.RolePanelRole, 0, len(guildRoles))
+	for _, role := range guildRoles {
+		roles = append(roles, &entities.RolePanelRole{
+			RoleID: role.ID,
+			Label:  truncate(role.Name, 80),
+		})
+	}
+	return roles, nil
+}
+
+// assignableRoles returns the roles of the guild with the IDs, in the same order. Every role must be a role of the
+// guild that can be given by both the member, unless they own the guild, and the bot.
+func assignableRoles(ctx context.Context, c *commands.Context, roleIDs []string) ([]*discordgo.Role, error) {
+	guild, err := c.Session().Guild(c.GuildID, discordgo.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting guild: %w", err)
+	}
+
+	bot, err := c.Session().GuildMember(c.GuildID, c.Session().State.User.ID, discordgo.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error getting bot member: %w", err)
+	}
+
+	memberPosition := highestRolePosition(guild.Roles, c.Member.Roles)
+	botPosition := highestRolePosition(guild.Roles, bot.Roles)
+
+	roles := make([]*discordgo.Role, 0, len(roleIDs))
+	for _, id := range roleIDs {
+		var role *discordgo.Role
+		for _, r := range guild.Roles {
+			if r.ID == id {
+				role = r
+				break
+			}
+		}
+
+		switch {
+		case role == nil || role.ID == guild.ID:
+			return nil, commands.NewUserError("`%s` is not a role that can be given.", id)
+		case role.Managed:
+			return nil, commands.NewUserError("<@&%s> is managed by an integration, so it cannot be given.", id)
+		case c.Member.User.ID != guild.OwnerID && role.Position >= memberPosition:
+			return nil, commands.NewUserError("You cannot give <@&%s>, as it is not below your highest role.", id)
+		case role.Position >= botPosition:
+			return nil, commands.NewUserError("I cannot give <@&%s>, as it is not below my highest role.", id)
+		}
+
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+// rolePanelEmbed returns the embed of the panel, which describes the limits of the panel.
+func rolePanelEmbed(panel *entities.RolePanel) *discordgo.MessageEmbed {
+	var rules []string
+	switch limit := rolepanel.Limit(panel); {
+	case panel.SingleChoice:
+		rules = append(rules, "You can have one of these roles.")
+	case limit < len(panel.Roles):
+		rules = append(rules, fmt.Sprintf("You can have up to %d of these roles.", limit))
+	}
+	if len(panel.RequiredRoleIDs) > 0 {
+		rules = append(rules, fmt.Sprintf("You need %s to choose these roles.", roleMentions(panel.RequiredRoleIDs)))
+	}
+	if panel.Style == entities.RolePanelStyleSelect {
+		rules = append(rules, "The roles you choose replace your roles from this panel.")
+	} else {
+		rules = append(rules, "Press a button to add or remove its role.")
+	}
+
+	description := strings.Join(rules, "\n")
+	if panel.Description != "" {
+		description = panel.Description + "\n\n" + description
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       panel.Title,
+		Description: description,
+		Color:       0x0099ff,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Role panel #%d", panel.ID),
+		},
+	}
+}
+
+// rolePanelComponents returns the buttons or the select menu of the panel.
+func rolePanelComponents(panel *entities.RolePanel) []discordgo.MessageComponent {
+	id := strconv.Itoa(panel.ID)
+
+	if panel.Style == entities.RolePanelStyleSelect {
+		minValues := 0
+		options := make([]discordgo.SelectMenuOption, 0, len(panel.Roles))
+		for _, role := range panel.Roles {
+			options = append(options, discordgo.SelectMenuOption{
+				Label: role.Label,
+				Value: role.RoleID,
+			})
+		}
+
+		return []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.SelectMenu{
+						CustomID:    commands.CustomID(RolePanelSelectID, id),
+						Placeholder: "Choose your roles",
+						MinValues:   &minValues,
+						MaxValues:   rolepanel.Limit(panel),
+						Options:     options,
+					},
+				},
+			},
+		}
+	}
+
+	rows := make([]discordgo.MessageComponent, 0, (len(panel.Roles)+rolepanel.ButtonsPerRow-1)/rolepanel.ButtonsPerRow)
+	for start := 0; start < len(panel.Roles); start += rolepanel.ButtonsPerRow {
+		end := min(start+rolepanel.ButtonsPerRow, len(panel.Roles))
+
+		buttons := make([]discordgo.MessageComponent, 0, end-start)
+		for _, role := range panel.Roles[start:end] {
+			buttons = append(buttons, discordgo.Button{
+				Label:    role.Label,
+				Style:    discordgo.SecondaryButton,
+				CustomID: commands.CustomID(RolePanelButtonID, id, role.RoleID),
+			})
+		}
+		rows = append(rows, discordgo.ActionsRow{Components: buttons})
+	}
+	return rows
+}
+
+// deleteRolePanelHandler is the handler for the delete role panel command. The message of the panel is deleted too.
+func deleteRolePanelHandler(c *commands.Context) error {
+	ctx := c.Context()
+	opts := c.Options().(*rolePanelOptions)
+
+	panel, err := getRolePanel(ctx, c.GuildID, opts.Number)
+	if err != nil {
+		return err
+	}
+
+	// The message may already have been deleted by hand.
+	if err := c.Session().ChannelMessageDelete(panel.ChannelID, panel.MessageID, discordgo.WithContext(ctx)); err != nil && !isNotFound(err) {
+		return fmt.Errorf("error deleting role panel message: %w", err)
+	}
+
+	if err := dataaccess.RolePanelDB.DeleteRolePanel(ctx, panel.GuildID, panel.ID); err != nil {
+		return fmt.Errorf("error deleting role panel: %w", err)
+	}
+
+	if err := c.RespondEphemeral(fmt.Sprintf("Role panel #%d has been deleted.", panel.ID)); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// listRolePanelsHandler is the handler for the list role panels command.
+func listRolePanelsHandler(c *commands.Context) error {
+	panels, err := dataaccess.RolePanelDB.ListRolePanels(c.Context(), c.GuildID)
+	if err != nil {
+		return fmt.Errorf("error listing role panels: %w", err)
+	}
+
+	if len(panels) == 0 {
+		if err := c.RespondEphemeral("There are no role panels."); err != nil {
+			return fmt.Errorf("error responding to interaction: %w", err)
+		}
+		return nil
+	}
+
+	lines := make([]string, 0, len(panels))
+	for _, panel := range panels {
+		lines = append(lines, fmt.Sprintf("**#%d** [%s](https://discord.com/channels/%s/%s/%s) in <#%s>: %d roles, %s",
+			panel.ID,
+			truncate(panel.Title, 50),
+			panel.GuildID,
+			panel.ChannelID,
+			panel.MessageID,
+			panel.ChannelID,
+			len(panel.Roles),
+			panel.Style,
+		))
+	}
+
+	err = c.Respond(&discordgo.InteractionResponseData{
+		Flags: discordgo.MessageFlagsEphemeral,
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       "Role panels",
+				Description: truncate(strings.Join(lines, "\n"), eventLogDescriptionLength),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// rolePanelButtonHandler toggles the role of the role panel button that was pressed.
+func rolePanelButtonHandler(c *commands.Context) error {
+	args := c.Args()
+	if len(args) != 2 {
+		return fmt.Errorf("invalid role panel button %s", c.MessageComponentData().CustomID)
+	}
+
+	panel, err := rolePanelForComponent(c, args[0])
+	if err != nil {
+		return err
+	}
+
+	change, err := rolepanel.Toggle(panel, c.Member.Roles, args[1])
+	if err != nil {
+		return rolePanelError(panel, err)
+	}
+
+	return applyRolePanelChange(c, panel, change)
+}
+
+// rolePanelSelectHandler gives the member the roles chosen from the role panel select menu, and takes the other roles
+// of the panel.
+func rolePanelSelectHandler(c *commands.Context) error {
+	args := c.Args()
+	if len(args) != 1 {
+		return fmt.Errorf("invalid role panel select menu %s", c.MessageComponentData().CustomID)
+	}
+
+	panel, err := rolePanelForComponent(c, args[0])
+	if err != nil {
+		return err
+	}
+
+	change, err := rolepanel.Select(panel, c.Member.Roles, c.Values())
+	if err != nil {
+		return rolePanelError(panel, err)
+	}
+
+	return applyRolePanelChange(c, panel, change)
+}
+
+// rolePanelForComponent gets the role panel whose number is encoded in the custom ID of the component.
+func rolePanelForComponent(c *commands.Context, number string) (*entities.RolePanel, error) {
+	id, err := strconv.Atoi(number)
+	if err != nil {
+		return nil, fmt.Errorf("invalid role panel number %q: %w", number, err)
+	}
+
+	panel, err := dataaccess.RolePanelDB.GetRolePanel(c.Context(), c.GuildID, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, commands.NewUserError("This role panel has been deleted.")
+	} else if err != nil {
+		return nil, fmt.Errorf("error getting role panel: %w", err)
+	}
+	return panel, nil
+}
+
+// rolePanelError returns the user error for the change being rejected.
+func rolePanelError(panel *entities.RolePanel, err error) error {
+	switch {
+	case errors.Is(err, rolepanel.ErrMissingRequiredRoles):
+		return commands.NewUserError("You need %s to choose roles from this panel.", roleMentions(panel.RequiredRoleIDs))
+	case errors.Is(err, rolepanel.ErrTooManyRoles):
+		return commands.NewUserError("You can only have %d of the roles from this panel.", rolepanel.Limit(panel))
+	case errors.Is(err, rolepanel.ErrUnknownRole):
+		return commands.NewUserError("That role is no longer on this panel.")
+	default:
+		return err
+	}
+}
+
+// applyRolePanelChange gives and takes the roles of the member, and confirms the change to the member unless the panel
+// is quiet.
+func applyRolePanelChange(c *commands.Context, panel *entities.RolePanel, change *rolepanel.Change) error {
+	ctx := c.Context()
+	reason := discordgo.WithAuditLogReason(fmt.Sprintf("Role panel #%d", panel.ID))
+
+	for _, roleID := range change.Remove {
+		if err := c.Session().GuildMemberRoleRemove(c.GuildID, c.Member.User.ID, roleID, discordgo.WithContext(ctx), reason); err != nil {
+			return fmt.Errorf("error removing role: %w", err)
+		}
+		RolePanelRoleChanges.WithLabelValues("remove").Inc()
+	}
+
+	for _, roleID := range change.Add {
+		if err := c.Session().GuildMemberRoleAdd(c.GuildID, c.Member.User.ID, roleID, discordgo.WithContext(ctx), reason); err != nil {
+			return fmt.Errorf("error adding role: %w", err)
+		}
+		RolePanelRoleChanges.WithLabelValues("add").Inc()
+	}
+
+	if panel.Quiet {
+		if err := c.Acknowledge(); err != nil {
+			return fmt.Errorf("error acknowledging interaction: %w", err)
+		}
+		return nil
+	}
+
+	var lines []string
+	if len(change.Add) > 0 {
+		lines = append(lines, "Added "+roleMentions(change.Add)+".")
+	}
+	if len(change.Remove) > 0 {
+		lines = append(lines, "Removed "+roleMentions(change.Remove)+".")
+	}
+	if change.Empty() {
+		lines = append(lines, "Your roles have not changed.")
+	}
+
+	if err := c.RespondEphemeral(strings.Join(lines, "\n")); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,458 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/commands"
+	"github.com/Jacobbrewer1/wolf/pkg/custom"
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"github.com/Jacobbrewer1/wolf/pkg/rolepersist"
+)
+
+const (
+	// defaultRolePersistenceRetention is how long the roles of the members that leave are kept for, when no retention
+	// has been set.
+	defaultRolePersistenceRetention = 30 * 24 * time.Hour
+
+	// maxRolePersistenceRetention is the longest the roles of the members that leave can be kept for.
+	maxRolePersistenceRetention = 365 * 24 * time.Hour
+
+	// maxRolePersistenceRoles is the most roles that can be allowed or denied.
+	maxRolePersistenceRoles = 25
+
+	// restoreNicknameOption is the option value that restores the nicknames of the members that rejoin.
+	restoreNicknameOption = "restore"
+
+	// rolePersistenceReason is the audit log reason of the roles and nicknames restored to the members that rejoin.
+	rolePersistenceReason = "Restored after rejoining"
+)
+
+// rolePersistenceOptions are the options for the role persistence configuration command. The settings that are not
+// given are kept.
+type rolePersistenceOptions struct {
+	// Enabled is whether the roles of the members that rejoin are restored.
+	Enabled bool `option:"enabled" description:"This is whether the roles of the members that rejoin are restored." required:"true"`
+
+	// Nickname is whether the nicknames of the members that rejoin are restored.
+	Nickname string `option:"nickname" description:"This is whether the nicknames of the members that rejoin are restored." choices:"restore,ignore"`
+
+	// AllowedRoles are the mentions or IDs of the only roles that are restored, or none to restore every role.
+	AllowedRoles string `option:"allowed_roles" description:"These are the only roles that are restored, as mentions or IDs, or none for all."`
+
+	// DeniedRoles are the mentions or IDs of the roles that are never restored, or none.
+	DeniedRoles string `option:"denied_roles" description:"These are the roles that are never restored, as mentions or IDs, or none."`
+
+	// Retention is how long the roles of the members that leave are kept for, such as 30d.
+	Retention string `option:"retention" description:"This is how long the roles of the members that leave are kept for, such as 30d."`
+
+	// allowedRoleIDs are the IDs parsed from AllowedRoles.
+	allowedRoleIDs []string
+
+	// deniedRoleIDs are the IDs parsed from DeniedRoles.
+	deniedRoleIDs []string
+
+	// retention is the parsed Retention.
+	retention time.Duration
+}
+
+// Validate parses the allowed and denied roles and the retention.
+func (o *rolePersistenceOptions) Validate() error {
+	var err error
+	if o.allowedRoleIDs, err = parseRolePersistenceRoles(o.AllowedRoles); err != nil {
+		return err
+	}
+	if o.deniedRoleIDs, err = parseRolePersistenceRoles(o.DeniedRoles); err != nil {
+		return err
+	}
+
+	if o.Retention != "" {
+		d, err := parseModerationDuration(o.Retention)
+		if err != nil {
+			return err
+		}
+		if d > maxRolePersistenceRetention {
+			return commands.NewUserError("The retention can be at most %s.", custom.FormatDuration(maxRolePersistenceRetention))
+		}
+		o.retention = d
+	}
+	return nil
+}
+
+// parseRolePersistenceRoles returns the IDs of the roles mentioned in the option, without duplicates.
+func parseRolePersistenceRoles(option string) ([]string, error) {
+	var ids []string
+	for _, id := range roleIDPattern.FindAllString(option, -1) {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	switch {
+	case option != "" && option != noneOption && len(ids) == 0:
+		return nil, commands.NewUserError("Mention the roles, or use none to clear them.")
+	case len(ids) > maxRolePersistenceRoles:
+		return nil, commands.NewUserError("At most %d roles can be allowed or denied.", maxRolePersistenceRoles)
+	}
+	return ids, nil
+}
+
+// rolePersistenceCmdController is the controller for the role persistence configuration command. The members in the
+// guild are snapshotted when it is enabled, and the snapshots are expired when it is disabled.
+func rolePersistenceCmdController(c *commands.Context) error {
+	ctx := c.Context()
+
+	opts := c.Options().(*rolePersistenceOptions)
+
+	guild, err := getGuildConfig(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+
+	// Set the role persistence configuration, defaulting the settings that have never been set.
+	cfg := &guild.RolePersistence
+	wasEnabled := cfg.Enabled
+	cfg.Enabled = opts.Enabled
+	if opts.Nickname != "" {
+		cfg.Nickname = opts.Nickname == restoreNicknameOption
+	}
+	if opts.AllowedRoles == noneOption {
+		cfg.AllowedRoleIDs = nil
+	} else if len(opts.allowedRoleIDs) > 0 {
+		cfg.AllowedRoleIDs = opts.allowedRoleIDs
+	}
+	if opts.DeniedRoles == noneOption {
+		cfg.DeniedRoleIDs = nil
+	} else if len(opts.deniedRoleIDs) > 0 {
+		cfg.DeniedRoleIDs = opts.deniedRoleIDs
+	}
+	if opts.retention > 0 {
+		cfg.Retention = opts.retention
+	} else if cfg.Retention == 0 {
+		cfg.Retention = defaultRolePersistenceRetention
+	}
+
+	switch {
+	case cfg.Enabled && !wasEnabled:
+		// The roles of the members are only known from their snapshots when they leave.
+		if state, err := c.Session().State.Guild(c.GuildID); err == nil {
+			if err := snapshotMembers(ctx, c.GuildID, state.Members); err != nil {
+				return err
+			}
+		}
+	case !cfg.Enabled && wasEnabled:
+		// The snapshots of the members in the guild are no longer kept up to date, so they are removed.
+		if _, err := dataaccess.MemberSnapshotDB.ExpireMissingSnapshots(ctx, c.GuildID, nil, time.Now()); err != nil {
+			return fmt.Errorf("error expiring member snapshots: %w", err)
+		}
+	}
+
+	// Save the guild.
+	update := &dataaccess.GuildUpdate{Set: map[string]any{"role_persistence": cfg}}
+	if err := dataaccess.GuildDB.UpdateGuild(ctx, c.GuildID, update); err != nil {
+		return fmt.Errorf("error saving guild: %w", err)
+	}
+
+	msg := "Roles will no longer be restored to the members that rejoin."
+	if cfg.Enabled {
+		restored := "every role"
+		if len(cfg.AllowedRoleIDs) > 0 {
+			restored = roleMentions(cfg.AllowedRoleIDs)
+		}
+		msg = fmt.Sprintf("The members that rejoin within %s will have %s restored", custom.FormatDuration(cfg.Retention), restored)
+		if len(cfg.DeniedRoleIDs) > 0 {
+			msg += fmt.Sprintf(", except %s", roleMentions(cfg.DeniedRoleIDs))
+		}
+		if cfg.Nickname {
+			msg += ", along with their nickname"
+		}
+		msg += ". Roles with the administrator permission, and roles that are not below my highest role, are never restored."
+	}
+
+	// Respond to the interaction with the new configuration.
+	if err := c.RespondEphemeral(msg); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// memberSnapshot returns the snapshot of the roles and nickname of the member.
+func memberSnapshot(guildID string, member *discordgo.Member) *entities.MemberSnapshot {
+	return &entities.MemberSnapshot{
+		GuildID:   guildID,
+		UserID:    member.User.ID,
+		RoleIDs:   member.Roles,
+		Nickname:  member.Nick,
+		UpdatedAt: custom.Datetime(time.Now().UTC()),
+	}
+}
+
+// snapshotMembers saves the snapshots of the members of the guild. Bots are left out, as their roles are managed by
+// their integrations.
+func snapshotMembers(ctx context.Context, guildID string, members []*discordgo.Member) error {
+	snapshots := make([]*entities.MemberSnapshot, 0, len(members))
+	for _, member := range members {
+		if member.User == nil || member.User.Bot {
+			continue
+		}
+		snapshots = append(snapshots, memberSnapshot(guildID, member))
+	}
+
+	if err := dataaccess.MemberSnapshotDB.SaveSnapshots(ctx, snapshots...); err != nil {
+		return fmt.Errorf("error saving member snapshots: %w", err)
+	}
+	return nil
+}
+
+// rolePersistenceReconcileHandler snapshots the members of the guilds as they become available, which includes every
+// guild of the shard when it connects. The changes made while the bot was offline are caught up on, and the members
+// that left are expired when every member of the guild is known.
+func (a *App) rolePersistenceReconcileHandler() func(s *discordgo.Session, g *discordgo.GuildCreate) {
+	return func(s *discordgo.Session, g *discordgo.GuildCreate) {
+		if g.Guild == nil || g.Unavailable {
+			return
+		}
+
+		l := a.With(
+			slog.String(logging.KeyComponent, componentRolePersistence),
+			slog.String("guild_id", g.ID),
+		)
+
+		guild, err := getGuildConfig(a.ctx, g.ID)
+		if err != nil {
+			l.Error("Error getting role persistence configuration", slog.String(logging.KeyError, err.Error()))
+			return
+		}
+
+		cfg := &guild.RolePersistence
+		if !cfg.Enabled {
+			return
+		}
+
+		if err := snapshotMembers(a.ctx, g.ID, g.Members); err != nil {
+			l.Error("Error snapshotting members", slog.String(logging.KeyError, err.Error()))
+			return
+		}
+
+		// Large guilds are not sent with every member, so the members that are missing may still be in the guild.
+		if len(g.Members) < g.MemberCount {
+			return
+		}
+
+		userIDs := make([]string, 0, len(g.Members))
+		for _, member := range g.Members {
+			if member.User != nil {
+				userIDs = append(userIDs, member.User.ID)
+			}
+		}
+
+		expired, err := dataaccess.MemberSnapshotDB.ExpireMissingSnapshots(a.ctx, g.ID, userIDs, time.Now().Add(cfg.Retention))
+		if err != nil {
+			l.Error("Error expiring member snapshots", slog.String(logging.KeyError, err.Error()))
+			return
+		}
+		if expired > 0 {
+			l.Info("Expired snapshots of members that left while offline", slog.Int64("count", expired))
+		}
+	}
+}
+
+// rolePersistenceUpdateHandler takes the snapshot of the members whose roles or nickname change, so the snapshot is
+// up to date when they leave.
+func (a *App) rolePersistenceUpdateHandler() func(s *discordgo.Session, m *discordgo.GuildMemberUpdate) {
+	return func(s *discordgo.Session, m *discordgo.GuildMemberUpdate) {
+		if m.Member == nil || m.User == nil || m.User.Bot || !rolepersist.Changed(m.BeforeUpdate, m.Member) {
+			return
+		}
+
+		l := a.With(
+			slog.String(logging.KeyComponent, componentRolePersistence),
+			slog.String("guild_id", m.GuildID),
+			slog.String("user_id", m.User.ID),
+		)
+
+		guild, err := getGuildConfig(a.ctx, m.GuildID)
+		if err != nil {
+			l.Error("Error getting role persistence configuration", slog.String(logging.KeyError, err.Error()))
+			return
+		} else if !guild.RolePersistence.Enabled {
+			return
+		}
+
+		if err := dataaccess.MemberSnapshotDB.UpdateSnapshot(a.ctx, memberSnapshot(m.GuildID, m.Member)); err != nil {
+			l.Error("Error updating member snapshot", slog.String(logging.KeyError, err.Error()))
+		}
+	}
+}
+
+// rolePersistenceLeaveHandler keeps the snapshot of the members that leave for the retention period.
+func (a *App) rolePersistenceLeaveHandler() func(s *discordgo.Session, m *discordgo.GuildMemberRemove) {
+	return func(s *discordgo.Session, m *discordgo.GuildMemberRemove) {
+		if m.Member == nil || m.User == nil || m.User.Bot {
+			return
+		}
+
+		l := a.With(
+			slog.String(logging.KeyComponent, componentRolePersistence),
+			slog.String("guild_id", m.GuildID),
+			slog.String("user_id", m.User.ID),
+		)
+
+		guild, err := getGuildConfig(a.ctx, m.GuildID)
+		if err != nil {
+			l.Error("Error getting role persistence configuration", slog.String(logging.KeyError, err.Error()))
+			return
+		}
+
+		cfg := &guild.RolePersistence
+		if !cfg.Enabled {
+			return
+		}
+
+		if err := dataaccess.MemberSnapshotDB.ExpireSnapshot(a.ctx, m.GuildID, m.User.ID, time.Now().Add(cfg.Retention)); err != nil {
+			l.Error("Error expiring member snapshot", slog.String(logging.KeyError, err.Error()))
+		}
+	}
+}
+
+// rolePersistenceJoinHandler restores the roles and nickname of the members that rejoin. The members that must pass
+// the verification gate are restored once they pass it, and the members quarantined by raid mode are not restored, so
+// neither is bypassed by the restored roles.
+func (a *App) rolePersistenceJoinHandler() func(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+	return func(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+		if m.Member == nil || m.User == nil || m.User.Bot {
+			return
+		}
+
+		l := a.With(
+			slog.String(logging.KeyComponent, componentRolePersistence),
+			slog.String("guild_id", m.GuildID),
+			slog.String("user_id", m.User.ID),
+		)
+
+		guild, err := getGuildConfig(a.ctx, m.GuildID)
+		if err != nil {
+			l.Error("Error getting role persistence configuration", slog.String(logging.KeyError, err.Error()))
+			return
+		}
+
+		if !guild.RolePersistence.Enabled {
+			return
+		}
+
+		// The member is restored by verifyMember once they pass the verification gate.
+		if guild.Verification.Enabled && guild.Verification.UnverifiedRoleID != "" {
+			return
+		}
+
+		// The snapshot is left until it expires, as the member keeps the quarantine role when raid mode ends.
+		if guild.Raid.Enabled && guild.Raid.Action == entities.RaidActionQuarantine && guild.Raid.QuarantineRoleID != "" {
+			raid, err := getRaid(a.ctx, m.GuildID)
+			if err != nil {
+				l.Error("Error getting raid mode", slog.String(logging.KeyError, err.Error()))
+				return
+			}
+			if raid != nil && raid.Active {
+				return
+			}
+		}
+
+		a.restorePersistedMember(l, s, &guild.RolePersistence, m.Member)
+	}
+}
+
+// restorePersistedMember restores the roles and nickname of the member from their snapshot, if they have one. The
+// snapshot is taken, so the changes made while it is restored do not replace it, and then saved again with the
+// restored roles.
+func (a *App) restorePersistedMember(l *slog.Logger, s *discordgo.Session, cfg *entities.RolePersistenceConfig, m *discordgo.Member) {
+	snapshot, err := dataaccess.MemberSnapshotDB.TakeSnapshot(a.ctx, m.GuildID, m.User.ID)
+	if isNotFound(err) {
+		return
+	} else if err != nil {
+		l.Error("Error taking member snapshot", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	restored, nickname := a.restoreMember(l, s, cfg, m, snapshot)
+
+	// The snapshot is saved again, as the member is back in the guild.
+	member := *m
+	member.Roles = append(append([]string{}, m.Roles...), restored...)
+	if nickname != "" {
+		member.Nick = nickname
+	}
+	if err := dataaccess.MemberSnapshotDB.SaveSnapshots(a.ctx, memberSnapshot(m.GuildID, &member)); err != nil {
+		l.Error("Error saving member snapshot", slog.String(logging.KeyError, err.Error()))
+	}
+
+	if len(restored) == 0 && nickname == "" {
+		return
+	}
+
+	fields := []*discordgo.MessageEmbedField{userField("Member", m.User)}
+	if len(restored) > 0 {
+		RolePersistenceRestores.WithLabelValues("roles").Inc()
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Roles", Value: truncate(roleMentions(restored), 1024)})
+	}
+	if nickname != "" {
+		RolePersistenceRestores.WithLabelValues("nickname").Inc()
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Nickname", Value: nickname, Inline: true})
+	}
+	a.events.log(a.ctx, m.GuildID, entities.LogTypeMemberJoin, &discordgo.MessageEmbed{
+		Title:  "Member Restored",
+		Fields: fields,
+	})
+}
+
+// restoreMember gives the member the roles of the snapshot that can be restored, and sets their nickname if it is
+// restored and they do not have one. It returns the roles and nickname that were restored.
+func (a *App) restoreMember(l *slog.Logger, s *discordgo.Session, cfg *entities.RolePersistenceConfig, member *discordgo.Member, snapshot *entities.MemberSnapshot) ([]string, string) {
+	guild, err := s.State.Guild(member.GuildID)
+	if err != nil {
+		l.Error("Error getting guild from state", slog.String(logging.KeyError, err.Error()))
+		return nil, ""
+	}
+
+	bot, err := s.State.Member(member.GuildID, s.State.User.ID)
+	if err != nil {
+		l.Error("Error getting bot member from state", slog.String(logging.KeyError, err.Error()))
+		return nil, ""
+	}
+
+	var restored []string
+	for _, roleID := range rolepersist.Restorable(cfg, guild, highestRolePosition(guild.Roles, bot.Roles), snapshot.RoleIDs, member.Roles) {
+		err := s.GuildMemberRoleAdd(member.GuildID, member.User.ID, roleID,
+			discordgo.WithContext(a.ctx),
+			discordgo.WithAuditLogReason(rolePersistenceReason),
+		)
+		if err != nil {
+			l.Error("Error restoring role",
+				slog.String("role_id", roleID),
+				slog.String(logging.KeyError, err.Error()),
+			)
+			continue
+		}
+		restored = append(restored, roleID)
+	}
+
+	if !cfg.Nickname || snapshot.Nickname == "" || member.Nick != "" {
+		return restored, ""
+	}
+
+	err = s.GuildMemberNickname(member.GuildID, member.User.ID, snapshot.Nickname,
+		discordgo.WithContext(a.ctx),
+		discordgo.WithAuditLogReason(rolePersistenceReason),
+	)
+	if err != nil {
+		l.Error("Error restoring nickname", slog.String(logging.KeyError, err.Error()))
+		return restored, ""
+	}
+	return restored, snapshot.Nickname
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/custom"
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"github.com/Jacobbrewer1/wolf/pkg/scheduler"
+)
+
+// newScheduler creates the worker that runs the scheduled actions.
+func (a *App) newScheduler() *scheduler.Worker {
+	w := scheduler.NewWorker(
+		a.With(slog.String(logging.KeyComponent, componentScheduler)),
+		dataaccess.ScheduledActionDB,
+		scheduler.WithObserver(a.observeScheduledAction),
+	)
+
+	w.Handle(entities.ScheduledActionUnban, a.runUnban)
+	w.Handle(entities.ScheduledActionUnmute, a.runUnmute)
+	w.Handle(entities.ScheduledActionDeleteChannel, a.runDeleteChannel)
+	w.Handle(entities.ScheduledActionEndRaid, a.runEndRaid)
+	w.Handle(entities.ScheduledActionAddRole, a.runAddRole)
+	w.Handle(entities.ScheduledActionKickUnverified, a.runKickUnverified)
+	return w
+}
+
+// scheduleAction schedules the action to run at the time.
+func scheduleAction(ctx context.Context, action *entities.ScheduledAction, at time.Time) error {
+	action.RunAt = custom.Datetime(at.UTC())
+	action.Status = entities.ScheduledActionPending
+	action.CreatedAt = custom.Datetime(time.Now().UTC())
+
+	if err := dataaccess.ScheduledActionDB.ScheduleAction(ctx, action); err != nil {
+		return fmt.Errorf("error scheduling %s: %w", action.Type, err)
+	}
+	return nil
+}
+
+// runUnban removes the ban of a temporary ban that has expired.
+func (a *App) runUnban(ctx context.Context, action *entities.ScheduledAction) error {
+	err := a.Session().GuildBanDelete(action.GuildID, action.TargetID,
+		discordgo.WithContext(ctx),
+		discordgo.WithAuditLogReason(fmt.Sprintf("Case #%d expired", action.CaseID)),
+	)
+	return scheduledActionError(err)
+}
+
+// runUnmute removes the mute role of a temporary mute that has expired.
+func (a *App) runUnmute(ctx context.Context, action *entities.ScheduledAction) error {
+	err := a.Session().GuildMemberRoleRemove(action.GuildID, action.TargetID, action.RoleID,
+		discordgo.WithContext(ctx),
+		discordgo.WithAuditLogReason(fmt.Sprintf("Case #%d expired", action.CaseID)),
+	)
+	if isNotFound(err) {
+		// The member has left, so the mute must not be restored if they rejoin.
+		if err := dataaccess.MemberSnapshotDB.RemoveSnapshotRole(ctx, action.GuildID, action.TargetID, action.RoleID); err != nil {
+			return fmt.Errorf("error removing mute role from member snapshot: %w", err)
+		}
+	}
+	return scheduledActionError(err)
+}
+
+// runDeleteChannel deletes the channel.
+func (a *App) runDeleteChannel(ctx context.Context, action *entities.ScheduledAction) error {
+	_, err := a.Session().ChannelDelete(action.TargetID, discordgo.WithContext(ctx))
+	return scheduledActionError(err)
+}
+
+// scheduledActionError returns the error of a Discord request made by a scheduled action. A resource that no longer
+// exists leaves the action with nothing to do, such as a ban that was already removed. Missing access is not retried,
+// as it is not granted by waiting.
+func scheduledActionError(err error) error {
+	if err == nil || isNotFound(err) {
+		return nil
+	}
+
+	restErr := new(discordgo.RESTError)
+	if errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == http.StatusForbidden {
+		return scheduler.Permanent(err)
+	}
+	return err
+}
+
+// observeScheduledAction records the outcome of a scheduled action. The outcome of an action that reverses a
+// moderation action is recorded against its case.
+func (a *App) observeScheduledAction(ctx context.Context, action *entities.ScheduledAction) {
+	ScheduledActionsRun.WithLabelValues(string(action.Type), string(action.Status)).Inc()
+
+	if action.CaseID == 0 || action.Status == entities.ScheduledActionPending {
+		return
+	}
+
+	l := a.With(slog.String(logging.KeyComponent, componentScheduler), slog.Int("case", action.CaseID))
+
+	guildCase, err := dataaccess.CaseDB.GetCase(ctx, action.GuildID, action.CaseID)
+	if err != nil {
+		l.Error("Error getting case of scheduled action", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	if action.Status == entities.ScheduledActionDone {
+		guildCase.ReversedAt = action.CompletedAt
+		guildCase.ReversalError = ""
+	} else {
+		guildCase.ReversalError = action.LastError
+	}
+
+	if err := dataaccess.CaseDB.SaveCase(ctx, guildCase); err != nil {
+		l.Error("Error saving case of scheduled action", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	updateCaseLog(ctx, l, a.Session(), guildCase)
+}
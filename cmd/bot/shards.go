@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+)
+
+const (
+	// shardStartDelay is the delay between opening the shards, as Discord limits how often a bot can identify.
+	shardStartDelay = 5 * time.Second
+
+	// leaderLeaseName is the name of the lease held by the leader.
+	leaderLeaseName = AppName + "-leader"
+
+	// leaderLeaseTTL is how long the leader holds the lease without renewing it.
+	leaderLeaseTTL = 15 * time.Second
+)
+
+// resolveShards returns the total number of shards and the IDs of the shards run by this instance. When the shard
+// count is not configured, the number of shards recommended by Discord is used. When the shard IDs are not configured,
+// every shard is run.
+func resolveShards(s *discordgo.Session) (int, []int, error) {
+	count := ShardCount
+	if count == 0 {
+		gw, err := s.GatewayBot()
+		if err != nil {
+			return 0, nil, fmt.Errorf("error getting recommended shard count: %w", err)
+		}
+		count = gw.Shards
+	}
+
+	if count < 1 {
+		count = 1
+	}
+
+	ids := ShardIDs
+	if len(ids) == 0 {
+		ids = make([]int, 0, count)
+		for id := 0; id < count; id++ {
+			ids = append(ids, id)
+		}
+	}
+
+	for _, id := range ids {
+		if id < 0 || id >= count {
+			return 0, nil, fmt.Errorf("shard ID %d is out of range for %d shards", id, count)
+		}
+	}
+
+	return count, ids, nil
+}
+
+// openShards opens the websocket of each shard.
+func (a *App) openShards() error {
+	for i, shard := range a.shards {
+		if i > 0 {
+			time.Sleep(shardStartDelay)
+		}
+
+		a.startup.setStage(fmt.Sprintf("opening shard %d (%d of %d)", shard.ShardID, i+1, len(a.shards)))
+		if err := shard.Open(); err != nil {
+			return fmt.Errorf("error opening shard %d: %w", shard.ShardID, err)
+		}
+	}
+	return nil
+}
+
+// lead runs the singletons while this instance is the leader.
+func (a *App) lead(ctx context.Context) {
+	IsLeader.Set(1)
+	defer IsLeader.Set(0)
+
+	// Register slash commands.
+	if err := a.registerSlashCommands(); err != nil {
+		a.Error("Error registering slash commands", slog.String(logging.KeyError, err.Error()))
+	}
+
+	// Run the scheduled actions until leadership is lost.
+	a.newScheduler().Run(ctx)
+}
+
+// shardLabel returns the metrics label for the shard of the session.
+func shardLabel(s *discordgo.Session) string {
+	return strconv.Itoa(s.ShardID)
+}
+
+// instanceID identifies this instance in the leader election.
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
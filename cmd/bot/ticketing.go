@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/commands"
 	"github.com/Jacobbrewer1/wolf/pkg/custom"
 	"github.com/Jacobbrewer1/wolf/pkg/dataaccess"
 	"github.com/Jacobbrewer1/wolf/pkg/entities"
@@ -35,6 +36,18 @@ const (
 	DeleteConfirmationButtonID = "delete_confirmation_button"
 )
 
+// ticketChannelDeleteDelay is how long the channel of a deleted ticket is kept, so the users can see that it was
+// deleted.
+const ticketChannelDeleteDelay = 60 * time.Second
+
+var (
+	// errTicketAlreadyClosed is returned when closing a ticket that is already closed.
+	errTicketAlreadyClosed = errors.New("ticket is already closed")
+
+	// errTicketDeleted is returned when changing a ticket that has been deleted.
+	errTicketDeleted = errors.New("ticket has been deleted")
+)
+
 const (
 	// ClaimEmoji is the emoji that will be used for the claim button. (Ticket)
 	ClaimEmoji = "\U0001F3AB"
@@ -71,30 +84,33 @@ const (
 
 var (
 	// ticketCmd is the command for controlling tickets.
-	ticketCmd = &discordgo.ApplicationCommand{
+	ticketCmd = &commands.Command{
 		Name:        TicketCmdName,
-		Type:        discordgo.ChatApplicationCommand,
 		Description: "This is the command for controlling tickets.",
-		Options: []*discordgo.ApplicationCommandOption{
+		GuildOnly:   true,
+		Subcommands: []*commands.Command{
 			{
 				Name:        ClaimCmdName,
-				Type:        discordgo.ApplicationCommandOptionSubCommand,
 				Description: "This claims the ticket for the channel that the command was executed in.",
+				Guards:      []commands.Guard{ticketRoleGuard},
+				Handler:     claimTicketHandler,
 			},
 			{
 				Name:        CloseCmdName,
-				Type:        discordgo.ApplicationCommandOptionSubCommand,
 				Description: "This closes the ticket for the channel that the command was executed in.",
+				Guards:      []commands.Guard{ticketRoleGuard},
+				Handler:     closeTicketHandler,
 			},
 			{
 				Name:        DeleteCmdName,
-				Type:        discordgo.ApplicationCommandOptionSubCommand,
 				Description: "This deletes the ticket for the channel that the command was executed in.",
+				Guards:      []commands.Guard{ticketRoleGuard},
+				Handler:     deleteTicketHandler,
 			},
 			{
 				Name:        ReopenCmdName,
-				Type:        discordgo.ApplicationCommandOptionSubCommand,
 				Description: "This reopens the ticket for the channel that the command was executed in.",
+				Handler:     reopenTicketHandler,
 			},
 		},
 	}
@@ -150,7 +166,126 @@ Please provide any additional info you deem relevant to help us answer faster.`,
 	}
 )
 
-func sendOpenTicketMessage(a IApp, channel *discordgo.Channel) (*discordgo.Message, error) {
+// ticketRoleGuard only allows members with the ticket role of the guild.
+func ticketRoleGuard(c *commands.Context) error {
+	// Get the guild configuration.
+	guild, err := dataaccess.GuildDB.GetGuildByID(c.Context(), c.GuildID)
+	if err != nil {
+		return fmt.Errorf("error getting guild configuration: %w", err)
+	}
+
+	// Ensure that the user has the ticket role.
+	if !hasRole(c.Member, guild.Ticketing.RoleID) {
+		return commands.NewUserError("You do not have the ticket role to manage tickets. [<@&%s>]", guild.Ticketing.RoleID)
+	}
+	return nil
+}
+
+// ticketCategory is a category that the tickets are moved to.
+type ticketCategory struct {
+	// name is the name the category is created with.
+	name string
+
+	// field is the path of the ID of the category in the guild configuration.
+	field string
+
+	// id returns the ID of the category in the ticketing configuration.
+	id func(cfg *entities.TicketingConfig) *string
+}
+
+var (
+	// createdTicketsCategory is the category of the open tickets.
+	createdTicketsCategory = &ticketCategory{
+		name:  "Created Tickets",
+		field: "ticketing.created_tickets_category_id",
+		id: func(cfg *entities.TicketingConfig) *string {
+			return &cfg.CreatedTicketsCategoryID
+		},
+	}
+
+	// claimedTicketsCategory is the category of the claimed tickets.
+	claimedTicketsCategory = &ticketCategory{
+		name:  "Claimed Tickets",
+		field: "ticketing.claimed_tickets_category_id",
+		id: func(cfg *entities.TicketingConfig) *string {
+			return &cfg.ClaimedTicketsCategoryID
+		},
+	}
+
+	// closedTicketsCategory is the category of the closed tickets.
+	closedTicketsCategory = &ticketCategory{
+		name:  "Closed Tickets",
+		field: "ticketing.closed_tickets_category_id",
+		id: func(cfg *entities.TicketingConfig) *string {
+			return &cfg.ClosedTicketsCategoryID
+		},
+	}
+)
+
+// ensureTicketCategory returns the category that tickets are moved to, creating it if it does not exist. The ID in the
+// guild configuration is updated, and saved, if the category changes. The member, if not empty, can see the new
+// category along with the ticket role.
+func ensureTicketCategory(ctx context.Context, l *slog.Logger, a IApp, guild *entities.Guild, tc *ticketCategory, memberID string) (*discordgo.Channel, error) {
+	categoryID := tc.id(&guild.Ticketing)
+	category, err := a.Session().Channel(*categoryID, discordgo.WithContext(ctx))
+	if err != nil {
+		er := new(discordgo.RESTError)
+		if !errors.As(err, &er) || (er.Message.Code != discordgo.ErrCodeUnknownChannel && er.Message.Code != discordgo.ErrCodeGeneralError) { // General is thrown when a 404 is returned.
+			return nil, fmt.Errorf("error getting category: %w", err)
+		}
+
+		l.Warn("Tickets category does not exist, creating it now", slog.String("category", tc.name))
+
+		overwrites := []*discordgo.PermissionOverwrite{
+			// Deny @everyone from seeing the ticket.
+			{
+				ID:    guild.ID,
+				Type:  discordgo.PermissionOverwriteTypeRole,
+				Allow: 0,
+				Deny:  discordgo.PermissionAll,
+			},
+			// Add the ticket role.
+			{
+				ID:    guild.Ticketing.RoleID,
+				Type:  discordgo.PermissionOverwriteTypeRole,
+				Allow: discordgo.PermissionAllText,
+				Deny:  discordgo.PermissionMentionEveryone,
+			},
+		}
+
+		if memberID != "" {
+			// The member can see the ticket.
+			overwrites = append(overwrites, &discordgo.PermissionOverwrite{
+				ID:    memberID,
+				Type:  discordgo.PermissionOverwriteTypeMember,
+				Allow: discordgo.PermissionAllText,
+				Deny:  discordgo.PermissionMentionEveryone,
+			})
+		}
+
+		category, err = a.Session().GuildChannelCreateComplex(guild.ID, discordgo.GuildChannelCreateData{
+			Name:                 tc.name,
+			Type:                 discordgo.ChannelTypeGuildCategory,
+			PermissionOverwrites: overwrites,
+		}, discordgo.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("error creating category: %w", err)
+		}
+	}
+
+	if category.ID != *categoryID {
+		// Save the guild configuration.
+		*categoryID = category.ID
+		update := &dataaccess.GuildUpdate{Set: map[string]any{tc.field: category.ID}}
+		if err := dataaccess.GuildDB.UpdateGuild(ctx, guild.ID, update); err != nil {
+			return nil, fmt.Errorf("error saving guild configuration: %w", err)
+		}
+	}
+
+	return category, nil
+}
+
+func sendOpenTicketMessage(ctx context.Context, a IApp, channel *discordgo.Channel) (*discordgo.Message, error) {
 	const messageText = `How can we help?
 Welcome to our tickets channel. If you have any questions or inquiries, please click on the button below to contact the staff by opening a ticket!`
 
@@ -185,7 +320,7 @@ Welcome to our tickets channel. If you have any questions or inquiries, please c
 	}
 
 	// Send the message.
-	msg, err := a.Session().ChannelMessageSendComplex(channel.ID, &message)
+	msg, err := a.Session().ChannelMessageSendComplex(channel.ID, &message, discordgo.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("error sending message: %w", err)
 	}
@@ -194,71 +329,23 @@ Welcome to our tickets channel. If you have any questions or inquiries, please c
 }
 
 // createTicket is the function for creating a ticket.
-func createTicket(a IApp, i *discordgo.InteractionCreate) error {
-	ctx := context.Background()
+func createTicket(c *commands.Context) error {
+	ctx := c.Context()
 
 	// Get the guild configuration.
-	guild, err := dataaccess.GuildDB.GetGuildByID(ctx, i.GuildID)
+	guild, err := dataaccess.GuildDB.GetGuildByID(ctx, c.GuildID)
 	if err != nil {
 		return fmt.Errorf("error getting guild configuration: %w", err)
 	}
 
 	// Ensure that the category exists for created tickets.
-	category, err := a.Session().Channel(guild.Ticketing.CreatedTicketsCategoryID)
+	category, err := ensureTicketCategory(ctx, c.Logger(), c, guild, createdTicketsCategory, c.Member.User.ID)
 	if err != nil {
-		er := new(discordgo.RESTError)
-		if errors.As(err, &er) && (er.Message.Code == discordgo.ErrCodeUnknownChannel || er.Message.Code == discordgo.ErrCodeGeneralError) { // General is thrown when a 404 is returned.
-			slog.Warn("Created tickets category does not exist, creating it now")
-
-			category, err = a.Session().GuildChannelCreateComplex(i.GuildID, discordgo.GuildChannelCreateData{
-				Name: "Created Tickets",
-				Type: discordgo.ChannelTypeGuildCategory,
-				PermissionOverwrites: []*discordgo.PermissionOverwrite{
-					// Deny @everyone from seeing the ticket.
-					{
-						ID:    i.GuildID,
-						Type:  discordgo.PermissionOverwriteTypeRole,
-						Allow: 0,
-						Deny:  discordgo.PermissionAll,
-					},
-					// The creator of the ticket can see the ticket.
-					{
-						ID:    i.Member.User.ID,
-						Type:  discordgo.PermissionOverwriteTypeMember,
-						Allow: discordgo.PermissionAllText,
-						Deny:  discordgo.PermissionMentionEveryone,
-					},
-					// Add the ticket role.
-					{
-						ID:    guild.Ticketing.RoleID,
-						Type:  discordgo.PermissionOverwriteTypeRole,
-						Allow: discordgo.PermissionAllText,
-						Deny:  discordgo.PermissionMentionEveryone,
-					},
-				},
-			})
-			if err != nil {
-				return fmt.Errorf("error creating category: %w", err)
-			}
-
-			// Save the guild configuration.
-			guild.Ticketing.CreatedTicketsCategoryID = category.ID
-			if err := dataaccess.GuildDB.SaveGuild(ctx, guild); err != nil {
-				return fmt.Errorf("error saving guild configuration: %w", err)
-			}
-		} else {
-			return fmt.Errorf("error getting category: %w", err)
-		}
-	} else if category != nil && category.ID != guild.Ticketing.CreatedTicketsCategoryID {
-		// Update the guild configuration.
-		guild.Ticketing.CreatedTicketsCategoryID = category.ID
-		if err := dataaccess.GuildDB.SaveGuild(ctx, guild); err != nil {
-			return fmt.Errorf("error saving guild configuration: %w", err)
-		}
+		return err
 	}
 
 	// Get the latest ticket.
-	latestTicket, err := dataaccess.TicketDB.GetLatestTicket(ctx, i.GuildID)
+	latestTicket, err := dataaccess.TicketDB.GetLatestTicket(ctx, c.GuildID)
 	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
 		return fmt.Errorf("error getting latest ticket: %w", err)
 	}
@@ -272,30 +359,31 @@ func createTicket(a IApp, i *discordgo.InteractionCreate) error {
 	// Create the ticket.
 	ticket := &entities.Ticket{
 		ID:        ticketID,
-		GuildID:   i.GuildID,
-		UserID:    i.Member.User.ID,
-		Username:  i.Member.User.Username,
+		GuildID:   c.GuildID,
+		UserID:    c.Member.User.ID,
+		Username:  c.Member.User.Username,
 		CreatedAt: custom.Datetime(time.Now().UTC()),
 	}
+	ticket.AddEvent(entities.TicketEventCreated, c.Member.User.ID, entities.TicketEventSourceDiscord)
 
 	topicStr := calculateTopicString(ticket, OpenTicketButtonID)
 
 	// Create the ticket channel only the ticket role and the creator can see.
-	ticketChannel, err := a.Session().GuildChannelCreateComplex(i.GuildID, discordgo.GuildChannelCreateData{
+	ticketChannel, err := c.Session().GuildChannelCreateComplex(c.GuildID, discordgo.GuildChannelCreateData{
 		Name:  ticket.Name(),
 		Type:  discordgo.ChannelTypeGuildText,
 		Topic: topicStr,
 		PermissionOverwrites: []*discordgo.PermissionOverwrite{
 			// Deny @everyone from seeing the ticket.
 			{
-				ID:    i.GuildID,
+				ID:    c.GuildID,
 				Type:  discordgo.PermissionOverwriteTypeRole,
 				Allow: 0,
 				Deny:  discordgo.PermissionAll,
 			},
 			// The creator of the ticket can see the ticket.
 			{
-				ID:    i.Member.User.ID,
+				ID:    c.Member.User.ID,
 				Type:  discordgo.PermissionOverwriteTypeMember,
 				Allow: discordgo.PermissionAllText,
 				Deny:  discordgo.PermissionMentionEveryone,
@@ -314,7 +402,7 @@ func createTicket(a IApp, i *discordgo.InteractionCreate) error {
 		Bitrate:          0,
 		UserLimit:        0,
 		RateLimitPerUser: 0,
-	})
+	}, discordgo.WithContext(ctx))
 	if err != nil {
 		return err
 	}
@@ -328,34 +416,35 @@ func createTicket(a IApp, i *discordgo.InteractionCreate) error {
 	}
 
 	go func() {
-		err := setupNewTicketChannel(a, ticket)
+		// The setup outlives the interaction, so it is not cancelled with it.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Context()), commands.DefaultTimeout)
+		defer cancel()
+
+		err := setupNewTicketChannel(ctx, c, ticket)
 		if err != nil {
-			slog.Error("Error setting up new ticket channel", slog.String(logging.KeyError, err.Error()))
+			c.Logger().Error("Error setting up new ticket channel", slog.String(logging.KeyError, err.Error()))
 		}
 	}()
 
 	// Respond to the interaction saying that the ticket has been created in channel <channel>.
 	// This message is an embedded ephemeral message with all the information about the ticket.
-	err = a.Session().InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Flags: discordgo.MessageFlagsEphemeral,
-			Embeds: []*discordgo.MessageEmbed{
-				{
-					Title:       "Ticket Created",
-					Description: fmt.Sprintf("<@%s>, you created a ticket and it has been moved to the **Created Tickets** category.", i.Member.User.ID),
-					Color:       0x00ff00,
-					Fields: []*discordgo.MessageEmbedField{
-						{
-							Name:   "Ticket Name",
-							Value:  ticket.Name(),
-							Inline: true,
-						},
-						{
-							Name:   "Ticket Channel",
-							Value:  fmt.Sprintf("<#%s>", ticket.ChannelID),
-							Inline: true,
-						},
+	err = c.Respond(&discordgo.InteractionResponseData{
+		Flags: discordgo.MessageFlagsEphemeral,
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       "Ticket Created",
+				Description: fmt.Sprintf("<@%s>, you created a ticket and it has been moved to the **Created Tickets** category.", c.Member.User.ID),
+				Color:       0x00ff00,
+				Fields: []*discordgo.MessageEmbedField{
+					{
+						Name:   "Ticket Name",
+						Value:  ticket.Name(),
+						Inline: true,
+					},
+					{
+						Name:   "Ticket Channel",
+						Value:  fmt.Sprintf("<#%s>", ticket.ChannelID),
+						Inline: true,
 					},
 				},
 			},
@@ -367,23 +456,21 @@ func createTicket(a IApp, i *discordgo.InteractionCreate) error {
 	return nil
 }
 
-func setupNewTicketChannel(a IApp, ticket *entities.Ticket) error {
-	ctx := context.Background()
-
+func setupNewTicketChannel(ctx context.Context, a IApp, ticket *entities.Ticket) error {
 	// Get the channel.
-	channel, err := a.Session().Channel(ticket.ChannelID)
+	channel, err := a.Session().Channel(ticket.ChannelID, discordgo.WithContext(ctx))
 	if err != nil {
 		return fmt.Errorf("error getting channel: %w", err)
 	}
 
 	// Send the initial message to the channel.
-	msg, err := a.Session().ChannelMessageSendComplex(channel.ID, NewTicketMessage)
+	msg, err := a.Session().ChannelMessageSendComplex(channel.ID, NewTicketMessage, discordgo.WithContext(ctx))
 	if err != nil {
 		return fmt.Errorf("error sending message: %w", err)
 	}
 
 	// Pin the message to the channel.
-	if err := a.Session().ChannelMessagePin(channel.ID, msg.ID); err != nil {
+	if err := a.Session().ChannelMessagePin(channel.ID, msg.ID, discordgo.WithContext(ctx)); err != nil {
 		return fmt.Errorf("error pinning message: %w", err)
 	}
 
@@ -398,51 +485,36 @@ func setupNewTicketChannel(a IApp, ticket *entities.Ticket) error {
 	return nil
 }
 
-func claimTicketHandler(a IApp, i *discordgo.InteractionCreate) error {
-	ctx := context.Background()
+func claimTicketHandler(c *commands.Context) error {
+	ctx := c.Context()
 
 	// Get the channel name.
-	channel, err := a.Session().Channel(i.ChannelID)
+	channel, err := c.Session().Channel(c.ChannelID, discordgo.WithContext(ctx))
 	if err != nil {
 		return fmt.Errorf("error getting channel: %w", err)
 	}
 
 	// Get the ticket.
-	ticket, err := dataaccess.TicketDB.GetTicket(ctx, i.GuildID, channel.ID)
+	ticket, err := dataaccess.TicketDB.GetTicket(ctx, c.GuildID, channel.ID)
 	if err != nil {
 		return fmt.Errorf("error getting ticket: %w", err)
 	}
 
 	// Get the guild configuration.
-	guild, err := dataaccess.GuildDB.GetGuildByID(ctx, i.GuildID)
+	guild, err := dataaccess.GuildDB.GetGuildByID(ctx, c.GuildID)
 	if err != nil {
 		return fmt.Errorf("error getting guild configuration: %w", err)
 	}
 
-	// Get the member that executed the command.
-	member, err := a.Session().GuildMember(i.GuildID, i.Member.User.ID)
-	if err != nil {
-		return fmt.Errorf("error getting member: %w", err)
-	}
-
-	// Ensure that the user has the ticket role.
-	if !hasRole(member, guild.Ticketing.RoleID) {
-		err = respondEphemeral(a, i, "You do not have the ticket role to claim tickets. [<@&"+guild.Ticketing.RoleID+">]")
-		if err != nil {
-			return fmt.Errorf("error responding to interaction: %w", err)
-		}
-		return nil
-	}
-
 	// Ensure that the ticket is not already claimed.
-	if ticket.ClaimedBy != "" && ticket.ClaimedBy != i.Member.User.ID {
-		err = respondEphemeral(a, i, "This ticket is already claimed by <@"+ticket.ClaimedBy+">.")
+	if ticket.ClaimedBy != "" && ticket.ClaimedBy != c.Member.User.ID {
+		err = c.RespondEphemeral("This ticket is already claimed by <@" + ticket.ClaimedBy + ">.")
 		if err != nil {
 			return fmt.Errorf("error responding to interaction: %w", err)
 		}
 		return nil
-	} else if ticket.ClaimedBy == i.Member.User.ID {
-		err = respondEphemeral(a, i, "You have already claimed this ticket <@"+ticket.ClaimedBy+">")
+	} else if ticket.ClaimedBy == c.Member.User.ID {
+		err = c.RespondEphemeral("You have already claimed this ticket <@" + ticket.ClaimedBy + ">")
 		if err != nil {
 			return fmt.Errorf("error responding to interaction: %w", err)
 		}
@@ -450,71 +522,24 @@ func claimTicketHandler(a IApp, i *discordgo.InteractionCreate) error {
 	}
 
 	// Claim the ticket.
-	ticket.ClaimedBy = i.Member.User.ID
+	ticket.ClaimedBy = c.Member.User.ID
+	ticket.AddEvent(entities.TicketEventClaimed, c.Member.User.ID, entities.TicketEventSourceDiscord)
 
-	// Ensure that the category exists for created tickets.
-	category, err := a.Session().Channel(guild.Ticketing.ClaimedTicketsCategoryID)
+	// Ensure that the category exists for claimed tickets.
+	category, err := ensureTicketCategory(ctx, c.Logger(), c, guild, claimedTicketsCategory, c.Member.User.ID)
 	if err != nil {
-		er := new(discordgo.RESTError)
-		if errors.As(err, &er) && (er.Message.Code == discordgo.ErrCodeUnknownChannel || er.Message.Code == discordgo.ErrCodeGeneralError) { // General is thrown when a 404 is returned.
-			slog.Warn("Claimed tickets category does not exist, creating it now")
-
-			category, err = a.Session().GuildChannelCreateComplex(i.GuildID, discordgo.GuildChannelCreateData{
-				Name: "Claimed Tickets",
-				Type: discordgo.ChannelTypeGuildCategory,
-				PermissionOverwrites: []*discordgo.PermissionOverwrite{
-					// Deny @everyone from seeing the ticket.
-					{
-						ID:    i.GuildID,
-						Type:  discordgo.PermissionOverwriteTypeRole,
-						Allow: 0,
-						Deny:  discordgo.PermissionAll,
-					},
-					// The creator of the ticket can see the ticket.
-					{
-						ID:    i.Member.User.ID,
-						Type:  discordgo.PermissionOverwriteTypeMember,
-						Allow: discordgo.PermissionAllText,
-						Deny:  discordgo.PermissionMentionEveryone,
-					},
-					// Add the ticket role.
-					{
-						ID:    guild.Ticketing.RoleID,
-						Type:  discordgo.PermissionOverwriteTypeRole,
-						Allow: discordgo.PermissionAllText,
-						Deny:  discordgo.PermissionMentionEveryone,
-					},
-				},
-			})
-			if err != nil {
-				return fmt.Errorf("error creating category: %w", err)
-			}
-
-			// Save the guild configuration.
-			guild.Ticketing.ClaimedTicketsCategoryID = category.ID
-			if err := dataaccess.GuildDB.SaveGuild(ctx, guild); err != nil {
-				return fmt.Errorf("error saving guild configuration: %w", err)
-			}
-		} else {
-			return fmt.Errorf("error getting category: %w", err)
-		}
-	} else if category != nil && category.ID != guild.Ticketing.ClaimedTicketsCategoryID {
-		// Update the guild configuration.
-		guild.Ticketing.ClaimedTicketsCategoryID = category.ID
-		if err := dataaccess.GuildDB.SaveGuild(ctx, guild); err != nil {
-			return fmt.Errorf("error saving guild configuration: %w", err)
-		}
+		return err
 	}
 
 	topicStr := calculateTopicString(ticket, ClaimTicketButtonID)
 
 	// Move the ticket to the claimed tickets' category.
-	if _, err := a.Session().ChannelEditComplex(ticket.ChannelID, &discordgo.ChannelEdit{
+	if _, err := c.Session().ChannelEditComplex(ticket.ChannelID, &discordgo.ChannelEdit{
 		Name:     ticket.Name(),
 		Position: &channel.Position,
 		ParentID: category.ID,
 		Topic:    topicStr,
-	}); err != nil {
+	}, discordgo.WithContext(ctx)); err != nil {
 		return fmt.Errorf("error editing channel: %w", err)
 	}
 
@@ -524,46 +549,29 @@ func claimTicketHandler(a IApp, i *discordgo.InteractionCreate) error {
 	}
 
 	// Set the claim button to be disabled.
-	if err := setButtonDisabled(a, i, ClaimTicketButtonID, true); err != nil {
+	if err := setButtonDisabled(ctx, c, ticket, ClaimTicketButtonID, true); err != nil {
 		return fmt.Errorf("error setting button disabled: %w", err)
 	}
 
 	// Respond to the interaction saying that the ticket has been claimed.
-	err = a.Session().InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("<@%s>, you have claimed this ticket.", i.Member.User.ID),
-		},
+	err = c.Respond(&discordgo.InteractionResponseData{
+		Content: fmt.Sprintf("<@%s>, you have claimed this ticket.", c.Member.User.ID),
 	})
 	if err != nil {
 		return fmt.Errorf("error responding to interaction: %w", err)
 	}
 
 	// Update the channel topic.
-	if err := updateChannelTopic(a, ticket, ClaimTicketButtonID); err != nil {
-		slog.Error("Error updating ticket channel topic", slog.String(logging.KeyError, err.Error()))
+	if err := updateChannelTopic(ctx, c, ticket, ClaimTicketButtonID); err != nil {
+		c.Logger().Error("Error updating ticket channel topic", slog.String(logging.KeyError, err.Error()))
 	}
 
 	return nil
 }
 
-func setButtonDisabled(a IApp, i *discordgo.InteractionCreate, buttonID string, disabled bool) error {
-	ctx := context.Background()
-
-	// Get the channel name.
-	channel, err := a.Session().Channel(i.ChannelID)
-	if err != nil {
-		return fmt.Errorf("error getting channel: %w", err)
-	}
-
-	// Get the ticket.
-	ticket, err := dataaccess.TicketDB.GetTicket(ctx, i.GuildID, channel.ID)
-	if err != nil {
-		return fmt.Errorf("error getting ticket: %w", err)
-	}
-
+func setButtonDisabled(ctx context.Context, a IApp, ticket *entities.Ticket, buttonID string, disabled bool) error {
 	// Get the message.
-	msg, err := a.Session().ChannelMessage(channel.ID, ticket.SetupMessageID)
+	msg, err := a.Session().ChannelMessage(ticket.ChannelID, ticket.SetupMessageID, discordgo.WithContext(ctx))
 	if err != nil {
 		return fmt.Errorf("error getting message: %w", err)
 	}
@@ -584,7 +592,7 @@ func setButtonDisabled(a IApp, i *discordgo.InteractionCreate, buttonID string,
 
 	// Update the message.
 	if _, err := a.Session().ChannelMessageEditComplex(&discordgo.MessageEdit{
-		Channel: channel.ID,
+		Channel: ticket.ChannelID,
 		ID:      msg.ID,
 		Content: &NewTicketMessage.Content,
 		Embed:   nil,
@@ -592,110 +600,72 @@ func setButtonDisabled(a IApp, i *discordgo.InteractionCreate, buttonID string,
 		Components: []discordgo.MessageComponent{
 			msg.Components[0],
 		},
-	}); err != nil {
+	}, discordgo.WithContext(ctx)); err != nil {
 		return fmt.Errorf("error editing message: %w", err)
 	}
 
 	return nil
 }
 
-func closeTicketHandler(a IApp, i *discordgo.InteractionCreate) error {
-	ctx := context.Background()
-
-	// Get the channel name.
-	channel, err := a.Session().Channel(i.ChannelID)
-	if err != nil {
-		return fmt.Errorf("error getting channel: %w", err)
-	}
+func closeTicketHandler(c *commands.Context) error {
+	ctx := c.Context()
 
 	// Get the ticket.
-	ticket, err := dataaccess.TicketDB.GetTicket(ctx, i.GuildID, channel.ID)
+	ticket, err := dataaccess.TicketDB.GetTicket(ctx, c.GuildID, c.ChannelID)
 	if err != nil {
 		return fmt.Errorf("error getting ticket: %w", err)
 	}
 
-	// Get the guild configuration.
-	guild, err := dataaccess.GuildDB.GetGuildByID(ctx, i.GuildID)
+	err = closeTicket(ctx, c.Logger(), c, ticket, c.Member.User.ID, entities.TicketEventSourceDiscord)
+	if errors.Is(err, errTicketAlreadyClosed) {
+		err = c.RespondEphemeral("This ticket is already closed.")
+		if err != nil {
+			return fmt.Errorf("error responding to interaction: %w", err)
+		}
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// Respond to the interaction saying that the ticket has been closed.
+	err = c.Respond(&discordgo.InteractionResponseData{
+		Content: fmt.Sprintf("<@%s>, congratulations on closing this ticket.", c.Member.User.ID),
+	})
 	if err != nil {
-		return fmt.Errorf("error getting guild configuration: %w", err)
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// closeTicket moves the ticket to the closed tickets' category and records who closed it. This is shared by the
+// close button and command, and the admin API.
+func closeTicket(ctx context.Context, l *slog.Logger, a IApp, ticket *entities.Ticket, userID string, source entities.TicketEventSource) error {
+	if ticket.Deleted {
+		return errTicketDeleted
 	}
 
-	// Get the member that executed the command.
-	member, err := a.Session().GuildMember(i.GuildID, i.Member.User.ID)
+	// Get the channel.
+	channel, err := a.Session().Channel(ticket.ChannelID, discordgo.WithContext(ctx))
 	if err != nil {
-		return fmt.Errorf("error getting member: %w", err)
+		return fmt.Errorf("error getting channel: %w", err)
 	}
 
-	// Ensure that the user has the ticket role.
-	if !hasRole(member, guild.Ticketing.RoleID) {
-		err = respondEphemeral(a, i, "You do not have the ticket role to claim tickets. [<@&"+guild.Ticketing.RoleID+">]")
-		if err != nil {
-			return fmt.Errorf("error responding to interaction: %w", err)
-		}
-		return nil
+	// Get the guild configuration.
+	guild, err := dataaccess.GuildDB.GetGuildByID(ctx, ticket.GuildID)
+	if err != nil {
+		return fmt.Errorf("error getting guild configuration: %w", err)
 	}
 
 	// Ensure that the ticket is not already closed by using the category ID.
 	if channel.ParentID == guild.Ticketing.ClosedTicketsCategoryID {
-		err = respondEphemeral(a, i, "This ticket is already closed.")
-		if err != nil {
-			return fmt.Errorf("error responding to interaction: %w", err)
-		}
-		return nil
+		return errTicketAlreadyClosed
 	}
 
-	// Ensure that the category exists for created tickets.
-	category, err := a.Session().Channel(guild.Ticketing.ClosedTicketsCategoryID)
+	// Ensure that the category exists for closed tickets.
+	category, err := ensureTicketCategory(ctx, l, a, guild, closedTicketsCategory, userID)
 	if err != nil {
-		er := new(discordgo.RESTError)
-		if errors.As(err, &er) && (er.Message.Code == discordgo.ErrCodeUnknownChannel || er.Message.Code == discordgo.ErrCodeGeneralError) { // General is thrown when a 404 is returned.
-			slog.Warn("Claimed tickets category does not exist, creating it now")
-
-			category, err = a.Session().GuildChannelCreateComplex(i.GuildID, discordgo.GuildChannelCreateData{
-				Name: "Closed Tickets",
-				Type: discordgo.ChannelTypeGuildCategory,
-				PermissionOverwrites: []*discordgo.PermissionOverwrite{
-					// Deny @everyone from seeing the ticket.
-					{
-						ID:    i.GuildID,
-						Type:  discordgo.PermissionOverwriteTypeRole,
-						Allow: 0,
-						Deny:  discordgo.PermissionAll,
-					},
-					// The creator of the ticket can see the ticket.
-					{
-						ID:    i.Member.User.ID,
-						Type:  discordgo.PermissionOverwriteTypeMember,
-						Allow: discordgo.PermissionAllText,
-						Deny:  discordgo.PermissionMentionEveryone,
-					},
-					// Add the ticket role.
-					{
-						ID:    guild.Ticketing.RoleID,
-						Type:  discordgo.PermissionOverwriteTypeRole,
-						Allow: discordgo.PermissionAllText,
-						Deny:  discordgo.PermissionMentionEveryone,
-					},
-				},
-			})
-			if err != nil {
-				return fmt.Errorf("error creating category: %w", err)
-			}
-
-			// Save the guild configuration.
-			guild.Ticketing.ClosedTicketsCategoryID = category.ID
-			if err := dataaccess.GuildDB.SaveGuild(ctx, guild); err != nil {
-				return fmt.Errorf("error saving guild configuration: %w", err)
-			}
-		} else {
-			return fmt.Errorf("error getting category: %w", err)
-		}
-	} else if category != nil && category.ID != guild.Ticketing.ClosedTicketsCategoryID {
-		// Update the guild configuration.
-		guild.Ticketing.ClosedTicketsCategoryID = category.ID
-		if err := dataaccess.GuildDB.SaveGuild(ctx, guild); err != nil {
-			return fmt.Errorf("error saving guild configuration: %w", err)
-		}
+		return err
 	}
 
 	topicStr := calculateTopicString(ticket, CloseTicketButtonID)
@@ -706,12 +676,13 @@ func closeTicketHandler(a IApp, i *discordgo.InteractionCreate) error {
 		Position: &channel.Position,
 		ParentID: category.ID,
 		Topic:    topicStr,
-	}); err != nil {
+	}, discordgo.WithContext(ctx)); err != nil {
 		return fmt.Errorf("error editing channel: %w", err)
 	}
 
 	// Update the ticket.
-	ticket.ClosedBy = i.Member.User.ID
+	ticket.ClosedBy = userID
+	ticket.AddEvent(entities.TicketEventClosed, userID, source)
 
 	// Save the ticket.
 	if err := dataaccess.TicketDB.SaveTicket(ctx, ticket); err != nil {
@@ -719,65 +690,58 @@ func closeTicketHandler(a IApp, i *discordgo.InteractionCreate) error {
 	}
 
 	go func() {
+		// The buttons are updated after the ticket is closed, so they are not cancelled with the request.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), commands.DefaultTimeout)
+		defer cancel()
+
 		// Set the close button to be disabled.
-		if err := setButtonDisabled(a, i, CloseTicketButtonID, true); err != nil {
-			slog.Error("Error setting close button disabled", slog.String(logging.KeyError, err.Error()))
+		if err := setButtonDisabled(ctx, a, ticket, CloseTicketButtonID, true); err != nil {
+			l.Error("Error setting close button disabled", slog.String(logging.KeyError, err.Error()))
 		}
 
 		// Set the reopen button to be enabled.
-		if err := setButtonDisabled(a, i, ReopenTicketButtonID, false); err != nil {
-			slog.Error("Error setting reopen button enabled", slog.String(logging.KeyError, err.Error()))
+		if err := setButtonDisabled(ctx, a, ticket, ReopenTicketButtonID, false); err != nil {
+			l.Error("Error setting reopen button enabled", slog.String(logging.KeyError, err.Error()))
 		}
 
 		// Set the claim button to be disabled.
-		if err := setButtonDisabled(a, i, ClaimTicketButtonID, true); err != nil {
-			slog.Error("Error setting claim button disabled", slog.String(logging.KeyError, err.Error()))
+		if err := setButtonDisabled(ctx, a, ticket, ClaimTicketButtonID, true); err != nil {
+			l.Error("Error setting claim button disabled", slog.String(logging.KeyError, err.Error()))
 		}
 
 		// Set the delete button to be disabled.
-		if err := setButtonDisabled(a, i, DeleteTicketButtonID, true); err != nil {
-			slog.Error("Error setting delete button disabled", slog.String(logging.KeyError, err.Error()))
+		if err := setButtonDisabled(ctx, a, ticket, DeleteTicketButtonID, true); err != nil {
+			l.Error("Error setting delete button disabled", slog.String(logging.KeyError, err.Error()))
 		}
 	}()
 
-	// Respond to the interaction saying that the ticket has been closed.
-	err = a.Session().InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("<@%s>, congratulations on closing this ticket.", i.Member.User.ID),
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("error responding to interaction: %w", err)
-	}
-
 	return nil
 }
 
-func reopenTicketHandler(a IApp, i *discordgo.InteractionCreate) error {
-	ctx := context.Background()
+func reopenTicketHandler(c *commands.Context) error {
+	ctx := c.Context()
 
 	// Get the channel name.
-	channel, err := a.Session().Channel(i.ChannelID)
+	channel, err := c.Session().Channel(c.ChannelID, discordgo.WithContext(ctx))
 	if err != nil {
 		return fmt.Errorf("error getting channel: %w", err)
 	}
 
 	// Get the ticket.
-	ticket, err := dataaccess.TicketDB.GetTicket(ctx, i.GuildID, channel.ID)
+	ticket, err := dataaccess.TicketDB.GetTicket(ctx, c.GuildID, channel.ID)
 	if err != nil {
 		return fmt.Errorf("error getting ticket: %w", err)
 	}
 
 	// Get the guild configuration.
-	guild, err := dataaccess.GuildDB.GetGuildByID(ctx, i.GuildID)
+	guild, err := dataaccess.GuildDB.GetGuildByID(ctx, c.GuildID)
 	if err != nil {
 		return fmt.Errorf("error getting guild configuration: %w", err)
 	}
 
 	// Only the ticket creator can reopen the ticket.
-	if ticket.UserID != i.Member.User.ID {
-		err = respondEphemeral(a, i, "Only the ticket creator can reopen the ticket.")
+	if ticket.UserID != c.Member.User.ID {
+		err = c.RespondEphemeral("Only the ticket creator can reopen the ticket.")
 		if err != nil {
 			return fmt.Errorf("error responding to interaction: %w", err)
 		}
@@ -786,7 +750,7 @@ func reopenTicketHandler(a IApp, i *discordgo.InteractionCreate) error {
 
 	// Ensure that the ticket is not already open by using the category ID.
 	if channel.ParentID == guild.Ticketing.CreatedTicketsCategoryID {
-		err = respondEphemeral(a, i, "This ticket is already open.")
+		err = c.RespondEphemeral("This ticket is already open.")
 		if err != nil {
 			return fmt.Errorf("error responding to interaction: %w", err)
 		}
@@ -794,94 +758,51 @@ func reopenTicketHandler(a IApp, i *discordgo.InteractionCreate) error {
 	}
 
 	// Ensure that the category exists for created tickets.
-	category, err := a.Session().Channel(guild.Ticketing.CreatedTicketsCategoryID)
+	category, err := ensureTicketCategory(ctx, c.Logger(), c, guild, createdTicketsCategory, c.Member.User.ID)
 	if err != nil {
-		er := new(discordgo.RESTError)
-		if errors.As(err, &er) && (er.Message.Code == discordgo.ErrCodeUnknownChannel || er.Message.Code == discordgo.ErrCodeGeneralError) { // General is thrown when a 404 is returned.
-			slog.Warn("Open tickets category does not exist, creating it now")
-
-			category, err = a.Session().GuildChannelCreateComplex(i.GuildID, discordgo.GuildChannelCreateData{
-				Name: "Created Tickets",
-				Type: discordgo.ChannelTypeGuildCategory,
-				PermissionOverwrites: []*discordgo.PermissionOverwrite{
-					// Deny @everyone from seeing the ticket.
-					{
-						ID:    i.GuildID,
-						Type:  discordgo.PermissionOverwriteTypeRole,
-						Allow: 0,
-						Deny:  discordgo.PermissionAll,
-					},
-					// The creator of the ticket can see the ticket.
-					{
-						ID:    i.Member.User.ID,
-						Type:  discordgo.PermissionOverwriteTypeMember,
-						Allow: discordgo.PermissionAllText,
-						Deny:  discordgo.PermissionMentionEveryone,
-					},
-					// Add the ticket role.
-					{
-						ID:    guild.Ticketing.RoleID,
-						Type:  discordgo.PermissionOverwriteTypeRole,
-						Allow: discordgo.PermissionAllText,
-						Deny:  discordgo.PermissionMentionEveryone,
-					},
-				},
-			})
-			if err != nil {
-				return fmt.Errorf("error creating category: %w", err)
-			}
-
-			// Save the guild configuration.
-			guild.Ticketing.CreatedTicketsCategoryID = category.ID
-			if err := dataaccess.GuildDB.SaveGuild(ctx, guild); err != nil {
-				return fmt.Errorf("error saving guild configuration: %w", err)
-			}
-		} else {
-			return fmt.Errorf("error getting category: %w", err)
-		}
-	} else if category != nil && category.ID != guild.Ticketing.CreatedTicketsCategoryID {
-		// Update the guild configuration.
-		guild.Ticketing.CreatedTicketsCategoryID = category.ID
-		if err := dataaccess.GuildDB.SaveGuild(ctx, guild); err != nil {
-			return fmt.Errorf("error saving guild configuration: %w", err)
-		}
+		return err
 	}
 
 	// Set the ticket to be unclaimed.
 	ticket.ClaimedBy = ""
 	ticket.ClosedBy = ""
+	ticket.AddEvent(entities.TicketEventReopened, c.Member.User.ID, entities.TicketEventSourceDiscord)
 
 	topicStr := calculateTopicString(ticket, ReopenTicketButtonID)
 
 	// Move the ticket to the open tickets' category.
-	if _, err := a.Session().ChannelEditComplex(ticket.ChannelID, &discordgo.ChannelEdit{
+	if _, err := c.Session().ChannelEditComplex(ticket.ChannelID, &discordgo.ChannelEdit{
 		Name:     ticket.Name(),
 		Position: &channel.Position,
 		ParentID: category.ID,
 		Topic:    topicStr,
-	}); err != nil {
+	}, discordgo.WithContext(ctx)); err != nil {
 		return fmt.Errorf("error editing channel: %w", err)
 	}
 
 	go func() {
+		// The buttons are updated after the interaction is handled, so they are not cancelled with it.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), commands.DefaultTimeout)
+		defer cancel()
+
 		// Set the close button to be disabled.
-		if err := setButtonDisabled(a, i, CloseTicketButtonID, false); err != nil {
-			slog.Error("Error setting close button disabled", slog.String(logging.KeyError, err.Error()))
+		if err := setButtonDisabled(ctx, c, ticket, CloseTicketButtonID, false); err != nil {
+			c.Logger().Error("Error setting close button disabled", slog.String(logging.KeyError, err.Error()))
 		}
 
 		// Set the reopen button to be enabled.
-		if err := setButtonDisabled(a, i, ReopenTicketButtonID, true); err != nil {
-			slog.Error("Error setting reopen button enabled", slog.String(logging.KeyError, err.Error()))
+		if err := setButtonDisabled(ctx, c, ticket, ReopenTicketButtonID, true); err != nil {
+			c.Logger().Error("Error setting reopen button enabled", slog.String(logging.KeyError, err.Error()))
 		}
 
 		// Set the claim button to be disabled.
-		if err := setButtonDisabled(a, i, ClaimTicketButtonID, false); err != nil {
-			slog.Error("Error setting claim button disabled", slog.String(logging.KeyError, err.Error()))
+		if err := setButtonDisabled(ctx, c, ticket, ClaimTicketButtonID, false); err != nil {
+			c.Logger().Error("Error setting claim button disabled", slog.String(logging.KeyError, err.Error()))
 		}
 
 		// Set the delete button to be disabled.
-		if err := setButtonDisabled(a, i, DeleteTicketButtonID, false); err != nil {
-			slog.Error("Error setting delete button disabled", slog.String(logging.KeyError, err.Error()))
+		if err := setButtonDisabled(ctx, c, ticket, DeleteTicketButtonID, false); err != nil {
+			c.Logger().Error("Error setting delete button disabled", slog.String(logging.KeyError, err.Error()))
 		}
 	}()
 
@@ -891,11 +812,8 @@ func reopenTicketHandler(a IApp, i *discordgo.InteractionCreate) error {
 	}
 
 	// Respond to the interaction saying that the ticket has been reopened.
-	err = a.Session().InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("<@%s>, you have reopened this ticket.", i.Member.User.ID),
-		},
+	err = c.Respond(&discordgo.InteractionResponseData{
+		Content: fmt.Sprintf("<@%s>, you have reopened this ticket.", c.Member.User.ID),
 	})
 	if err != nil {
 		return fmt.Errorf("error responding to interaction: %w", err)
@@ -904,53 +822,27 @@ func reopenTicketHandler(a IApp, i *discordgo.InteractionCreate) error {
 	return nil
 }
 
-func deleteTicketHandler(a IApp, i *discordgo.InteractionCreate) error {
-	ctx := context.Background()
-
-	// Get the guild configuration.
-	guild, err := dataaccess.GuildDB.GetGuildByID(ctx, i.GuildID)
-	if err != nil {
-		return fmt.Errorf("error getting guild configuration: %w", err)
-	}
-
-	// Get the member that executed the command.
-	member, err := a.Session().GuildMember(i.GuildID, i.Member.User.ID)
-	if err != nil {
-		return fmt.Errorf("error getting member: %w", err)
-	}
-
-	// Ensure that the user has the ticket role.
-	if !hasRole(member, guild.Ticketing.RoleID) {
-		err = respondEphemeral(a, i, "You do not have the ticket role to claim tickets. [<@&"+guild.Ticketing.RoleID+">]")
-		if err != nil {
-			return fmt.Errorf("error responding to interaction: %w", err)
-		}
-		return nil
-	}
-
+func deleteTicketHandler(c *commands.Context) error {
 	// Send confirmation embedded message with confirmation buttons.
-	confirmationMessage := &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Flags: discordgo.MessageFlagsEphemeral,
-			Embeds: []*discordgo.MessageEmbed{
-				{
-					Title:       "Please confirm",
-					Description: "Are you sure you want to delete this ticket?",
-					Color:       0x00ff00,
-				},
+	confirmationMessage := &discordgo.InteractionResponseData{
+		Flags: discordgo.MessageFlagsEphemeral,
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       "Please confirm",
+				Description: "Are you sure you want to delete this ticket?",
+				Color:       0x00ff00,
 			},
-			Components: []discordgo.MessageComponent{
-				discordgo.ActionsRow{
-					Components: []discordgo.MessageComponent{
-						discordgo.Button{
-							Label:    fmt.Sprintf("%s Proceed", WasteBasketEmoji),
-							Style:    discordgo.DangerButton,
-							Disabled: false,
-							Emoji:    discordgo.ComponentEmoji{},
-							URL:      "",
-							CustomID: DeleteConfirmationButtonID,
-						},
+		},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    fmt.Sprintf("%s Proceed", WasteBasketEmoji),
+						Style:    discordgo.DangerButton,
+						Disabled: false,
+						Emoji:    discordgo.ComponentEmoji{},
+						URL:      "",
+						CustomID: DeleteConfirmationButtonID,
 					},
 				},
 			},
@@ -958,71 +850,88 @@ func deleteTicketHandler(a IApp, i *discordgo.InteractionCreate) error {
 	}
 
 	// Send the message.
-	err = a.Session().InteractionRespond(i.Interaction, confirmationMessage)
-	if err != nil {
+	if err := c.Respond(confirmationMessage); err != nil {
 		return fmt.Errorf("error responding to interaction: %w", err)
 	}
 
 	return nil
 }
 
-func deleteTicketConfirmationHandler(a IApp, i *discordgo.InteractionCreate) error {
-	ctx := context.Background()
+func deleteTicketConfirmationHandler(c *commands.Context) error {
+	ctx := c.Context()
 
-	// Get the channel name.
-	channel, err := a.Session().Channel(i.ChannelID)
+	// Get the ticket.
+	ticket, err := dataaccess.TicketDB.GetTicket(ctx, c.GuildID, c.ChannelID)
 	if err != nil {
-		return fmt.Errorf("error getting channel: %w", err)
+		return fmt.Errorf("error getting ticket: %w", err)
 	}
 
-	// Get the ticket.
-	ticket, err := dataaccess.TicketDB.GetTicket(ctx, i.GuildID, channel.ID)
+	if err := deleteTicket(ctx, c.Logger(), c, ticket, c.Member.User.ID, entities.TicketEventSourceDiscord); err != nil {
+		return err
+	}
+
+	// Respond to the interaction saying that the ticket has been deleted.
+	err = c.Respond(&discordgo.InteractionResponseData{
+		Content: fmt.Sprintf("<@%s>, this ticket has been deleted. This channel will be deleted in 60 seconds.", c.Member.User.ID),
+	})
 	if err != nil {
-		return fmt.Errorf("error getting ticket: %w", err)
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// deleteTicket marks the ticket as deleted, and schedules its channel to be deleted after ticketChannelDeleteDelay. This
+// is shared by the delete confirmation button and the admin API.
+func deleteTicket(ctx context.Context, l *slog.Logger, a IApp, ticket *entities.Ticket, userID string, source entities.TicketEventSource) error {
+	if ticket.Deleted {
+		return errTicketDeleted
+	}
+
+	// Delete the channel after the delay. This is scheduled, so the channel is deleted even if the bot restarts. It is
+	// scheduled before the ticket is saved, so a deleted ticket never keeps its channel.
+	deleteChannel := &entities.ScheduledAction{
+		Type:     entities.ScheduledActionDeleteChannel,
+		GuildID:  ticket.GuildID,
+		TargetID: ticket.ChannelID,
+	}
+	if err := scheduleAction(ctx, deleteChannel, time.Now().Add(ticketChannelDeleteDelay)); err != nil {
+		return fmt.Errorf("error scheduling channel deletion: %w", err)
 	}
 
 	// Mark the ticket as deleted.
 	ticket.Deleted = true
+	ticket.AddEvent(entities.TicketEventDeleted, userID, source)
 
-	// Save the ticket.
+	// Save the ticket. If it cannot be saved, the ticket is still open, so its channel must not be deleted.
 	if err := dataaccess.TicketDB.SaveTicket(ctx, ticket); err != nil {
+		deleteChannel.Status = entities.ScheduledActionCancelled
+		deleteChannel.CompletedAt = custom.Datetime(time.Now().UTC())
+		if err := dataaccess.ScheduledActionDB.SaveScheduledAction(context.WithoutCancel(ctx), deleteChannel); err != nil {
+			l.Error("Error cancelling channel deletion", slog.String(logging.KeyError, err.Error()))
+		}
 		return fmt.Errorf("error saving ticket: %w", err)
 	}
 
 	go func() {
-		// Update the channel topic.
-		if err := updateChannelTopic(a, ticket, DeleteConfirmationButtonID); err != nil {
-			slog.Error("Error updating channel topic", slog.String(logging.KeyError, err.Error()))
-		}
-	}()
-
-	// Respond to the interaction saying that the ticket has been deleted.
-	err = a.Session().InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("<@%s>, this ticket has been deleted. This channel will be deleted in 60 seconds.", i.Member.User.ID),
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("error responding to interaction: %w", err)
-	}
+		// The channel is updated after the ticket is deleted, so it is not cancelled with the request.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), commands.DefaultTimeout)
+		defer cancel()
 
-	// Delete the channel in 60 seconds on a separate goroutine.
-	go func() {
-		time.Sleep(60 * time.Second)
-		if _, err := a.Session().ChannelDelete(ticket.ChannelID); err != nil {
-			slog.Error("Error deleting channel", slog.String(logging.KeyError, err.Error()))
+		// Update the channel topic.
+		if err := updateChannelTopic(ctx, a, ticket, DeleteConfirmationButtonID); err != nil {
+			l.Error("Error updating channel topic", slog.String(logging.KeyError, err.Error()))
 		}
 	}()
 
 	return nil
 }
 
-func updateChannelTopic(a IApp, ticket *entities.Ticket, newStatus string) error {
+func updateChannelTopic(ctx context.Context, a IApp, ticket *entities.Ticket, newStatus string) error {
 	topicStr := calculateTopicString(ticket, newStatus)
 
 	// Get the channel.
-	channel, err := a.Session().Channel(ticket.ChannelID)
+	channel, err := a.Session().Channel(ticket.ChannelID, discordgo.WithContext(ctx))
 	if err != nil {
 		return fmt.Errorf("error getting channel: %w", err)
 	}
@@ -1039,7 +948,7 @@ func updateChannelTopic(a IApp, ticket *entities.Ticket, newStatus string) error
 		Name:     channel.Name,
 		Position: &channel.Position,
 		Topic:    topicStr,
-	}); err != nil {
+	}, discordgo.WithContext(ctx)); err != nil {
 		slog.Error("Error updating channel topic", slog.String(logging.KeyError, err.Error()))
 		return fmt.Errorf("error editing channel: %w", err)
 	}
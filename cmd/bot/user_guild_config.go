@@ -1,11 +1,14 @@
 package main
 
 import (
-	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/antiraid"
+	"github.com/Jacobbrewer1/wolf/pkg/commands"
+	"github.com/Jacobbrewer1/wolf/pkg/custom"
 	"github.com/Jacobbrewer1/wolf/pkg/dataaccess"
 	"github.com/Jacobbrewer1/wolf/pkg/entities"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -21,95 +24,270 @@ const (
 	// disableTicketingCmdName is the command for all ticketing configuration commands.
 	disableTicketingCmdName = "ticketing_disable"
 
-	// channelCmdName is the text for the channel command.
-	channelCmdName = "channel"
+	// moderationCmdName is the command for the moderation configuration.
+	moderationCmdName = "moderation"
 
-	// roleCmdName is the text for the role command.
-	roleCmdName = "role"
+	// loggingCmdName is the command for the server event logging configuration.
+	loggingCmdName = "logging"
+
+	// raidSetupCmdName is the command for the anti-raid configuration.
+	raidSetupCmdName = "raid"
+
+	// welcomeSetupCmdName is the command for the welcome and goodbye message configuration.
+	welcomeSetupCmdName = "welcome"
+
+	// welcomeMessageCmdName is the command for the welcome message configuration.
+	welcomeMessageCmdName = "message"
+
+	// goodbyeMessageCmdName is the command for the goodbye message configuration.
+	goodbyeMessageCmdName = "goodbye"
+
+	// autoRoleCmdName is the command for the auto role configuration.
+	autoRoleCmdName = "autorole"
+
+	// testWelcomeCmdName is the command that previews the welcome and goodbye messages.
+	testWelcomeCmdName = "test"
+
+	// verificationSetupCmdName is the command for the member verification configuration.
+	verificationSetupCmdName = "verification"
+
+	// verificationConfigCmdName is the command that sets how the members are verified.
+	verificationConfigCmdName = "config"
+
+	// addVerificationQuestionCmdName is the command that adds a verification question.
+	addVerificationQuestionCmdName = "add_question"
+
+	// removeVerificationQuestionCmdName is the command that removes a verification question.
+	removeVerificationQuestionCmdName = "remove_question"
+
+	// rolePersistenceCmdName is the command for the role persistence configuration.
+	rolePersistenceCmdName = "role_persistence"
+
+	// logTypeAll is the option value that configures every type of server event at once.
+	logTypeAll = "all"
 )
 
 var (
 	// setupCmd is the command for all configuration commands.
-	setupCmd = &discordgo.ApplicationCommand{
+	setupCmd = &commands.Command{
 		Name:        setupCmdName,
-		Type:        discordgo.ChatApplicationCommand,
 		Description: "This is the command for all configuration commands.",
-		Options: []*discordgo.ApplicationCommandOption{
+		Permissions: discordgo.PermissionAdministrator,
+		GuildOnly:   true,
+		Ephemeral:   true,
+		Subcommands: []*commands.Command{
 			{
 				Name:        enableTicketingCmdName,
-				Type:        discordgo.ApplicationCommandOptionSubCommand,
 				Description: "This will in the channel you specify.",
-				Options: []*discordgo.ApplicationCommandOption{
+				Options:     new(enableTicketingOptions),
+				Handler:     enableTicketingCmdController,
+			},
+			{
+				Name:        disableTicketingCmdName,
+				Description: "This will disable ticketing for your server.",
+				Handler:     disableTicketingCmdController,
+			},
+			{
+				Name:        moderationCmdName,
+				Description: "This sets the mod-log channel, the mute role and whether members are told about actions.",
+				Options:     new(moderationConfigOptions),
+				Handler:     moderationConfigCmdController,
+			},
+			{
+				Name:        loggingCmdName,
+				Description: "This sets the channel that a type of server event is logged to.",
+				Options:     new(loggingConfigOptions),
+				Handler:     loggingConfigCmdController,
+			},
+			{
+				Name:        raidSetupCmdName,
+				Description: "This sets how join floods are detected and what raid mode does.",
+				Options:     new(raidConfigOptions),
+				Handler:     raidConfigCmdController,
+			},
+			{
+				Name:        welcomeSetupCmdName,
+				Description: "This is the command for the welcome and goodbye messages.",
+				Subcommands: []*commands.Command{
 					{
-						Name:        channelCmdName,
-						Type:        discordgo.ApplicationCommandOptionChannel,
-						Description: "This is the channel you want to enable ticketing in.",
-						Required:    true,
+						Name:        welcomeMessageCmdName,
+						Description: "This sets the message posted, and sent directly, when a member joins.",
+						Options:     new(welcomeMessageOptions),
+						Handler:     welcomeMessageCmdController,
 					},
 					{
-						Name:        roleCmdName,
-						Type:        discordgo.ApplicationCommandOptionRole,
-						Description: "This is the role you want to handle tickets.",
-						Required:    true,
+						Name:        goodbyeMessageCmdName,
+						Description: "This sets the message posted when a member leaves.",
+						Options:     new(goodbyeMessageOptions),
+						Handler:     goodbyeMessageCmdController,
+					},
+					{
+						Name:        autoRoleCmdName,
+						Description: "This adds or removes a role that is given to the members that join.",
+						Options:     new(autoRoleOptions),
+						Handler:     autoRoleCmdController,
+					},
+					{
+						Name:        testWelcomeCmdName,
+						Description: "This previews the welcome or goodbye message as if you had joined or left.",
+						Options:     new(testWelcomeOptions),
+						Handler:     testWelcomeCmdController,
 					},
 				},
 			},
 			{
-				Name:        disableTicketingCmdName,
-				Type:        discordgo.ApplicationCommandOptionSubCommand,
-				Description: "This will disable ticketing for your server.",
+				Name:        verificationSetupCmdName,
+				Description: "This is the command for the verification of the members that join.",
+				Subcommands: []*commands.Command{
+					{
+						Name:        verificationConfigCmdName,
+						Description: "This sets how the members that join are verified.",
+						Options:     new(verificationConfigOptions),
+						Handler:     verificationConfigCmdController,
+					},
+					{
+						Name:        addVerificationQuestionCmdName,
+						Description: "This adds a question that members answer to be verified.",
+						Options:     new(addVerificationQuestionOptions),
+						Handler:     addVerificationQuestionCmdController,
+					},
+					{
+						Name:        removeVerificationQuestionCmdName,
+						Description: "This removes a question that members answer to be verified.",
+						Options:     new(removeVerificationQuestionOptions),
+						Handler:     removeVerificationQuestionCmdController,
+					},
+				},
+			},
+			{
+				Name:        rolePersistenceCmdName,
+				Description: "This sets which roles are restored to the members that leave and rejoin.",
+				Options:     new(rolePersistenceOptions),
+				Handler:     rolePersistenceCmdController,
 			},
 		},
 	}
 )
 
-func setupCmdController(a IApp, i *discordgo.InteractionCreate) (commandProcessor, error) {
-	// Ensure the user is an administrator.
-	if i.Member.Permissions&discordgo.PermissionAdministrator != discordgo.PermissionAdministrator {
-		err := respondEphemeral(a, i, "You must be an administrator to use this command")
+// enableTicketingOptions are the options for the enable ticketing command.
+type enableTicketingOptions struct {
+	// Channel is the channel to enable ticketing in.
+	Channel *discordgo.Channel `option:"channel" description:"This is the channel you want to enable ticketing in." required:"true" channel_types:"text"`
+
+	// Role is the role that handles tickets.
+	Role *discordgo.Role `option:"role" description:"This is the role you want to handle tickets." required:"true"`
+}
+
+// moderationConfigOptions are the options for the moderation configuration command.
+type moderationConfigOptions struct {
+	// LogChannel is the channel that moderation cases are posted to.
+	LogChannel *discordgo.Channel `option:"log_channel" description:"This is the channel that moderation cases are posted to." required:"true" channel_types:"text"`
+
+	// DMTargets is whether members are sent a direct message when an action is taken against them.
+	DMTargets bool `option:"dm_targets" description:"This is whether members are sent a direct message when an action is taken against them."`
+
+	// MuteRole is the role that is given to muted members.
+	MuteRole *discordgo.Role `option:"mute_role" description:"This is the role that is given to muted members."`
+}
+
+// loggingConfigOptions are the options for the logging configuration command.
+type loggingConfigOptions struct {
+	// Type is the type of server event to configure.
+	Type string `option:"type" description:"This is the type of server event to configure." required:"true" choices:"all,message_edit,message_delete,message_bulk_delete,member_join,member_leave,member_ban,member_nickname,member_roles,channel_create,channel_delete,invite_create,verification"`
+
+	// Channel is the channel the events are logged to. The events are not logged when it is not set.
+	Channel *discordgo.Channel `option:"channel" description:"This is the channel the events are logged to. Leave it empty to stop logging them." channel_types:"text"`
+}
+
+// raidConfigOptions are the options for the anti-raid configuration command. The settings that are not given are
+// kept.
+type raidConfigOptions struct {
+	// Enabled is whether join floods are detected.
+	Enabled bool `option:"enabled" description:"This is whether join floods are detected." required:"true"`
+
+	// JoinThreshold is the number of suspicious joins within the window that starts raid mode.
+	JoinThreshold int `option:"join_threshold" description:"This is the number of suspicious joins within the window that starts raid mode." min:"2" max:"1000"`
+
+	// JoinWindow is how far back the joins are counted, such as 10s.
+	JoinWindow string `option:"join_window" description:"This is how far back the joins are counted, such as 10s."`
+
+	// AccountAge is the age under which an account that joins is suspicious, such as 7d, or 0 to count every account.
+	AccountAge string `option:"account_age" description:"This is the age under which a joining account is suspicious, such as 7d, or 0 for all."`
+
+	// Action is the action taken against the members that join in raid mode.
+	Action string `option:"action" description:"This is the action taken against the members that join in raid mode." choices:"none,kick,quarantine"`
+
+	// QuarantineRole is the role given to the members that join in raid mode, when they are quarantined.
+	QuarantineRole *discordgo.Role `option:"quarantine_role" description:"This is the role given to the members that join in raid mode."`
+
+	// VerificationLevel is the verification level that the server is raised to in raid mode.
+	VerificationLevel string `option:"verification_level" description:"This is the verification level that the server is raised to in raid mode." choices:"low,medium,high,very_high"`
+
+	// CoolDown is how long raid mode lasts, such as 30m.
+	CoolDown string `option:"cool_down" description:"This is how long raid mode lasts before it is left, such as 30m."`
+
+	// joinWindow is the parsed JoinWindow.
+	joinWindow time.Duration
+
+	// accountAge is the parsed AccountAge. It is negative when every account is suspicious.
+	accountAge time.Duration
+
+	// coolDown is the parsed CoolDown.
+	coolDown time.Duration
+}
+
+// Validate parses the durations of the configuration.
+func (o *raidConfigOptions) Validate() error {
+	if o.JoinWindow != "" {
+		d, err := parseModerationDuration(o.JoinWindow)
 		if err != nil {
-			return nil, nil
+			return err
 		}
-		return nil, nil
+		if d > antiraid.MaxJoinWindow {
+			return commands.NewUserError("The join window can be at most %s.", custom.FormatDuration(antiraid.MaxJoinWindow))
+		}
+		o.joinWindow = d
 	}
 
-	// Extract the sub command.
-	subCmd := i.ApplicationCommandData().Options[0].Name
+	if o.AccountAge == "0" {
+		o.accountAge = -1
+	} else if o.AccountAge != "" {
+		d, err := parseModerationDuration(o.AccountAge)
+		if err != nil {
+			return err
+		}
+		o.accountAge = d
+	}
 
-	switch subCmd {
-	case enableTicketingCmdName:
-		return enableTicketingCmdController, nil
-	case disableTicketingCmdName:
-		return disableTicketingCmdController, nil
-	default:
-		return nil, fmt.Errorf("unhandled sub command %s", subCmd)
+	if o.CoolDown != "" {
+		d, err := parseModerationDuration(o.CoolDown)
+		if err != nil {
+			return err
+		}
+		o.coolDown = d
 	}
+	return nil
 }
 
 // enableTicketingCmdController is the controller for the enable ticketing command.
-func enableTicketingCmdController(a IApp, i *discordgo.InteractionCreate) error {
-	ctx := context.Background()
+func enableTicketingCmdController(c *commands.Context) error {
+	ctx := c.Context()
 
-	// Extract the channel provided.
-	channel := i.ApplicationCommandData().Options[0].Options[0].ChannelValue(a.Session())
+	opts := c.Options().(*enableTicketingOptions)
 
-	// Extract the role provided.
-	role := i.ApplicationCommandData().Options[0].Options[1].RoleValue(a.Session(), i.GuildID)
-
-	// Ensure the channel is a text channel.
-	if channel.Type != discordgo.ChannelTypeGuildText {
-		return respondEphemeral(a, i, "You must provide a text channel for ticketing.")
-	}
+	// Extract the channel and role provided.
+	channel := opts.Channel
+	role := opts.Role
 
 	// Get the guild.
-	guild, err := dataaccess.GuildDB.GetGuildByID(ctx, i.GuildID)
+	guild, err := dataaccess.GuildDB.GetGuildByID(ctx, c.GuildID)
 	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
 		return fmt.Errorf("error getting guild: %w", err)
 	}
 
 	if guild == nil {
 		guild = &entities.Guild{
-			ID: i.GuildID,
+			ID: c.GuildID,
 		}
 	}
 
@@ -127,7 +305,7 @@ func enableTicketingCmdController(a IApp, i *discordgo.InteractionCreate) error
 	// Check to see if the ticketing message still exists.
 	if guild.Ticketing.OpenMessageID != "" {
 		// Get the ticketing message.
-		msg, err = a.Session().ChannelMessage(channel.ID, guild.Ticketing.OpenMessageID)
+		msg, err = c.Session().ChannelMessage(channel.ID, guild.Ticketing.OpenMessageID, discordgo.WithContext(ctx))
 		// If the message does not exist, set the message ID to an empty string.
 		if err != nil {
 			var restErr *discordgo.RESTError
@@ -148,7 +326,7 @@ func enableTicketingCmdController(a IApp, i *discordgo.InteractionCreate) error
 	// If the ticketing message ID is empty, send a new message.
 	if guild.Ticketing.OpenMessageID == "" {
 		// Send the ticketing message to the channel.
-		msg, err = sendOpenTicketMessage(a, channel)
+		msg, err = sendOpenTicketMessage(ctx, c, channel)
 		if err != nil {
 			return fmt.Errorf("error sending open ticket message: %w", err)
 		}
@@ -163,7 +341,7 @@ func enableTicketingCmdController(a IApp, i *discordgo.InteractionCreate) error
 	}
 
 	// Respond to the interaction saying that ticketing has been enabled in channel <channel>.
-	if err := respondEphemeral(a, i, fmt.Sprintf("Ticketing has been enabled in channel <#%s>", channel.ID)); err != nil {
+	if err := c.RespondEphemeral(fmt.Sprintf("Ticketing has been enabled in channel <#%s>", channel.ID)); err != nil {
 		return fmt.Errorf("error responding to interaction: %w", err)
 	}
 
@@ -171,18 +349,18 @@ func enableTicketingCmdController(a IApp, i *discordgo.InteractionCreate) error
 }
 
 // disableTicketingCmdController is the controller for the disable ticketing command.
-func disableTicketingCmdController(a IApp, i *discordgo.InteractionCreate) error {
-	ctx := context.Background()
+func disableTicketingCmdController(c *commands.Context) error {
+	ctx := c.Context()
 
 	// Get the guild.
-	guild, err := dataaccess.GuildDB.GetGuildByID(ctx, i.GuildID)
+	guild, err := dataaccess.GuildDB.GetGuildByID(ctx, c.GuildID)
 	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
 		return fmt.Errorf("error getting guild: %w", err)
 	}
 
 	if guild == nil {
 		guild = &entities.Guild{
-			ID: i.GuildID,
+			ID: c.GuildID,
 		}
 	}
 
@@ -195,7 +373,196 @@ func disableTicketingCmdController(a IApp, i *discordgo.InteractionCreate) error
 	}
 
 	// Respond to the interaction saying that ticketing has been disabled.
-	if err := respondEphemeral(a, i, "Ticketing has been disabled"); err != nil {
+	if err := c.RespondEphemeral("Ticketing has been disabled"); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// moderationConfigCmdController is the controller for the moderation configuration command.
+func moderationConfigCmdController(c *commands.Context) error {
+	ctx := c.Context()
+
+	opts := c.Options().(*moderationConfigOptions)
+
+	// Get the guild.
+	guild, err := dataaccess.GuildDB.GetGuildByID(ctx, c.GuildID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("error getting guild: %w", err)
+	}
+
+	if guild == nil {
+		guild = &entities.Guild{
+			ID: c.GuildID,
+		}
+	}
+
+	// Set the moderation configuration.
+	guild.Moderation.LogChannelID = opts.LogChannel.ID
+	guild.Moderation.DMTargets = opts.DMTargets
+	guild.Moderation.MuteRoleID = ""
+	if opts.MuteRole != nil {
+		guild.Moderation.MuteRoleID = opts.MuteRole.ID
+	}
+
+	// Save the guild.
+	if err := dataaccess.GuildDB.SaveGuild(ctx, guild); err != nil {
+		return fmt.Errorf("error saving guild: %w", err)
+	}
+
+	msg := fmt.Sprintf("Moderation cases will be posted in channel <#%s>", opts.LogChannel.ID)
+	if opts.DMTargets {
+		msg += ", members will be sent a direct message when an action is taken against them"
+	}
+	if opts.MuteRole != nil {
+		msg += fmt.Sprintf(", muted members will be given the role <@&%s>", opts.MuteRole.ID)
+	}
+
+	// Respond to the interaction with the new configuration.
+	if err := c.RespondEphemeral(msg); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// loggingConfigCmdController is the controller for the logging configuration command.
+func loggingConfigCmdController(c *commands.Context) error {
+	ctx := c.Context()
+
+	opts := c.Options().(*loggingConfigOptions)
+
+	// Get the guild.
+	guild, err := dataaccess.GuildDB.GetGuildByID(ctx, c.GuildID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("error getting guild: %w", err)
+	}
+
+	if guild == nil {
+		guild = &entities.Guild{
+			ID: c.GuildID,
+		}
+	}
+
+	types := []entities.LogType{entities.LogType(opts.Type)}
+	if opts.Type == logTypeAll {
+		types = entities.LogTypes
+	}
+
+	channelID := ""
+	if opts.Channel != nil {
+		channelID = opts.Channel.ID
+	}
+
+	// Set the logging configuration.
+	for _, t := range types {
+		guild.Logging.SetChannel(t, channelID)
+	}
+
+	// Save the guild.
+	if err := dataaccess.GuildDB.SaveGuild(ctx, guild); err != nil {
+		return fmt.Errorf("error saving guild: %w", err)
+	}
+
+	msg := fmt.Sprintf("Events of type %s will no longer be logged", opts.Type)
+	if opts.Channel != nil {
+		msg = fmt.Sprintf("Events of type %s will be logged in channel <#%s>", opts.Type, opts.Channel.ID)
+	}
+
+	// Respond to the interaction with the new configuration.
+	if err := c.RespondEphemeral(msg); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// raidConfigCmdController is the controller for the anti-raid configuration command.
+func raidConfigCmdController(c *commands.Context) error {
+	ctx := c.Context()
+
+	opts := c.Options().(*raidConfigOptions)
+
+	// Get the guild.
+	guild, err := dataaccess.GuildDB.GetGuildByID(ctx, c.GuildID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("error getting guild: %w", err)
+	}
+
+	if guild == nil {
+		guild = &entities.Guild{
+			ID: c.GuildID,
+		}
+	}
+
+	// Set the anti-raid configuration, defaulting the settings that have never been set.
+	cfg := &guild.Raid
+	cfg.Enabled = opts.Enabled
+	if opts.JoinThreshold > 0 {
+		cfg.JoinThreshold = opts.JoinThreshold
+	} else if cfg.JoinThreshold == 0 {
+		cfg.JoinThreshold = antiraid.DefaultJoinThreshold
+	}
+	if opts.joinWindow > 0 {
+		cfg.JoinWindow = opts.joinWindow
+	} else if cfg.JoinWindow == 0 {
+		cfg.JoinWindow = antiraid.DefaultJoinWindow
+	}
+	switch {
+	case opts.accountAge < 0:
+		cfg.MaxAccountAge = 0
+	case opts.accountAge > 0:
+		cfg.MaxAccountAge = opts.accountAge
+	case opts.AccountAge == "" && cfg.Action == "":
+		// The configuration has never been set.
+		cfg.MaxAccountAge = antiraid.DefaultMaxAccountAge
+	}
+	if opts.Action != "" {
+		cfg.Action = entities.RaidAction(opts.Action)
+	} else if cfg.Action == "" {
+		cfg.Action = entities.RaidActionNone
+	}
+	if opts.QuarantineRole != nil {
+		cfg.QuarantineRoleID = opts.QuarantineRole.ID
+	}
+	if opts.VerificationLevel != "" {
+		cfg.VerificationLevel = int(raidVerificationLevels[opts.VerificationLevel])
+	} else if cfg.VerificationLevel == 0 {
+		cfg.VerificationLevel = int(discordgo.VerificationLevelHigh)
+	}
+	if opts.coolDown > 0 {
+		cfg.CoolDown = opts.coolDown
+	} else if cfg.CoolDown == 0 {
+		cfg.CoolDown = antiraid.DefaultCoolDown
+	}
+
+	if cfg.Action == entities.RaidActionQuarantine && cfg.QuarantineRoleID == "" {
+		return commands.NewUserError("A quarantine role is needed to quarantine the members that join in raid mode.")
+	}
+
+	// Save the guild.
+	if err := dataaccess.GuildDB.SaveGuild(ctx, guild); err != nil {
+		return fmt.Errorf("error saving guild: %w", err)
+	}
+
+	msg := "Anti-raid protection has been disabled"
+	if cfg.Enabled {
+		accounts := "accounts"
+		if cfg.MaxAccountAge > 0 {
+			accounts = fmt.Sprintf("accounts younger than %s", custom.FormatDuration(cfg.MaxAccountAge))
+		}
+		msg = fmt.Sprintf("Raid mode will start when %d %s join within %s, and last %s. New members: %s",
+			cfg.JoinThreshold,
+			accounts,
+			custom.FormatDuration(cfg.JoinWindow),
+			custom.FormatDuration(cfg.CoolDown),
+			raidActionDescription(guild),
+		)
+	}
+
+	// Respond to the interaction with the new configuration.
+	if err := c.RespondEphemeral(msg); err != nil {
 		return fmt.Errorf("error responding to interaction: %w", err)
 	}
 
@@ -0,0 +1,867 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/captcha"
+	"github.com/Jacobbrewer1/wolf/pkg/commands"
+	"github.com/Jacobbrewer1/wolf/pkg/custom"
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"github.com/Jacobbrewer1/wolf/pkg/request"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// VerifyButtonID is the custom ID of the button of the verification message, which starts the challenge.
+	VerifyButtonID = "verify_button"
+
+	// VerifyAnswerButtonID is the prefix of the custom IDs of the buttons that open the modal to answer a captcha. The
+	// ID of the challenge is encoded after it.
+	VerifyAnswerButtonID = "verify_answer_button"
+
+	// VerifyModalID is the prefix of the custom IDs of the verification modals. For captchas, the ID of the challenge
+	// is encoded after it.
+	VerifyModalID = "verify_modal"
+)
+
+const (
+	// MaxVerificationQuestions is the most questions that can be asked. This is the most inputs a modal can have.
+	MaxVerificationQuestions = 5
+
+	// verificationQuestionLength is the longest question that can be asked. This is the longest label of an input.
+	verificationQuestionLength = 45
+
+	// verificationAnswerLength is the longest answer to a question.
+	verificationAnswerLength = 100
+
+	// verificationChallengeTTL is how long a captcha can be answered for.
+	verificationChallengeTTL = 5 * time.Minute
+
+	// verificationMaxAttempts is the number of wrong answers to a captcha before a new one must be started.
+	verificationMaxAttempts = 3
+
+	// verificationMaxSuccessRoles is the most roles that can be given to the members that are verified.
+	verificationMaxSuccessRoles = 10
+
+	// verificationAnswerInputID is the custom ID of the input of the captcha modal.
+	verificationAnswerInputID = "answer"
+
+	// verificationCaptchaFile is the name of the captcha image when it is attached to the challenge.
+	verificationCaptchaFile = "captcha.png"
+
+	// verificationReason is the audit log reason of the role changes of the members that are verified.
+	verificationReason = "Verified"
+
+	// verificationJoinReason is the audit log reason of the unverified role given to the members that join.
+	verificationJoinReason = "Awaiting verification"
+
+	// verificationKickReason is the audit log reason of the members kicked for not being verified in time.
+	verificationKickReason = "Not verified in time"
+)
+
+// challengeIDPattern matches the IDs of the verification challenges.
+var challengeIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// verificationConfigOptions are the options for the verification configuration command. The settings that are not
+// given are kept.
+type verificationConfigOptions struct {
+	// Enabled is whether the members that join must be verified.
+	Enabled bool `option:"enabled" description:"This is whether the members that join must be verified." required:"true"`
+
+	// Mode is the challenge that the members pass to be verified.
+	Mode string `option:"mode" description:"This is the challenge that the members pass to be verified." choices:"button,math,text,questions"`
+
+	// Channel is the channel the verification message is posted in.
+	Channel *discordgo.Channel `option:"channel" description:"This is the channel the verification message is posted in." channel_types:"text,news"`
+
+	// UnverifiedRole is the role given to the members that join, until they are verified.
+	UnverifiedRole *discordgo.Role `option:"unverified_role" description:"This is the role given to the members that join, until they are verified."`
+
+	// SuccessRoles are the mentions or IDs of the roles given to the members that are verified, or none.
+	SuccessRoles string `option:"success_roles" description:"These are the roles given to the verified members, as mentions or IDs, or none."`
+
+	// Timeout is how long the members have to be verified before they are kicked, such as 1h, or 0 to never kick them.
+	Timeout string `option:"timeout" description:"This is how long members have to verify before they are kicked, such as 1h, or 0 for never."`
+
+	// successRoleIDs are the IDs parsed from SuccessRoles.
+	successRoleIDs []string
+
+	// timeout is the parsed Timeout. It is negative when the members are never kicked.
+	timeout time.Duration
+}
+
+// Validate parses the success roles and the timeout.
+func (o *verificationConfigOptions) Validate() error {
+	for _, id := range roleIDPattern.FindAllString(o.SuccessRoles, -1) {
+		if !slices.Contains(o.successRoleIDs, id) {
+			o.successRoleIDs = append(o.successRoleIDs, id)
+		}
+	}
+
+	switch {
+	case o.SuccessRoles != "" && o.SuccessRoles != noneOption && len(o.successRoleIDs) == 0:
+		return commands.NewUserError("Mention the roles to give, or use none to give no roles.")
+	case len(o.successRoleIDs) > verificationMaxSuccessRoles:
+		return commands.NewUserError("At most %d roles can be given to the verified members.", verificationMaxSuccessRoles)
+	}
+
+	if o.Timeout == "0" {
+		o.timeout = -1
+	} else if o.Timeout != "" {
+		d, err := parseModerationDuration(o.Timeout)
+		if err != nil {
+			return err
+		}
+		o.timeout = d
+	}
+	return nil
+}
+
+// addVerificationQuestionOptions are the options for the add verification question command.
+type addVerificationQuestionOptions struct {
+	// Question is the question.
+	Question string `option:"question" description:"This is the question, which is at most 45 characters." required:"true"`
+
+	// Answer is the answer to the question.
+	Answer string `option:"answer" description:"This is the answer to the question. The case of the answer is ignored." required:"true"`
+}
+
+// Validate checks the lengths of the question and the answer.
+func (o *addVerificationQuestionOptions) Validate() error {
+	o.Question = strings.TrimSpace(o.Question)
+	o.Answer = strings.TrimSpace(o.Answer)
+
+	switch {
+	case o.Question == "" || o.Answer == "":
+		return commands.NewUserError("The question and the answer cannot be empty.")
+	case len([]rune(o.Question)) > verificationQuestionLength:
+		return commands.NewUserError("The question can be at most %d characters.", verificationQuestionLength)
+	case len([]rune(o.Answer)) > verificationAnswerLength:
+		return commands.NewUserError("The answer can be at most %d characters.", verificationAnswerLength)
+	}
+	return nil
+}
+
+// removeVerificationQuestionOptions are the options for the remove verification question command.
+type removeVerificationQuestionOptions struct {
+	// Number is the number of the question.
+	Number int `option:"number" description:"This is the number of the question." required:"true" min:"1" max:"5"`
+}
+
+// verificationConfigCmdController is the controller for the verification configuration command. The verification
+// message is posted, or moved to the new channel, when verification is enabled and removed when it is disabled.
+func verificationConfigCmdController(c *commands.Context) error {
+	ctx := c.Context()
+
+	opts := c.Options().(*verificationConfigOptions)
+
+	guild, err := getGuildConfig(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+
+	// Set the verification configuration, defaulting the settings that have never been set.
+	cfg := &guild.Verification
+	previous := *cfg
+	cfg.Enabled = opts.Enabled
+	if opts.Mode != "" {
+		cfg.Mode = entities.VerificationMode(opts.Mode)
+	} else if cfg.Mode == "" {
+		cfg.Mode = entities.VerificationModeButton
+	}
+	if opts.Channel != nil {
+		cfg.ChannelID = opts.Channel.ID
+	}
+	if opts.UnverifiedRole != nil {
+		cfg.UnverifiedRoleID = opts.UnverifiedRole.ID
+	}
+	if opts.SuccessRoles == noneOption {
+		cfg.SuccessRoleIDs = nil
+	} else if len(opts.successRoleIDs) > 0 {
+		cfg.SuccessRoleIDs = opts.successRoleIDs
+	}
+	switch {
+	case opts.timeout < 0:
+		cfg.Timeout = 0
+	case opts.timeout > 0:
+		cfg.Timeout = opts.timeout
+	}
+
+	if cfg.Enabled {
+		switch {
+		case cfg.ChannelID == "":
+			return commands.NewUserError("A channel is needed to post the verification message in.")
+		case cfg.UnverifiedRoleID == "":
+			return commands.NewUserError("An unverified role is needed to give to the members that join.")
+		case cfg.Mode == entities.VerificationModeQuestions && len(cfg.Questions) == 0:
+			return commands.NewUserError("Add a question with `/setup verification add_question` to ask questions.")
+		}
+
+		// The bot gives and takes the roles, so it must be able to manage them.
+		if _, err := assignableRoles(ctx, c, append([]string{cfg.UnverifiedRoleID}, cfg.SuccessRoleIDs...)); err != nil {
+			return err
+		}
+	}
+
+	// The message is moved when the channel changes, and removed when verification is disabled.
+	if previous.MessageID != "" && (!cfg.Enabled || previous.ChannelID != cfg.ChannelID) {
+		err := c.Session().ChannelMessageDelete(previous.ChannelID, previous.MessageID, discordgo.WithContext(ctx))
+		if err != nil && !isNotFound(err) {
+			c.Logger().Warn("Error deleting verification message", slog.String(logging.KeyError, err.Error()))
+		}
+		cfg.MessageID = ""
+	}
+	if cfg.Enabled {
+		if err := postVerificationMessage(ctx, c.Session(), cfg); err != nil {
+			return err
+		}
+	}
+
+	// Save the guild. The questions are left as they are, as they are changed by their own commands.
+	err = dataaccess.GuildDB.UpdateGuild(ctx, c.GuildID, &dataaccess.GuildUpdate{
+		Set: map[string]any{
+			"verification.enabled":            cfg.Enabled,
+			"verification.mode":               cfg.Mode,
+			"verification.channel_id":         cfg.ChannelID,
+			"verification.message_id":         cfg.MessageID,
+			"verification.unverified_role_id": cfg.UnverifiedRoleID,
+			"verification.success_role_ids":   cfg.SuccessRoleIDs,
+			"verification.timeout":            cfg.Timeout,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error saving guild: %w", err)
+	}
+
+	msg := "Verification has been disabled"
+	if cfg.Enabled {
+		msg = fmt.Sprintf("Members that join will be given <@&%s> until they are verified in <#%s> with the %s challenge",
+			cfg.UnverifiedRoleID, cfg.ChannelID, cfg.Mode)
+		if len(cfg.SuccessRoleIDs) > 0 {
+			msg += fmt.Sprintf(", and then given %s", roleMentions(cfg.SuccessRoleIDs))
+		}
+		if cfg.Timeout > 0 {
+			msg += fmt.Sprintf(". Members that are not verified within %s will be kicked", custom.FormatDuration(cfg.Timeout))
+		}
+		msg += fmt.Sprintf(". Make sure that <@&%s> can only see <#%s>.", cfg.UnverifiedRoleID, cfg.ChannelID)
+	}
+
+	// Respond to the interaction with the new configuration.
+	if err := c.RespondEphemeral(msg); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// postVerificationMessage posts the verification message in the channel, or updates it if it has already been
+// posted. The ID of the message is set on the configuration.
+func postVerificationMessage(ctx context.Context, s *discordgo.Session, cfg *entities.VerificationConfig) error {
+	embeds := []*discordgo.MessageEmbed{verificationEmbed(cfg)}
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Verify",
+					Style:    discordgo.SuccessButton,
+					CustomID: VerifyButtonID,
+				},
+			},
+		},
+	}
+
+	if cfg.MessageID != "" {
+		_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:         cfg.MessageID,
+			Channel:    cfg.ChannelID,
+			Embeds:     embeds,
+			Components: components,
+		}, discordgo.WithContext(ctx))
+		if err == nil {
+			return nil
+		} else if !isNotFound(err) {
+			return fmt.Errorf("error editing verification message: %w", err)
+		}
+	}
+
+	// The message has not been posted, or it has been deleted.
+	msg, err := s.ChannelMessageSendComplex(cfg.ChannelID, &discordgo.MessageSend{
+		Embeds:     embeds,
+		Components: components,
+	}, discordgo.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("error sending verification message: %w", err)
+	}
+
+	cfg.MessageID = msg.ID
+	return nil
+}
+
+// verificationEmbed returns the embed of the verification message, which describes the challenge.
+func verificationEmbed(cfg *entities.VerificationConfig) *discordgo.MessageEmbed {
+	var challenge string
+	switch cfg.Mode {
+	case entities.VerificationModeMath:
+		challenge = "solve the sum in the image you are shown"
+	case entities.VerificationModeText:
+		challenge = "type the text in the image you are shown"
+	case entities.VerificationModeQuestions:
+		challenge = "answer the questions about the rules"
+	default:
+		challenge = "that is all"
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "Verification",
+		Description: fmt.Sprintf("Press the button below to get access to the server, then %s.", challenge),
+		Color:       0x0099ff,
+	}
+}
+
+// addVerificationQuestionCmdController is the controller for the add verification question command.
+func addVerificationQuestionCmdController(c *commands.Context) error {
+	ctx := c.Context()
+
+	opts := c.Options().(*addVerificationQuestionOptions)
+
+	guild, err := getGuildConfig(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+
+	cfg := &guild.Verification
+	if len(cfg.Questions) >= MaxVerificationQuestions {
+		return commands.NewUserError("At most %d questions can be asked.", MaxVerificationQuestions)
+	}
+	question := &entities.VerificationQuestion{
+		Question: opts.Question,
+		Answer:   opts.Answer,
+	}
+	cfg.Questions = append(cfg.Questions, question)
+
+	// Save the guild.
+	update := &dataaccess.GuildUpdate{Push: map[string]any{"verification.questions": question}}
+	if err := dataaccess.GuildDB.UpdateGuild(ctx, c.GuildID, update); err != nil {
+		return fmt.Errorf("error saving guild: %w", err)
+	}
+
+	if err := c.RespondEphemeral(verificationQuestionList(cfg)); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// removeVerificationQuestionCmdController is the controller for the remove verification question command.
+func removeVerificationQuestionCmdController(c *commands.Context) error {
+	ctx := c.Context()
+
+	opts := c.Options().(*removeVerificationQuestionOptions)
+
+	guild, err := getGuildConfig(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+
+	cfg := &guild.Verification
+	switch {
+	case opts.Number > len(cfg.Questions):
+		return commands.NewUserError("Question #%d does not exist.", opts.Number)
+	case len(cfg.Questions) == 1 && cfg.Enabled && cfg.Mode == entities.VerificationModeQuestions:
+		return commands.NewUserError("The last question cannot be removed while members are verified with questions.")
+	}
+	removed := cfg.Questions[opts.Number-1]
+	cfg.Questions = append(cfg.Questions[:opts.Number-1], cfg.Questions[opts.Number:]...)
+
+	// Save the guild. The question is removed by its content, as its number changes when other questions are removed.
+	update := &dataaccess.GuildUpdate{Pull: map[string]any{"verification.questions": removed}}
+	if err := dataaccess.GuildDB.UpdateGuild(ctx, c.GuildID, update); err != nil {
+		return fmt.Errorf("error saving guild: %w", err)
+	}
+
+	if err := c.RespondEphemeral(verificationQuestionList(cfg)); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// verificationQuestionList returns the list of the questions and their answers.
+func verificationQuestionList(cfg *entities.VerificationConfig) string {
+	if len(cfg.Questions) == 0 {
+		return "No questions are asked."
+	}
+
+	lines := make([]string, 0, len(cfg.Questions)+1)
+	lines = append(lines, "The questions that are asked are:")
+	for i, q := range cfg.Questions {
+		lines = append(lines, fmt.Sprintf("%d. %s: ||%s||", i+1, q.Question, q.Answer))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// verificationJoinHandler gives the unverified role to the members that join, and schedules them to be kicked if
+// they are not verified in time.
+func (a *App) verificationJoinHandler() func(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+	return func(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+		if m.Member == nil || m.User == nil || m.User.Bot {
+			return
+		}
+
+		l := a.With(
+			slog.String(logging.KeyComponent, componentVerification),
+			slog.String("guild_id", m.GuildID),
+			slog.String("user_id", m.User.ID),
+		)
+
+		guild, err := getGuildConfig(a.ctx, m.GuildID)
+		if err != nil {
+			l.Error("Error getting verification configuration", slog.String(logging.KeyError, err.Error()))
+			return
+		}
+
+		cfg := &guild.Verification
+		if !cfg.Enabled || cfg.UnverifiedRoleID == "" {
+			return
+		}
+
+		err = s.GuildMemberRoleAdd(m.GuildID, m.User.ID, cfg.UnverifiedRoleID,
+			discordgo.WithContext(a.ctx),
+			discordgo.WithAuditLogReason(verificationJoinReason),
+		)
+		if err != nil {
+			l.Error("Error giving unverified role", slog.String(logging.KeyError, err.Error()))
+			return
+		}
+
+		if cfg.Timeout <= 0 {
+			return
+		}
+
+		err = scheduleAction(a.ctx, &entities.ScheduledAction{
+			Type:     entities.ScheduledActionKickUnverified,
+			GuildID:  m.GuildID,
+			TargetID: m.User.ID,
+		}, time.Now().Add(cfg.Timeout))
+		if err != nil {
+			l.Error("Error scheduling verification kick", slog.String(logging.KeyError, err.Error()))
+		}
+	}
+}
+
+// verifyButtonHandler starts the verification challenge of the member. Members verified by the button are verified
+// straight away.
+func (a *App) verifyButtonHandler(c *commands.Context) error {
+	cfg, err := verificationConfig(c)
+	if err != nil {
+		return err
+	}
+
+	switch cfg.Mode {
+	case entities.VerificationModeMath, entities.VerificationModeText:
+		return startCaptcha(c, cfg)
+	case entities.VerificationModeQuestions:
+		return c.RespondModal(questionsModal(cfg))
+	default:
+		return a.verifyMember(c, cfg)
+	}
+}
+
+// verifyAnswerButtonHandler opens the modal to answer the captcha.
+func (a *App) verifyAnswerButtonHandler(c *commands.Context) error {
+	return c.RespondModal(&discordgo.InteractionResponseData{
+		CustomID: commands.CustomID(VerifyModalID, c.Args()...),
+		Title:    "Verification",
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:  verificationAnswerInputID,
+						Label:     "What does the image show?",
+						Style:     discordgo.TextInputShort,
+						Required:  true,
+						MaxLength: 20,
+					},
+				},
+			},
+		},
+	})
+}
+
+// verifyModalHandler checks the answers of the member, and verifies the member if they are right.
+func (a *App) verifyModalHandler(c *commands.Context) error {
+	cfg, err := verificationConfig(c)
+	if err != nil {
+		return err
+	}
+
+	if args := c.Args(); len(args) > 0 {
+		return a.checkCaptcha(c, cfg, args[0])
+	} else if cfg.Mode != entities.VerificationModeQuestions {
+		return commands.NewUserError("The verification challenge has changed. Press Verify to start again.")
+	}
+
+	for i, q := range cfg.Questions {
+		if !strings.EqualFold(strings.TrimSpace(c.Field(questionInputID(i))), q.Answer) {
+			VerificationAttempts.WithLabelValues(string(cfg.Mode), "failed").Inc()
+			a.logVerification(c.Context(), c.GuildID, c.Member.User, "Verification Failed", "Wrong answers to the questions")
+			return commands.NewUserError("Some of your answers are wrong. Read the rules and try again.")
+		}
+	}
+	return a.verifyMember(c, cfg)
+}
+
+// verificationConfig returns the verification configuration of the guild, returning a user error if the member
+// cannot be verified.
+func verificationConfig(c *commands.Context) (*entities.VerificationConfig, error) {
+	guild, err := getGuildConfig(c.Context(), c.GuildID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &guild.Verification
+	switch {
+	case !cfg.Enabled:
+		return nil, commands.NewUserError("Verification is not enabled in this server.")
+	case !slices.Contains(c.Member.Roles, cfg.UnverifiedRoleID):
+		return nil, commands.NewUserError("You are already verified.")
+	}
+	return cfg, nil
+}
+
+// questionsModal returns the modal that asks the questions of the guild.
+func questionsModal(cfg *entities.VerificationConfig) *discordgo.InteractionResponseData {
+	rows := make([]discordgo.MessageComponent, 0, len(cfg.Questions))
+	for i, q := range cfg.Questions {
+		rows = append(rows, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:  questionInputID(i),
+					Label:     q.Question,
+					Style:     discordgo.TextInputShort,
+					Required:  true,
+					MaxLength: verificationAnswerLength,
+				},
+			},
+		})
+	}
+
+	return &discordgo.InteractionResponseData{
+		CustomID:   VerifyModalID,
+		Title:      "Verification",
+		Components: rows,
+	}
+}
+
+// questionInputID returns the custom ID of the input of the question.
+func questionInputID(i int) string {
+	return fmt.Sprintf("question_%d", i)
+}
+
+// startCaptcha gives the member a new captcha. The image is linked from the monitoring server if it can be reached
+// by the members, and attached otherwise.
+func startCaptcha(c *commands.Context, cfg *entities.VerificationConfig) error {
+	ctx := c.Context()
+
+	challenge, err := captcha.New(captcha.Kind(cfg.Mode), captcha.NewRand())
+	if err != nil {
+		return fmt.Errorf("error creating captcha: %w", err)
+	}
+
+	id, err := newChallengeID()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	err = dataaccess.VerificationDB.SaveChallenge(ctx, &entities.VerificationChallenge{
+		ID:        id,
+		GuildID:   c.GuildID,
+		UserID:    c.Member.User.ID,
+		Text:      challenge.Text,
+		Answer:    challenge.Answer,
+		Seed:      challenge.Seed,
+		CreatedAt: custom.Datetime(now),
+		ExpiresAt: custom.Datetime(now.Add(verificationChallengeTTL)),
+	})
+	if err != nil {
+		return fmt.Errorf("error saving verification challenge: %w", err)
+	}
+
+	instruction := "Type the text in the image"
+	if cfg.Mode == entities.VerificationModeMath {
+		instruction = "Solve the sum in the image"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Verification",
+		Description: fmt.Sprintf("%s, then press Answer. The challenge expires <t:%d:R>.",
+			instruction, now.Add(verificationChallengeTTL).Unix()),
+		Color: 0x0099ff,
+		Image: &discordgo.MessageEmbedImage{
+			URL: "attachment://" + verificationCaptchaFile,
+		},
+	}
+
+	data := &discordgo.InteractionResponseData{
+		Flags:  discordgo.MessageFlagsEphemeral,
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "Answer",
+						Style:    discordgo.PrimaryButton,
+						CustomID: commands.CustomID(VerifyAnswerButtonID, id),
+					},
+				},
+			},
+		},
+	}
+
+	if PublicURL != "" {
+		embed.Image.URL = captchaURL(id)
+	} else {
+		image, err := challenge.PNG()
+		if err != nil {
+			return fmt.Errorf("error drawing captcha: %w", err)
+		}
+		data.Files = []*discordgo.File{{
+			Name:        verificationCaptchaFile,
+			ContentType: request.ContentTypePng.String(),
+			Reader:      bytes.NewReader(image),
+		}}
+	}
+
+	if err := c.Respond(data); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// checkCaptcha checks the answer of the member to the captcha. A captcha can only be answered wrongly a few times,
+// before a new one must be started.
+func (a *App) checkCaptcha(c *commands.Context, cfg *entities.VerificationConfig, id string) error {
+	ctx := c.Context()
+
+	challenge, err := dataaccess.VerificationDB.GetChallenge(ctx, id)
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("error getting verification challenge: %w", err)
+	}
+	if challenge == nil || challenge.GuildID != c.GuildID || challenge.UserID != c.Member.User.ID ||
+		time.Now().After(time.Time(challenge.ExpiresAt)) {
+		return commands.NewUserError("This challenge has expired. Press Verify to get a new one.")
+	}
+
+	if challengeCaptcha(challenge).Check(c.Field(verificationAnswerInputID)) {
+		if err := dataaccess.VerificationDB.DeleteChallenge(ctx, c.GuildID, c.Member.User.ID); err != nil {
+			c.Logger().Warn("Error deleting verification challenge", slog.String(logging.KeyError, err.Error()))
+		}
+		return a.verifyMember(c, cfg)
+	}
+
+	VerificationAttempts.WithLabelValues(string(cfg.Mode), "failed").Inc()
+
+	challenge.Attempts++
+	if challenge.Attempts >= verificationMaxAttempts {
+		if err := dataaccess.VerificationDB.DeleteChallenge(ctx, c.GuildID, c.Member.User.ID); err != nil {
+			return fmt.Errorf("error deleting verification challenge: %w", err)
+		}
+		a.logVerification(ctx, c.GuildID, c.Member.User, "Verification Failed",
+			fmt.Sprintf("Answered the captcha wrongly %d times", challenge.Attempts))
+		return commands.NewUserError("That is not the answer. Press Verify to get a new challenge.")
+	}
+
+	if err := dataaccess.VerificationDB.SaveChallenge(ctx, challenge); err != nil {
+		return fmt.Errorf("error saving verification challenge: %w", err)
+	}
+	return commands.NewUserError("That is not the answer. You have %d more attempts.", verificationMaxAttempts-challenge.Attempts)
+}
+
+// verifyMember gives the success roles to the member, and then takes the unverified role. The roles are given first,
+// so the member stays unverified if they cannot be given.
+func (a *App) verifyMember(c *commands.Context, cfg *entities.VerificationConfig) error {
+	ctx := c.Context()
+	s := c.Session()
+	userID := c.Member.User.ID
+
+	for _, roleID := range cfg.SuccessRoleIDs {
+		err := s.GuildMemberRoleAdd(c.GuildID, userID, roleID,
+			discordgo.WithContext(ctx),
+			discordgo.WithAuditLogReason(verificationReason),
+		)
+		if err != nil {
+			return fmt.Errorf("error giving verified role: %w", err)
+		}
+	}
+
+	err := s.GuildMemberRoleRemove(c.GuildID, userID, cfg.UnverifiedRoleID,
+		discordgo.WithContext(ctx),
+		discordgo.WithAuditLogReason(verificationReason),
+	)
+	if err != nil {
+		return fmt.Errorf("error removing unverified role: %w", err)
+	}
+
+	// The member no longer needs to be kicked.
+	if _, err := dataaccess.ScheduledActionDB.CancelScheduledActions(ctx, c.GuildID, userID, entities.ScheduledActionKickUnverified); err != nil {
+		c.Logger().Error("Error cancelling verification kick", slog.String(logging.KeyError, err.Error()))
+	}
+
+	VerificationAttempts.WithLabelValues(string(cfg.Mode), "passed").Inc()
+	a.logVerification(ctx, c.GuildID, c.Member.User, "Member Verified", fmt.Sprintf("Passed the %s challenge", cfg.Mode))
+
+	if err := c.RespondEphemeral("You have been verified. Welcome!"); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	a.restoreVerifiedMember(c, cfg)
+	return nil
+}
+
+// restoreVerifiedMember restores the roles and nickname of the member that rejoined, which are held back until they
+// are verified. The roles the member has now are the roles they joined with, without the unverified role and with the
+// success roles.
+func (a *App) restoreVerifiedMember(c *commands.Context, cfg *entities.VerificationConfig) {
+	l := c.Logger().With(slog.String(logging.KeyComponent, componentRolePersistence))
+
+	guild, err := getGuildConfig(c.Context(), c.GuildID)
+	if err != nil {
+		l.Error("Error getting role persistence configuration", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+	if !guild.RolePersistence.Enabled {
+		return
+	}
+
+	member := *c.Member
+	member.GuildID = c.GuildID
+	member.Roles = slices.DeleteFunc(slices.Clone(c.Member.Roles), func(id string) bool {
+		return id == cfg.UnverifiedRoleID
+	})
+	for _, roleID := range cfg.SuccessRoleIDs {
+		if !slices.Contains(member.Roles, roleID) {
+			member.Roles = append(member.Roles, roleID)
+		}
+	}
+
+	a.restorePersistedMember(l, c.Session(), &guild.RolePersistence, &member)
+}
+
+// runKickUnverified kicks the member if they have not been verified in time. Members that have been verified, or
+// that have left, are left alone.
+func (a *App) runKickUnverified(ctx context.Context, action *entities.ScheduledAction) error {
+	guild, err := getGuildConfig(ctx, action.GuildID)
+	if err != nil {
+		return err
+	}
+
+	cfg := &guild.Verification
+	if !cfg.Enabled || cfg.UnverifiedRoleID == "" {
+		return nil
+	}
+
+	member, err := a.Session().GuildMember(action.GuildID, action.TargetID, discordgo.WithContext(ctx))
+	if err != nil {
+		return scheduledActionError(err)
+	}
+	if !slices.Contains(member.Roles, cfg.UnverifiedRoleID) {
+		return nil
+	}
+
+	err = a.Session().GuildMemberDelete(action.GuildID, action.TargetID,
+		discordgo.WithContext(ctx),
+		discordgo.WithAuditLogReason(verificationKickReason),
+	)
+	if err != nil {
+		return scheduledActionError(err)
+	}
+
+	if err := dataaccess.VerificationDB.DeleteChallenge(ctx, action.GuildID, action.TargetID); err != nil {
+		a.Warn("Error deleting verification challenge", slog.String(logging.KeyError, err.Error()))
+	}
+
+	VerificationAttempts.WithLabelValues(string(cfg.Mode), "kicked").Inc()
+	a.logVerification(ctx, action.GuildID, member.User, "Member Kicked",
+		fmt.Sprintf("Not verified within %s", custom.FormatDuration(cfg.Timeout)))
+	return nil
+}
+
+// logVerification logs the outcome of the verification of the user to the verification log of the guild.
+func (a *App) logVerification(ctx context.Context, guildID string, user *discordgo.User, title, outcome string) {
+	a.events.log(ctx, guildID, entities.LogTypeVerification, &discordgo.MessageEmbed{
+		Title: title,
+		Fields: []*discordgo.MessageEmbedField{
+			userField("Member", user),
+			{Name: "Outcome", Value: outcome, Inline: true},
+		},
+	})
+}
+
+// newChallengeID returns a random ID for a verification challenge.
+func newChallengeID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating challenge ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// challengeCaptcha returns the captcha of the stored challenge.
+func challengeCaptcha(challenge *entities.VerificationChallenge) *captcha.Challenge {
+	return &captcha.Challenge{
+		Text:   challenge.Text,
+		Answer: challenge.Answer,
+		Seed:   challenge.Seed,
+	}
+}
+
+// captchaURL returns the public URL of the image of the challenge.
+func captchaURL(id string) string {
+	return PublicURL + PathVerify + "/" + id + "/" + verificationCaptchaFile
+}
+
+// getCaptchaImage serves the image of a challenge that has not expired. The challenges can only be found by their
+// random IDs, so the images are served without authentication.
+func getCaptchaImage(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["challenge_id"]
+	if !challengeIDPattern.MatchString(id) {
+		request.RespondError(w, r, http.StatusNotFound, "Challenge not found")
+		return
+	}
+
+	challenge, err := dataaccess.VerificationDB.GetChallenge(r.Context(), id)
+	if isNotFound(err) || (err == nil && time.Now().After(time.Time(challenge.ExpiresAt))) {
+		request.RespondError(w, r, http.StatusNotFound, "Challenge not found")
+		return
+	} else if err != nil {
+		writeAPIInternalError(w, r, err)
+		return
+	}
+
+	image, err := challengeCaptcha(challenge).PNG()
+	if err != nil {
+		writeAPIInternalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", request.ContentTypePng.String())
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := w.Write(image); err != nil {
+		logging.FromContext(r.Context()).Error("Error writing response", slog.String(logging.KeyError, err.Error()))
+	}
+}
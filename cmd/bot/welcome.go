@@ -0,0 +1,620 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/commands"
+	"github.com/Jacobbrewer1/wolf/pkg/custom"
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"github.com/Jacobbrewer1/wolf/pkg/templates"
+)
+
+const (
+	// defaultWelcomeTitle is the title of the welcome message when none has been set.
+	defaultWelcomeTitle = "Welcome!"
+
+	// defaultWelcomeMessage is the welcome message when none has been set.
+	defaultWelcomeMessage = "Welcome to **{server}**, {user}! You are member #{member_count}."
+
+	// defaultGoodbyeMessage is the goodbye message when none has been set.
+	defaultGoodbyeMessage = "**{username}** has left **{server}**."
+
+	// welcomeTitleLength is the longest title of a welcome message. This is the longest title of an embed.
+	welcomeTitleLength = 256
+
+	// autoRoleReason is the audit log reason of the roles given to the members that join.
+	autoRoleReason = "Auto role"
+
+	// maxAutoRoles is the most roles that can be given to the members that join.
+	maxAutoRoles = 10
+
+	// noneOption is the option value that clears a message.
+	noneOption = "none"
+)
+
+// welcomePlaceholders are the placeholders of the welcome and goodbye messages.
+var welcomePlaceholders = []*templates.Placeholder{
+	{Name: "user", Description: "A mention of the member"},
+	{Name: "username", Description: "The username of the member"},
+	{Name: "user_id", Description: "The ID of the member"},
+	{Name: "server", Description: "The name of the server"},
+	{Name: "member_count", Description: "The number of members of the server"},
+	{Name: "account_age", Description: "How old the account of the member is, such as 3 days"},
+}
+
+var (
+	// welcomeTemplates renders the messages of the welcome and goodbye messages.
+	welcomeTemplates = templates.NewEngine(welcomePlaceholders)
+
+	// welcomeTitleTemplates renders the titles of the welcome messages.
+	welcomeTitleTemplates = templates.NewEngine(welcomePlaceholders,
+		templates.WithMaxTemplateLength(welcomeTitleLength),
+		templates.WithMaxOutputLength(welcomeTitleLength),
+	)
+)
+
+// welcomeVars returns the values of the placeholders for the user in the guild, at the time.
+func welcomeVars(ctx context.Context, s *discordgo.Session, guildID string, user *discordgo.User, at time.Time) templates.Vars {
+	vars := templates.Vars{
+		"user":     user.Mention(),
+		"username": user.Username,
+		"user_id":  user.ID,
+	}
+
+	if created, err := discordgo.SnowflakeTimestamp(user.ID); err == nil {
+		vars["account_age"] = accountAge(at.Sub(created))
+	}
+
+	// The state keeps the member count up to date with the joins and leaves.
+	if guild, err := s.State.Guild(guildID); err == nil {
+		vars["server"] = guild.Name
+		vars["member_count"] = strconv.Itoa(guild.MemberCount)
+	} else if guild, err := s.GuildWithCounts(guildID, discordgo.WithContext(ctx)); err == nil {
+		vars["server"] = guild.Name
+		vars["member_count"] = strconv.Itoa(guild.ApproximateMemberCount)
+	}
+	return vars
+}
+
+// accountAge returns how old an account is in its largest unit, such as 3 days.
+func accountAge(d time.Duration) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s", unit)
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+
+	switch {
+	case d >= 365*24*time.Hour:
+		return plural(int(d/(365*24*time.Hour)), "year")
+	case d >= 24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day")
+	case d >= time.Hour:
+		return plural(int(d/time.Hour), "hour")
+	case d >= time.Minute:
+		return plural(int(d/time.Minute), "minute")
+	default:
+		return "less than a minute"
+	}
+}
+
+// welcomeEmbed renders the embed of a welcome or goodbye message for the user.
+func welcomeEmbed(title, message string, vars templates.Vars, user *discordgo.User, color int) (*discordgo.MessageEmbed, error) {
+	renderedTitle, err := welcomeTitleTemplates.Render(title, vars)
+	if err != nil {
+		return nil, fmt.Errorf("error rendering title: %w", err)
+	}
+
+	description, err := welcomeTemplates.Render(message, vars)
+	if err != nil {
+		return nil, fmt.Errorf("error rendering message: %w", err)
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       renderedTitle,
+		Description: description,
+		Color:       color,
+		Thumbnail: &discordgo.MessageEmbedThumbnail{
+			URL: user.AvatarURL(""),
+		},
+	}, nil
+}
+
+// welcomeMessage returns the title and the message of the welcome message of the guild, defaulting any that have not
+// been set.
+func welcomeMessage(cfg *entities.WelcomeConfig) (string, string) {
+	title, message := cfg.Title, cfg.Message
+	if title == "" {
+		title = defaultWelcomeTitle
+	}
+	if message == "" {
+		message = defaultWelcomeMessage
+	}
+	return title, message
+}
+
+// goodbyeMessage returns the goodbye message of the guild, defaulting it if it has not been set.
+func goodbyeMessage(cfg *entities.GoodbyeConfig) string {
+	if cfg.Message == "" {
+		return defaultGoodbyeMessage
+	}
+	return cfg.Message
+}
+
+// welcomeHandler welcomes the members that join, and gives them the auto roles.
+func (a *App) welcomeHandler() func(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+	return func(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+		if m.Member == nil || m.User == nil || m.User.Bot {
+			return
+		}
+
+		l := a.With(
+			slog.String(logging.KeyComponent, componentWelcome),
+			slog.String("guild_id", m.GuildID),
+			slog.String("user_id", m.User.ID),
+		)
+
+		guild, err := getGuildConfig(a.ctx, m.GuildID)
+		if err != nil {
+			l.Error("Error getting welcome configuration", slog.String(logging.KeyError, err.Error()))
+			return
+		}
+
+		cfg := &guild.Welcome
+		giveAutoRoles(a.ctx, l, s, m.GuildID, m.User.ID, cfg)
+
+		if !cfg.Enabled && cfg.DMMessage == "" {
+			return
+		}
+
+		vars := welcomeVars(a.ctx, s, m.GuildID, m.User, time.Now())
+
+		if cfg.Enabled && cfg.ChannelID != "" {
+			title, message := welcomeMessage(cfg)
+			sendWelcomeMessage(a.ctx, l, s, cfg.ChannelID, "welcome", title, message, vars, m.User, 0x00ff00)
+		}
+
+		if cfg.DMMessage != "" {
+			channel, err := s.UserChannelCreate(m.User.ID, discordgo.WithContext(a.ctx))
+			if err != nil {
+				l.Warn("Error creating direct message channel", slog.String(logging.KeyError, err.Error()))
+				return
+			}
+
+			// Members can turn off direct messages from servers, so a failure is expected.
+			title, _ := welcomeMessage(cfg)
+			sendWelcomeMessage(a.ctx, l, s, channel.ID, "dm", title, cfg.DMMessage, vars, m.User, 0x00ff00)
+		}
+	}
+}
+
+// goodbyeHandler says goodbye to the members that leave.
+func (a *App) goodbyeHandler() func(s *discordgo.Session, m *discordgo.GuildMemberRemove) {
+	return func(s *discordgo.Session, m *discordgo.GuildMemberRemove) {
+		if m.Member == nil || m.User == nil || m.User.Bot {
+			return
+		}
+
+		l := a.With(
+			slog.String(logging.KeyComponent, componentWelcome),
+			slog.String("guild_id", m.GuildID),
+			slog.String("user_id", m.User.ID),
+		)
+
+		guild, err := getGuildConfig(a.ctx, m.GuildID)
+		if err != nil {
+			l.Error("Error getting goodbye configuration", slog.String(logging.KeyError, err.Error()))
+			return
+		}
+
+		cfg := &guild.Goodbye
+		if !cfg.Enabled || cfg.ChannelID == "" {
+			return
+		}
+
+		vars := welcomeVars(a.ctx, s, m.GuildID, m.User, time.Now())
+		sendWelcomeMessage(a.ctx, l, s, cfg.ChannelID, "goodbye", "", goodbyeMessage(cfg), vars, m.User, 0xff0000)
+	}
+}
+
+// sendWelcomeMessage renders and sends a welcome, goodbye or direct message. Failures are logged and counted, as
+// there is nobody to return them to.
+func sendWelcomeMessage(ctx context.Context, l *slog.Logger, s *discordgo.Session, channelID, kind, title, message string, vars templates.Vars, user *discordgo.User, color int) {
+	embed, err := welcomeEmbed(title, message, vars, user, color)
+	if err == nil {
+		_, err = s.ChannelMessageSendEmbed(channelID, embed, discordgo.WithContext(ctx))
+	}
+	if err != nil {
+		WelcomeMessages.WithLabelValues(kind, "failed").Inc()
+		l.Warn("Error sending "+kind+" message", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+	WelcomeMessages.WithLabelValues(kind, "sent").Inc()
+}
+
+// giveAutoRoles gives the auto roles to the member that joined. When there is a delay, the roles are given by the
+// scheduler so they are still given if the bot is restarted.
+func giveAutoRoles(ctx context.Context, l *slog.Logger, s *discordgo.Session, guildID, userID string, cfg *entities.WelcomeConfig) {
+	for _, roleID := range cfg.AutoRoleIDs {
+		if cfg.AutoRoleDelay > 0 {
+			err := scheduleAction(ctx, &entities.ScheduledAction{
+				Type:     entities.ScheduledActionAddRole,
+				GuildID:  guildID,
+				TargetID: userID,
+				RoleID:   roleID,
+			}, time.Now().Add(cfg.AutoRoleDelay))
+			if err != nil {
+				l.Error("Error scheduling auto role", slog.String("role_id", roleID), slog.String(logging.KeyError, err.Error()))
+			}
+			continue
+		}
+
+		err := s.GuildMemberRoleAdd(guildID, userID, roleID, discordgo.WithContext(ctx), discordgo.WithAuditLogReason(autoRoleReason))
+		if err != nil {
+			l.Error("Error giving auto role", slog.String("role_id", roleID), slog.String(logging.KeyError, err.Error()))
+		}
+	}
+}
+
+// runAddRole gives the role to the member, such as an auto role that was delayed. A member that has left has nothing
+// to give the role to.
+func (a *App) runAddRole(ctx context.Context, action *entities.ScheduledAction) error {
+	err := a.Session().GuildMemberRoleAdd(action.GuildID, action.TargetID, action.RoleID,
+		discordgo.WithContext(ctx),
+		discordgo.WithAuditLogReason(autoRoleReason),
+	)
+	return scheduledActionError(err)
+}
+
+// welcomeMessageOptions are the options for the welcome message configuration command. The settings that are not
+// given are kept.
+type welcomeMessageOptions struct {
+	// Enabled is whether the welcome message is posted.
+	Enabled bool `option:"enabled" description:"This is whether the welcome message is posted when a member joins." required:"true"`
+
+	// Channel is the channel the welcome message is posted in.
+	Channel *discordgo.Channel `option:"channel" description:"This is the channel the welcome message is posted in." channel_types:"text,news"`
+
+	// Title is the template of the title of the welcome message.
+	Title string `option:"title" description:"This is the title of the welcome message, which can use placeholders such as {user}."`
+
+	// Message is the template of the welcome message.
+	Message string `option:"message" description:"This is the welcome message, which can use placeholders such as {user} and {server}."`
+
+	// DMMessage is the template of the direct message sent to the members that join, or none to stop sending it.
+	DMMessage string `option:"dm_message" description:"This is the message sent directly to the members that join, or none to stop sending it."`
+}
+
+// Validate checks the templates of the welcome message.
+func (o *welcomeMessageOptions) Validate() error {
+	if err := welcomeTitleTemplates.Validate(o.Title); err != nil {
+		return commands.NewUserError("The title is not valid: %s.", err)
+	}
+	if err := welcomeTemplates.Validate(o.Message); err != nil {
+		return commands.NewUserError("The message is not valid: %s.", err)
+	}
+	if o.DMMessage != noneOption {
+		if err := welcomeTemplates.Validate(o.DMMessage); err != nil {
+			return commands.NewUserError("The direct message is not valid: %s.", err)
+		}
+	}
+	return nil
+}
+
+// goodbyeMessageOptions are the options for the goodbye message configuration command. The settings that are not
+// given are kept.
+type goodbyeMessageOptions struct {
+	// Enabled is whether the goodbye message is posted.
+	Enabled bool `option:"enabled" description:"This is whether the goodbye message is posted when a member leaves." required:"true"`
+
+	// Channel is the channel the goodbye message is posted in.
+	Channel *discordgo.Channel `option:"channel" description:"This is the channel the goodbye message is posted in." channel_types:"text,news"`
+
+	// Message is the template of the goodbye message.
+	Message string `option:"message" description:"This is the goodbye message, which can use placeholders such as {username}."`
+}
+
+// Validate checks the template of the goodbye message.
+func (o *goodbyeMessageOptions) Validate() error {
+	if err := welcomeTemplates.Validate(o.Message); err != nil {
+		return commands.NewUserError("The message is not valid: %s.", err)
+	}
+	return nil
+}
+
+// autoRoleOptions are the options for the auto role configuration command.
+type autoRoleOptions struct {
+	// Role is the role to add or remove.
+	Role *discordgo.Role `option:"role" description:"This is the role that is given to the members that join." required:"true"`
+
+	// Remove is whether the role is no longer given.
+	Remove bool `option:"remove" description:"This is whether the role is no longer given to the members that join."`
+
+	// Delay is how long after joining the auto roles are given, such as 10m, or 0 to give them straight away.
+	Delay string `option:"delay" description:"This is how long after joining the auto roles are given, such as 10m, or 0 for no delay."`
+
+	// delay is the parsed Delay. It is negative when the roles are given straight away.
+	delay time.Duration
+}
+
+// Validate parses the delay of the auto roles.
+func (o *autoRoleOptions) Validate() error {
+	switch o.Delay {
+	case "":
+	case "0":
+		o.delay = -1
+	default:
+		d, err := parseModerationDuration(o.Delay)
+		if err != nil {
+			return err
+		}
+		o.delay = d
+	}
+	return nil
+}
+
+// testWelcomeOptions are the options for the welcome test command.
+type testWelcomeOptions struct {
+	// Type is the message to preview.
+	Type string `option:"type" description:"This is the message to preview." required:"true" choices:"welcome,goodbye"`
+}
+
+// welcomeMessageCmdController is the controller for the welcome message configuration command.
+func welcomeMessageCmdController(c *commands.Context) error {
+	ctx := c.Context()
+
+	opts := c.Options().(*welcomeMessageOptions)
+
+	guild, err := getGuildConfig(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+
+	// Set the welcome configuration.
+	cfg := &guild.Welcome
+	cfg.Enabled = opts.Enabled
+	if opts.Channel != nil {
+		cfg.ChannelID = opts.Channel.ID
+	}
+	if opts.Title != "" {
+		cfg.Title = opts.Title
+	}
+	if opts.Message != "" {
+		cfg.Message = opts.Message
+	}
+	switch opts.DMMessage {
+	case "":
+	case noneOption:
+		cfg.DMMessage = ""
+	default:
+		cfg.DMMessage = opts.DMMessage
+	}
+
+	if cfg.Enabled && cfg.ChannelID == "" {
+		return commands.NewUserError("A channel is needed to post the welcome message in.")
+	}
+
+	// Save the guild. The auto roles are left as they are, as they are changed by their own command.
+	err = dataaccess.GuildDB.UpdateGuild(ctx, c.GuildID, &dataaccess.GuildUpdate{
+		Set: map[string]any{
+			"welcome.enabled":    cfg.Enabled,
+			"welcome.channel_id": cfg.ChannelID,
+			"welcome.title":      cfg.Title,
+			"welcome.message":    cfg.Message,
+			"welcome.dm_message": cfg.DMMessage,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error saving guild: %w", err)
+	}
+
+	msg := "The welcome message will not be posted"
+	if cfg.Enabled {
+		msg = fmt.Sprintf("The welcome message will be posted in channel <#%s>", cfg.ChannelID)
+	}
+	if cfg.DMMessage != "" {
+		msg += ", and the members that join will be sent a direct message"
+	}
+
+	// Respond to the interaction with the new configuration.
+	if err := c.RespondEphemeral(msg + ". Use `/setup welcome test` to preview it."); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// goodbyeMessageCmdController is the controller for the goodbye message configuration command.
+func goodbyeMessageCmdController(c *commands.Context) error {
+	ctx := c.Context()
+
+	opts := c.Options().(*goodbyeMessageOptions)
+
+	guild, err := getGuildConfig(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+
+	// Set the goodbye configuration.
+	cfg := &guild.Goodbye
+	cfg.Enabled = opts.Enabled
+	if opts.Channel != nil {
+		cfg.ChannelID = opts.Channel.ID
+	}
+	if opts.Message != "" {
+		cfg.Message = opts.Message
+	}
+
+	if cfg.Enabled && cfg.ChannelID == "" {
+		return commands.NewUserError("A channel is needed to post the goodbye message in.")
+	}
+
+	// Save the guild.
+	err = dataaccess.GuildDB.UpdateGuild(ctx, c.GuildID, &dataaccess.GuildUpdate{
+		Set: map[string]any{
+			"goodbye.enabled":    cfg.Enabled,
+			"goodbye.channel_id": cfg.ChannelID,
+			"goodbye.message":    cfg.Message,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error saving guild: %w", err)
+	}
+
+	msg := "The goodbye message will not be posted."
+	if cfg.Enabled {
+		msg = fmt.Sprintf("The goodbye message will be posted in channel <#%s>. Use `/setup welcome test` to preview it.", cfg.ChannelID)
+	}
+
+	// Respond to the interaction with the new configuration.
+	if err := c.RespondEphemeral(msg); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// autoRoleCmdController is the controller for the auto role configuration command.
+func autoRoleCmdController(c *commands.Context) error {
+	ctx := c.Context()
+
+	opts := c.Options().(*autoRoleOptions)
+
+	guild, err := getGuildConfig(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+
+	cfg := &guild.Welcome
+	if opts.Remove {
+		kept := make([]string, 0, len(cfg.AutoRoleIDs))
+		for _, id := range cfg.AutoRoleIDs {
+			if id != opts.Role.ID {
+				kept = append(kept, id)
+			}
+		}
+		cfg.AutoRoleIDs = kept
+	} else if !slices.Contains(cfg.AutoRoleIDs, opts.Role.ID) {
+		if len(cfg.AutoRoleIDs) >= maxAutoRoles {
+			return commands.NewUserError("There can be at most %d auto roles.", maxAutoRoles)
+		}
+
+		// The roles are given by the bot, so they must be roles that the member could give themselves.
+		if _, err := assignableRoles(ctx, c, []string{opts.Role.ID}); err != nil {
+			return err
+		}
+		cfg.AutoRoleIDs = append(cfg.AutoRoleIDs, opts.Role.ID)
+	}
+
+	update := toggleIDs(opts.Remove, map[string]any{"welcome.auto_role_ids": opts.Role.ID})
+	switch {
+	case opts.delay < 0:
+		cfg.AutoRoleDelay = 0
+		update.Set = map[string]any{"welcome.auto_role_delay": cfg.AutoRoleDelay}
+	case opts.delay > 0:
+		cfg.AutoRoleDelay = opts.delay
+		update.Set = map[string]any{"welcome.auto_role_delay": cfg.AutoRoleDelay}
+	}
+
+	// Save the guild.
+	if err := dataaccess.GuildDB.UpdateGuild(ctx, c.GuildID, update); err != nil {
+		return fmt.Errorf("error saving guild: %w", err)
+	}
+
+	msg := "No roles will be given to the members that join."
+	if len(cfg.AutoRoleIDs) > 0 {
+		when := "when they join"
+		if cfg.AutoRoleDelay > 0 {
+			when = fmt.Sprintf("%s after they join", custom.FormatDuration(cfg.AutoRoleDelay))
+		}
+		msg = fmt.Sprintf("Members will be given %s %s.", roleMentions(cfg.AutoRoleIDs), when)
+	}
+
+	// Respond to the interaction with the new configuration.
+	if err := c.RespondEphemeral(msg); err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
+
+// testWelcomeCmdController is the controller for the welcome test command. It renders the messages as if the member
+// had joined or left, without sending them.
+func testWelcomeCmdController(c *commands.Context) error {
+	ctx := c.Context()
+
+	opts := c.Options().(*testWelcomeOptions)
+
+	guild, err := getGuildConfig(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+
+	user := c.Member.User
+	vars := welcomeVars(ctx, c.Session(), c.GuildID, user, time.Now())
+
+	var embeds []*discordgo.MessageEmbed
+	status := ""
+	switch opts.Type {
+	case goodbyeMessageCmdName:
+		cfg := &guild.Goodbye
+		embed, err := welcomeEmbed("", goodbyeMessage(cfg), vars, user, 0xff0000)
+		if err != nil {
+			return commands.NewUserError("The goodbye message cannot be rendered: %s.", err)
+		}
+		embeds = append(embeds, embed)
+		if !cfg.Enabled {
+			status = "The goodbye message is disabled."
+		}
+	default:
+		cfg := &guild.Welcome
+		title, message := welcomeMessage(cfg)
+		embed, err := welcomeEmbed(title, message, vars, user, 0x00ff00)
+		if err != nil {
+			return commands.NewUserError("The welcome message cannot be rendered: %s.", err)
+		}
+		embeds = append(embeds, embed)
+		if cfg.DMMessage != "" {
+			embed, err := welcomeEmbed(title, cfg.DMMessage, vars, user, 0x00ff00)
+			if err != nil {
+				return commands.NewUserError("The direct message cannot be rendered: %s.", err)
+			}
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: "Direct message"}
+			embeds = append(embeds, embed)
+		}
+		if !cfg.Enabled {
+			status = "The welcome message is disabled."
+		}
+	}
+
+	placeholders := make([]string, 0, len(welcomePlaceholders))
+	for _, p := range welcomeTemplates.Placeholders() {
+		placeholders = append(placeholders, fmt.Sprintf("`{%s}` %s", p.Name, p.Description))
+	}
+	embeds = append(embeds, &discordgo.MessageEmbed{
+		Title:       "Placeholders",
+		Description: strings.Join(placeholders, "\n"),
+		Color:       0x0099ff,
+	})
+
+	err = c.Respond(&discordgo.InteractionResponseData{
+		Flags:   discordgo.MessageFlagsEphemeral,
+		Content: status,
+		Embeds:  embeds,
+	})
+	if err != nil {
+		return fmt.Errorf("error responding to interaction: %w", err)
+	}
+
+	return nil
+}
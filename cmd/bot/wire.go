@@ -12,7 +12,7 @@ import (
 func InitializeApp() (*App, error) {
 	wire.Build(
 		wire.Value(logging.Name(AppName)),
-		logging.NewConfig,
+		logging.NewConfigFromEnv,
 		logging.CommonLogger,
 		mux.NewRouter,
 		NewApp,
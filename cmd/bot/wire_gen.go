@@ -15,13 +15,16 @@ import (
 
 func InitializeApp() (*App, error) {
 	name := _wireNameValue
-	config := logging.NewConfig(name)
+	config, err := logging.NewConfigFromEnv(name)
+	if err != nil {
+		return nil, err
+	}
 	logger, err := logging.CommonLogger(config)
 	if err != nil {
 		return nil, err
 	}
 	router := mux.NewRouter()
-	app := NewApp(logger, router)
+	app := NewApp(logger, router, config)
 	return app, nil
 }
 
@@ -0,0 +1,116 @@
+package antiraid
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Jacobbrewer1/wolf/pkg/cache"
+)
+
+const (
+	// DefaultJoinThreshold is the number of suspicious joins within the window that is a raid by default.
+	DefaultJoinThreshold = 10
+
+	// DefaultJoinWindow is how far back the joins are counted by default.
+	DefaultJoinWindow = 10 * time.Second
+
+	// DefaultMaxAccountAge is the age under which an account is suspicious by default.
+	DefaultMaxAccountAge = 7 * 24 * time.Hour
+
+	// DefaultCoolDown is how long raid mode lasts by default.
+	DefaultCoolDown = 30 * time.Minute
+
+	// MaxJoinWindow is the longest window that joins can be counted over.
+	MaxJoinWindow = 5 * time.Minute
+
+	// DefaultGuilds is the number of guilds whose recent joins are remembered.
+	DefaultGuilds = 10000
+)
+
+// Settings are the settings a guild detects raids with.
+type Settings struct {
+	// Threshold is the number of suspicious joins within the window that is a raid.
+	Threshold int
+
+	// Window is how far back the joins are counted.
+	Window time.Duration
+
+	// MaxAccountAge is the age under which the account of a member that joins is suspicious. If zero, every member
+	// that joins is suspicious.
+	MaxAccountAge time.Duration
+}
+
+// join is a suspicious join.
+type join struct {
+	// userID is the ID of the member that joined.
+	userID string
+
+	// at is when the member joined.
+	at time.Time
+}
+
+// Detector detects raids from the members joining the guilds. It remembers the recent suspicious joins of each guild.
+// It is safe for concurrent use.
+type Detector struct {
+	// mut guards the joins.
+	mut sync.Mutex
+
+	// joins are the recent suspicious joins, oldest first, by the guild.
+	joins *cache.Cache[string, []join]
+}
+
+// NewDetector creates a new Detector that remembers the joins of up to the number of guilds.
+func NewDetector(guilds int) *Detector {
+	return &Detector{
+		joins: cache.New[string, []join](guilds, MaxJoinWindow),
+	}
+}
+
+// IsSuspicious returns true if the account, created at the time, is young enough when it joins to count towards a
+// raid.
+func IsSuspicious(created, joinedAt time.Time, maxAccountAge time.Duration) bool {
+	return maxAccountAge <= 0 || joinedAt.Sub(created) < maxAccountAge
+}
+
+// Join records the member joining the guild. If the join makes a raid, the IDs of the members whose joins made it are
+// returned, and the guild starts counting again.
+func (d *Detector) Join(guildID, userID string, created, joinedAt time.Time, settings Settings) []string {
+	if settings.Threshold < 1 || !IsSuspicious(created, joinedAt, settings.MaxAccountAge) {
+		return nil
+	}
+
+	d.mut.Lock()
+	defer d.mut.Unlock()
+
+	previous, _ := d.joins.Get(guildID)
+
+	joins := make([]join, 0, len(previous)+1)
+	for _, j := range previous {
+		if joinedAt.Sub(j.at) <= settings.Window {
+			joins = append(joins, j)
+		}
+	}
+	joins = append(joins, join{userID: userID, at: joinedAt})
+
+	if len(joins) < settings.Threshold {
+		// Only the joins that can still be counted are kept, so the guild never remembers more than the threshold.
+		d.joins.Set(guildID, joins)
+		return nil
+	}
+
+	d.joins.Delete(guildID)
+
+	userIDs := make([]string, 0, len(joins))
+	for _, j := range joins {
+		userIDs = append(userIDs, j.userID)
+	}
+	return userIDs
+}
+
+// Reset forgets the recent joins of the guild.
+func (d *Detector) Reset(guildID string) {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+
+	d.joins.Delete(guildID)
+}
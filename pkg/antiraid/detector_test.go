@@ -0,0 +1,75 @@
+package antiraid
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSuspicious(t *testing.T) {
+	joinedAt := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		created       time.Time
+		maxAccountAge time.Duration
+		want          bool
+	}{
+		{
+			name:          "young account",
+			created:       joinedAt.Add(-time.Hour),
+			maxAccountAge: 24 * time.Hour,
+			want:          true,
+		},
+		{
+			name:          "old account",
+			created:       joinedAt.Add(-48 * time.Hour),
+			maxAccountAge: 24 * time.Hour,
+		},
+		{
+			name:    "every account",
+			created: joinedAt.Add(-365 * 24 * time.Hour),
+			want:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, IsSuspicious(tt.created, joinedAt, tt.maxAccountAge))
+		})
+	}
+}
+
+func TestDetector_Join(t *testing.T) {
+	start := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+	young := start.Add(-time.Hour)
+	old := start.Add(-365 * 24 * time.Hour)
+
+	settings := Settings{
+		Threshold:     3,
+		Window:        10 * time.Second,
+		MaxAccountAge: 24 * time.Hour,
+	}
+
+	d := NewDetector(DefaultGuilds)
+
+	// Joins spread out over more than the window are not a raid.
+	require.Nil(t, d.Join("guild", "a", young, start, settings))
+	require.Nil(t, d.Join("guild", "b", young, start.Add(6*time.Second), settings))
+	require.Nil(t, d.Join("guild", "c", young, start.Add(12*time.Second), settings))
+
+	// Old accounts and other guilds do not count.
+	require.Nil(t, d.Join("guild", "old", old, start.Add(13*time.Second), settings))
+	require.Nil(t, d.Join("other", "d", young, start.Add(13*time.Second), settings))
+
+	require.Equal(t, []string{"b", "c", "e"}, d.Join("guild", "e", young, start.Add(14*time.Second), settings))
+
+	// The guild starts counting again after a raid.
+	require.Nil(t, d.Join("guild", "f", young, start.Add(15*time.Second), settings))
+
+	d.Reset("guild")
+	require.Nil(t, d.Join("guild", "g", young, start.Add(16*time.Second), settings))
+	require.Nil(t, d.Join("guild", "h", young, start.Add(17*time.Second), settings))
+	require.Equal(t, []string{"g", "h", "i"}, d.Join("guild", "i", young, start.Add(18*time.Second), settings))
+}
@@ -0,0 +1,355 @@
+package automod
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/cache"
+	"github.com/Jacobbrewer1/wolf/pkg/custom"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+)
+
+const (
+	// MaxWindow is the longest window that messages can be counted over.
+	MaxWindow = 10 * time.Minute
+
+	// DefaultHistorySize is the number of members whose recent messages are remembered.
+	DefaultHistorySize = 10000
+
+	// DefaultPatternCacheSize is the number of compiled word lists and regular expressions that are remembered.
+	DefaultPatternCacheSize = 1000
+
+	// maxHistoryMessages is the number of recent messages remembered for each member.
+	maxHistoryMessages = 50
+)
+
+var (
+	// inviteRegexp matches Discord invite links.
+	inviteRegexp = regexp.MustCompile(`(?i)(?:discord\.gg|discord(?:app)?\.com/invite)/[a-z0-9-]+`)
+
+	// linkRegexp matches links, capturing their host.
+	linkRegexp = regexp.MustCompile(`(?i)\bhttps?://([^\s/?#<>]+)`)
+)
+
+// Hit is a rule broken by a message.
+type Hit struct {
+	// Type is the type of the rule.
+	Type entities.AutomodRuleType
+
+	// Rule is the rule that was broken.
+	Rule *entities.AutomodRule
+
+	// Reason describes how the rule was broken.
+	Reason string
+}
+
+// Option configures an Engine.
+type Option func(e *Engine)
+
+// WithHistorySize sets the number of members whose recent messages are remembered for the rate and duplicate rules.
+func WithHistorySize(size int) Option {
+	return func(e *Engine) {
+		e.historySize = size
+	}
+}
+
+// historyMessage is a message remembered for the rate and duplicate rules.
+type historyMessage struct {
+	// at is when the message was sent.
+	at time.Time
+
+	// content is the normalised content of the message.
+	content string
+}
+
+// Engine evaluates the automod rules of the guilds against their messages. It remembers the recent messages of each
+// member for the rules that count messages. It is safe for concurrent use.
+type Engine struct {
+	// mut guards the histories.
+	mut sync.Mutex
+
+	// historySize is the number of members whose recent messages are remembered.
+	historySize int
+
+	// histories are the recent messages of the members, oldest first, by the guild and member.
+	histories *cache.Cache[string, []historyMessage]
+
+	// patterns are the compiled word lists and regular expressions, by their source.
+	patterns *cache.Cache[string, *regexp.Regexp]
+
+	// now returns the current time. It is used for the messages without a timestamp.
+	now func() time.Time
+}
+
+// NewEngine creates a new Engine.
+func NewEngine(opts ...Option) *Engine {
+	e := &Engine{
+		historySize: DefaultHistorySize,
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	e.histories = cache.New[string, []historyMessage](e.historySize, MaxWindow)
+	e.patterns = cache.New[string, *regexp.Regexp](DefaultPatternCacheSize, time.Hour)
+	return e
+}
+
+// Evaluate evaluates the rules against the message, returning the rules that it breaks in the order they are
+// evaluated. Messages outside of guilds, and messages without an author, break no rules.
+func (e *Engine) Evaluate(cfg *entities.AutomodConfig, m *discordgo.MessageCreate) []*Hit {
+	if cfg == nil || len(cfg.Rules) == 0 || m.Message == nil || m.GuildID == "" || m.Author == nil {
+		return nil
+	}
+
+	history := e.record(cfg, m)
+
+	hits := make([]*Hit, 0)
+	for _, t := range entities.AutomodRuleTypes {
+		rule := cfg.Rule(t)
+		if rule == nil || isExempt(rule, m) {
+			continue
+		}
+
+		reason, ok := e.check(t, rule, m, history)
+		if !ok {
+			continue
+		}
+
+		hits = append(hits, &Hit{
+			Type:   t,
+			Rule:   rule,
+			Reason: reason,
+		})
+
+		// The member starts counting again, so a single burst is only punished once.
+		if t == entities.AutomodRuleRate || t == entities.AutomodRuleDuplicate {
+			e.reset(m)
+		}
+	}
+	return hits
+}
+
+// record remembers the message for the rules that count messages, returning the recent messages of the member with
+// the message last. Nothing is remembered if none of those rules are enabled.
+func (e *Engine) record(cfg *entities.AutomodConfig, m *discordgo.MessageCreate) []historyMessage {
+	if cfg.Rule(entities.AutomodRuleRate) == nil && cfg.Rule(entities.AutomodRuleDuplicate) == nil {
+		return nil
+	}
+
+	at := m.Timestamp
+	if at.IsZero() {
+		at = e.now()
+	}
+
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	key := historyKey(m)
+	previous, _ := e.histories.Get(key)
+
+	// The messages that can no longer be counted by any rule are forgotten.
+	history := make([]historyMessage, 0, len(previous)+1)
+	for _, msg := range previous {
+		if at.Sub(msg.at) <= MaxWindow {
+			history = append(history, msg)
+		}
+	}
+	history = append(history, historyMessage{
+		at:      at,
+		content: normalise(m.Content),
+	})
+	if len(history) > maxHistoryMessages {
+		history = history[len(history)-maxHistoryMessages:]
+	}
+
+	e.histories.Set(key, history)
+	return history
+}
+
+// reset forgets the recent messages of the author of the message.
+func (e *Engine) reset(m *discordgo.MessageCreate) {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	e.histories.Delete(historyKey(m))
+}
+
+// check returns how the message breaks the rule, and whether it does.
+func (e *Engine) check(t entities.AutomodRuleType, rule *entities.AutomodRule, m *discordgo.MessageCreate, history []historyMessage) (string, bool) {
+	switch t {
+	case entities.AutomodRuleRate:
+		count := countWithin(history, rule.Window, func(historyMessage) bool {
+			return true
+		})
+		if count >= rule.Threshold {
+			return fmt.Sprintf("Sent %d messages in %s", count, custom.FormatDuration(rule.Window)), true
+		}
+	case entities.AutomodRuleDuplicate:
+		if len(history) == 0 {
+			return "", false
+		}
+		last := history[len(history)-1]
+		if last.content == "" {
+			return "", false
+		}
+		count := countWithin(history, rule.Window, func(msg historyMessage) bool {
+			return msg.content == last.content
+		})
+		if count >= rule.Threshold {
+			return fmt.Sprintf("Sent the same message %d times in %s", count, custom.FormatDuration(rule.Window)), true
+		}
+	case entities.AutomodRuleMentions:
+		if count := countMentions(m); count >= rule.Threshold {
+			return fmt.Sprintf("Mentioned %d users and roles", count), true
+		}
+	case entities.AutomodRuleInvites:
+		if invite := inviteRegexp.FindString(m.Content); invite != "" {
+			return fmt.Sprintf("Posted an invite link: %s", invite), true
+		}
+	case entities.AutomodRuleDomains:
+		if domain := blockedDomain(m.Content, rule.Values); domain != "" {
+			return fmt.Sprintf("Posted a link to a blocked domain: %s", domain), true
+		}
+	case entities.AutomodRuleWords:
+		re, err := e.compile(wordsPattern(rule.Values))
+		if err != nil {
+			return "", false
+		}
+		if word := re.FindString(m.Content); word != "" {
+			return fmt.Sprintf("Used a blocked word: %s", word), true
+		}
+	case entities.AutomodRuleRegex:
+		for _, pattern := range rule.Values {
+			re, err := e.compile(pattern)
+			if err != nil {
+				continue
+			}
+			if re.MatchString(m.Content) {
+				return fmt.Sprintf("Matched a blocked pattern: %s", pattern), true
+			}
+		}
+	}
+	return "", false
+}
+
+// compile returns the compiled regular expression, compiling it the first time it is used.
+func (e *Engine) compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := e.patterns.Get(pattern); ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("error compiling pattern: %w", err)
+	}
+	e.patterns.Set(pattern, re)
+	return re, nil
+}
+
+// historyKey returns the key of the recent messages of the author of the message.
+func historyKey(m *discordgo.MessageCreate) string {
+	return m.GuildID + ":" + m.Author.ID
+}
+
+// normalise returns the content of a message in the form it is compared in, so small differences do not hide
+// duplicates.
+func normalise(content string) string {
+	return strings.ToLower(strings.Join(strings.Fields(content), " "))
+}
+
+// countWithin counts the messages that match within the window before the last message.
+func countWithin(history []historyMessage, window time.Duration, match func(historyMessage) bool) int {
+	if len(history) == 0 {
+		return 0
+	}
+
+	last := history[len(history)-1].at
+	count := 0
+	for _, msg := range history {
+		if last.Sub(msg.at) <= window && match(msg) {
+			count++
+		}
+	}
+	return count
+}
+
+// countMentions counts the distinct users and roles mentioned by the message. Mentioning everyone counts as a
+// single mention.
+func countMentions(m *discordgo.MessageCreate) int {
+	users := make(map[string]struct{}, len(m.Mentions))
+	for _, user := range m.Mentions {
+		users[user.ID] = struct{}{}
+	}
+
+	roles := make(map[string]struct{}, len(m.MentionRoles))
+	for _, role := range m.MentionRoles {
+		roles[role] = struct{}{}
+	}
+
+	count := len(users) + len(roles)
+	if m.MentionEveryone {
+		count++
+	}
+	return count
+}
+
+// blockedDomain returns the host of the first link in the content to one of the domains or their subdomains, or an
+// empty string if there is none.
+func blockedDomain(content string, domains []string) string {
+	for _, match := range linkRegexp.FindAllStringSubmatch(content, -1) {
+		host := strings.ToLower(match[1])
+		if i := strings.LastIndex(host, "@"); i >= 0 {
+			host = host[i+1:]
+		}
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+		host = strings.TrimSuffix(host, ".")
+
+		for _, domain := range domains {
+			domain = strings.ToLower(domain)
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return host
+			}
+		}
+	}
+	return ""
+}
+
+// wordsPattern returns the regular expression that matches any of the words, ignoring case.
+func wordsPattern(words []string) string {
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		quoted = append(quoted, regexp.QuoteMeta(word))
+	}
+	return `(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`
+}
+
+// isExempt returns true if the rule does not apply to the message, because of its channel or the roles of its
+// author.
+func isExempt(rule *entities.AutomodRule, m *discordgo.MessageCreate) bool {
+	for _, id := range rule.ExemptChannelIDs {
+		if id == m.ChannelID {
+			return true
+		}
+	}
+
+	if m.Member == nil {
+		return false
+	}
+	for _, id := range rule.ExemptRoleIDs {
+		for _, role := range m.Member.Roles {
+			if id == role {
+				return true
+			}
+		}
+	}
+	return false
+}
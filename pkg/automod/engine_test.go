@@ -0,0 +1,313 @@
+package automod
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/stretchr/testify/require"
+)
+
+// start is the time the first test message is sent.
+var start = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// message returns a synthetic message from the user, sent after the start.
+func message(userID, content string, after time.Duration) *discordgo.MessageCreate {
+	return &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ID:        "message",
+			ChannelID: "channel",
+			GuildID:   "guild",
+			Content:   content,
+			Timestamp: start.Add(after),
+			Author:    &discordgo.User{ID: userID},
+			Member:    &discordgo.Member{Roles: []string{"member"}},
+		},
+	}
+}
+
+// config returns the configuration with the single rule.
+func config(t entities.AutomodRuleType, rule *entities.AutomodRule) *entities.AutomodConfig {
+	cfg := new(entities.AutomodConfig)
+	cfg.SetRule(t, rule)
+	return cfg
+}
+
+// hitTypes returns the types of the rules that were broken.
+func hitTypes(hits []*Hit) []entities.AutomodRuleType {
+	types := make([]entities.AutomodRuleType, 0, len(hits))
+	for _, hit := range hits {
+		types = append(types, hit.Type)
+	}
+	return types
+}
+
+func TestEngine_Evaluate_Content(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    entities.AutomodRuleType
+		values  []string
+		content string
+		modify  func(m *discordgo.MessageCreate)
+		want    string
+	}{
+		{
+			name:    "invite",
+			rule:    entities.AutomodRuleInvites,
+			content: "join us at discord.gg/abc123 now",
+			want:    "Posted an invite link: discord.gg/abc123",
+		},
+		{
+			name:    "invite long form",
+			rule:    entities.AutomodRuleInvites,
+			content: "https://discord.com/invite/abc123",
+			want:    "Posted an invite link: discord.com/invite/abc123",
+		},
+		{
+			name:    "no invite",
+			rule:    entities.AutomodRuleInvites,
+			content: "discord is great",
+		},
+		{
+			name:    "blocked domain",
+			rule:    entities.AutomodRuleDomains,
+			values:  []string{"example.com"},
+			content: "see https://Example.com/page",
+			want:    "Posted a link to a blocked domain: example.com",
+		},
+		{
+			name:    "blocked subdomain with port",
+			rule:    entities.AutomodRuleDomains,
+			values:  []string{"example.com"},
+			content: "see http://user@cdn.example.com:8080/file",
+			want:    "Posted a link to a blocked domain: cdn.example.com",
+		},
+		{
+			name:    "similar domain",
+			rule:    entities.AutomodRuleDomains,
+			values:  []string{"example.com"},
+			content: "see https://notexample.com",
+		},
+		{
+			name:    "blocked word",
+			rule:    entities.AutomodRuleWords,
+			values:  []string{"heck", "dang it"},
+			content: "Oh HECK, that hurt",
+			want:    "Used a blocked word: HECK",
+		},
+		{
+			name:    "blocked phrase",
+			rule:    entities.AutomodRuleWords,
+			values:  []string{"heck", "dang it"},
+			content: "well dang it",
+			want:    "Used a blocked word: dang it",
+		},
+		{
+			name:    "word inside another word",
+			rule:    entities.AutomodRuleWords,
+			values:  []string{"heck"},
+			content: "let me check",
+		},
+		{
+			name:    "regex",
+			rule:    entities.AutomodRuleRegex,
+			values:  []string{`free\s+nitro`},
+			content: "get free   nitro here",
+			want:    `Matched a blocked pattern: free\s+nitro`,
+		},
+		{
+			name: "mentions",
+			rule: entities.AutomodRuleMentions,
+			modify: func(m *discordgo.MessageCreate) {
+				m.Mentions = []*discordgo.User{{ID: "a"}, {ID: "b"}, {ID: "a"}}
+				m.MentionRoles = []string{"role"}
+				m.MentionEveryone = true
+			},
+			want: "Mentioned 4 users and roles",
+		},
+		{
+			name: "few mentions",
+			rule: entities.AutomodRuleMentions,
+			modify: func(m *discordgo.MessageCreate) {
+				m.Mentions = []*discordgo.User{{ID: "a"}, {ID: "b"}}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := &entities.AutomodRule{Values: tt.values, Threshold: 4}
+			require.NoError(t, ValidateRule(tt.rule, rule))
+
+			m := message("user", tt.content, 0)
+			if tt.modify != nil {
+				tt.modify(m)
+			}
+
+			hits := NewEngine().Evaluate(config(tt.rule, rule), m)
+			if tt.want == "" {
+				require.Empty(t, hits)
+				return
+			}
+
+			require.Len(t, hits, 1)
+			require.Equal(t, tt.rule, hits[0].Type)
+			require.Same(t, rule, hits[0].Rule)
+			require.Equal(t, tt.want, hits[0].Reason)
+		})
+	}
+}
+
+func TestEngine_Evaluate_Rate(t *testing.T) {
+	rule := &entities.AutomodRule{Threshold: 3, Window: 5 * time.Second}
+	cfg := config(entities.AutomodRuleRate, rule)
+	e := NewEngine()
+
+	// Messages spread out over more than the window do not break the rule.
+	require.Empty(t, e.Evaluate(cfg, message("user", "a", 0)))
+	require.Empty(t, e.Evaluate(cfg, message("user", "b", 4*time.Second)))
+	require.Empty(t, e.Evaluate(cfg, message("user", "c", 8*time.Second)))
+
+	// Other members are counted separately.
+	require.Empty(t, e.Evaluate(cfg, message("other", "d", 9*time.Second)))
+
+	hits := e.Evaluate(cfg, message("user", "e", 9*time.Second))
+	require.Len(t, hits, 1)
+	require.Equal(t, "Sent 3 messages in 5s", hits[0].Reason)
+
+	// The member starts counting again after breaking the rule.
+	require.Empty(t, e.Evaluate(cfg, message("user", "f", 10*time.Second)))
+}
+
+func TestEngine_Evaluate_Duplicate(t *testing.T) {
+	rule := &entities.AutomodRule{Threshold: 3, Window: 30 * time.Second}
+	cfg := config(entities.AutomodRuleDuplicate, rule)
+	e := NewEngine()
+
+	require.Empty(t, e.Evaluate(cfg, message("user", "Buy   now", 0)))
+	require.Empty(t, e.Evaluate(cfg, message("user", "something else", time.Second)))
+	require.Empty(t, e.Evaluate(cfg, message("user", "buy now", 2*time.Second)))
+
+	hits := e.Evaluate(cfg, message("user", "BUY NOW", 3*time.Second))
+	require.Len(t, hits, 1)
+	require.Equal(t, "Sent the same message 3 times in 30s", hits[0].Reason)
+
+	// Messages without content, such as attachments, are not duplicates.
+	for i := 0; i < 3; i++ {
+		require.Empty(t, e.Evaluate(cfg, message("user", "", time.Duration(4+i)*time.Second)))
+	}
+}
+
+func TestEngine_Evaluate_Exemptions(t *testing.T) {
+	tests := []struct {
+		name string
+		rule *entities.AutomodRule
+		want bool
+	}{
+		{
+			name: "not exempt",
+			rule: &entities.AutomodRule{ExemptChannelIDs: []string{"other"}, ExemptRoleIDs: []string{"staff"}},
+			want: true,
+		},
+		{
+			name: "exempt channel",
+			rule: &entities.AutomodRule{ExemptChannelIDs: []string{"channel"}},
+		},
+		{
+			name: "exempt role",
+			rule: &entities.AutomodRule{ExemptRoleIDs: []string{"member"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits := NewEngine().Evaluate(config(entities.AutomodRuleInvites, tt.rule), message("user", "discord.gg/abc", 0))
+			require.Equal(t, tt.want, len(hits) == 1)
+		})
+	}
+}
+
+func TestEngine_Evaluate_Multiple(t *testing.T) {
+	cfg := new(entities.AutomodConfig)
+	cfg.SetRule(entities.AutomodRuleWords, &entities.AutomodRule{Values: []string{"spam"}})
+	cfg.SetRule(entities.AutomodRuleInvites, &entities.AutomodRule{})
+
+	hits := NewEngine().Evaluate(cfg, message("user", "spam discord.gg/abc", 0))
+	require.Equal(t, []entities.AutomodRuleType{entities.AutomodRuleInvites, entities.AutomodRuleWords}, hitTypes(hits))
+
+	// Messages outside of guilds are not evaluated.
+	m := message("user", "spam", 0)
+	m.GuildID = ""
+	require.Empty(t, NewEngine().Evaluate(cfg, m))
+}
+
+func TestValidateRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    entities.AutomodRuleType
+		setting entities.AutomodRule
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			rule: entities.AutomodRuleRate,
+		},
+		{
+			name:    "low threshold",
+			rule:    entities.AutomodRuleDuplicate,
+			setting: entities.AutomodRule{Threshold: 1},
+			wantErr: "the threshold must be at least 2 messages",
+		},
+		{
+			name:    "long window",
+			rule:    entities.AutomodRuleRate,
+			setting: entities.AutomodRule{Window: time.Hour},
+			wantErr: "the window must be between 1s and 10m",
+		},
+		{
+			name:    "no words",
+			rule:    entities.AutomodRuleWords,
+			wantErr: "the words rule needs at least one value",
+		},
+		{
+			name:    "invalid regex",
+			rule:    entities.AutomodRuleRegex,
+			setting: entities.AutomodRule{Values: []string{"("}},
+			wantErr: `"(" is not a valid regular expression`,
+		},
+		{
+			name:    "default timeout",
+			rule:    entities.AutomodRuleInvites,
+			setting: entities.AutomodRule{Actions: []entities.AutomodAction{entities.AutomodActionDelete, entities.AutomodActionTimeout}},
+		},
+		{
+			name: "long timeout",
+			rule: entities.AutomodRuleInvites,
+			setting: entities.AutomodRule{
+				Actions:         []entities.AutomodAction{entities.AutomodActionTimeout},
+				TimeoutDuration: 30 * 24 * time.Hour,
+			},
+			wantErr: "the timeout must be between 1s and 4w",
+		},
+		{
+			name:    "unknown type",
+			rule:    "unknown",
+			wantErr: `unknown rule type "unknown"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.setting
+			ApplyDefaults(tt.rule, &rule)
+
+			err := ValidateRule(tt.rule, &rule)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package automod
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/Jacobbrewer1/wolf/pkg/custom"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+)
+
+const (
+	// DefaultRateThreshold is the number of messages within the window that breaks the rate rule by default.
+	DefaultRateThreshold = 5
+
+	// DefaultRateWindow is the window of the rate rule by default.
+	DefaultRateWindow = 5 * time.Second
+
+	// DefaultDuplicateThreshold is the number of the same message within the window that breaks the duplicate rule by
+	// default.
+	DefaultDuplicateThreshold = 3
+
+	// DefaultDuplicateWindow is the window of the duplicate rule by default.
+	DefaultDuplicateWindow = 30 * time.Second
+
+	// DefaultMentionsThreshold is the number of mentions in a message that breaks the mentions rule by default.
+	DefaultMentionsThreshold = 5
+
+	// DefaultTimeoutDuration is how long a member is timed out for by default.
+	DefaultTimeoutDuration = 10 * time.Minute
+
+	// MaxTimeoutDuration is the longest that Discord allows a member to be timed out for.
+	MaxTimeoutDuration = 28 * 24 * time.Hour
+)
+
+// ApplyDefaults sets the settings of the rule that have not been set to the defaults of its type.
+func ApplyDefaults(t entities.AutomodRuleType, rule *entities.AutomodRule) {
+	switch t {
+	case entities.AutomodRuleRate:
+		if rule.Threshold == 0 {
+			rule.Threshold = DefaultRateThreshold
+		}
+		if rule.Window == 0 {
+			rule.Window = DefaultRateWindow
+		}
+	case entities.AutomodRuleDuplicate:
+		if rule.Threshold == 0 {
+			rule.Threshold = DefaultDuplicateThreshold
+		}
+		if rule.Window == 0 {
+			rule.Window = DefaultDuplicateWindow
+		}
+	case entities.AutomodRuleMentions:
+		if rule.Threshold == 0 {
+			rule.Threshold = DefaultMentionsThreshold
+		}
+	}
+
+	if rule.HasAction(entities.AutomodActionTimeout) && rule.TimeoutDuration == 0 {
+		rule.TimeoutDuration = DefaultTimeoutDuration
+	}
+}
+
+// ValidateRule checks that the rule can be evaluated. The error describes the problem to the user that configured the
+// rule.
+func ValidateRule(t entities.AutomodRuleType, rule *entities.AutomodRule) error {
+	switch t {
+	case entities.AutomodRuleRate, entities.AutomodRuleDuplicate:
+		if rule.Threshold < 2 {
+			return errors.New("the threshold must be at least 2 messages")
+		}
+		if rule.Window <= 0 || rule.Window > MaxWindow {
+			return fmt.Errorf("the window must be between 1s and %s", custom.FormatDuration(MaxWindow))
+		}
+	case entities.AutomodRuleMentions:
+		if rule.Threshold < 1 {
+			return errors.New("the threshold must be at least 1 mention")
+		}
+	case entities.AutomodRuleInvites:
+	case entities.AutomodRuleDomains, entities.AutomodRuleWords:
+		if len(rule.Values) == 0 {
+			return fmt.Errorf("the %s rule needs at least one value", t)
+		}
+	case entities.AutomodRuleRegex:
+		if len(rule.Values) == 0 {
+			return fmt.Errorf("the %s rule needs at least one value", t)
+		}
+		for _, pattern := range rule.Values {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("%q is not a valid regular expression", pattern)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown rule type %q", t)
+	}
+
+	for _, action := range rule.Actions {
+		switch action {
+		case entities.AutomodActionDelete, entities.AutomodActionWarn, entities.AutomodActionLog:
+		case entities.AutomodActionTimeout:
+			if rule.TimeoutDuration <= 0 || rule.TimeoutDuration > MaxTimeoutDuration {
+				return fmt.Errorf("the timeout must be between 1s and %s", custom.FormatDuration(MaxTimeoutDuration))
+			}
+		default:
+			return fmt.Errorf("unknown action %q", action)
+		}
+	}
+	return nil
+}
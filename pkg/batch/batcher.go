@@ -0,0 +1,167 @@
+package batch
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxItems is the maximum number of items flushed at once for a key.
+	DefaultMaxItems = 10
+
+	// DefaultMaxPending is the maximum number of items waiting to be flushed for a key.
+	DefaultMaxPending = 500
+
+	// DefaultInterval is how often the pending items are flushed.
+	DefaultInterval = 2 * time.Second
+)
+
+// FlushFunc delivers a batch of items for a key. The batches of a key are delivered in order, one at a time.
+type FlushFunc[T any] func(ctx context.Context, key string, items []T)
+
+// Option configures a Batcher.
+type Option func(o *options)
+
+// options are the options of a Batcher.
+type options struct {
+	// maxItems is the maximum number of items flushed at once for a key.
+	maxItems int
+
+	// maxPending is the maximum number of items waiting to be flushed for a key.
+	maxPending int
+
+	// interval is how often the pending items are flushed.
+	interval time.Duration
+}
+
+// WithMaxItems sets the maximum number of items flushed at once for a key. A key is flushed early when it has this
+// many items pending.
+func WithMaxItems(n int) Option {
+	return func(o *options) {
+		o.maxItems = n
+	}
+}
+
+// WithMaxPending sets the maximum number of items waiting to be flushed for a key. Items added to a key that is full
+// are dropped.
+func WithMaxPending(n int) Option {
+	return func(o *options) {
+		o.maxPending = n
+	}
+}
+
+// WithInterval sets how often the pending items are flushed.
+func WithInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.interval = interval
+	}
+}
+
+// Batcher groups the items added for each key, and flushes them in batches. This turns many small deliveries into
+// fewer large ones, such as sending many embeds in a single message. It is safe for concurrent use.
+type Batcher[T any] struct {
+	// mut guards the pending items.
+	mut sync.Mutex
+
+	// pending are the items waiting to be flushed, by key.
+	pending map[string][]T
+
+	// flush delivers the batches.
+	flush FlushFunc[T]
+
+	// full is signalled when a key has a full batch pending, so it is flushed before the interval.
+	full chan struct{}
+
+	// opts are the options of the batcher.
+	opts options
+}
+
+// New creates a new Batcher that delivers the batches with the flush function.
+func New[T any](flush FlushFunc[T], opts ...Option) *Batcher[T] {
+	o := options{
+		maxItems:   DefaultMaxItems,
+		maxPending: DefaultMaxPending,
+		interval:   DefaultInterval,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.maxItems < 1 {
+		o.maxItems = 1
+	}
+	if o.maxPending < o.maxItems {
+		o.maxPending = o.maxItems
+	}
+
+	return &Batcher[T]{
+		pending: make(map[string][]T),
+		flush:   flush,
+		full:    make(chan struct{}, 1),
+		opts:    o,
+	}
+}
+
+// Add adds the item to the pending items of the key. It returns false if the item was dropped, as the key has too
+// many items pending.
+func (b *Batcher[T]) Add(key string, item T) bool {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	if len(b.pending[key]) >= b.opts.maxPending {
+		return false
+	}
+
+	b.pending[key] = append(b.pending[key], item)
+
+	if len(b.pending[key]) >= b.opts.maxItems {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+// Run flushes the pending items until the context is cancelled. The items still pending when the context is cancelled
+// are flushed before it returns, with a context that is not cancelled.
+func (b *Batcher[T]) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.opts.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.Flush(context.WithoutCancel(ctx))
+			return
+		case <-ticker.C:
+		case <-b.full:
+		}
+
+		b.Flush(ctx)
+	}
+}
+
+// Flush delivers every pending item, in batches of at most the maximum items. The keys are flushed in order.
+func (b *Batcher[T]) Flush(ctx context.Context) {
+	b.mut.Lock()
+	pending := b.pending
+	b.pending = make(map[string][]T, len(pending))
+	b.mut.Unlock()
+
+	keys := make([]string, 0, len(pending))
+	for key := range pending {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		items := pending[key]
+		for len(items) > 0 {
+			n := min(len(items), b.opts.maxItems)
+			b.flush(ctx, key, items[:n])
+			items = items[n:]
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package batch
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recorder records the batches that are flushed.
+type recorder struct {
+	mut     sync.Mutex
+	batches map[string][][]int
+	flushed chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		batches: make(map[string][][]int),
+		flushed: make(chan struct{}, 100),
+	}
+}
+
+func (r *recorder) flush(_ context.Context, key string, items []int) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.batches[key] = append(r.batches[key], append([]int(nil), items...))
+	r.flushed <- struct{}{}
+}
+
+func (r *recorder) get(key string) [][]int {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	return r.batches[key]
+}
+
+func TestBatcher_Flush(t *testing.T) {
+	rec := newRecorder()
+	b := New(rec.flush, WithMaxItems(2))
+
+	for i := 1; i <= 5; i++ {
+		require.True(t, b.Add("a", i))
+	}
+	require.True(t, b.Add("b", 10))
+
+	b.Flush(context.Background())
+
+	require.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, rec.get("a"))
+	require.Equal(t, [][]int{{10}}, rec.get("b"))
+
+	// The flushed items are not delivered again.
+	b.Flush(context.Background())
+	require.Len(t, rec.get("a"), 3)
+}
+
+func TestBatcher_Add_MaxPending(t *testing.T) {
+	rec := newRecorder()
+	b := New(rec.flush, WithMaxItems(2), WithMaxPending(3))
+
+	require.True(t, b.Add("a", 1))
+	require.True(t, b.Add("a", 2))
+	require.True(t, b.Add("a", 3))
+	require.False(t, b.Add("a", 4))
+
+	// Other keys are not affected by a full key.
+	require.True(t, b.Add("b", 1))
+
+	b.Flush(context.Background())
+	require.Equal(t, [][]int{{1, 2}, {3}}, rec.get("a"))
+
+	// The key accepts items again once it has been flushed.
+	require.True(t, b.Add("a", 5))
+}
+
+func TestBatcher_Run(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		add     []int
+		want    [][]int
+		waitFor int
+	}{
+		{
+			name:    "interval",
+			opts:    []Option{WithInterval(10 * time.Millisecond)},
+			add:     []int{1, 2},
+			want:    [][]int{{1, 2}},
+			waitFor: 1,
+		},
+		{
+			name:    "full batch before interval",
+			opts:    []Option{WithMaxItems(2), WithInterval(time.Hour)},
+			add:     []int{1, 2},
+			want:    [][]int{{1, 2}},
+			waitFor: 1,
+		},
+		{
+			name: "pending flushed on stop",
+			opts: []Option{WithInterval(time.Hour)},
+			add:  []int{1},
+			want: [][]int{{1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := newRecorder()
+			b := New(rec.flush, tt.opts...)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				b.Run(ctx)
+			}()
+
+			for _, item := range tt.add {
+				b.Add("a", item)
+			}
+
+			for i := 0; i < tt.waitFor; i++ {
+				select {
+				case <-rec.flushed:
+				case <-time.After(time.Second):
+					t.Fatal("batch was not flushed")
+				}
+			}
+
+			cancel()
+			<-done
+
+			require.Equal(t, tt.want, rec.get("a"))
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache is a size bound cache whose entries expire after a time to live. When the cache is full, the least recently
+// used entry is evicted. It is safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	// mut guards the entries.
+	mut sync.Mutex
+
+	// size is the maximum number of entries.
+	size int
+
+	// ttl is how long an entry is kept for.
+	ttl time.Duration
+
+	// items are the entries, keyed by their key.
+	items map[K]*list.Element
+
+	// order is the entries in order of use, the most recently used first.
+	order *list.List
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+// entry is an entry in the cache.
+type entry[K comparable, V any] struct {
+	// key is the key of the entry.
+	key K
+
+	// value is the cached value.
+	value V
+
+	// expires is when the entry expires.
+	expires time.Time
+}
+
+// New creates a new Cache that holds up to size entries for the ttl.
+func New[K comparable, V any](size int, ttl time.Duration) *Cache[K, V] {
+	if size < 1 {
+		size = 1
+	}
+
+	return &Cache[K, V]{
+		size:  size,
+		ttl:   ttl,
+		items: make(map[K]*list.Element, size),
+		order: list.New(),
+		now:   time.Now,
+	}
+}
+
+// Get returns the value for the key, and whether it was found and has not expired.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	var zero V
+
+	elem, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+
+	e := elem.Value.(*entry[K, V])
+	if !c.now().Before(e.expires) {
+		c.remove(elem)
+		return zero, false
+	}
+
+	c.order.MoveToFront(elem)
+	return e.value, true
+}
+
+// Set sets the value for the key, evicting the least recently used entry if the cache is full.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	expires := c.now().Add(c.ttl)
+
+	if elem, ok := c.items[key]; ok {
+		e := elem.Value.(*entry[K, V])
+		e.value = value
+		e.expires = expires
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	if c.order.Len() >= c.size {
+		c.remove(c.order.Back())
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{
+		key:     key,
+		value:   value,
+		expires: expires,
+	})
+}
+
+// Delete removes the key from the cache.
+func (c *Cache[K, V]) Delete(key K) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.remove(elem)
+	}
+}
+
+// Purge removes every entry from the cache.
+func (c *Cache[K, V]) Purge() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.items = make(map[K]*list.Element, c.size)
+	c.order.Init()
+}
+
+// Len returns the number of entries in the cache, including any that have expired but not yet been removed.
+func (c *Cache[K, V]) Len() int {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.order.Len()
+}
+
+// remove removes the entry from the cache.
+func (c *Cache[K, V]) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*entry[K, V]).key)
+}
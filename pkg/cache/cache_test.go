@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// clock is a fake clock for the tests.
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.t
+}
+
+func newTestCache(size int, ttl time.Duration) (*Cache[string, int], *clock) {
+	clk := &clock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := New[string, int](size, ttl)
+	c.now = clk.Now
+	return c, clk
+}
+
+func TestCache_GetSet(t *testing.T) {
+	c, _ := newTestCache(2, time.Minute)
+
+	_, ok := c.Get("a")
+	require.False(t, ok)
+
+	c.Set("a", 1)
+	got, ok := c.Get("a")
+	require.True(t, ok)
+	require.Equal(t, 1, got)
+
+	// Setting an existing key replaces the value.
+	c.Set("a", 2)
+	got, ok = c.Get("a")
+	require.True(t, ok)
+	require.Equal(t, 2, got)
+	require.Equal(t, 1, c.Len())
+}
+
+func TestCache_Expiry(t *testing.T) {
+	c, clk := newTestCache(2, time.Minute)
+
+	c.Set("a", 1)
+
+	clk.t = clk.t.Add(59 * time.Second)
+	_, ok := c.Get("a")
+	require.True(t, ok)
+
+	clk.t = clk.t.Add(time.Second)
+	_, ok = c.Get("a")
+	require.False(t, ok)
+	require.Equal(t, 0, c.Len())
+
+	// Setting the key again restarts the time to live.
+	c.Set("a", 1)
+	clk.t = clk.t.Add(30 * time.Second)
+	c.Set("a", 2)
+	clk.t = clk.t.Add(45 * time.Second)
+	got, ok := c.Get("a")
+	require.True(t, ok)
+	require.Equal(t, 2, got)
+}
+
+func TestCache_Eviction(t *testing.T) {
+	c, _ := newTestCache(2, time.Minute)
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	// Using a makes b the least recently used.
+	_, ok := c.Get("a")
+	require.True(t, ok)
+
+	c.Set("c", 3)
+	require.Equal(t, 2, c.Len())
+
+	_, ok = c.Get("b")
+	require.False(t, ok)
+
+	_, ok = c.Get("a")
+	require.True(t, ok)
+
+	_, ok = c.Get("c")
+	require.True(t, ok)
+}
+
+func TestCache_DeletePurge(t *testing.T) {
+	c, _ := newTestCache(3, time.Minute)
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	c.Delete("a")
+	c.Delete("missing")
+	_, ok := c.Get("a")
+	require.False(t, ok)
+	require.Equal(t, 2, c.Len())
+
+	c.Purge()
+	require.Equal(t, 0, c.Len())
+
+	_, ok = c.Get("b")
+	require.False(t, ok)
+
+	// The cache is usable after a purge.
+	c.Set("d", 4)
+	got, ok := c.Get("d")
+	require.True(t, ok)
+	require.Equal(t, 4, got)
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	c := New[string, int](10, time.Minute)
+
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := fmt.Sprintf("%d", j%20)
+				c.Set(key, i)
+				c.Get(key)
+				if j%10 == 0 {
+					c.Delete(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	require.LessOrEqual(t, c.Len(), 10)
+}
@@ -0,0 +1,259 @@
+package captcha
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Kind is the kind of challenge that is asked.
+type Kind string
+
+const (
+	// KindMath is a sum, such as 7 + 5, which is answered with the result.
+	KindMath Kind = "math"
+
+	// KindText is a random text, which is answered by typing it.
+	KindText Kind = "text"
+)
+
+const (
+	// TextLength is the number of characters of a text challenge.
+	TextLength = 6
+
+	// textAlphabet are the characters of a text challenge. The characters that are easily confused, such as 0 and O,
+	// are left out.
+	textAlphabet = "ACDEFHJKLMNPRTUVWXY2345679"
+
+	// dotSize is the size of a dot of a glyph, in pixels.
+	dotSize = 6
+
+	// glyphSpacing is the space between the glyphs, in pixels.
+	glyphSpacing = 10
+
+	// padding is the space around the text, in pixels.
+	padding = 20
+
+	// jitter is the most a glyph is moved up or down, in pixels.
+	jitter = 8
+
+	// noiseLines is the number of lines drawn across the image.
+	noiseLines = 6
+
+	// noiseDots is the number of dots scattered over the image, per glyph.
+	noiseDots = 60
+)
+
+var (
+	// ErrUnknownKind is returned when a challenge of an unknown kind is created.
+	ErrUnknownKind = errors.New("unknown captcha kind")
+
+	// ErrUnknownCharacter is returned when a challenge has a character that cannot be drawn.
+	ErrUnknownCharacter = errors.New("character cannot be drawn")
+)
+
+// Challenge is a captcha. The text is drawn, and the answer must be entered to pass it.
+type Challenge struct {
+	// Text is the text that is drawn.
+	Text string
+
+	// Answer is the answer that passes the challenge.
+	Answer string
+
+	// Seed is the seed of the noise of the image, so the same image is drawn every time.
+	Seed int64
+}
+
+// NewRand returns a random source that is seeded from a cryptographic source, so the challenges cannot be predicted.
+func NewRand() *rand.Rand {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		// This should never happen, the challenges are only as good as the seed so there is nothing better to fall
+		// back to.
+		panic(fmt.Errorf("error seeding captcha: %w", err))
+	}
+	return rand.New(rand.NewSource(int64(binary.LittleEndian.Uint64(b[:]))))
+}
+
+// New creates a challenge of the kind, chosen with the random source.
+func New(kind Kind, rnd *rand.Rand) (*Challenge, error) {
+	c := &Challenge{
+		Seed: rnd.Int63(),
+	}
+
+	switch kind {
+	case KindMath:
+		var a, b, result int
+		var op string
+		switch rnd.Intn(3) {
+		case 0:
+			a, b = rnd.Intn(20)+1, rnd.Intn(20)+1
+			op, result = "+", a+b
+		case 1:
+			a = rnd.Intn(16) + 5
+			b = rnd.Intn(a) + 1
+			op, result = "-", a-b
+		default:
+			a, b = rnd.Intn(8)+2, rnd.Intn(8)+2
+			op, result = "×", a*b
+		}
+		c.Text = fmt.Sprintf("%d %s %d = ?", a, op, b)
+		c.Answer = strconv.Itoa(result)
+	case KindText:
+		text := make([]byte, TextLength)
+		for i := range text {
+			text[i] = textAlphabet[rnd.Intn(len(textAlphabet))]
+		}
+		c.Text = string(text)
+		c.Answer = c.Text
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+	}
+	return c, nil
+}
+
+// Check returns true if the input is the answer to the challenge. The case and the spaces of the input are ignored.
+func (c *Challenge) Check(input string) bool {
+	return subtle.ConstantTimeCompare([]byte(normalize(input)), []byte(normalize(c.Answer))) == 1
+}
+
+// normalize returns the text without its spaces and in upper case.
+func normalize(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, text)
+}
+
+// PNG draws the text of the challenge as a PNG image. The glyphs are moved, the image is warped and noise is drawn
+// over it, so the text is hard to read by a machine.
+func (c *Challenge) PNG() ([]byte, error) {
+	img, err := c.draw()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		return nil, fmt.Errorf("error encoding image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// draw draws the text of the challenge.
+func (c *Challenge) draw() (*image.RGBA, error) {
+	text := []rune(c.Text)
+	rnd := rand.New(rand.NewSource(c.Seed))
+
+	glyphPixels := glyphWidth * dotSize
+	width := 2*padding + len(text)*glyphPixels + (len(text)-1)*glyphSpacing
+	height := 2*padding + glyphHeight*dotSize + 2*jitter
+
+	// The text is drawn on a mask first, so it can be warped as a whole.
+	mask := image.NewAlpha(image.Rect(0, 0, width, height))
+	for i, r := range text {
+		glyph, ok := glyphs[unicode.ToUpper(r)]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownCharacter, r)
+		}
+
+		x0 := padding + i*(glyphPixels+glyphSpacing)
+		y0 := padding + jitter + rnd.Intn(2*jitter+1) - jitter
+		slant := rnd.Float64()*0.4 - 0.2
+
+		for row, line := range glyph {
+			for col, dot := range line {
+				if dot != '#' {
+					continue
+				}
+				x := x0 + col*dotSize + int(slant*float64((glyphHeight-row)*dotSize))
+				y := y0 + row*dotSize
+				fillRect(mask, x, y, dotSize, dotSize)
+			}
+		}
+	}
+
+	background := color.RGBA{R: 240, G: 240, B: 235, A: 255}
+	img := image.NewRGBA(mask.Bounds())
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, background)
+		}
+	}
+
+	// Warp the text along a wave, and colour it with a gradient.
+	amplitude := 2 + rnd.Float64()*2
+	period := 80 + rnd.Float64()*60
+	phase := rnd.Float64() * 2 * math.Pi
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sx := x + int(amplitude*math.Sin(float64(y)/period*2*math.Pi+phase))
+			sy := y + int(amplitude*math.Cos(float64(x)/period*2*math.Pi+phase))
+			if !(image.Point{X: sx, Y: sy}.In(mask.Bounds())) || mask.AlphaAt(sx, sy).A == 0 {
+				continue
+			}
+			shade := uint8(40 + 80*x/width)
+			img.SetRGBA(x, y, color.RGBA{R: shade, G: 40, B: 120 - shade/2, A: 255})
+		}
+	}
+
+	// Draw the noise over the text.
+	for i := 0; i < noiseLines; i++ {
+		drawLine(img, rnd.Intn(width), rnd.Intn(height), rnd.Intn(width), rnd.Intn(height), randomColor(rnd))
+	}
+	for i := 0; i < noiseDots*len(text); i++ {
+		img.SetRGBA(rnd.Intn(width), rnd.Intn(height), randomColor(rnd))
+	}
+	return img, nil
+}
+
+// fillRect fills the rectangle of the mask, clipped to its bounds.
+func fillRect(mask *image.Alpha, x, y, w, h int) {
+	r := image.Rect(x, y, x+w, y+h).Intersect(mask.Bounds())
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			mask.SetAlpha(px, py, color.Alpha{A: 255})
+		}
+	}
+}
+
+// drawLine draws a line between the points, two pixels thick.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	steps := max(abs(x1-x0), abs(y1-y0))
+	for i := 0; i <= steps; i++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		x := x0 + int(t*float64(x1-x0))
+		y := y0 + int(t*float64(y1-y0))
+		img.SetRGBA(x, y, c)
+		img.SetRGBA(x, y+1, c)
+	}
+}
+
+// randomColor returns a random dark colour, so the noise looks like the text.
+func randomColor(rnd *rand.Rand) color.RGBA {
+	return color.RGBA{R: uint8(rnd.Intn(160)), G: uint8(rnd.Intn(160)), B: uint8(rnd.Intn(160)), A: 255}
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
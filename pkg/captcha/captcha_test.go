@@ -0,0 +1,111 @@
+package captcha
+
+import (
+	"bytes"
+	"image/png"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name string
+		kind Kind
+	}{
+		{name: "math", kind: KindMath},
+		{name: "text", kind: KindText},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rnd := rand.New(rand.NewSource(1))
+			for i := 0; i < 100; i++ {
+				c, err := New(tt.kind, rnd)
+				require.NoError(t, err)
+				require.True(t, c.Check(c.Answer))
+
+				switch tt.kind {
+				case KindMath:
+					a, op, b := parseMath(t, c.Text)
+
+					want := map[string]int{"+": a + b, "-": a - b, "×": a * b}[op]
+					require.Equal(t, strconv.Itoa(want), c.Answer)
+					require.GreaterOrEqual(t, want, 0)
+				case KindText:
+					require.Len(t, c.Text, TextLength)
+					for _, r := range c.Text {
+						require.Contains(t, textAlphabet, string(r))
+					}
+				}
+			}
+		})
+	}
+
+	_, err := New("unknown", rand.New(rand.NewSource(1)))
+	require.ErrorIs(t, err, ErrUnknownKind)
+}
+
+// parseMath parses the text of a math challenge, such as "7 + 5 = ?".
+func parseMath(t *testing.T, text string) (int, string, int) {
+	t.Helper()
+
+	parts := strings.Fields(text)
+	require.Len(t, parts, 5)
+
+	a, err := strconv.Atoi(parts[0])
+	require.NoError(t, err)
+
+	b, err := strconv.Atoi(parts[2])
+	require.NoError(t, err)
+	return a, parts[1], b
+}
+
+func TestChallenge_Check(t *testing.T) {
+	c := &Challenge{Text: "AC3 D4E", Answer: "AC3D4E"}
+
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "exact", input: "AC3D4E", want: true},
+		{name: "lower case", input: "ac3d4e", want: true},
+		{name: "spaces", input: " ac3 d4e ", want: true},
+		{name: "wrong", input: "AC3D4F", want: false},
+		{name: "short", input: "AC3D4", want: false},
+		{name: "empty", input: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, c.Check(tt.input))
+		})
+	}
+}
+
+func TestChallenge_PNG(t *testing.T) {
+	c, err := New(KindMath, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+
+	b, err := c.PNG()
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(b))
+	require.NoError(t, err)
+
+	glyphs := len([]rune(c.Text))
+	require.Equal(t, 2*padding+glyphs*glyphWidth*dotSize+(glyphs-1)*glyphSpacing, img.Bounds().Dx())
+	require.Equal(t, 2*padding+glyphHeight*dotSize+2*jitter, img.Bounds().Dy())
+
+	// The same image is drawn every time.
+	again, err := c.PNG()
+	require.NoError(t, err)
+	require.Equal(t, b, again)
+
+	_, err = (&Challenge{Text: "abc!"}).PNG()
+	require.ErrorIs(t, err, ErrUnknownCharacter)
+}
@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Jacobbrewer1/discordgo"
+)
+
+// Handler handles an interaction.
+type Handler func(c *Context) error
+
+// Command is a slash command, or a sub command of one.
+type Command struct {
+	// Name is the name of the command.
+	Name string
+
+	// Description is the description of the command.
+	Description string
+
+	// Options is a pointer to a struct whose tagged fields are the options of the command. A new struct of the same
+	// type is bound for each interaction and can be retrieved with Context.Options.
+	Options any
+
+	// Permissions are the Discord permissions the member requires to use the command.
+	Permissions int64
+
+	// Roles are the roles that allow a member to use the command. A member with any of the roles is allowed, even if
+	// they do not have the Permissions.
+	Roles []string
+
+	// Guards are additional checks that are run before the handler.
+	Guards []Guard
+
+	// GuildOnly is whether the command can only be used in a guild, and not in DMs.
+	GuildOnly bool
+
+	// Ephemeral is whether the deferred acknowledgement, sent when the handler is slow to respond, is only visible to
+	// the user. This is inherited by the sub commands.
+	Ephemeral bool
+
+	// Subcommands are the sub commands of the command. A command with sub commands does not have options or a
+	// handler of its own. A sub command with sub commands is a sub command group.
+	Subcommands []*Command
+
+	// Handler handles the command.
+	Handler Handler
+
+	// fields are the parsed option fields.
+	fields []*optionField
+}
+
+// Definition builds the application command that is registered with Discord.
+func (c *Command) Definition() (*discordgo.ApplicationCommand, error) {
+	if err := c.prepare(0); err != nil {
+		return nil, fmt.Errorf("command %s: %w", c.Name, err)
+	}
+
+	def := &discordgo.ApplicationCommand{
+		Name:        c.Name,
+		Type:        discordgo.ChatApplicationCommand,
+		Description: c.Description,
+		Options:     c.optionDefinitions(),
+	}
+
+	if c.Permissions != 0 {
+		perms := c.Permissions
+		def.DefaultMemberPermissions = &perms
+	}
+
+	if c.GuildOnly {
+		dm := false
+		def.DMPermission = &dm
+	}
+
+	return def, nil
+}
+
+// prepare validates the command and parses the options of it and its sub commands.
+func (c *Command) prepare(depth int) error {
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if len(c.Subcommands) == 0 {
+		if c.Handler == nil {
+			return errors.New("handler is required")
+		}
+
+		fields, err := parseOptions(c.Options)
+		if err != nil {
+			return err
+		}
+		c.fields = fields
+		return nil
+	}
+
+	// Discord only allows sub command groups to be nested one level deep.
+	if depth > 1 {
+		return errors.New("sub commands are nested too deeply")
+	}
+
+	if c.Handler != nil || c.Options != nil {
+		return errors.New("a command with sub commands cannot have a handler or options")
+	}
+
+	for _, sub := range c.Subcommands {
+		if err := sub.prepare(depth + 1); err != nil {
+			return fmt.Errorf("sub command %s: %w", sub.Name, err)
+		}
+	}
+	return nil
+}
+
+// optionDefinitions returns the definitions of the options or sub commands of the command.
+func (c *Command) optionDefinitions() []*discordgo.ApplicationCommandOption {
+	if len(c.Subcommands) == 0 {
+		opts := make([]*discordgo.ApplicationCommandOption, 0, len(c.fields))
+		for _, f := range c.fields {
+			opts = append(opts, f.option)
+		}
+		return opts
+	}
+
+	opts := make([]*discordgo.ApplicationCommandOption, 0, len(c.Subcommands))
+	for _, sub := range c.Subcommands {
+		optType := discordgo.ApplicationCommandOptionSubCommand
+		if len(sub.Subcommands) > 0 {
+			optType = discordgo.ApplicationCommandOptionSubCommandGroup
+		}
+
+		opts = append(opts, &discordgo.ApplicationCommandOption{
+			Type:        optType,
+			Name:        sub.Name,
+			Description: sub.Description,
+			Options:     sub.optionDefinitions(),
+		})
+	}
+	return opts
+}
+
+// subcommand returns the sub command with the given name.
+func (c *Command) subcommand(name string) (*Command, bool) {
+	for _, sub := range c.Subcommands {
+		if sub.Name == name {
+			return sub, true
+		}
+	}
+	return nil, false
+}
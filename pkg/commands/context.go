@@ -0,0 +1,190 @@
+package commands
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Context is the context of an interaction that is being handled.
+type Context struct {
+	// InteractionCreate is the interaction being handled.
+	*discordgo.InteractionCreate
+
+	// ctx is the context of the request. It carries the logger for the interaction.
+	ctx context.Context
+
+	// session is the discord session that received the interaction.
+	session *discordgo.Session
+
+	// correlationID identifies the interaction in the logs. It is shown to the user when an error occurs.
+	correlationID string
+
+	// responder delivers the responses to the interaction.
+	responder *responder
+
+	// command is the full name of the command being handled, including any sub commands.
+	command string
+
+	// options are the options bound for the command.
+	options any
+
+	// args are the arguments encoded in the custom ID of a prefix component.
+	args []string
+
+	// values are the values chosen in a select menu.
+	values []string
+
+	// fields are the values of the text inputs of a submitted modal, keyed by their custom ID.
+	fields map[string]string
+}
+
+// newContext creates a new Context. The logger is tagged with the correlation ID, the trace ID and the guild, channel
+// and user of the interaction.
+func newContext(ctx context.Context, l *slog.Logger, s *discordgo.Session, i *discordgo.InteractionCreate) *Context {
+	correlationID := newCorrelationID()
+
+	l = l.With(
+		slog.String(logging.KeyCorrelationID, correlationID),
+		slog.String(logging.KeyGuildID, i.GuildID),
+		slog.String(logging.KeyChannelID, i.ChannelID),
+		slog.String(logging.KeyUserID, interactionUserID(i.Interaction)),
+	)
+
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		l = l.With(slog.String(logging.KeyTraceID, sc.TraceID().String()))
+	}
+
+	return &Context{
+		InteractionCreate: i,
+		ctx:               logging.WithLogger(ctx, l),
+		session:           s,
+		correlationID:     correlationID,
+		responder:         newResponder(ctx, s, i.Interaction),
+	}
+}
+
+// newCorrelationID returns a short random ID that is easy to search for in the logs.
+func newCorrelationID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		// This should never happen, but the ID is only used for correlation so fall back to a fixed value.
+		return "00000000"
+	}
+	return hex.EncodeToString(b)
+}
+
+// interactionUserID returns the ID of the user that triggered the interaction.
+func interactionUserID(i *discordgo.Interaction) string {
+	switch {
+	case i.Member != nil && i.Member.User != nil:
+		return i.Member.User.ID
+	case i.User != nil:
+		return i.User.ID
+	default:
+		return ""
+	}
+}
+
+// Context returns the context of the request.
+func (c *Context) Context() context.Context {
+	return c.ctx
+}
+
+// Logger returns the logger for the interaction. It is tagged with the correlation ID, the guild, channel and user of
+// the interaction and, once resolved, the command.
+func (c *Context) Logger() *slog.Logger {
+	return logging.FromContext(c.ctx)
+}
+
+// CorrelationID returns the ID that identifies the interaction in the logs.
+func (c *Context) CorrelationID() string {
+	return c.correlationID
+}
+
+// Session returns the discord session that received the interaction.
+func (c *Context) Session() *discordgo.Session {
+	return c.session
+}
+
+// Command returns the full name of the command being handled, including any sub commands. For message components
+// this is the custom ID of the component.
+func (c *Context) Command() string {
+	return c.command
+}
+
+// setCommand sets the full name of the command being handled, and adds it to the logger and the span of the
+// interaction.
+func (c *Context) setCommand(command string) {
+	c.command = command
+	c.ctx = logging.WithLogger(c.ctx, c.Logger().With(slog.String(logging.KeyCommand, command)))
+
+	span := trace.SpanFromContext(c.ctx)
+	span.SetName("interaction " + command)
+	span.SetAttributes(attribute.String(logging.KeyCommand, command))
+}
+
+// Options returns the options bound for the command. This is a pointer to a struct of the same type as the Options
+// of the command, or nil if the command has no options.
+func (c *Context) Options() any {
+	return c.options
+}
+
+// Args returns the arguments encoded in the custom ID of a prefix component, or nil for any other interaction.
+func (c *Context) Args() []string {
+	return c.args
+}
+
+// Values returns the values chosen in a select menu, or nil for any other interaction.
+func (c *Context) Values() []string {
+	return c.values
+}
+
+// Field returns the value of the text input of a submitted modal, or an empty string if the modal has no such input.
+func (c *Context) Field(customID string) string {
+	return c.fields[customID]
+}
+
+// Respond responds to the interaction with a message. If the response has been deferred, the deferred response is
+// replaced, and if a response has already been delivered the message is sent as a followup.
+func (c *Context) Respond(data *discordgo.InteractionResponseData) error {
+	return c.responder.respond(data)
+}
+
+// RespondEphemeral responds to the interaction with a message that only the user can see.
+func (c *Context) RespondEphemeral(content string) error {
+	return c.Respond(&discordgo.InteractionResponseData{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+}
+
+// RespondModal responds to the interaction with a modal. A modal can only be the first response to an interaction, so
+// it cannot be shown once the interaction has been deferred or responded to. The submission of the modal is routed to
+// the component registered for the custom ID of the modal.
+func (c *Context) RespondModal(data *discordgo.InteractionResponseData) error {
+	return c.responder.modal(data)
+}
+
+// Acknowledge acknowledges a message component without sending a message, so the member is not told anything. If a
+// deferred acknowledgement has already been sent, it is removed. It does nothing once a response has been delivered.
+func (c *Context) Acknowledge() error {
+	return c.responder.acknowledge()
+}
+
+// Defer sends a deferred acknowledgement for the interaction, if nothing has been sent yet. This is sent
+// automatically when the handler has not responded within the defer threshold of the router.
+func (c *Context) Defer(ephemeral bool) error {
+	return c.responder.deferResponse(ephemeral)
+}
+
+// Responded returns true if a response has been delivered for the interaction.
+func (c *Context) Responded() bool {
+	return c.responder.responded()
+}
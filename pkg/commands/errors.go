@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Jacobbrewer1/discordgo"
+)
+
+var (
+	// ErrUnknownCommand is returned when there is no command registered for an interaction.
+	ErrUnknownCommand = errors.New("unknown command")
+
+	// ErrUnknownComponent is returned when there is no handler registered for a message component.
+	ErrUnknownComponent = errors.New("unknown component")
+
+	// ErrModalNotFirst is returned when a modal is shown after the interaction has been deferred or responded to.
+	ErrModalNotFirst = errors.New("a modal can only be the first response to an interaction")
+
+	// ErrUnknownInteraction is returned when the interaction type is not supported.
+	ErrUnknownInteraction = errors.New("unknown interaction type")
+)
+
+// UserError is an error whose message is shown to the user that triggered the interaction.
+type UserError struct {
+	// Message is the message shown to the user.
+	Message string
+}
+
+// NewUserError creates a new UserError.
+func NewUserError(format string, args ...any) *UserError {
+	return &UserError{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// Error implements the error interface.
+func (e *UserError) Error() string {
+	return e.Message
+}
+
+// PanicError is the error returned when a handler panics.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// ErrorClass is the class of an error returned while handling an interaction.
+type ErrorClass string
+
+const (
+	// ErrorClassUser is an error meant for the user, such as a failed guard or an invalid option.
+	ErrorClassUser ErrorClass = "user"
+
+	// ErrorClassPanic is a handler that panicked.
+	ErrorClassPanic ErrorClass = "panic"
+
+	// ErrorClassUnknown is an interaction that has no handler registered.
+	ErrorClassUnknown ErrorClass = "unknown"
+
+	// ErrorClassTimeout is a handler that ran out of time, or was cancelled by a shutdown.
+	ErrorClassTimeout ErrorClass = "timeout"
+
+	// ErrorClassDiscord is an error returned by the Discord API.
+	ErrorClassDiscord ErrorClass = "discord"
+
+	// ErrorClassInternal is any other error.
+	ErrorClassInternal ErrorClass = "internal"
+)
+
+// Classify returns the class of the error.
+func Classify(err error) ErrorClass {
+	var (
+		userErr  *UserError
+		panicErr *PanicError
+		restErr  *discordgo.RESTError
+	)
+
+	switch {
+	case errors.As(err, &userErr):
+		return ErrorClassUser
+	case errors.As(err, &panicErr):
+		return ErrorClassPanic
+	case errors.Is(err, ErrUnknownCommand), errors.Is(err, ErrUnknownComponent), errors.Is(err, ErrUnknownInteraction):
+		return ErrorClassUnknown
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return ErrorClassTimeout
+	case errors.As(err, &restErr):
+		return ErrorClassDiscord
+	default:
+		return ErrorClassInternal
+	}
+}
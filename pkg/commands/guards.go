@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/messages"
+)
+
+// Guard is a check that is run before a handler. A guard denies the interaction by returning a UserError, any other
+// error is treated as a failure.
+type Guard func(c *Context) error
+
+// RequirePermissions returns a guard that only allows members with all the given permissions.
+func RequirePermissions(perms int64) Guard {
+	return func(c *Context) error {
+		if c.Member == nil || !hasPermissions(c.Member, perms) {
+			return NewUserError(messages.ErrUserNoPermission)
+		}
+		return nil
+	}
+}
+
+// RequireRoles returns a guard that only allows members with any of the given roles.
+func RequireRoles(roleIDs ...string) Guard {
+	return func(c *Context) error {
+		if c.Member == nil || !hasAnyRole(c.Member, roleIDs) {
+			return NewUserError(messages.ErrUserNoPermission)
+		}
+		return nil
+	}
+}
+
+// checkGuards runs the guards of the command against the interaction.
+func checkGuards(c *Context, cmd *Command) error {
+	if cmd.GuildOnly && c.GuildID == "" {
+		return NewUserError(messages.ErrUserGuildOnly)
+	}
+
+	if cmd.Permissions != 0 || len(cmd.Roles) > 0 {
+		if c.Member == nil {
+			return NewUserError(messages.ErrUserNoPermission)
+		}
+
+		allowed := cmd.Permissions != 0 && hasPermissions(c.Member, cmd.Permissions)
+		if !allowed && len(cmd.Roles) > 0 {
+			allowed = hasAnyRole(c.Member, cmd.Roles)
+		}
+
+		if !allowed {
+			return NewUserError(messages.ErrUserNoPermission)
+		}
+	}
+
+	for _, guard := range cmd.Guards {
+		if err := guard(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasPermissions returns true if the member has all the permissions, or is an administrator.
+func hasPermissions(member *discordgo.Member, perms int64) bool {
+	if member.Permissions&discordgo.PermissionAdministrator == discordgo.PermissionAdministrator {
+		return true
+	}
+	return member.Permissions&perms == perms
+}
+
+// hasAnyRole returns true if the member has any of the roles.
+func hasAnyRole(member *discordgo.Member, roleIDs []string) bool {
+	for _, role := range member.Roles {
+		for _, id := range roleIDs {
+			if role == id {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,315 @@
+package commands
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Jacobbrewer1/discordgo"
+)
+
+const (
+	// tagOption is the struct tag holding the name of the option.
+	tagOption = "option"
+
+	// tagDescription is the struct tag holding the description of the option.
+	tagDescription = "description"
+
+	// tagRequired is the struct tag marking the option as required.
+	tagRequired = "required"
+
+	// tagChoices is the struct tag holding the comma separated choices of a string option.
+	tagChoices = "choices"
+
+	// tagMin is the struct tag holding the minimum value of a number option.
+	tagMin = "min"
+
+	// tagMax is the struct tag holding the maximum value of a number option.
+	tagMax = "max"
+
+	// tagChannelTypes is the struct tag holding the comma separated channel types of a channel option.
+	tagChannelTypes = "channel_types"
+)
+
+var (
+	typeUser    = reflect.TypeOf((*discordgo.User)(nil))
+	typeChannel = reflect.TypeOf((*discordgo.Channel)(nil))
+	typeRole    = reflect.TypeOf((*discordgo.Role)(nil))
+)
+
+// channelTypes maps the names accepted by the channel_types tag to the channel type.
+var channelTypes = map[string]discordgo.ChannelType{
+	"text":     discordgo.ChannelTypeGuildText,
+	"voice":    discordgo.ChannelTypeGuildVoice,
+	"category": discordgo.ChannelTypeGuildCategory,
+	"news":     discordgo.ChannelTypeGuildNews,
+	"forum":    discordgo.ChannelTypeGuildForum,
+}
+
+// Validator is implemented by option structs that validate the values bound into them. The error returned is shown
+// to the user.
+type Validator interface {
+	Validate() error
+}
+
+// optionField is a field of an options struct that is bound to a command option.
+type optionField struct {
+	// index is the index of the field in the struct.
+	index int
+
+	// option is the definition of the option.
+	option *discordgo.ApplicationCommandOption
+}
+
+// parseOptions parses the option fields from an options struct. The options are given as a pointer to a struct, with
+// each bound field tagged with the option name. For example:
+//
+//	type options struct {
+//		Channel *discordgo.Channel `option:"channel" description:"The channel" required:"true" channel_types:"text"`
+//		Reason  string             `option:"reason" description:"The reason"`
+//	}
+func parseOptions(opts any) ([]*optionField, error) {
+	if opts == nil {
+		return nil, nil
+	}
+
+	t := reflect.TypeOf(opts)
+	if t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("options must be a pointer to a struct, got %T", opts)
+	}
+	t = t.Elem()
+
+	fields := make([]*optionField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name, ok := f.Tag.Lookup(tagOption)
+		if !ok {
+			continue
+		}
+
+		optType, err := optionType(f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+
+		opt := &discordgo.ApplicationCommandOption{
+			Type:        optType,
+			Name:        name,
+			Description: f.Tag.Get(tagDescription),
+			Required:    f.Tag.Get(tagRequired) == "true",
+		}
+
+		if choices := f.Tag.Get(tagChoices); choices != "" {
+			if optType != discordgo.ApplicationCommandOptionString {
+				return nil, fmt.Errorf("field %s: choices are only supported on string options", f.Name)
+			}
+
+			for _, choice := range strings.Split(choices, ",") {
+				opt.Choices = append(opt.Choices, &discordgo.ApplicationCommandOptionChoice{
+					Name:  choice,
+					Value: choice,
+				})
+			}
+		}
+
+		if minimum := f.Tag.Get(tagMin); minimum != "" {
+			v, err := strconv.ParseFloat(minimum, 64)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: invalid minimum: %w", f.Name, err)
+			}
+			opt.MinValue = &v
+		}
+
+		if maximum := f.Tag.Get(tagMax); maximum != "" {
+			v, err := strconv.ParseFloat(maximum, 64)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: invalid maximum: %w", f.Name, err)
+			}
+			opt.MaxValue = v
+		}
+
+		if types := f.Tag.Get(tagChannelTypes); types != "" {
+			if optType != discordgo.ApplicationCommandOptionChannel {
+				return nil, fmt.Errorf("field %s: channel types are only supported on channel options", f.Name)
+			}
+
+			for _, ct := range strings.Split(types, ",") {
+				channelType, ok := channelTypes[ct]
+				if !ok {
+					return nil, fmt.Errorf("field %s: unknown channel type %s", f.Name, ct)
+				}
+				opt.ChannelTypes = append(opt.ChannelTypes, channelType)
+			}
+		}
+
+		fields = append(fields, &optionField{
+			index:  i,
+			option: opt,
+		})
+	}
+
+	// Discord requires the required options to be listed before the optional ones.
+	definitions := make([]*optionField, 0, len(fields))
+	for _, f := range fields {
+		if f.option.Required {
+			definitions = append(definitions, f)
+		}
+	}
+	for _, f := range fields {
+		if !f.option.Required {
+			definitions = append(definitions, f)
+		}
+	}
+
+	return definitions, nil
+}
+
+// optionType returns the option type for the field type.
+func optionType(t reflect.Type) (discordgo.ApplicationCommandOptionType, error) {
+	switch t {
+	case typeUser:
+		return discordgo.ApplicationCommandOptionUser, nil
+	case typeChannel:
+		return discordgo.ApplicationCommandOptionChannel, nil
+	case typeRole:
+		return discordgo.ApplicationCommandOptionRole, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return discordgo.ApplicationCommandOptionString, nil
+	case reflect.Int, reflect.Int64:
+		return discordgo.ApplicationCommandOptionInteger, nil
+	case reflect.Float64:
+		return discordgo.ApplicationCommandOptionNumber, nil
+	case reflect.Bool:
+		return discordgo.ApplicationCommandOptionBoolean, nil
+	default:
+		return 0, fmt.Errorf("unsupported option type %s", t)
+	}
+}
+
+// bindOptions creates a new options struct of the same type as proto and binds the interaction options into it by
+// name.
+func bindOptions(
+	proto any,
+	fields []*optionField,
+	opts []*discordgo.ApplicationCommandInteractionDataOption,
+	resolved *discordgo.ApplicationCommandInteractionDataResolved,
+) (any, error) {
+	if proto == nil {
+		return nil, nil
+	}
+
+	ptr := reflect.New(reflect.TypeOf(proto).Elem())
+	v := ptr.Elem()
+
+	byName := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(opts))
+	for _, opt := range opts {
+		byName[opt.Name] = opt
+	}
+
+	for _, f := range fields {
+		opt, ok := byName[f.option.Name]
+		if !ok {
+			if f.option.Required {
+				return nil, NewUserError("The %s option is required.", f.option.Name)
+			}
+			continue
+		}
+
+		if opt.Type != f.option.Type {
+			return nil, NewUserError("The %s option must be a %s.", f.option.Name, strings.ToLower(f.option.Type.String()))
+		}
+
+		field := v.Field(f.index)
+		switch opt.Type {
+		case discordgo.ApplicationCommandOptionString:
+			value := opt.StringValue()
+			if len(f.option.Choices) > 0 && !isChoice(f.option.Choices, value) {
+				return nil, NewUserError("%q is not a valid value for the %s option.", value, f.option.Name)
+			}
+			field.SetString(value)
+		case discordgo.ApplicationCommandOptionInteger:
+			value := opt.IntValue()
+			if err := checkRange(f.option, float64(value)); err != nil {
+				return nil, err
+			}
+			field.SetInt(value)
+		case discordgo.ApplicationCommandOptionNumber:
+			value := opt.FloatValue()
+			if err := checkRange(f.option, value); err != nil {
+				return nil, err
+			}
+			field.SetFloat(value)
+		case discordgo.ApplicationCommandOptionBoolean:
+			field.SetBool(opt.BoolValue())
+		case discordgo.ApplicationCommandOptionUser:
+			id := fmt.Sprint(opt.Value)
+			user := &discordgo.User{ID: id}
+			if resolved != nil && resolved.Users[id] != nil {
+				user = resolved.Users[id]
+			}
+			field.Set(reflect.ValueOf(user))
+		case discordgo.ApplicationCommandOptionChannel:
+			id := fmt.Sprint(opt.Value)
+			channel := &discordgo.Channel{ID: id}
+			if resolved != nil && resolved.Channels[id] != nil {
+				channel = resolved.Channels[id]
+			}
+			if len(f.option.ChannelTypes) > 0 && !isChannelType(f.option.ChannelTypes, channel.Type) {
+				return nil, NewUserError("The %s option is not a supported type of channel.", f.option.Name)
+			}
+			field.Set(reflect.ValueOf(channel))
+		case discordgo.ApplicationCommandOptionRole:
+			id := fmt.Sprint(opt.Value)
+			role := &discordgo.Role{ID: id}
+			if resolved != nil && resolved.Roles[id] != nil {
+				role = resolved.Roles[id]
+			}
+			field.Set(reflect.ValueOf(role))
+		}
+	}
+
+	bound := ptr.Interface()
+	if validator, ok := bound.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return nil, NewUserError("%s", err.Error())
+		}
+	}
+
+	return bound, nil
+}
+
+// isChoice returns true if the value is one of the choices.
+func isChoice(choices []*discordgo.ApplicationCommandOptionChoice, value string) bool {
+	for _, choice := range choices {
+		if choice.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// isChannelType returns true if the channel type is one of the types.
+func isChannelType(types []discordgo.ChannelType, t discordgo.ChannelType) bool {
+	for _, ct := range types {
+		if ct == t {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRange returns an error if the value is outside the minimum and maximum of the option.
+func checkRange(opt *discordgo.ApplicationCommandOption, value float64) error {
+	if opt.MinValue != nil && value < *opt.MinValue {
+		return NewUserError("The %s option must be at least %v.", opt.Name, *opt.MinValue)
+	}
+	if opt.MaxValue != 0 && value > opt.MaxValue {
+		return NewUserError("The %s option must be at most %v.", opt.Name, opt.MaxValue)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/stretchr/testify/require"
+)
+
+type testOptions struct {
+	Reason  string             `option:"reason" description:"The reason"`
+	Channel *discordgo.Channel `option:"channel" description:"The channel" required:"true" channel_types:"text"`
+	Days    int                `option:"days" description:"The days" min:"1" max:"7"`
+	Mode    string             `option:"mode" description:"The mode" choices:"on,off"`
+	Silent  bool               `option:"silent" description:"Silently"`
+	Ignored string
+}
+
+type validatedOptions struct {
+	Reason string `option:"reason" description:"The reason"`
+}
+
+func (o *validatedOptions) Validate() error {
+	if o.Reason == "" {
+		return errors.New("A reason must be given.")
+	}
+	return nil
+}
+
+func TestParseOptions(t *testing.T) {
+	fields, err := parseOptions(new(testOptions))
+	require.NoError(t, err)
+	require.Len(t, fields, 5)
+
+	// Required options are listed first.
+	require.Equal(t, "channel", fields[0].option.Name)
+	require.True(t, fields[0].option.Required)
+	require.Equal(t, discordgo.ApplicationCommandOptionChannel, fields[0].option.Type)
+	require.Equal(t, []discordgo.ChannelType{discordgo.ChannelTypeGuildText}, fields[0].option.ChannelTypes)
+
+	require.Equal(t, "reason", fields[1].option.Name)
+	require.Equal(t, discordgo.ApplicationCommandOptionString, fields[1].option.Type)
+
+	require.Equal(t, "days", fields[2].option.Name)
+	require.Equal(t, discordgo.ApplicationCommandOptionInteger, fields[2].option.Type)
+	require.Equal(t, 1.0, *fields[2].option.MinValue)
+	require.Equal(t, 7.0, fields[2].option.MaxValue)
+
+	require.Equal(t, "mode", fields[3].option.Name)
+	require.Len(t, fields[3].option.Choices, 2)
+
+	require.Equal(t, "silent", fields[4].option.Name)
+	require.Equal(t, discordgo.ApplicationCommandOptionBoolean, fields[4].option.Type)
+}
+
+func TestParseOptions_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    any
+		wantErr string
+	}{
+		{
+			name:    "not a pointer",
+			opts:    testOptions{},
+			wantErr: "options must be a pointer to a struct, got commands.testOptions",
+		},
+		{
+			name: "unsupported type",
+			opts: new(struct {
+				Value []string `option:"value"`
+			}),
+			wantErr: "field Value: unsupported option type []string",
+		},
+		{
+			name: "choices on a number",
+			opts: new(struct {
+				Value int `option:"value" choices:"1,2"`
+			}),
+			wantErr: "field Value: choices are only supported on string options",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseOptions(tt.opts)
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestBindOptions(t *testing.T) {
+	fields, err := parseOptions(new(testOptions))
+	require.NoError(t, err)
+
+	resolved := &discordgo.ApplicationCommandInteractionDataResolved{
+		Channels: map[string]*discordgo.Channel{
+			"123": {ID: "123", Name: "general", Type: discordgo.ChannelTypeGuildText},
+			"456": {ID: "456", Name: "voice", Type: discordgo.ChannelTypeGuildVoice},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		opts    []*discordgo.ApplicationCommandInteractionDataOption
+		want    *testOptions
+		wantErr error
+	}{
+		{
+			name: "bound by name in any order",
+			opts: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "silent", Type: discordgo.ApplicationCommandOptionBoolean, Value: true},
+				{Name: "days", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(3)},
+				{Name: "channel", Type: discordgo.ApplicationCommandOptionChannel, Value: "123"},
+				{Name: "reason", Type: discordgo.ApplicationCommandOptionString, Value: "spam"},
+				{Name: "mode", Type: discordgo.ApplicationCommandOptionString, Value: "on"},
+			},
+			want: &testOptions{
+				Reason:  "spam",
+				Channel: resolved.Channels["123"],
+				Days:    3,
+				Mode:    "on",
+				Silent:  true,
+			},
+		},
+		{
+			name:    "missing required",
+			opts:    nil,
+			wantErr: NewUserError("The channel option is required."),
+		},
+		{
+			name: "wrong type",
+			opts: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "channel", Type: discordgo.ApplicationCommandOptionString, Value: "123"},
+			},
+			wantErr: NewUserError("The channel option must be a channel."),
+		},
+		{
+			name: "wrong channel type",
+			opts: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "channel", Type: discordgo.ApplicationCommandOptionChannel, Value: "456"},
+			},
+			wantErr: NewUserError("The channel option is not a supported type of channel."),
+		},
+		{
+			name: "out of range",
+			opts: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "channel", Type: discordgo.ApplicationCommandOptionChannel, Value: "123"},
+				{Name: "days", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(8)},
+			},
+			wantErr: NewUserError("The days option must be at most 7."),
+		},
+		{
+			name: "invalid choice",
+			opts: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "channel", Type: discordgo.ApplicationCommandOptionChannel, Value: "123"},
+				{Name: "mode", Type: discordgo.ApplicationCommandOptionString, Value: "maybe"},
+			},
+			wantErr: NewUserError(`"maybe" is not a valid value for the mode option.`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bindOptions(new(testOptions), fields, tt.opts, resolved)
+			if tt.wantErr != nil {
+				require.Equal(t, tt.wantErr, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBindOptions_Validator(t *testing.T) {
+	fields, err := parseOptions(new(validatedOptions))
+	require.NoError(t, err)
+
+	_, err = bindOptions(new(validatedOptions), fields, nil, nil)
+	require.Equal(t, NewUserError("A reason must be given."), err)
+
+	got, err := bindOptions(new(validatedOptions), fields, []*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "reason", Type: discordgo.ApplicationCommandOptionString, Value: "spam"},
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, &validatedOptions{Reason: "spam"}, got)
+}
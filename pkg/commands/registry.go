@@ -0,0 +1,237 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"github.com/Jacobbrewer1/discordgo"
+)
+
+// GlobalScope is the guild ID used when registering commands globally.
+const GlobalScope = ""
+
+// Session is the subset of the discord session that the registry requires.
+type Session interface {
+	// ApplicationCommands returns the commands currently registered for the application in the guild.
+	ApplicationCommands(appID, guildID string, options ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error)
+
+	// ApplicationCommandBulkOverwrite replaces all the commands for the application in the guild.
+	ApplicationCommandBulkOverwrite(appID string, guildID string, commands []*discordgo.ApplicationCommand, options ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error)
+}
+
+// Registry keeps the slash commands registered with Discord in line with the desired definitions.
+type Registry struct {
+	// l is the logger.
+	l *slog.Logger
+
+	// appID is the ID of the application the commands belong to.
+	appID string
+
+	// definitions are the desired command definitions.
+	definitions []*discordgo.ApplicationCommand
+
+	// mut protects registered.
+	mut sync.RWMutex
+
+	// registered holds the IDs of the registered commands, keyed by guild ID and then command name.
+	registered map[string]map[string]string
+}
+
+// NewRegistry creates a new command registry for the given definitions.
+func NewRegistry(l *slog.Logger, appID string, definitions ...*discordgo.ApplicationCommand) *Registry {
+	return &Registry{
+		l:           l,
+		appID:       appID,
+		definitions: definitions,
+		registered:  make(map[string]map[string]string),
+	}
+}
+
+// Sync ensures that the commands registered in the guild match the definitions. Use GlobalScope to sync the global
+// commands. The commands are only overwritten when they differ, and the returned bool reports whether they were.
+func (r *Registry) Sync(s Session, guildID string) (bool, error) {
+	current, err := s.ApplicationCommands(r.appID, guildID)
+	if err != nil {
+		return false, fmt.Errorf("error getting registered commands: %w", err)
+	}
+
+	if equalCommands(current, r.definitions) {
+		r.l.Debug("Slash commands are up to date", slog.String("guild_id", guildID))
+		r.store(guildID, current)
+		return false, nil
+	}
+
+	created, err := s.ApplicationCommandBulkOverwrite(r.appID, guildID, r.definitions)
+	if err != nil {
+		return false, fmt.Errorf("error overwriting commands: %w", err)
+	}
+
+	r.l.Info("Slash commands registered",
+		slog.String("guild_id", guildID),
+		slog.Int("count", len(created)),
+	)
+
+	r.store(guildID, created)
+	return true, nil
+}
+
+// Clear removes all the commands registered for the application in the guild.
+func (r *Registry) Clear(s Session, guildID string) error {
+	if _, err := s.ApplicationCommandBulkOverwrite(r.appID, guildID, []*discordgo.ApplicationCommand{}); err != nil {
+		return fmt.Errorf("error clearing commands: %w", err)
+	}
+
+	r.mut.Lock()
+	delete(r.registered, guildID)
+	r.mut.Unlock()
+	return nil
+}
+
+// Prune removes the commands registered for the application in the guild, if there are any. This removes the commands
+// left behind in a scope that is no longer used, such as the guild commands after switching to global commands. The
+// returned bool reports whether any commands were removed.
+func (r *Registry) Prune(s Session, guildID string) (bool, error) {
+	current, err := s.ApplicationCommands(r.appID, guildID)
+	if err != nil {
+		return false, fmt.Errorf("error getting registered commands: %w", err)
+	}
+
+	if len(current) == 0 {
+		return false, nil
+	}
+
+	if err := r.Clear(s, guildID); err != nil {
+		return false, err
+	}
+
+	r.l.Info("Slash commands removed from unused scope",
+		slog.String("guild_id", guildID),
+		slog.Int("count", len(current)),
+	)
+	return true, nil
+}
+
+// CommandID returns the ID of the registered command with the given name in the guild.
+func (r *Registry) CommandID(guildID, name string) (string, bool) {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+
+	id, ok := r.registered[guildID][name]
+	return id, ok
+}
+
+// store records the IDs of the commands registered in the guild.
+func (r *Registry) store(guildID string, cmds []*discordgo.ApplicationCommand) {
+	ids := make(map[string]string, len(cmds))
+	for _, cmd := range cmds {
+		ids[cmd.Name] = cmd.ID
+	}
+
+	r.mut.Lock()
+	r.registered[guildID] = ids
+	r.mut.Unlock()
+}
+
+// equalCommands reports whether the registered commands match the desired definitions.
+func equalCommands(registered, desired []*discordgo.ApplicationCommand) bool {
+	if len(registered) != len(desired) {
+		return false
+	}
+
+	got, err := normalizeCommands(registered)
+	if err != nil {
+		return false
+	}
+
+	want, err := normalizeCommands(desired)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(got, want)
+}
+
+// normalizeCommands encodes the commands without the fields that Discord assigns, with the defaults that Discord
+// applies filled in, and in name order. This allows the definitions to be compared with what Discord returns.
+func normalizeCommands(cmds []*discordgo.ApplicationCommand) ([]byte, error) {
+	normalized := make([]*discordgo.ApplicationCommand, 0, len(cmds))
+	for _, cmd := range cmds {
+		n := &discordgo.ApplicationCommand{
+			Type:                     cmd.Type,
+			Name:                     cmd.Name,
+			NameLocalizations:        cmd.NameLocalizations,
+			DefaultMemberPermissions: cmd.DefaultMemberPermissions,
+			DMPermission:             cmd.DMPermission,
+			NSFW:                     cmd.NSFW,
+			Description:              cmd.Description,
+			DescriptionLocalizations: cmd.DescriptionLocalizations,
+			Options:                  normalizeOptions(cmd.Options),
+		}
+
+		if n.Type == 0 {
+			n.Type = discordgo.ChatApplicationCommand
+		}
+
+		if n.DMPermission == nil {
+			dm := true
+			n.DMPermission = &dm
+		}
+
+		if n.NSFW == nil {
+			nsfw := false
+			n.NSFW = &nsfw
+		}
+
+		if n.NameLocalizations != nil && len(*n.NameLocalizations) == 0 {
+			n.NameLocalizations = nil
+		}
+
+		if n.DescriptionLocalizations != nil && len(*n.DescriptionLocalizations) == 0 {
+			n.DescriptionLocalizations = nil
+		}
+
+		normalized = append(normalized, n)
+	}
+
+	sort.Slice(normalized, func(i, j int) bool {
+		return normalized[i].Name < normalized[j].Name
+	})
+
+	return json.Marshal(normalized)
+}
+
+// normalizeOptions returns a copy of the options with empty collections removed.
+func normalizeOptions(opts []*discordgo.ApplicationCommandOption) []*discordgo.ApplicationCommandOption {
+	if len(opts) == 0 {
+		return nil
+	}
+
+	normalized := make([]*discordgo.ApplicationCommandOption, 0, len(opts))
+	for _, opt := range opts {
+		n := *opt
+		n.Options = normalizeOptions(opt.Options)
+
+		if len(n.ChannelTypes) == 0 {
+			n.ChannelTypes = nil
+		}
+
+		if len(n.Choices) == 0 {
+			n.Choices = nil
+		}
+
+		if len(n.NameLocalizations) == 0 {
+			n.NameLocalizations = nil
+		}
+
+		if len(n.DescriptionLocalizations) == 0 {
+			n.DescriptionLocalizations = nil
+		}
+
+		normalized = append(normalized, &n)
+	}
+	return normalized
+}
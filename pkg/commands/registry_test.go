@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSession struct {
+	commands   map[string][]*discordgo.ApplicationCommand
+	overwrites int
+	err        error
+}
+
+func (f *fakeSession) ApplicationCommands(_, guildID string, _ ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.commands[guildID], nil
+}
+
+func (f *fakeSession) ApplicationCommandBulkOverwrite(appID string, guildID string, cmds []*discordgo.ApplicationCommand, _ ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error) {
+	f.overwrites++
+
+	created := make([]*discordgo.ApplicationCommand, 0, len(cmds))
+	for _, cmd := range cmds {
+		c := *cmd
+		c.ID = "id-" + cmd.Name
+		c.ApplicationID = appID
+		c.GuildID = guildID
+		c.Version = "1"
+		created = append(created, &c)
+	}
+	f.commands[guildID] = created
+	return created, nil
+}
+
+func testCommand(description string) *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        "setup",
+		Description: description,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "enable",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Description: "Enable it",
+			},
+		},
+	}
+}
+
+func TestRegistry_Sync(t *testing.T) {
+	tests := []struct {
+		name           string
+		registered     []*discordgo.ApplicationCommand
+		definition     *discordgo.ApplicationCommand
+		wantChanged    bool
+		wantOverwrites int
+	}{
+		{
+			name:           "nothing registered",
+			registered:     nil,
+			definition:     testCommand("Setup"),
+			wantChanged:    true,
+			wantOverwrites: 1,
+		},
+		{
+			name: "up to date",
+			registered: func() []*discordgo.ApplicationCommand {
+				cmd := testCommand("Setup")
+				cmd.ID = "123"
+				cmd.Version = "456"
+				cmd.Type = discordgo.ChatApplicationCommand
+				dm := true
+				cmd.DMPermission = &dm
+				return []*discordgo.ApplicationCommand{cmd}
+			}(),
+			definition:     testCommand("Setup"),
+			wantChanged:    false,
+			wantOverwrites: 0,
+		},
+		{
+			name:           "changed description",
+			registered:     []*discordgo.ApplicationCommand{testCommand("Old")},
+			definition:     testCommand("New"),
+			wantChanged:    true,
+			wantOverwrites: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &fakeSession{commands: map[string][]*discordgo.ApplicationCommand{"guild": tt.registered}}
+			r := NewRegistry(slog.New(slog.NewTextHandler(io.Discard, nil)), "app", tt.definition)
+
+			changed, err := r.Sync(s, "guild")
+			require.NoError(t, err)
+			require.Equal(t, tt.wantChanged, changed)
+			require.Equal(t, tt.wantOverwrites, s.overwrites)
+
+			_, ok := r.CommandID("guild", tt.definition.Name)
+			require.True(t, ok, "expected the command ID to be recorded")
+
+			// A second sync must never overwrite again.
+			changed, err = r.Sync(s, "guild")
+			require.NoError(t, err)
+			require.False(t, changed)
+			require.Equal(t, tt.wantOverwrites, s.overwrites)
+		})
+	}
+}
+
+func TestRegistry_SyncError(t *testing.T) {
+	s := &fakeSession{err: errors.New("boom")}
+	r := NewRegistry(slog.New(slog.NewTextHandler(io.Discard, nil)), "app", testCommand("Setup"))
+
+	_, err := r.Sync(s, GlobalScope)
+	require.EqualError(t, err, "error getting registered commands: boom")
+}
+
+func TestRegistry_Clear(t *testing.T) {
+	s := &fakeSession{commands: map[string][]*discordgo.ApplicationCommand{}}
+	r := NewRegistry(slog.New(slog.NewTextHandler(io.Discard, nil)), "app", testCommand("Setup"))
+
+	_, err := r.Sync(s, "guild")
+	require.NoError(t, err)
+
+	require.NoError(t, r.Clear(s, "guild"))
+	require.Empty(t, s.commands["guild"])
+
+	_, ok := r.CommandID("guild", "setup")
+	require.False(t, ok)
+}
+
+func TestRegistry_Prune(t *testing.T) {
+	s := &fakeSession{commands: map[string][]*discordgo.ApplicationCommand{}}
+	r := NewRegistry(slog.New(slog.NewTextHandler(io.Discard, nil)), "app", testCommand("Setup"))
+
+	// Nothing is overwritten when no commands are registered.
+	pruned, err := r.Prune(s, "guild")
+	require.NoError(t, err)
+	require.False(t, pruned)
+	require.Equal(t, 0, s.overwrites)
+
+	_, err = r.Sync(s, "guild")
+	require.NoError(t, err)
+
+	pruned, err = r.Prune(s, "guild")
+	require.NoError(t, err)
+	require.True(t, pruned)
+	require.Empty(t, s.commands["guild"])
+	require.Equal(t, 2, s.overwrites)
+
+	_, ok := r.CommandID("guild", "setup")
+	require.False(t, ok)
+}
@@ -0,0 +1,198 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Jacobbrewer1/discordgo"
+)
+
+// responseState is the state of the response to an interaction.
+type responseState int
+
+const (
+	// statePending is when nothing has been sent for the interaction.
+	statePending responseState = iota
+
+	// stateDeferred is when a deferred acknowledgement has been sent, and the response is still to be delivered.
+	stateDeferred
+
+	// stateResponded is when the response has been delivered. Any further responses are sent as followups.
+	stateResponded
+)
+
+// responder delivers the responses to an interaction, using the method that matches the state of the interaction.
+type responder struct {
+	// mut serialises the responses.
+	mut sync.Mutex
+
+	// ctx is the context of the interaction. It is not cancelled with the interaction, so the error response can still
+	// be delivered after the handler has timed out.
+	ctx context.Context
+
+	// s is the discord session.
+	s *discordgo.Session
+
+	// i is the interaction being responded to.
+	i *discordgo.Interaction
+
+	// state is the state of the response.
+	state responseState
+
+	// ephemeral is whether the deferred acknowledgement was ephemeral.
+	ephemeral bool
+}
+
+// newResponder creates a new responder for the interaction.
+func newResponder(ctx context.Context, s *discordgo.Session, i *discordgo.Interaction) *responder {
+	return &responder{
+		ctx: context.WithoutCancel(ctx),
+		s:   s,
+		i:   i,
+	}
+}
+
+// deferResponse sends a deferred acknowledgement if nothing has been sent for the interaction yet.
+func (r *responder) deferResponse(ephemeral bool) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.state != statePending {
+		return nil
+	}
+
+	var flags discordgo.MessageFlags
+	if ephemeral {
+		flags = discordgo.MessageFlagsEphemeral
+	}
+
+	if err := r.s.InteractionRespond(r.i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: flags,
+		},
+	}, discordgo.WithContext(r.ctx)); err != nil {
+		return fmt.Errorf("error deferring response: %w", err)
+	}
+
+	r.state = stateDeferred
+	r.ephemeral = ephemeral
+	return nil
+}
+
+// respond delivers the response to the interaction.
+func (r *responder) respond(data *discordgo.InteractionResponseData) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	switch r.state {
+	case statePending:
+		if err := r.s.InteractionRespond(r.i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: data,
+		}, discordgo.WithContext(r.ctx)); err != nil {
+			return fmt.Errorf("error responding to interaction: %w", err)
+		}
+	case stateDeferred:
+		ephemeral := data.Flags&discordgo.MessageFlagsEphemeral == discordgo.MessageFlagsEphemeral
+		if ephemeral == r.ephemeral {
+			// The visibility matches the acknowledgement, so it can be replaced with the response.
+			if _, err := r.s.InteractionResponseEdit(r.i, &discordgo.WebhookEdit{
+				Content:         &data.Content,
+				Components:      &data.Components,
+				Embeds:          &data.Embeds,
+				Files:           data.Files,
+				AllowedMentions: data.AllowedMentions,
+			}, discordgo.WithContext(r.ctx)); err != nil {
+				return fmt.Errorf("error editing deferred response: %w", err)
+			}
+			break
+		}
+
+		// The visibility of the acknowledgement cannot be changed, so it is removed and the response is sent as a
+		// followup instead.
+		if err := r.s.InteractionResponseDelete(r.i, discordgo.WithContext(r.ctx)); err != nil {
+			return fmt.Errorf("error deleting deferred response: %w", err)
+		}
+
+		if err := r.followup(data); err != nil {
+			return err
+		}
+	case stateResponded:
+		if err := r.followup(data); err != nil {
+			return err
+		}
+	}
+
+	r.state = stateResponded
+	return nil
+}
+
+// modal responds to the interaction with a modal, which is only possible if nothing has been sent for the interaction.
+func (r *responder) modal(data *discordgo.InteractionResponseData) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.state != statePending {
+		return ErrModalNotFirst
+	}
+
+	if err := r.s.InteractionRespond(r.i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: data,
+	}, discordgo.WithContext(r.ctx)); err != nil {
+		return fmt.Errorf("error responding with modal: %w", err)
+	}
+
+	r.state = stateResponded
+	return nil
+}
+
+// acknowledge acknowledges a message component without sending a message, if nothing has been delivered yet. A
+// deferred acknowledgement that has already been sent is removed instead.
+func (r *responder) acknowledge() error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	switch r.state {
+	case statePending:
+		if err := r.s.InteractionRespond(r.i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredMessageUpdate,
+		}, discordgo.WithContext(r.ctx)); err != nil {
+			return fmt.Errorf("error acknowledging interaction: %w", err)
+		}
+	case stateDeferred:
+		if err := r.s.InteractionResponseDelete(r.i, discordgo.WithContext(r.ctx)); err != nil {
+			return fmt.Errorf("error deleting deferred response: %w", err)
+		}
+	case stateResponded:
+		return nil
+	}
+
+	r.state = stateResponded
+	return nil
+}
+
+// followup sends the response as a followup message.
+func (r *responder) followup(data *discordgo.InteractionResponseData) error {
+	if _, err := r.s.FollowupMessageCreate(r.i, true, &discordgo.WebhookParams{
+		Content:         data.Content,
+		Components:      data.Components,
+		Embeds:          data.Embeds,
+		Files:           data.Files,
+		AllowedMentions: data.AllowedMentions,
+		Flags:           data.Flags,
+	}, discordgo.WithContext(r.ctx)); err != nil {
+		return fmt.Errorf("error sending followup message: %w", err)
+	}
+	return nil
+}
+
+// responded returns true if the response has been delivered.
+func (r *responder) responded() bool {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	return r.state == stateResponded
+}
@@ -0,0 +1,232 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_DeferredResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		ephemeral bool
+		response  *discordgo.InteractionResponseData
+		wantPaths []string
+	}{
+		{
+			name:     "edited when the visibility matches",
+			response: &discordgo.InteractionResponseData{Content: "done"},
+			wantPaths: []string{
+				"POST /api/v9/interactions/interaction/token/callback",
+				"PATCH /api/v9/webhooks/app/token/messages/@original",
+			},
+		},
+		{
+			name:      "followup when the visibility differs",
+			ephemeral: false,
+			response:  &discordgo.InteractionResponseData{Content: "done", Flags: discordgo.MessageFlagsEphemeral},
+			wantPaths: []string{
+				"POST /api/v9/interactions/interaction/token/callback",
+				"DELETE /api/v9/webhooks/app/token/messages/@original",
+				"POST /api/v9/webhooks/app/token",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRouter(WithDeferAfter(10 * time.Millisecond))
+			require.NoError(t, r.AddComponent(&Component{
+				CustomID:  "slow",
+				Ephemeral: tt.ephemeral,
+				Handler: func(c *Context) error {
+					time.Sleep(50 * time.Millisecond)
+					return c.Respond(tt.response)
+				},
+			}))
+
+			s, rec := newTestSession(t)
+			require.NoError(t, r.Handle(s, &discordgo.InteractionCreate{
+				Interaction: &discordgo.Interaction{
+					ID:    "interaction",
+					AppID: "app",
+					Type:  discordgo.InteractionMessageComponent,
+					Token: "token",
+					Data:  discordgo.MessageComponentInteractionData{CustomID: "slow"},
+				},
+			}))
+
+			reqs := rec.Requests()
+			paths := make([]string, 0, len(reqs))
+			for _, req := range reqs {
+				paths = append(paths, req.Method+" "+req.Path)
+			}
+			require.Equal(t, tt.wantPaths, paths)
+
+			// The acknowledgement is a deferred message.
+			require.Equal(t, float64(discordgo.InteractionResponseDeferredChannelMessageWithSource), reqs[0].Body["type"])
+		})
+	}
+}
+
+func TestRouter_FastResponse(t *testing.T) {
+	r := NewRouter(WithDeferAfter(50 * time.Millisecond))
+	require.NoError(t, r.AddComponent(&Component{
+		CustomID: "fast",
+		Handler: func(c *Context) error {
+			if err := c.RespondEphemeral("first"); err != nil {
+				return err
+			}
+			return c.RespondEphemeral("second")
+		},
+	}))
+
+	s, rec := newTestSession(t)
+	require.NoError(t, r.Handle(s, &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    "interaction",
+			AppID: "app",
+			Type:  discordgo.InteractionMessageComponent,
+			Token: "token",
+			Data:  discordgo.MessageComponentInteractionData{CustomID: "fast"},
+		},
+	}))
+
+	// Wait past the threshold to ensure the acknowledgement is never sent.
+	time.Sleep(100 * time.Millisecond)
+
+	reqs := rec.Requests()
+	require.Len(t, reqs, 2)
+	require.Equal(t, "/api/v9/interactions/interaction/token/callback", reqs[0].Path)
+	require.Equal(t, float64(discordgo.InteractionResponseChannelMessageWithSource), reqs[0].Body["type"])
+	require.Equal(t, "/api/v9/webhooks/app/token", reqs[1].Path)
+	require.Equal(t, "second", reqs[1].Body["content"])
+}
+
+func TestRouter_Acknowledge(t *testing.T) {
+	tests := []struct {
+		name      string
+		delay     time.Duration
+		wantPaths []string
+	}{
+		{
+			name:      "acknowledged without a message",
+			wantPaths: []string{"POST /api/v9/interactions/interaction/token/callback"},
+		},
+		{
+			name:  "deferred acknowledgement removed",
+			delay: 50 * time.Millisecond,
+			wantPaths: []string{
+				"POST /api/v9/interactions/interaction/token/callback",
+				"DELETE /api/v9/webhooks/app/token/messages/@original",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRouter(WithDeferAfter(10 * time.Millisecond))
+			require.NoError(t, r.AddComponent(&Component{
+				CustomID: "toggle",
+				Handler: func(c *Context) error {
+					time.Sleep(tt.delay)
+					if err := c.Acknowledge(); err != nil {
+						return err
+					}
+					// Acknowledging again does nothing.
+					return c.Acknowledge()
+				},
+			}))
+
+			s, rec := newTestSession(t)
+			require.NoError(t, r.Handle(s, &discordgo.InteractionCreate{
+				Interaction: &discordgo.Interaction{
+					ID:    "interaction",
+					AppID: "app",
+					Type:  discordgo.InteractionMessageComponent,
+					Token: "token",
+					Data:  discordgo.MessageComponentInteractionData{CustomID: "toggle"},
+				},
+			}))
+
+			reqs := rec.Requests()
+			paths := make([]string, 0, len(reqs))
+			for _, req := range reqs {
+				paths = append(paths, req.Method+" "+req.Path)
+			}
+			require.Equal(t, tt.wantPaths, paths)
+			if tt.delay == 0 {
+				require.Equal(t, float64(discordgo.InteractionResponseDeferredMessageUpdate), reqs[0].Body["type"])
+			}
+		})
+	}
+}
+
+func TestRouter_RespondModal(t *testing.T) {
+	tests := []struct {
+		name      string
+		delay     time.Duration
+		wantErr   error
+		wantPaths []string
+	}{
+		{
+			name:      "modal shown",
+			wantPaths: []string{"POST /api/v9/interactions/interaction/token/callback"},
+		},
+		{
+			name:    "modal after deferral",
+			delay:   50 * time.Millisecond,
+			wantErr: ErrModalNotFirst,
+			wantPaths: []string{
+				"POST /api/v9/interactions/interaction/token/callback",
+				"DELETE /api/v9/webhooks/app/token/messages/@original",
+				"POST /api/v9/webhooks/app/token",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRouter(WithDeferAfter(10 * time.Millisecond))
+			require.NoError(t, r.AddComponent(&Component{
+				CustomID: "open",
+				Handler: func(c *Context) error {
+					time.Sleep(tt.delay)
+					return c.RespondModal(&discordgo.InteractionResponseData{
+						CustomID: "modal",
+						Title:    "Modal",
+					})
+				},
+			}))
+
+			s, rec := newTestSession(t)
+			err := r.Handle(s, &discordgo.InteractionCreate{
+				Interaction: &discordgo.Interaction{
+					ID:    "interaction",
+					AppID: "app",
+					Type:  discordgo.InteractionMessageComponent,
+					Token: "token",
+					Data:  discordgo.MessageComponentInteractionData{CustomID: "open"},
+				},
+			})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			reqs := rec.Requests()
+			paths := make([]string, 0, len(reqs))
+			for _, req := range reqs {
+				paths = append(paths, req.Method+" "+req.Path)
+			}
+			require.Equal(t, tt.wantPaths, paths)
+			if tt.wantErr == nil {
+				require.Equal(t, float64(discordgo.InteractionResponseModal), reqs[0].Body["type"])
+				require.Equal(t, "modal", reqs[0].Body["data"].(map[string]any)["custom_id"])
+			}
+		})
+	}
+}
@@ -0,0 +1,438 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"github.com/Jacobbrewer1/wolf/pkg/messages"
+	"github.com/Jacobbrewer1/wolf/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultDeferAfter is how long a handler has to respond before a deferred acknowledgement is sent. Discord requires
+// the initial response within 3 seconds.
+const DefaultDeferAfter = 2 * time.Second
+
+// DefaultTimeout is how long a handler has to handle an interaction before its context is cancelled.
+const DefaultTimeout = 10 * time.Second
+
+// CustomIDSeparator separates the custom ID of a prefix component from the arguments encoded in it.
+const CustomIDSeparator = ":"
+
+// CustomID returns the custom ID for a prefix component with the arguments encoded in it. The arguments must not
+// contain the CustomIDSeparator.
+func CustomID(prefix string, args ...string) string {
+	return strings.Join(append([]string{prefix}, args...), CustomIDSeparator)
+}
+
+// Component is a handler for a message component, or for the submission of a modal. Modals are routed by their custom
+// ID in the same way as the components.
+type Component struct {
+	// CustomID is the custom ID of the component.
+	CustomID string
+
+	// Prefix is whether the component also handles the custom IDs that encode arguments after the custom ID, as built
+	// by CustomID. The arguments are available from Context.Args.
+	Prefix bool
+
+	// Guards are checks that are run before the handler.
+	Guards []Guard
+
+	// Ephemeral is whether the deferred acknowledgement, sent when the handler is slow to respond, is only visible to
+	// the user.
+	Ephemeral bool
+
+	// Handler handles the component.
+	Handler Handler
+}
+
+// RouterOption configures a Router.
+type RouterOption func(r *Router)
+
+// WithDeferAfter sets how long a handler has to respond before a deferred acknowledgement is sent.
+func WithDeferAfter(d time.Duration) RouterOption {
+	return func(r *Router) {
+		r.deferAfter = d
+	}
+}
+
+// WithTimeout sets how long a handler has to handle an interaction before its context is cancelled.
+func WithTimeout(d time.Duration) RouterOption {
+	return func(r *Router) {
+		r.timeout = d
+	}
+}
+
+// WithContext sets the context the per interaction contexts are derived from. Cancelling it, such as on shutdown,
+// cancels the handlers that are running.
+func WithContext(ctx context.Context) RouterOption {
+	return func(r *Router) {
+		r.ctx = ctx
+	}
+}
+
+// WithLogger sets the logger the per interaction loggers are derived from.
+func WithLogger(l *slog.Logger) RouterOption {
+	return func(r *Router) {
+		r.l = l
+	}
+}
+
+// ErrorHook is called for every error returned while handling an interaction, including errors meant for the user.
+type ErrorHook func(c *Context, class ErrorClass, err error)
+
+// WithErrorHook sets the hook that is called for every error returned while handling an interaction.
+func WithErrorHook(hook ErrorHook) RouterOption {
+	return func(r *Router) {
+		r.errorHook = hook
+	}
+}
+
+// Router routes interactions to the commands and components registered with it.
+type Router struct {
+	// commands are the registered commands, keyed by name.
+	commands map[string]*Command
+
+	// definitions are the application commands for the registered commands, in registration order.
+	definitions []*discordgo.ApplicationCommand
+
+	// components are the registered message components and modals, keyed by custom ID, or by the prefix for prefix
+	// components.
+	components map[string]*Component
+
+	// deferAfter is how long a handler has to respond before a deferred acknowledgement is sent.
+	deferAfter time.Duration
+
+	// timeout is how long a handler has to handle an interaction before its context is cancelled.
+	timeout time.Duration
+
+	// ctx is the context the per interaction contexts are derived from.
+	ctx context.Context
+
+	// l is the logger the per interaction loggers are derived from.
+	l *slog.Logger
+
+	// errorHook is called for every error returned while handling an interaction.
+	errorHook ErrorHook
+}
+
+// NewRouter creates a new Router.
+func NewRouter(opts ...RouterOption) *Router {
+	r := &Router{
+		commands:   make(map[string]*Command),
+		components: make(map[string]*Component),
+		deferAfter: DefaultDeferAfter,
+		timeout:    DefaultTimeout,
+		ctx:        context.Background(),
+		l:          slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// AddCommand registers a slash command with the router.
+func (r *Router) AddCommand(cmd *Command) error {
+	if _, ok := r.commands[cmd.Name]; ok {
+		return fmt.Errorf("command %s is already registered", cmd.Name)
+	}
+
+	def, err := cmd.Definition()
+	if err != nil {
+		return err
+	}
+
+	r.commands[cmd.Name] = cmd
+	r.definitions = append(r.definitions, def)
+	return nil
+}
+
+// AddComponent registers a handler for a message component or a modal.
+func (r *Router) AddComponent(comp *Component) error {
+	if comp.CustomID == "" || comp.Handler == nil {
+		return errors.New("component requires a custom ID and a handler")
+	}
+
+	if comp.Prefix && strings.Contains(comp.CustomID, CustomIDSeparator) {
+		return fmt.Errorf("prefix component %s cannot contain %q", comp.CustomID, CustomIDSeparator)
+	}
+
+	if _, ok := r.components[comp.CustomID]; ok {
+		return fmt.Errorf("component %s is already registered", comp.CustomID)
+	}
+
+	r.components[comp.CustomID] = comp
+	return nil
+}
+
+// Definitions returns the application commands for the registered commands.
+func (r *Router) Definitions() []*discordgo.ApplicationCommand {
+	return r.definitions
+}
+
+// Handle routes the interaction to its handler. When the handler does not respond within the defer threshold, a
+// deferred acknowledgement is sent and the response is delivered when the handler is done. A panic in the handler is
+// recovered and handled as an error. The context of the handler is cancelled when the timeout of the router is
+// reached. Errors meant for the user are responded to the interaction, any other error is
+// logged, responded with a generic message that includes the correlation ID and returned.
+func (r *Router) Handle(s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	ctx, cancel := context.WithTimeout(r.ctx, r.timeout)
+	defer cancel()
+
+	// The span is renamed once the command is resolved.
+	ctx, span := tracing.Tracer().Start(ctx, "interaction "+i.Type.String(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("interaction.type", i.Type.String()),
+			attribute.String(logging.KeyGuildID, i.GuildID),
+			attribute.String(logging.KeyChannelID, i.ChannelID),
+		),
+	)
+	defer span.End()
+
+	c := newContext(ctx, r.l, s, i)
+	span.SetAttributes(attribute.String(logging.KeyCorrelationID, c.CorrelationID()))
+
+	err := r.safeRoute(c)
+	if err == nil {
+		return nil
+	}
+
+	class := Classify(err)
+	span.SetAttributes(attribute.String("error.class", string(class)))
+	if class != ErrorClassUser {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	if r.errorHook != nil {
+		r.errorHook(c, class, err)
+	}
+
+	userErr := new(UserError)
+	if errors.As(err, &userErr) {
+		if err := c.RespondEphemeral(userErr.Message); err != nil {
+			return fmt.Errorf("error responding to interaction: %w", err)
+		}
+		return nil
+	}
+
+	attrs := []any{
+		slog.String(logging.KeyError, err.Error()),
+		slog.String("class", string(class)),
+		slog.String("type", i.Type.String()),
+	}
+
+	panicErr := new(PanicError)
+	if errors.As(err, &panicErr) {
+		attrs = append(attrs, slog.String(logging.KeyStack, string(panicErr.Stack)))
+	}
+
+	c.Logger().Error("Error handling interaction", attrs...)
+
+	if i.Type != discordgo.InteractionPing && i.Type != discordgo.InteractionApplicationCommandAutocomplete {
+		if respErr := c.RespondEphemeral(fmt.Sprintf(messages.ErrUserErrorReference, c.CorrelationID())); respErr != nil {
+			return errors.Join(err, fmt.Errorf("error responding to interaction: %w", respErr))
+		}
+	}
+	return err
+}
+
+// safeRoute routes the interaction, recovering any panic in the handler.
+func (r *Router) safeRoute(c *Context) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = &PanicError{
+				Value: rec,
+				Stack: debug.Stack(),
+			}
+		}
+	}()
+
+	return r.route(c)
+}
+
+// route resolves the handler for the interaction and runs it.
+func (r *Router) route(c *Context) error {
+	var (
+		handler   Handler
+		guards    func() error
+		ephemeral bool
+	)
+
+	switch c.Type {
+	case discordgo.InteractionApplicationCommand:
+		chain, opts, err := r.resolveCommand(c)
+		if err != nil {
+			return err
+		}
+
+		cmd := chain[len(chain)-1]
+		handler = cmd.Handler
+
+		path := make([]string, 0, len(chain))
+		for _, link := range chain {
+			path = append(path, link.Name)
+			ephemeral = ephemeral || link.Ephemeral
+		}
+		c.setCommand(strings.Join(path, " "))
+
+		guards = func() error {
+			for _, link := range chain {
+				if err := checkGuards(c, link); err != nil {
+					return err
+				}
+			}
+
+			bound, err := bindOptions(cmd.Options, cmd.fields, opts, c.ApplicationCommandData().Resolved)
+			if err != nil {
+				return err
+			}
+			c.options = bound
+			return nil
+		}
+	case discordgo.InteractionMessageComponent:
+		data := c.MessageComponentData()
+
+		comp, args, ok := r.resolveComponent(data.CustomID)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownComponent, data.CustomID)
+		}
+
+		handler = comp.Handler
+		ephemeral = comp.Ephemeral
+		c.args = args
+
+		// Select menus are handled by the same components as buttons, with the chosen values available from the
+		// context.
+		switch data.ComponentType {
+		case discordgo.SelectMenuComponent, discordgo.UserSelectMenuComponent, discordgo.RoleSelectMenuComponent,
+			discordgo.MentionableSelectMenuComponent, discordgo.ChannelSelectMenuComponent:
+			c.values = data.Values
+		}
+
+		// Prefix components are named by the prefix, so the arguments do not make every interaction a unique name.
+		c.setCommand(comp.CustomID)
+
+		guards = componentGuards(c, comp)
+	case discordgo.InteractionModalSubmit:
+		data := c.ModalSubmitData()
+
+		comp, args, ok := r.resolveComponent(data.CustomID)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownComponent, data.CustomID)
+		}
+
+		handler = comp.Handler
+		ephemeral = comp.Ephemeral
+		c.args = args
+		c.fields = modalFields(data.Components)
+		c.setCommand(comp.CustomID)
+
+		guards = componentGuards(c, comp)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownInteraction, c.Type)
+	}
+
+	// Acknowledge the interaction if the guards or the handler are slow to respond.
+	if r.deferAfter > 0 {
+		timer := time.AfterFunc(r.deferAfter, func() {
+			// Any error is surfaced when the response is delivered.
+			_ = c.Defer(ephemeral)
+		})
+		defer timer.Stop()
+	}
+
+	if err := guards(); err != nil {
+		return err
+	}
+	return handler(c)
+}
+
+// componentGuards returns a function that runs the guards of the component.
+func componentGuards(c *Context, comp *Component) func() error {
+	return func() error {
+		for _, guard := range comp.Guards {
+			if err := guard(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// modalFields returns the values of the text inputs of a submitted modal, keyed by their custom ID.
+func modalFields(components []discordgo.MessageComponent) map[string]string {
+	fields := make(map[string]string)
+	for _, component := range components {
+		switch t := component.(type) {
+		case *discordgo.ActionsRow:
+			for k, v := range modalFields(t.Components) {
+				fields[k] = v
+			}
+		case *discordgo.TextInput:
+			fields[t.CustomID] = t.Value
+		}
+	}
+	return fields
+}
+
+// resolveComponent resolves the component for the custom ID. The exact custom ID is preferred, otherwise the prefix
+// component for the part before the CustomIDSeparator is resolved along with the arguments after it.
+func (r *Router) resolveComponent(customID string) (*Component, []string, bool) {
+	if comp, ok := r.components[customID]; ok {
+		return comp, nil, true
+	}
+
+	prefix, rest, found := strings.Cut(customID, CustomIDSeparator)
+	if !found {
+		return nil, nil, false
+	}
+
+	comp, ok := r.components[prefix]
+	if !ok || !comp.Prefix {
+		return nil, nil, false
+	}
+	return comp, strings.Split(rest, CustomIDSeparator), true
+}
+
+// resolveCommand resolves the command, and the chain of sub commands, for a slash command. The options of the last
+// command in the chain are returned.
+func (r *Router) resolveCommand(c *Context) ([]*Command, []*discordgo.ApplicationCommandInteractionDataOption, error) {
+	data := c.ApplicationCommandData()
+
+	cmd, ok := r.commands[data.Name]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownCommand, data.Name)
+	}
+
+	chain := []*Command{cmd}
+	opts := data.Options
+	for len(cmd.Subcommands) > 0 {
+		if len(opts) == 0 || (opts[0].Type != discordgo.ApplicationCommandOptionSubCommand &&
+			opts[0].Type != discordgo.ApplicationCommandOptionSubCommandGroup) {
+			return nil, nil, fmt.Errorf("%w: %s requires a sub command", ErrUnknownCommand, data.Name)
+		}
+
+		sub, ok := cmd.subcommand(opts[0].Name)
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %s %s", ErrUnknownCommand, data.Name, opts[0].Name)
+		}
+
+		chain = append(chain, sub)
+		opts = opts[0].Options
+		cmd = sub
+	}
+
+	return chain, opts, nil
+}
@@ -0,0 +1,535 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/messages"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordedRequest is a request made to the Discord API during a test.
+type recordedRequest struct {
+	Method string
+	Path   string
+	Body   map[string]any
+}
+
+// recorder records the requests made to the Discord API.
+type recorder struct {
+	mut      sync.Mutex
+	requests []recordedRequest
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := recordedRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+	}
+
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, &rec.Body); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	r.mut.Lock()
+	r.requests = append(r.requests, rec)
+	r.mut.Unlock()
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"id":"1"}`)),
+		Request:    req,
+	}, nil
+}
+
+// Requests returns the recorded requests.
+func (r *recorder) Requests() []recordedRequest {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	return append([]recordedRequest(nil), r.requests...)
+}
+
+func newTestSession(t *testing.T) (*discordgo.Session, *recorder) {
+	t.Helper()
+
+	s, err := discordgo.New("Bot token")
+	require.NoError(t, err)
+
+	rec := new(recorder)
+	s.Client = &http.Client{Transport: rec}
+	return s, rec
+}
+
+func newCommandInteraction(member *discordgo.Member, data discordgo.ApplicationCommandInteractionData) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:      "interaction",
+			Type:    discordgo.InteractionApplicationCommand,
+			GuildID: "guild",
+			Member:  member,
+			Token:   "token",
+			Data:    data,
+		},
+	}
+}
+
+func TestRouter_HandleCommand(t *testing.T) {
+	type options struct {
+		Role *discordgo.Role `option:"role" description:"The role" required:"true"`
+	}
+
+	var got *Context
+	handler := func(c *Context) error {
+		got = c
+		return nil
+	}
+
+	r := NewRouter()
+	require.NoError(t, r.AddCommand(&Command{
+		Name:        "setup",
+		Description: "Setup",
+		Permissions: discordgo.PermissionManageServer,
+		GuildOnly:   true,
+		Subcommands: []*Command{
+			{
+				Name:        "ticketing",
+				Description: "Ticketing",
+				Subcommands: []*Command{
+					{
+						Name:        "enable",
+						Description: "Enable",
+						Options:     new(options),
+						Handler:     handler,
+					},
+				},
+			},
+		},
+	}))
+
+	defs := r.Definitions()
+	require.Len(t, defs, 1)
+	require.Equal(t, discordgo.ApplicationCommandOptionSubCommandGroup, defs[0].Options[0].Type)
+	require.Equal(t, discordgo.ApplicationCommandOptionSubCommand, defs[0].Options[0].Options[0].Type)
+	require.Equal(t, "role", defs[0].Options[0].Options[0].Options[0].Name)
+	require.Equal(t, int64(discordgo.PermissionManageServer), *defs[0].DefaultMemberPermissions)
+	require.False(t, *defs[0].DMPermission)
+
+	data := discordgo.ApplicationCommandInteractionData{
+		Name: "setup",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{
+				Name: "ticketing",
+				Type: discordgo.ApplicationCommandOptionSubCommandGroup,
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					{
+						Name: "enable",
+						Type: discordgo.ApplicationCommandOptionSubCommand,
+						Options: []*discordgo.ApplicationCommandInteractionDataOption{
+							{Name: "role", Type: discordgo.ApplicationCommandOptionRole, Value: "role"},
+						},
+					},
+				},
+			},
+		},
+		Resolved: &discordgo.ApplicationCommandInteractionDataResolved{
+			Roles: map[string]*discordgo.Role{"role": {ID: "role", Name: "Staff"}},
+		},
+	}
+
+	s, rec := newTestSession(t)
+
+	t.Run("routed", func(t *testing.T) {
+		got = nil
+		member := &discordgo.Member{Permissions: discordgo.PermissionManageServer}
+
+		require.NoError(t, r.Handle(s, newCommandInteraction(member, data)))
+		require.NotNil(t, got)
+		require.Equal(t, "setup ticketing enable", got.Command())
+		require.Equal(t, &options{Role: &discordgo.Role{ID: "role", Name: "Staff"}}, got.Options())
+		require.Empty(t, rec.Requests())
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		got = nil
+		member := &discordgo.Member{Permissions: discordgo.PermissionSendMessages}
+
+		require.NoError(t, r.Handle(s, newCommandInteraction(member, data)))
+		require.Nil(t, got)
+
+		reqs := rec.Requests()
+		require.Len(t, reqs, 1)
+		require.Equal(t, "/api/v9/interactions/interaction/token/callback", reqs[0].Path)
+		require.Equal(t, messages.ErrUserNoPermission, reqs[0].Body["data"].(map[string]any)["content"])
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		err := r.Handle(s, newCommandInteraction(nil, discordgo.ApplicationCommandInteractionData{Name: "unknown"}))
+		require.ErrorIs(t, err, ErrUnknownCommand)
+	})
+}
+
+func TestRouter_HandleComponent(t *testing.T) {
+	handlerErr := errors.New("boom")
+
+	type hooked struct {
+		command       string
+		class         ErrorClass
+		correlationID string
+	}
+	var hooks []hooked
+
+	r := NewRouter(WithErrorHook(func(c *Context, class ErrorClass, err error) {
+		hooks = append(hooks, hooked{
+			command:       c.Command(),
+			class:         class,
+			correlationID: c.CorrelationID(),
+		})
+	}))
+	require.NoError(t, r.AddComponent(&Component{
+		CustomID: "fail",
+		Handler: func(c *Context) error {
+			return handlerErr
+		},
+	}))
+	require.NoError(t, r.AddComponent(&Component{
+		CustomID: "panic",
+		Handler: func(c *Context) error {
+			var guild *discordgo.Guild
+			_ = guild.Name
+			return nil
+		},
+	}))
+	require.NoError(t, r.AddComponent(&Component{
+		CustomID: "guarded",
+		Guards:   []Guard{RequireRoles("staff")},
+		Handler: func(c *Context) error {
+			return nil
+		},
+	}))
+	require.Error(t, r.AddComponent(&Component{
+		CustomID: "fail",
+		Handler:  func(c *Context) error { return nil },
+	}))
+
+	s, rec := newTestSession(t)
+
+	component := func(customID string, member *discordgo.Member) *discordgo.InteractionCreate {
+		return &discordgo.InteractionCreate{
+			Interaction: &discordgo.Interaction{
+				ID:     "interaction",
+				Type:   discordgo.InteractionMessageComponent,
+				Member: member,
+				Token:  "token",
+				Data:   discordgo.MessageComponentInteractionData{CustomID: customID},
+			},
+		}
+	}
+
+	require.NoError(t, r.Handle(s, component("guarded", &discordgo.Member{Roles: []string{"staff"}})))
+	require.Empty(t, rec.Requests())
+
+	// A denied guard is responded to with the reason.
+	require.NoError(t, r.Handle(s, component("guarded", &discordgo.Member{})))
+	require.Len(t, rec.Requests(), 1)
+	require.Equal(t, messages.ErrUserNoPermission, rec.Requests()[0].Body["data"].(map[string]any)["content"])
+
+	// Any other error is responded to with a generic message, including the reference, and returned.
+	require.ErrorIs(t, r.Handle(s, component("fail", nil)), handlerErr)
+	require.Len(t, rec.Requests(), 2)
+	require.Len(t, hooks, 2)
+	require.Len(t, hooks[1].correlationID, 8)
+	require.Equal(t,
+		fmt.Sprintf(messages.ErrUserErrorReference, hooks[1].correlationID),
+		rec.Requests()[1].Body["data"].(map[string]any)["content"],
+	)
+
+	// A panic is recovered and handled as an error.
+	panicErr := new(PanicError)
+	require.ErrorAs(t, r.Handle(s, component("panic", nil)), &panicErr)
+	require.NotEmpty(t, panicErr.Stack)
+	require.Len(t, rec.Requests(), 3)
+
+	require.ErrorIs(t, r.Handle(s, component("missing", nil)), ErrUnknownComponent)
+
+	require.Equal(t, []ErrorClass{ErrorClassUser, ErrorClassInternal, ErrorClassPanic, ErrorClassUnknown}, []ErrorClass{
+		hooks[0].class, hooks[1].class, hooks[2].class, hooks[3].class,
+	})
+	require.Equal(t, "guarded", hooks[0].command)
+	require.Equal(t, "panic", hooks[2].command)
+}
+
+func TestRouter_HandlePrefixComponent(t *testing.T) {
+	type handled struct {
+		command string
+		args    []string
+		values  []string
+	}
+	var calls []handled
+
+	r := NewRouter()
+	require.NoError(t, r.AddComponent(&Component{
+		CustomID: "panel",
+		Prefix:   true,
+		Handler: func(c *Context) error {
+			calls = append(calls, handled{command: c.Command(), args: c.Args(), values: c.Values()})
+			return nil
+		},
+	}))
+	require.NoError(t, r.AddComponent(&Component{
+		CustomID: "exact",
+		Handler: func(c *Context) error {
+			calls = append(calls, handled{command: c.Command(), args: c.Args(), values: c.Values()})
+			return nil
+		},
+	}))
+	require.Error(t, r.AddComponent(&Component{
+		CustomID: "bad:prefix",
+		Prefix:   true,
+		Handler:  func(c *Context) error { return nil },
+	}))
+
+	s, _ := newTestSession(t)
+
+	component := func(data discordgo.MessageComponentInteractionData) *discordgo.InteractionCreate {
+		return &discordgo.InteractionCreate{
+			Interaction: &discordgo.Interaction{
+				ID:    "interaction",
+				Type:  discordgo.InteractionMessageComponent,
+				Token: "token",
+				Data:  data,
+			},
+		}
+	}
+
+	require.Equal(t, "panel:1:role", CustomID("panel", "1", "role"))
+
+	require.NoError(t, r.Handle(s, component(discordgo.MessageComponentInteractionData{
+		CustomID:      CustomID("panel", "1", "role"),
+		ComponentType: discordgo.ButtonComponent,
+	})))
+	require.NoError(t, r.Handle(s, component(discordgo.MessageComponentInteractionData{
+		CustomID:      CustomID("panel", "2"),
+		ComponentType: discordgo.SelectMenuComponent,
+		Values:        []string{"a", "b"},
+	})))
+	require.NoError(t, r.Handle(s, component(discordgo.MessageComponentInteractionData{
+		CustomID:      "panel",
+		ComponentType: discordgo.ButtonComponent,
+	})))
+
+	// Only prefix components handle the custom IDs with arguments.
+	require.ErrorIs(t, r.Handle(s, component(discordgo.MessageComponentInteractionData{
+		CustomID: CustomID("exact", "1"),
+	})), ErrUnknownComponent)
+	require.ErrorIs(t, r.Handle(s, component(discordgo.MessageComponentInteractionData{
+		CustomID: CustomID("missing", "1"),
+	})), ErrUnknownComponent)
+
+	require.Equal(t, []handled{
+		{command: "panel", args: []string{"1", "role"}},
+		{command: "panel", args: []string{"2"}, values: []string{"a", "b"}},
+		{command: "panel"},
+	}, calls)
+}
+
+func TestRouter_HandleModal(t *testing.T) {
+	type handled struct {
+		command string
+		args    []string
+		answer  string
+		missing string
+	}
+	var calls []handled
+
+	r := NewRouter()
+	require.NoError(t, r.AddComponent(&Component{
+		CustomID: "verify",
+		Prefix:   true,
+		Handler: func(c *Context) error {
+			calls = append(calls, handled{
+				command: c.Command(),
+				args:    c.Args(),
+				answer:  c.Field("answer"),
+				missing: c.Field("missing"),
+			})
+			return nil
+		},
+	}))
+
+	s, _ := newTestSession(t)
+
+	modal := func(customID string) *discordgo.InteractionCreate {
+		return &discordgo.InteractionCreate{
+			Interaction: &discordgo.Interaction{
+				ID:    "interaction",
+				Type:  discordgo.InteractionModalSubmit,
+				Token: "token",
+				Data: discordgo.ModalSubmitInteractionData{
+					CustomID: customID,
+					Components: []discordgo.MessageComponent{
+						&discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+							&discordgo.TextInput{CustomID: "answer", Value: "42"},
+						}},
+					},
+				},
+			},
+		}
+	}
+
+	require.NoError(t, r.Handle(s, modal(CustomID("verify", "challenge"))))
+	require.ErrorIs(t, r.Handle(s, modal("missing")), ErrUnknownComponent)
+
+	require.Equal(t, []handled{
+		{command: "verify", args: []string{"challenge"}, answer: "42"},
+	}, calls)
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{
+			name: "user",
+			err:  fmt.Errorf("wrapped: %w", NewUserError("nope")),
+			want: ErrorClassUser,
+		},
+		{
+			name: "panic",
+			err:  &PanicError{Value: "boom"},
+			want: ErrorClassPanic,
+		},
+		{
+			name: "unknown",
+			err:  fmt.Errorf("%w: test", ErrUnknownCommand),
+			want: ErrorClassUnknown,
+		},
+		{
+			name: "timeout",
+			err:  fmt.Errorf("error getting guild: %w", context.DeadlineExceeded),
+			want: ErrorClassTimeout,
+		},
+		{
+			name: "discord",
+			err:  fmt.Errorf("error getting guild: %w", &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusNotFound}}),
+			want: ErrorClassDiscord,
+		},
+		{
+			name: "internal",
+			err:  errors.New("boom"),
+			want: ErrorClassInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Classify(tt.err))
+		})
+	}
+}
+
+func TestRouter_HandleTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var class ErrorClass
+	r := NewRouter(
+		WithContext(ctx),
+		WithTimeout(50*time.Millisecond),
+		WithErrorHook(func(c *Context, got ErrorClass, err error) {
+			class = got
+		}),
+	)
+
+	deadline := make(chan bool, 1)
+	require.NoError(t, r.AddComponent(&Component{
+		CustomID: "slow",
+		Handler: func(c *Context) error {
+			_, ok := c.Context().Deadline()
+			deadline <- ok
+
+			<-c.Context().Done()
+			return c.Context().Err()
+		},
+	}))
+
+	s, _ := newTestSession(t)
+	component := &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    "interaction",
+			Type:  discordgo.InteractionMessageComponent,
+			Token: "token",
+			Data:  discordgo.MessageComponentInteractionData{CustomID: "slow"},
+		},
+	}
+
+	// The handler is cancelled when the timeout is reached.
+	require.ErrorIs(t, r.Handle(s, component), context.DeadlineExceeded)
+	require.True(t, <-deadline)
+	require.Equal(t, ErrorClassTimeout, class)
+
+	// The handler is cancelled when the base context is cancelled.
+	cancel()
+	require.ErrorIs(t, r.Handle(s, component), context.Canceled)
+}
+
+func TestRouter_HandleTracing(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+	})
+
+	var traceID trace.TraceID
+	r := NewRouter()
+	require.NoError(t, r.AddComponent(&Component{
+		CustomID: "fail",
+		Handler: func(c *Context) error {
+			traceID = trace.SpanContextFromContext(c.Context()).TraceID()
+			return errors.New("boom")
+		},
+	}))
+
+	s, _ := newTestSession(t)
+	require.Error(t, r.Handle(s, &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    "interaction",
+			Type:  discordgo.InteractionMessageComponent,
+			Token: "token",
+			Data:  discordgo.MessageComponentInteractionData{CustomID: "fail"},
+		},
+	}))
+
+	ended := spans.Ended()
+	require.Len(t, ended, 1)
+	require.Equal(t, "interaction fail", ended[0].Name())
+	require.Equal(t, traceID, ended[0].SpanContext().TraceID())
+	require.Equal(t, codes.Error, ended[0].Status().Code)
+	require.Contains(t, ended[0].Attributes(), attribute.String("error.class", string(ErrorClassInternal)))
+}
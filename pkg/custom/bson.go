@@ -0,0 +1,53 @@
+package custom
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// marshalBSONTime marshals the time as a BSON date, or null if the time is zero.
+func marshalBSONTime(t time.Time) (bsontype.Type, []byte, error) {
+	if t.IsZero() {
+		return bson.TypeNull, nil, nil
+	}
+	return bson.MarshalValue(t.UTC())
+}
+
+// unmarshalBSONTime unmarshals a BSON date, or a string in one of the layouts, into a time. Null is unmarshalled as
+// the zero time.
+func unmarshalBSONTime(t bsontype.Type, data []byte, layouts ...string) (time.Time, error) {
+	raw := bson.RawValue{Type: t, Value: data}
+
+	switch t {
+	case bson.TypeNull, bson.TypeUndefined:
+		return time.Time{}, nil
+	case bson.TypeDateTime:
+		got, ok := raw.TimeOK()
+		if !ok {
+			return time.Time{}, errors.New("malformed date")
+		}
+		return got.UTC(), nil
+	case bson.TypeString:
+		str, ok := raw.StringValueOK()
+		if !ok {
+			return time.Time{}, errors.New("malformed string")
+		}
+
+		if str == "" {
+			return time.Time{}, nil
+		}
+
+		for _, layout := range layouts {
+			if got, err := time.Parse(layout, str); err == nil {
+				return got.UTC(), nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unsupported format: %s", str)
+	default:
+		return time.Time{}, fmt.Errorf("unsupported type: %s", t)
+	}
+}
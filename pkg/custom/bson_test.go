@@ -0,0 +1,173 @@
+package custom
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+func TestDatetime_BSONRoundTrip(t *testing.T) {
+	type document struct {
+		CreatedAt Datetime `bson:"created_at"`
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	tests := []struct {
+		name     string
+		raw      bson.M
+		want     Datetime
+		wantType bsontype.Type
+		wantErr  bool
+	}{
+		{
+			name:     "native",
+			raw:      bson.M{"created_at": now},
+			want:     Datetime(now),
+			wantType: bson.TypeDateTime,
+		},
+		{
+			name:     "legacy string",
+			raw:      bson.M{"created_at": "2024-01-27T12:30:45Z"},
+			want:     Datetime(time.Date(2024, 1, 27, 12, 30, 45, 0, time.UTC)),
+			wantType: bson.TypeDateTime,
+		},
+		{
+			name:     "null",
+			raw:      bson.M{"created_at": nil},
+			want:     Datetime{},
+			wantType: bson.TypeNull,
+		},
+		{
+			name:     "empty string",
+			raw:      bson.M{"created_at": ""},
+			want:     Datetime{},
+			wantType: bson.TypeNull,
+		},
+		{
+			name:    "invalid string",
+			raw:     bson.M{"created_at": "yesterday"},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported type",
+			raw:     bson.M{"created_at": 12},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := bson.Marshal(tt.raw)
+			require.NoError(t, err)
+
+			got := new(document)
+			err = bson.Unmarshal(data, got)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, time.Time(tt.want), time.Time(got.CreatedAt))
+
+			// Encoding again writes the native form.
+			data, err = bson.Marshal(got)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantType, bson.Raw(data).Lookup("created_at").Type)
+
+			again := new(document)
+			require.NoError(t, bson.Unmarshal(data, again))
+			require.Equal(t, got, again)
+		})
+	}
+}
+
+func TestDatetime_MarshalBSONValue_Zero(t *testing.T) {
+	type document struct {
+		CreatedAt *Datetime `bson:"created_at"`
+	}
+
+	data, err := bson.Marshal(&document{CreatedAt: new(Datetime)})
+	require.NoError(t, err)
+	require.Equal(t, bson.TypeNull, bson.Raw(data).Lookup("created_at").Type)
+
+	// A nil pointer is also stored as null.
+	data, err = bson.Marshal(&document{})
+	require.NoError(t, err)
+	require.Equal(t, bson.TypeNull, bson.Raw(data).Lookup("created_at").Type)
+}
+
+func TestDate_BSONRoundTrip(t *testing.T) {
+	type document struct {
+		Day Date `bson:"day"`
+	}
+
+	day := time.Date(2024, 1, 27, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		raw      bson.M
+		want     Date
+		wantType bsontype.Type
+		wantErr  bool
+	}{
+		{
+			name:     "native",
+			raw:      bson.M{"day": day},
+			want:     Date(day),
+			wantType: bson.TypeDateTime,
+		},
+		{
+			name:     "date only string",
+			raw:      bson.M{"day": "2024-01-27"},
+			want:     Date(day),
+			wantType: bson.TypeDateTime,
+		},
+		{
+			name:     "null",
+			raw:      bson.M{"day": nil},
+			want:     Date{},
+			wantType: bson.TypeNull,
+		},
+		{
+			name:    "invalid string",
+			raw:     bson.M{"day": "27/01/2024"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := bson.Marshal(tt.raw)
+			require.NoError(t, err)
+
+			got := new(document)
+			err = bson.Unmarshal(data, got)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, time.Time(tt.want), time.Time(got.Day))
+
+			data, err = bson.Marshal(got)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantType, bson.Raw(data).Lookup("day").Type)
+
+			again := new(document)
+			require.NoError(t, bson.Unmarshal(data, again))
+			require.Equal(t, got, again)
+		})
+	}
+}
+
+func TestDate_MarshalBSONValue_Midnight(t *testing.T) {
+	// The time of day is dropped.
+	typ, data, err := Date(time.Date(2024, 1, 27, 15, 4, 5, 0, time.UTC)).MarshalBSONValue()
+	require.NoError(t, err)
+	require.Equal(t, bson.TypeDateTime, typ)
+	require.Equal(t, time.Date(2024, 1, 27, 0, 0, 0, 0, time.UTC), bson.RawValue{Type: typ, Value: data}.Time().UTC())
+}
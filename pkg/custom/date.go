@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"time"
+
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 )
 
 // Date is a custom type for time.Time that marshals to and from RFC3339 date only.
@@ -37,6 +39,24 @@ func (d *Date) UnmarshalText(text []byte) error {
 	return nil
 }
 
+// MarshalBSONValue implements the bson.ValueMarshaler interface. The date is stored as a BSON date at midnight UTC,
+// and the zero date as null.
+func (d Date) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	t := time.Time(d)
+	return marshalBSONTime(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC))
+}
+
+// UnmarshalBSONValue implements the bson.ValueUnmarshaler interface. Both BSON dates and date only strings are
+// accepted, null is decoded as the zero date.
+func (d *Date) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	got, err := unmarshalBSONTime(t, data, time.DateOnly, time.RFC3339Nano)
+	if err != nil {
+		return fmt.Errorf("invalid date: %w", err)
+	}
+	*d = Date(got)
+	return nil
+}
+
 // Scan scans the date from a database value.
 func (d *Date) Scan(src any) error {
 	t, ok := src.(time.Time)
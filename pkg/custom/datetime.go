@@ -5,7 +5,6 @@ import (
 	"regexp"
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/bsontype"
 )
 
@@ -20,18 +19,10 @@ func (d *Datetime) MarshalJSON() ([]byte, error) {
 	return []byte(fmt.Sprintf(`%q`, time.Time(*d).UTC().Format(time.RFC3339))), nil
 }
 
-func (d *Datetime) MarshalBSON() ([]byte, error) {
-	if d == nil || time.Time(*d).IsZero() {
-		return nil, nil
-	}
-	return []byte(time.Time(*d).UTC().Format(time.RFC3339)), nil
-}
-
-func (d *Datetime) MarshalBSONValue() (bsontype.Type, []byte, error) {
-	if d == nil || time.Time(*d).IsZero() {
-		return bson.TypeNull, nil, nil
-	}
-	return bson.MarshalValue(time.Time(*d).UTC().Format(time.RFC3339))
+// MarshalBSONValue implements the bson.ValueMarshaler interface. The datetime is stored as a BSON date, and the zero
+// datetime as null.
+func (d Datetime) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return marshalBSONTime(time.Time(d))
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
@@ -48,22 +39,14 @@ func (d *Datetime) UnmarshalJSON(text []byte) error {
 	return nil
 }
 
-func (d *Datetime) UnmarshalBSON(bytes []byte) error {
-	if len(bytes) == 0 {
-		return nil
-	}
-
-	// Remove all non-alphanumeric characters from the string.
-	got := regexp.MustCompile(`[^a-zA-Z0-9-:]`).ReplaceAllString(string(bytes), "")
-
-	// Parse the string into a time.Time.
-	t, err := time.Parse(time.RFC3339, got)
+// UnmarshalBSONValue implements the bson.ValueUnmarshaler interface. Both BSON dates and the legacy RFC3339 strings
+// are accepted, null is decoded as the zero datetime.
+func (d *Datetime) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	got, err := unmarshalBSONTime(t, data, time.RFC3339Nano)
 	if err != nil {
-		return fmt.Errorf("invalid datetime: %s", got)
+		return fmt.Errorf("invalid datetime: %w", err)
 	}
-
-	// Set the value of the Datetime.
-	*d = Datetime(t)
+	*d = Datetime(got)
 	return nil
 }
 
@@ -0,0 +1,94 @@
+package custom
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationUnits are the units accepted by ParseDuration, from the largest to the smallest.
+var durationUnits = []struct {
+	suffix string
+	unit   time.Duration
+}{
+	{"w", 7 * 24 * time.Hour},
+	{"d", 24 * time.Hour},
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+}
+
+// ParseDuration parses a duration written by a user, such as "30m", "1h30m" or "7d". It accepts the weeks (w), days
+// (d), hours (h), minutes (m) and seconds (s) units. The duration must be positive.
+func ParseDuration(s string) (time.Duration, error) {
+	text := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), " ", ""))
+	if text == "" {
+		return 0, errors.New("duration is empty")
+	}
+
+	var total time.Duration
+	for text != "" {
+		i := 0
+		for i < len(text) && text[i] >= '0' && text[i] <= '9' {
+			i++
+		}
+		if i == 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+
+		n, err := strconv.ParseInt(text[:i], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+		text = text[i:]
+
+		unit, ok := durationUnit(text)
+		if !ok {
+			return 0, fmt.Errorf("invalid unit in duration %q", s)
+		}
+		text = text[1:]
+
+		if n > int64((1<<63-1)/unit) || total > (1<<63-1)-time.Duration(n)*unit {
+			return 0, fmt.Errorf("duration %q is too long", s)
+		}
+		total += time.Duration(n) * unit
+	}
+
+	if total <= 0 {
+		return 0, errors.New("duration must be positive")
+	}
+	return total, nil
+}
+
+// durationUnit returns the unit that the text starts with.
+func durationUnit(text string) (time.Duration, bool) {
+	if text == "" {
+		return 0, false
+	}
+	for _, u := range durationUnits {
+		if text[:1] == u.suffix {
+			return u.unit, true
+		}
+	}
+	return 0, false
+}
+
+// FormatDuration formats the duration in the units accepted by ParseDuration, such as "1d2h". Parts smaller than a
+// second are dropped.
+func FormatDuration(d time.Duration) string {
+	if d < time.Second {
+		return "0s"
+	}
+
+	var b strings.Builder
+	for _, u := range durationUnits {
+		if n := d / u.unit; n > 0 {
+			b.WriteString(strconv.FormatInt(int64(n), 10))
+			b.WriteString(u.suffix)
+			d -= n * u.unit
+		}
+	}
+	return b.String()
+}
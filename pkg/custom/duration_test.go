@@ -0,0 +1,120 @@
+package custom
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    time.Duration
+		wantErr bool
+	}{
+		{
+			name: "minutes",
+			s:    "30m",
+			want: 30 * time.Minute,
+		},
+		{
+			name: "combined",
+			s:    "1h30m",
+			want: 90 * time.Minute,
+		},
+		{
+			name: "days",
+			s:    "7d",
+			want: 7 * 24 * time.Hour,
+		},
+		{
+			name: "weeks",
+			s:    "2w",
+			want: 14 * 24 * time.Hour,
+		},
+		{
+			name: "spaces and case",
+			s:    " 1D 12H ",
+			want: 36 * time.Hour,
+		},
+		{
+			name:    "empty",
+			s:       "",
+			wantErr: true,
+		},
+		{
+			name:    "no unit",
+			s:       "10",
+			wantErr: true,
+		},
+		{
+			name:    "unknown unit",
+			s:       "10y",
+			wantErr: true,
+		},
+		{
+			name:    "no number",
+			s:       "h",
+			wantErr: true,
+		},
+		{
+			name:    "zero",
+			s:       "0m",
+			wantErr: true,
+		},
+		{
+			name:    "too long",
+			s:       "99999999999w",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDuration(tt.s)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		name string
+		d    time.Duration
+		want string
+	}{
+		{
+			name: "zero",
+			d:    0,
+			want: "0s",
+		},
+		{
+			name: "minutes",
+			d:    30 * time.Minute,
+			want: "30m",
+		},
+		{
+			name: "combined",
+			d:    8*24*time.Hour + 2*time.Hour + 5*time.Second,
+			want: "1w1d2h5s",
+		},
+		{
+			name: "sub second dropped",
+			d:    time.Minute + time.Millisecond,
+			want: "1m",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, FormatDuration(tt.d))
+		})
+	}
+}
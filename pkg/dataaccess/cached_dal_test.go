@@ -0,0 +1,283 @@
+package dataaccess
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeGuildDal is an in memory GuildDal that counts the reads.
+type fakeGuildDal struct {
+	guilds map[string]entities.Guild
+	reads  int
+	err    error
+
+	// onRead is called after a guild is read, before it is returned.
+	onRead func()
+
+	// updates are the updates applied to the guilds.
+	updates []*GuildUpdate
+}
+
+func (f *fakeGuildDal) SaveGuild(_ context.Context, guild *entities.Guild) error {
+	f.guilds[guild.ID] = *guild
+	return nil
+}
+
+func (f *fakeGuildDal) UpdateGuild(_ context.Context, _ string, update *GuildUpdate) error {
+	f.updates = append(f.updates, update)
+	return nil
+}
+
+func (f *fakeGuildDal) GetGuildByID(_ context.Context, id string) (*entities.Guild, error) {
+	f.reads++
+	if f.err != nil {
+		return nil, f.err
+	}
+	guild, ok := f.guilds[id]
+	if f.onRead != nil {
+		f.onRead()
+	}
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &guild, nil
+}
+
+// fakeTicketDal is an in memory TicketDal that counts the reads.
+type fakeTicketDal struct {
+	tickets map[ticketKey]entities.Ticket
+	reads   int
+}
+
+func (f *fakeTicketDal) SaveTicket(_ context.Context, ticket *entities.Ticket) error {
+	f.tickets[ticketKey{guildID: ticket.GuildID, channelID: ticket.ChannelID}] = *ticket
+	return nil
+}
+
+func (f *fakeTicketDal) GetTicket(_ context.Context, guildID string, channelID string) (*entities.Ticket, error) {
+	f.reads++
+	ticket, ok := f.tickets[ticketKey{guildID: guildID, channelID: channelID}]
+	if !ok || ticket.Deleted {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &ticket, nil
+}
+
+func (f *fakeTicketDal) GetLatestTicket(_ context.Context, _ string) (*entities.Ticket, error) {
+	return nil, mongo.ErrNoDocuments
+}
+
+func (f *fakeTicketDal) GetTicketByID(_ context.Context, _ string, _ int) (*entities.Ticket, error) {
+	return nil, mongo.ErrNoDocuments
+}
+
+func (f *fakeTicketDal) ListTickets(_ context.Context, _ string, _ *TicketFilter) ([]*entities.Ticket, error) {
+	return nil, nil
+}
+
+func TestCachedGuildDal(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeGuildDal{guilds: map[string]entities.Guild{
+		"guild": {ID: "guild", Ticketing: entities.TicketingConfig{RoleID: "role"}},
+	}}
+	dal := NewCachedGuildDal(fake, 10, time.Minute)
+
+	// The first read misses, the second hits.
+	guild, err := dal.GetGuildByID(ctx, "guild")
+	require.NoError(t, err)
+	require.Equal(t, "role", guild.Ticketing.RoleID)
+
+	guild, err = dal.GetGuildByID(ctx, "guild")
+	require.NoError(t, err)
+	require.Equal(t, 1, fake.reads)
+
+	// Modifying the returned guild does not modify the cached guild.
+	guild.Ticketing.RoleID = "changed"
+	cached, err := dal.GetGuildByID(ctx, "guild")
+	require.NoError(t, err)
+	require.Equal(t, "role", cached.Ticketing.RoleID)
+	require.Equal(t, 1, fake.reads)
+
+	// Saving invalidates the cached guild.
+	require.NoError(t, dal.SaveGuild(ctx, guild))
+	guild, err = dal.GetGuildByID(ctx, "guild")
+	require.NoError(t, err)
+	require.Equal(t, "changed", guild.Ticketing.RoleID)
+	require.Equal(t, 2, fake.reads)
+
+	// Updating invalidates the cached guild.
+	require.NoError(t, dal.UpdateGuild(ctx, "guild", &GuildUpdate{Set: map[string]any{"ticketing.role_id": "updated"}}))
+	require.Len(t, fake.updates, 1)
+	_, err = dal.GetGuildByID(ctx, "guild")
+	require.NoError(t, err)
+	require.Equal(t, 3, fake.reads)
+
+	// A guild without a configuration is cached too.
+	_, err = dal.GetGuildByID(ctx, "missing")
+	require.ErrorIs(t, err, mongo.ErrNoDocuments)
+	_, err = dal.GetGuildByID(ctx, "missing")
+	require.ErrorIs(t, err, mongo.ErrNoDocuments)
+	require.Equal(t, 4, fake.reads)
+
+	// Saving the configuration of the guild invalidates it, so the new configuration is used straight away.
+	require.NoError(t, dal.SaveGuild(ctx, &entities.Guild{ID: "missing"}))
+	guild, err = dal.GetGuildByID(ctx, "missing")
+	require.NoError(t, err)
+	require.Equal(t, "missing", guild.ID)
+	require.Equal(t, 5, fake.reads)
+}
+
+func TestCachedGuildDal_Errors(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeGuildDal{guilds: map[string]entities.Guild{}, err: errors.New("connection refused")}
+	dal := NewCachedGuildDal(fake, 10, time.Minute)
+
+	// Errors other than the guild having no configuration are not cached.
+	for i := 0; i < 2; i++ {
+		_, err := dal.GetGuildByID(ctx, "guild")
+		require.ErrorIs(t, err, fake.err)
+	}
+	require.Equal(t, 2, fake.reads)
+}
+
+func TestCachedGuildDal_NestedCopies(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeGuildDal{guilds: map[string]entities.Guild{
+		"guild": {
+			ID: "guild",
+			Automod: entities.AutomodConfig{Rules: map[entities.AutomodRuleType]*entities.AutomodRule{
+				entities.AutomodRuleWords: {Values: []string{"wolf"}},
+			}},
+			ReactionRoles: entities.ReactionRoleConfig{Messages: []*entities.ReactionRoleMessage{
+				{MessageID: "first"},
+				{MessageID: "second"},
+			}},
+		},
+	}}
+	dal := NewCachedGuildDal(fake, 10, time.Minute)
+
+	// The guild returned by the miss and the guild returned by a hit are both copies.
+	for i := 0; i < 2; i++ {
+		guild, err := dal.GetGuildByID(ctx, "guild")
+		require.NoError(t, err)
+
+		guild.Automod.Rules[entities.AutomodRuleWords].Values[0] = "changed"
+		guild.Automod.SetRule(entities.AutomodRuleRate, &entities.AutomodRule{Threshold: 5})
+		guild.ReactionRoles.Messages[0].MessageID = "changed"
+		guild.ReactionRoles.Messages = guild.ReactionRoles.Messages[:1]
+	}
+
+	cached, err := dal.GetGuildByID(ctx, "guild")
+	require.NoError(t, err)
+	require.Equal(t, 1, fake.reads)
+	require.Len(t, cached.Automod.Rules, 1)
+	require.Equal(t, []string{"wolf"}, cached.Automod.Rule(entities.AutomodRuleWords).Values)
+	require.Len(t, cached.ReactionRoles.Messages, 2)
+	require.Equal(t, "first", cached.ReactionRoles.Messages[0].MessageID)
+}
+
+func TestCachedTicketDal(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeTicketDal{tickets: map[ticketKey]entities.Ticket{
+		{guildID: "guild", channelID: "channel"}: {ID: 1, GuildID: "guild", ChannelID: "channel"},
+	}}
+	dal := NewCachedTicketDal(fake, 10, time.Minute)
+
+	ticket, err := dal.GetTicket(ctx, "guild", "channel")
+	require.NoError(t, err)
+	require.Equal(t, 1, ticket.ID)
+
+	_, err = dal.GetTicket(ctx, "guild", "channel")
+	require.NoError(t, err)
+	require.Equal(t, 1, fake.reads)
+
+	// A deleted ticket is no longer returned once saved.
+	ticket.Deleted = true
+	require.NoError(t, dal.SaveTicket(ctx, ticket))
+	_, err = dal.GetTicket(ctx, "guild", "channel")
+	require.ErrorIs(t, err, mongo.ErrNoDocuments)
+	require.Equal(t, 2, fake.reads)
+}
+
+func TestCachedGuildDal_Expiry(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeGuildDal{guilds: map[string]entities.Guild{"guild": {ID: "guild"}}}
+	dal := NewCachedGuildDal(fake, 10, time.Millisecond)
+
+	_, err := dal.GetGuildByID(ctx, "guild")
+	require.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+
+	_, err = dal.GetGuildByID(ctx, "guild")
+	require.NoError(t, err)
+	require.Equal(t, 2, fake.reads)
+}
+
+func TestCachedGuildDal_InvalidatedWhileLoading(t *testing.T) {
+	tests := []struct {
+		name   string
+		guilds map[string]entities.Guild
+	}{
+		{
+			name:   "configured guild",
+			guilds: map[string]entities.Guild{"guild": {ID: "guild"}},
+		},
+		{
+			name:   "guild without a configuration",
+			guilds: map[string]entities.Guild{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			fake := &fakeGuildDal{guilds: tt.guilds}
+			dal := NewCachedGuildDal(fake, 10, time.Minute)
+
+			// The guild is saved after it was read, but before the read fills the cache.
+			fake.onRead = func() {
+				fake.onRead = nil
+				require.NoError(t, dal.SaveGuild(ctx, &entities.Guild{ID: "guild", Ticketing: entities.TicketingConfig{RoleID: "role"}}))
+			}
+			_, _ = dal.GetGuildByID(ctx, "guild")
+
+			// The read did not fill the cache with the guild from before the save.
+			guild, err := dal.GetGuildByID(ctx, "guild")
+			require.NoError(t, err)
+			require.Equal(t, "role", guild.Ticketing.RoleID)
+			require.Equal(t, 2, fake.reads)
+		})
+	}
+}
+
+func TestCachedGuildDal_WatchDown(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeGuildDal{guilds: map[string]entities.Guild{"guild": {ID: "guild"}}}
+	dal := NewCachedGuildDal(fake, 10, time.Minute)
+
+	_, err := dal.GetGuildByID(ctx, "guild")
+	require.NoError(t, err)
+	require.Equal(t, 1, fake.reads)
+
+	// The cache is bypassed while the changes are not watched.
+	dal.state.setDown(true)
+	for i := 0; i < 2; i++ {
+		_, err = dal.GetGuildByID(ctx, "guild")
+		require.NoError(t, err)
+	}
+	require.Equal(t, 3, fake.reads)
+
+	dal.state.setDown(false)
+	for i := 0; i < 2; i++ {
+		_, err = dal.GetGuildByID(ctx, "guild")
+		require.NoError(t, err)
+	}
+	require.Equal(t, 3, fake.reads)
+}
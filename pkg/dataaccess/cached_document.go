@@ -0,0 +1,26 @@
+package dataaccess
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// encodeCached encodes the document to be cached. The cache holds the encoded document rather than the document, so
+// it shares no maps, slices or pointers with the callers that modify the documents they are given.
+func encodeCached[T any](doc *T) (bson.Raw, error) {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding cached document: %w", err)
+	}
+	return raw, nil
+}
+
+// decodeCached decodes the cached document into a new document, which the caller is free to modify.
+func decodeCached[T any](raw bson.Raw) (*T, error) {
+	doc := new(T)
+	if err := bson.Unmarshal(raw, doc); err != nil {
+		return nil, fmt.Errorf("error decoding cached document: %w", err)
+	}
+	return doc, nil
+}
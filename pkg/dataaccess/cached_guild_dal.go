@@ -0,0 +1,146 @@
+package dataaccess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Jacobbrewer1/wolf/pkg/cache"
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess/monitoring"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	cachedGuildDalName = "cached_guild_dal"
+
+	// missingGuildTTL is how long a guild is cached as having no configuration. It is shorter than the TTL of the
+	// configured guilds, as a guild is usually looked up by its events just as it is being set up.
+	missingGuildTTL = time.Minute
+)
+
+// CachedGuildDal is a read-through cache in front of a GuildDal. The guilds without a configuration are cached too, as
+// the gateway events look up the configuration of every guild whether it is configured or not.
+type CachedGuildDal struct {
+	// GuildDal is the data access layer that is cached.
+	GuildDal
+
+	// l is the logger.
+	l *slog.Logger
+
+	// guilds are the encoded cached guilds, keyed by ID. The guilds are encoded, so every caller is given its own
+	// copy and the nested maps and slices are never shared.
+	guilds *cache.Cache[string, bson.Raw]
+
+	// missing are the IDs of the guilds that have no configuration.
+	missing *cache.Cache[string, struct{}]
+
+	// state stops the reads from filling the cache with the guilds they loaded before an invalidation.
+	state cacheState
+}
+
+// NewCachedGuildDal creates a new cache, holding up to size guilds for the ttl, in front of the data access layer.
+func NewCachedGuildDal(dal GuildDal, size int, ttl time.Duration) *CachedGuildDal {
+	return &CachedGuildDal{
+		GuildDal: dal,
+		l:        slog.Default().With(slog.String(logging.KeyDal, cachedGuildDalName)),
+		guilds:   cache.New[string, bson.Raw](size, ttl),
+		missing:  cache.New[string, struct{}](size, min(ttl, missingGuildTTL)),
+	}
+}
+
+// SaveGuild saves the guild and invalidates the cached guild.
+func (d *CachedGuildDal) SaveGuild(ctx context.Context, guild *entities.Guild) error {
+	// The guild is invalidated even if the save fails, as it is unknown whether the write was applied.
+	defer d.invalidate(guild.ID, invalidationSave)
+
+	return d.GuildDal.SaveGuild(ctx, guild)
+}
+
+// UpdateGuild updates the guild and invalidates the cached guild.
+func (d *CachedGuildDal) UpdateGuild(ctx context.Context, id string, update *GuildUpdate) error {
+	// The guild is invalidated even if the update fails, as it is unknown whether the write was applied.
+	defer d.invalidate(id, invalidationSave)
+
+	return d.GuildDal.UpdateGuild(ctx, id, update)
+}
+
+// GetGuildByID gets the guild from the cache, or from the data access layer if it is not cached.
+func (d *CachedGuildDal) GetGuildByID(ctx context.Context, id string) (*entities.Guild, error) {
+	generation, ok := d.state.begin()
+	if !ok {
+		monitoring.CacheTotalMisses.WithLabelValues(cachedGuildDalName, "get_guild_by_id").Inc()
+		return d.GuildDal.GetGuildByID(ctx, id)
+	}
+
+	if raw, ok := d.guilds.Get(id); ok {
+		guild, err := decodeCached[entities.Guild](raw)
+		if err == nil {
+			monitoring.CacheTotalHits.WithLabelValues(cachedGuildDalName, "get_guild_by_id").Inc()
+			return guild, nil
+		}
+		d.l.Warn("Error decoding cached guild", slog.String(logging.KeyError, err.Error()))
+	}
+	if _, ok := d.missing.Get(id); ok {
+		monitoring.CacheTotalHits.WithLabelValues(cachedGuildDalName, "get_guild_by_id").Inc()
+		return nil, fmt.Errorf("error getting guild: %w", mongo.ErrNoDocuments)
+	}
+	monitoring.CacheTotalMisses.WithLabelValues(cachedGuildDalName, "get_guild_by_id").Inc()
+
+	guild, err := d.GuildDal.GetGuildByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		d.state.fill(generation, func() {
+			d.missing.Set(id, struct{}{})
+		})
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	}
+
+	raw, err := encodeCached(guild)
+	if err != nil {
+		d.l.Warn("Error encoding guild to cache", slog.String(logging.KeyError, err.Error()))
+		return guild, nil
+	}
+	d.state.fill(generation, func() {
+		d.guilds.Set(id, raw)
+	})
+	return guild, nil
+}
+
+// Watch invalidates the cached guilds that are changed by other instances, until the context is cancelled. The cache
+// is bypassed while the changes cannot be watched.
+func (d *CachedGuildDal) Watch(ctx context.Context) {
+	watchChanges(ctx, d.l, MongoDB, "guilds", &d.state, d.purge, func(event *changeEvent[entities.Guild]) {
+		if event.FullDocument == nil {
+			// The changed guild is unknown, such as when it is deleted.
+			d.l.Debug("Purging cache", slog.String("operation", event.OperationType))
+			d.purge()
+			return
+		}
+
+		d.invalidate(event.FullDocument.ID, invalidationChangeStream)
+	})
+}
+
+// purge removes every guild from the cache.
+func (d *CachedGuildDal) purge() {
+	d.state.invalidate(func() {
+		d.guilds.Purge()
+		d.missing.Purge()
+	})
+	monitoring.CacheTotalInvalidations.WithLabelValues(cachedGuildDalName, invalidationChangeStream).Inc()
+}
+
+// invalidate removes the guild from the cache, including a guild cached as having no configuration.
+func (d *CachedGuildDal) invalidate(id, source string) {
+	d.state.invalidate(func() {
+		d.guilds.Delete(id)
+		d.missing.Delete(id)
+	})
+	monitoring.CacheTotalInvalidations.WithLabelValues(cachedGuildDalName, source).Inc()
+}
@@ -0,0 +1,123 @@
+package dataaccess
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Jacobbrewer1/wolf/pkg/cache"
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess/monitoring"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const cachedTicketDalName = "cached_ticket_dal"
+
+// ticketKey is the key of a cached ticket.
+type ticketKey struct {
+	// guildID is the ID of the guild the ticket is in.
+	guildID string
+
+	// channelID is the ID of the channel of the ticket.
+	channelID string
+}
+
+// CachedTicketDal is a read-through cache of the open tickets in front of a TicketDal. The latest ticket is not
+// cached, as it is used to number new tickets.
+type CachedTicketDal struct {
+	// TicketDal is the data access layer that is cached.
+	TicketDal
+
+	// l is the logger.
+	l *slog.Logger
+
+	// tickets are the encoded cached tickets. The tickets are encoded, so every caller is given its own copy and the
+	// history is never shared.
+	tickets *cache.Cache[ticketKey, bson.Raw]
+
+	// state stops the reads from filling the cache with the tickets they loaded before an invalidation.
+	state cacheState
+}
+
+// NewCachedTicketDal creates a new cache, holding up to size tickets for the ttl, in front of the data access layer.
+func NewCachedTicketDal(dal TicketDal, size int, ttl time.Duration) *CachedTicketDal {
+	return &CachedTicketDal{
+		TicketDal: dal,
+		l:         slog.Default().With(slog.String(logging.KeyDal, cachedTicketDalName)),
+		tickets:   cache.New[ticketKey, bson.Raw](size, ttl),
+	}
+}
+
+// SaveTicket saves the ticket and invalidates the cached ticket.
+func (d *CachedTicketDal) SaveTicket(ctx context.Context, ticket *entities.Ticket) error {
+	// The ticket is invalidated even if the save fails, as it is unknown whether the write was applied.
+	defer d.invalidate(ticketKey{guildID: ticket.GuildID, channelID: ticket.ChannelID}, invalidationSave)
+
+	return d.TicketDal.SaveTicket(ctx, ticket)
+}
+
+// GetTicket gets the ticket from the cache, or from the data access layer if it is not cached.
+func (d *CachedTicketDal) GetTicket(ctx context.Context, guildID string, channelID string) (*entities.Ticket, error) {
+	key := ticketKey{guildID: guildID, channelID: channelID}
+
+	generation, ok := d.state.begin()
+	if !ok {
+		monitoring.CacheTotalMisses.WithLabelValues(cachedTicketDalName, "get_ticket").Inc()
+		return d.TicketDal.GetTicket(ctx, guildID, channelID)
+	}
+
+	if raw, ok := d.tickets.Get(key); ok {
+		ticket, err := decodeCached[entities.Ticket](raw)
+		if err == nil {
+			monitoring.CacheTotalHits.WithLabelValues(cachedTicketDalName, "get_ticket").Inc()
+			return ticket, nil
+		}
+		d.l.Warn("Error decoding cached ticket", slog.String(logging.KeyError, err.Error()))
+	}
+	monitoring.CacheTotalMisses.WithLabelValues(cachedTicketDalName, "get_ticket").Inc()
+
+	ticket, err := d.TicketDal.GetTicket(ctx, guildID, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := encodeCached(ticket)
+	if err != nil {
+		d.l.Warn("Error encoding ticket to cache", slog.String(logging.KeyError, err.Error()))
+		return ticket, nil
+	}
+	d.state.fill(generation, func() {
+		d.tickets.Set(key, raw)
+	})
+	return ticket, nil
+}
+
+// Watch invalidates the cached tickets that are changed by other instances, until the context is cancelled. The cache
+// is bypassed while the changes cannot be watched.
+func (d *CachedTicketDal) Watch(ctx context.Context) {
+	watchChanges(ctx, d.l, MongoDB, "tickets", &d.state, d.purge, func(event *changeEvent[entities.Ticket]) {
+		if event.FullDocument == nil {
+			// The changed ticket is unknown, such as when it is deleted.
+			d.l.Debug("Purging cache", slog.String("operation", event.OperationType))
+			d.purge()
+			return
+		}
+
+		d.invalidate(ticketKey{guildID: event.FullDocument.GuildID, channelID: event.FullDocument.ChannelID}, invalidationChangeStream)
+	})
+}
+
+// purge removes every ticket from the cache.
+func (d *CachedTicketDal) purge() {
+	d.state.invalidate(d.tickets.Purge)
+	monitoring.CacheTotalInvalidations.WithLabelValues(cachedTicketDalName, invalidationChangeStream).Inc()
+}
+
+// invalidate removes the ticket from the cache.
+func (d *CachedTicketDal) invalidate(key ticketKey, source string) {
+	d.state.invalidate(func() {
+		d.tickets.Delete(key)
+	})
+	monitoring.CacheTotalInvalidations.WithLabelValues(cachedTicketDalName, source).Inc()
+}
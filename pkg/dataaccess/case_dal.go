@@ -0,0 +1,170 @@
+package dataaccess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess/monitoring"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	caseDalName = "case_dal"
+
+	// casesCollection is the collection of the moderation cases.
+	casesCollection = "cases"
+
+	// createCaseAttempts is how many times a case is numbered before giving up, when other cases are created in the
+	// guild at the same time.
+	createCaseAttempts = 5
+)
+
+var CaseDB CaseDal
+
+type CaseDal interface {
+	// CreateCase saves a new case, numbering it after the latest case of the guild. The ID of the case is set.
+	CreateCase(ctx context.Context, c *entities.Case) error
+
+	// SaveCase saves a case that has already been created.
+	SaveCase(ctx context.Context, c *entities.Case) error
+
+	// GetCase gets a case by its number.
+	GetCase(ctx context.Context, guildID string, id int) (*entities.Case, error)
+
+	// ListCases lists the cases of the guild against the user, newest first. If the limit is zero, every case is
+	// listed.
+	ListCases(ctx context.Context, guildID string, targetID string, limit int64) ([]*entities.Case, error)
+}
+
+type caseDalImpl struct {
+	// l is the logger.
+	l *slog.Logger
+
+	// client is the database.
+	client *mongo.Client
+}
+
+// NewCaseDal creates a new case data access layer.
+func NewCaseDal() CaseDal {
+	l := slog.Default().With(slog.String(logging.KeyDal, caseDalName))
+
+	if MongoDB == nil {
+		l.Warn("MongoDB is nil, this can cause a panic. Proceeding...")
+	}
+
+	return &caseDalImpl{
+		l:      l,
+		client: MongoDB,
+	}
+}
+
+func (d *caseDalImpl) CreateCase(ctx context.Context, c *entities.Case) (err error) {
+	// Get the case collection.
+	collection := d.client.Database(mongoDatabase).Collection(casesCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(caseDalName, "create_case", mongoDatabase, casesCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// Set the options to get the latest case.
+	opts := options.FindOne()
+	opts.SetSort(bson.M{"id": -1})
+	opts.SetProjection(bson.M{"id": 1})
+
+	// The number is unique in the guild, so the insert fails if another case took the number first.
+	for attempt := 1; ; attempt++ {
+		latest := new(entities.Case)
+		err = collection.FindOne(ctx, bson.M{"guild_id": c.GuildID}, opts).Decode(latest)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("error getting latest case: %w", err)
+		}
+		c.ID = latest.ID + 1
+
+		_, err = collection.InsertOne(ctx, c)
+		if err == nil {
+			return nil
+		}
+		if !mongo.IsDuplicateKeyError(err) || attempt == createCaseAttempts {
+			return fmt.Errorf("error inserting case: %w", err)
+		}
+
+		d.l.Debug("Case number taken, retrying", slog.String("guild_id", c.GuildID), slog.Int("id", c.ID))
+	}
+}
+
+func (d *caseDalImpl) SaveCase(ctx context.Context, c *entities.Case) (err error) {
+	// Get the case collection.
+	collection := d.client.Database(mongoDatabase).Collection(casesCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(caseDalName, "save_case", mongoDatabase, casesCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// Save the case.
+	_, err = collection.ReplaceOne(ctx, bson.M{"guild_id": c.GuildID, "id": c.ID}, c)
+	if err != nil {
+		return fmt.Errorf("error replacing case: %w", err)
+	}
+	return nil
+}
+
+func (d *caseDalImpl) GetCase(ctx context.Context, guildID string, id int) (_ *entities.Case, err error) {
+	// Get the case collection.
+	collection := d.client.Database(mongoDatabase).Collection(casesCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(caseDalName, "get_case", mongoDatabase, casesCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// Get the case.
+	c := new(entities.Case)
+	err = collection.FindOne(ctx, bson.M{"guild_id": guildID, "id": id}).Decode(c)
+	if err != nil {
+		return nil, fmt.Errorf("error getting case: %w", err)
+	}
+
+	return c, nil
+}
+
+func (d *caseDalImpl) ListCases(ctx context.Context, guildID string, targetID string, limit int64) (_ []*entities.Case, err error) {
+	// Get the case collection.
+	collection := d.client.Database(mongoDatabase).Collection(casesCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(caseDalName, "list_cases", mongoDatabase, casesCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// Set the options to list the newest cases first.
+	opts := options.Find()
+	opts.SetSort(bson.M{"id": -1})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	// List the cases.
+	cursor, err := collection.Find(ctx, bson.M{"guild_id": guildID, "target_id": targetID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error listing cases: %w", err)
+	}
+
+	cases := make([]*entities.Case, 0)
+	if err = cursor.All(ctx, &cases); err != nil {
+		return nil, fmt.Errorf("error decoding cases: %w", err)
+	}
+
+	return cases, nil
+}
@@ -0,0 +1,200 @@
+package dataaccess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// invalidationSave is the source of an invalidation made by a save on this instance.
+	invalidationSave = "save"
+
+	// invalidationChangeStream is the source of an invalidation received from a change stream.
+	invalidationChangeStream = "change_stream"
+)
+
+const (
+	// watchMinBackoff is how long the change stream waits before its first restart after failing. The wait doubles
+	// with each failure in a row.
+	watchMinBackoff = time.Second
+
+	// watchMaxBackoff is the longest the change stream waits before it is restarted.
+	watchMaxBackoff = time.Minute
+
+	// errorCodeChangeStreamHistoryLost is the error code of a change stream that cannot resume, as the changes after
+	// its resume token are no longer in the oplog.
+	errorCodeChangeStreamHistoryLost = 286
+)
+
+// changeEvent is an event received from a change stream.
+type changeEvent[T any] struct {
+	// OperationType is the type of the operation, such as insert, update or delete.
+	OperationType string `bson:"operationType"`
+
+	// FullDocument is the document after the change. This is nil for deletes, or when the document no longer exists.
+	FullDocument *T `bson:"fullDocument"`
+}
+
+// cacheState is the state shared by the reads, the invalidations and the change stream of a cache.
+type cacheState struct {
+	// mut guards the state, and makes the fills and the invalidations of the cache happen in order.
+	mut sync.Mutex
+
+	// generation is incremented by every invalidation. A read only fills the cache when the generation has not
+	// changed while it loaded, as it may have loaded the document from before the invalidation.
+	generation uint64
+
+	// down is set while the change stream of a watched cache is not running. The cache is bypassed while it is down,
+	// as the changes made by other instances are missed.
+	down bool
+}
+
+// begin returns the generation to give to fill once the read has loaded, and whether the cache can be read.
+func (s *cacheState) begin() (uint64, bool) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.generation, !s.down
+}
+
+// fill calls set to fill the cache, unless the cache was invalidated since the read began at the generation.
+func (s *cacheState) fill(generation uint64, set func()) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if !s.down && s.generation == generation {
+		set()
+	}
+}
+
+// invalidate calls remove to remove from the cache, and stops the reads that are loading from filling the cache.
+func (s *cacheState) invalidate(remove func()) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.generation++
+	remove()
+}
+
+// setDown sets whether the change stream is down.
+func (s *cacheState) setDown(down bool) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.generation++
+	s.down = down
+}
+
+// watchChanges watches the collection for changes, calling fn for each change until the context is cancelled. Change
+// streams require MongoDB to run as a replica set.
+//
+// The change stream is restarted with a backoff when it fails, resuming after the last change it received. The cache
+// is bypassed while the change stream is down, and purge is called when it cannot resume, as changes may have been
+// missed.
+func watchChanges[T any](
+	ctx context.Context,
+	l *slog.Logger,
+	client *mongo.Client,
+	collection string,
+	state *cacheState,
+	purge func(),
+	fn func(event *changeEvent[T]),
+) {
+	state.setDown(true)
+
+	var token bson.Raw
+	backoff := watchMinBackoff
+	for {
+		opened := false
+		err := streamChanges(ctx, l, client, collection, &token, func(resumed bool) {
+			if !resumed {
+				purge()
+			}
+			state.setDown(false)
+			opened = true
+			backoff = watchMinBackoff
+		}, fn)
+		state.setDown(true)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if serverErr := mongo.ServerError(nil); errors.As(err, &serverErr) &&
+			(!opened || serverErr.HasErrorCode(errorCodeChangeStreamHistoryLost)) {
+			// The stream cannot resume after the token, so it is started from now instead, and the cache is purged
+			// once it is open.
+			token = nil
+		}
+
+		l.Error("Error watching for changes, the cache is bypassed until the watch restarts",
+			slog.String("collection", collection),
+			slog.Bool("opened", opened),
+			slog.Duration("backoff", backoff),
+			slog.String(logging.KeyError, err.Error()),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, watchMaxBackoff)
+	}
+}
+
+// streamChanges calls fn for each change to the collection until the stream fails or the context is cancelled. The
+// stream resumes after the token if it is set, and the token is updated as the changes are received. opened is called
+// once the stream is open, with whether it resumed.
+func streamChanges[T any](
+	ctx context.Context,
+	l *slog.Logger,
+	client *mongo.Client,
+	collection string,
+	token *bson.Raw,
+	opened func(resumed bool),
+	fn func(event *changeEvent[T]),
+) error {
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if *token != nil {
+		opts.SetResumeAfter(*token)
+	}
+
+	stream, err := client.Database(mongoDatabase).Collection(collection).Watch(ctx, mongo.Pipeline{}, opts)
+	if err != nil {
+		return fmt.Errorf("error watching %s: %w", collection, err)
+	}
+	defer stream.Close(context.Background())
+
+	opened(*token != nil)
+
+	for stream.Next(ctx) {
+		event := new(changeEvent[T])
+		if err := stream.Decode(event); err != nil {
+			// The changed document is unknown, so the event is handled as if it was deleted.
+			l.Warn("Error decoding change event",
+				slog.String("collection", collection),
+				slog.String(logging.KeyError, err.Error()),
+			)
+			event = new(changeEvent[T])
+		}
+		fn(event)
+		*token = stream.ResumeToken()
+	}
+
+	if err := stream.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("error reading change stream for %s: %w", collection, err)
+	}
+	if ctx.Err() == nil {
+		return fmt.Errorf("change stream for %s closed", collection)
+	}
+	return nil
+}
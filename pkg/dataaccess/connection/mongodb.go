@@ -6,7 +6,7 @@ import (
 	"time"
 
 	dbMonitoring "github.com/Jacobbrewer1/wolf/pkg/dataaccess/monitoring"
-	"github.com/prometheus/client_golang/prometheus"
+	"github.com/Jacobbrewer1/wolf/pkg/tracing"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -41,11 +41,12 @@ func (m *MongoDB) GenerateConnectionString() {
 	m.ConnectionString = cs
 }
 
-func (m *MongoDB) Ping() error {
-	// Create a new timer to measure the latency of the check.
-	t := prometheus.NewTimer(dbMonitoring.MongoLatency.WithLabelValues("health_check", "ping", "-", "-"))
-	defer t.ObserveDuration()
-	dbMonitoring.MongoTotalRequests.WithLabelValues("health_check", "ping", "-", "-").Inc()
+func (m *MongoDB) Ping() (err error) {
+	// Record the latency and the result of the check.
+	observe := dbMonitoring.ObserveQuery("health_check", "ping", "-", "-")
+	defer func() {
+		observe(err)
+	}()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -70,7 +71,11 @@ func (m *MongoDB) Connect() (*mongo.Client, error) {
 	}
 	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
 
-	opts := options.Client().ApplyURI(m.ConnectionString).SetServerAPIOptions(serverAPI)
+	// The commands are traced as children of the operation that ran them.
+	opts := options.Client().
+		ApplyURI(m.ConnectionString).
+		SetServerAPIOptions(serverAPI).
+		SetMonitor(tracing.NewCommandMonitor())
 	client, err := mongo.Connect(context.Background(), opts)
 	if err != nil {
 		return nil, fmt.Errorf("error connecting to mongo: %w", err)
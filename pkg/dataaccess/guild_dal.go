@@ -8,7 +8,6 @@ import (
 	"github.com/Jacobbrewer1/wolf/pkg/dataaccess/monitoring"
 	"github.com/Jacobbrewer1/wolf/pkg/entities"
 	"github.com/Jacobbrewer1/wolf/pkg/logging"
-	"github.com/prometheus/client_golang/prometheus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -22,10 +21,58 @@ type GuildDal interface {
 	// SaveGuild saves a guild.
 	SaveGuild(ctx context.Context, guild *entities.Guild) error
 
+	// UpdateGuild applies the update to the fields of a guild, creating the guild if it does not exist. The other
+	// fields of the guild are left as they are.
+	UpdateGuild(ctx context.Context, id string, update *GuildUpdate) error
+
 	// GetGuildByID gets a guild by ID.
 	GetGuildByID(ctx context.Context, id string) (*entities.Guild, error)
 }
 
+// GuildUpdate is an update to some of the fields of a guild. The fields are given by their dotted path, such as
+// "welcome.channel_id". A field can only be in one of the operations of an update.
+type GuildUpdate struct {
+	// Set are the values to set the fields to.
+	Set map[string]any
+
+	// Unset are the fields to remove.
+	Unset []string
+
+	// AddToSet are the values to add to the array fields, unless the arrays already contain them.
+	AddToSet map[string]any
+
+	// Push are the values to append to the array fields.
+	Push map[string]any
+
+	// Pull are the values, or the conditions of the values, to remove from the array fields.
+	Pull map[string]any
+}
+
+// document returns the update document of the update.
+func (u *GuildUpdate) document() bson.M {
+	doc := bson.M{}
+	if len(u.Set) > 0 {
+		doc["$set"] = u.Set
+	}
+	if len(u.Unset) > 0 {
+		unset := make(bson.M, len(u.Unset))
+		for _, field := range u.Unset {
+			unset[field] = ""
+		}
+		doc["$unset"] = unset
+	}
+	if len(u.AddToSet) > 0 {
+		doc["$addToSet"] = u.AddToSet
+	}
+	if len(u.Push) > 0 {
+		doc["$push"] = u.Push
+	}
+	if len(u.Pull) > 0 {
+		doc["$pull"] = u.Pull
+	}
+	return doc
+}
+
 type guildDalImpl struct {
 	// l is the logger.
 	l *slog.Logger
@@ -49,18 +96,44 @@ func NewGuildDal() GuildDal {
 	}
 }
 
-func (g *guildDalImpl) SaveGuild(ctx context.Context, guild *entities.Guild) error {
+func (g *guildDalImpl) SaveGuild(ctx context.Context, guild *entities.Guild) (err error) {
 	// Get the guild collection.
 	collection := g.client.Database(mongoDatabase).Collection("guilds")
 
-	// Start the prometheus metrics.
-	monitoring.MongoTotalRequests.WithLabelValues(guildDalName, "save_guild_config", mongoDatabase, "guilds").Inc()
-	t := prometheus.NewTimer(monitoring.MongoLatency.WithLabelValues(guildDalName, "save_guild_config", mongoDatabase, "guilds"))
-	defer t.ObserveDuration()
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(guildDalName, "save_guild_config", mongoDatabase, "guilds")
+	defer func() {
+		observe(err)
+	}()
 
 	// Save the guild.
 	opts := options.Update().SetUpsert(true)
-	_, err := collection.UpdateOne(ctx, bson.M{"id": guild.ID}, bson.M{"$set": guild}, opts)
+	_, err = collection.UpdateOne(ctx, bson.M{"id": guild.ID}, bson.M{"$set": guild}, opts)
+	if err != nil {
+		return fmt.Errorf("error updating guild: %w", err)
+	}
+	return nil
+}
+
+// UpdateGuild applies the update to the fields of a guild, creating the guild if it does not exist.
+func (g *guildDalImpl) UpdateGuild(ctx context.Context, id string, update *GuildUpdate) (err error) {
+	doc := update.document()
+	if len(doc) == 0 {
+		return nil
+	}
+
+	// Get the guild collection.
+	collection := g.client.Database(mongoDatabase).Collection("guilds")
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(guildDalName, "update_guild", mongoDatabase, "guilds")
+	defer func() {
+		observe(err)
+	}()
+
+	// Update the guild.
+	opts := options.Update().SetUpsert(true)
+	_, err = collection.UpdateOne(ctx, bson.M{"id": id}, doc, opts)
 	if err != nil {
 		return fmt.Errorf("error updating guild: %w", err)
 	}
@@ -68,19 +141,20 @@ func (g *guildDalImpl) SaveGuild(ctx context.Context, guild *entities.Guild) err
 }
 
 // GetGuildByID gets a guild by ID.
-func (g *guildDalImpl) GetGuildByID(ctx context.Context, id string) (*entities.Guild, error) {
+func (g *guildDalImpl) GetGuildByID(ctx context.Context, id string) (_ *entities.Guild, err error) {
 	// Get the guild collection.
 	collection := g.client.Database(mongoDatabase).Collection("guilds")
 
-	// Start the prometheus metrics.
-	monitoring.MongoTotalRequests.WithLabelValues(guildDalName, "get_guild_by_id", mongoDatabase, "guilds").Inc()
-	t := prometheus.NewTimer(monitoring.MongoLatency.WithLabelValues(guildDalName, "get_guild_by_id", mongoDatabase, "guilds"))
-	defer t.ObserveDuration()
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(guildDalName, "get_guild_by_id", mongoDatabase, "guilds")
+	defer func() {
+		observe(err)
+	}()
 
 	// Get the guild.
 	guild := new(entities.Guild)
 
-	err := collection.FindOne(ctx, bson.M{"id": id}).Decode(guild)
+	err = collection.FindOne(ctx, bson.M{"id": id}).Decode(guild)
 	if err != nil {
 		return nil, fmt.Errorf("error getting guild: %w", err)
 	}
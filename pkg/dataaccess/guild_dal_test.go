@@ -0,0 +1,51 @@
+package dataaccess
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestGuildUpdate_Document(t *testing.T) {
+	tests := []struct {
+		name   string
+		update *GuildUpdate
+		want   bson.M
+	}{
+		{
+			name:   "empty",
+			update: &GuildUpdate{},
+			want:   bson.M{},
+		},
+		{
+			name:   "set",
+			update: &GuildUpdate{Set: map[string]any{"welcome.enabled": true}},
+			want:   bson.M{"$set": map[string]any{"welcome.enabled": true}},
+		},
+		{
+			name:   "unset",
+			update: &GuildUpdate{Unset: []string{"automod.rules.words"}},
+			want:   bson.M{"$unset": bson.M{"automod.rules.words": ""}},
+		},
+		{
+			name: "arrays",
+			update: &GuildUpdate{
+				AddToSet: map[string]any{"raid.lock_channel_ids": "channel"},
+				Push:     map[string]any{"verification.questions": "question"},
+				Pull:     map[string]any{"welcome.auto_role_ids": "role"},
+			},
+			want: bson.M{
+				"$addToSet": map[string]any{"raid.lock_channel_ids": "channel"},
+				"$push":     map[string]any{"verification.questions": "question"},
+				"$pull":     map[string]any{"welcome.auto_role_ids": "role"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.update.document())
+		})
+	}
+}
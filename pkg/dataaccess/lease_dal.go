@@ -0,0 +1,100 @@
+package dataaccess
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess/monitoring"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const leaseDalName = "lease_dal"
+
+var LeaseDB LeaseDal
+
+type LeaseDal interface {
+	// Acquire acquires, or renews, the lease for the holder for the ttl. It returns false if the lease is held by
+	// another holder.
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+
+	// Release releases the lease if it is held by the holder.
+	Release(ctx context.Context, name, holder string) error
+}
+
+type leaseDalImpl struct {
+	// l is the logger.
+	l *slog.Logger
+
+	// client is the database.
+	client *mongo.Client
+}
+
+// NewLeaseDal creates a new lease data access layer.
+func NewLeaseDal() LeaseDal {
+	l := slog.Default().With(slog.String(logging.KeyDal, leaseDalName))
+
+	if MongoDB == nil {
+		l.Warn("MongoDB is nil, this can cause a panic. Proceeding...")
+	}
+
+	return &leaseDalImpl{
+		l:      l,
+		client: MongoDB,
+	}
+}
+
+func (d *leaseDalImpl) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (_ bool, err error) {
+	// Get the lease collection.
+	collection := d.client.Database(mongoDatabase).Collection("leases")
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(leaseDalName, "acquire_lease", mongoDatabase, "leases")
+	defer func() {
+		observe(err)
+	}()
+
+	// The lease is taken if it is already held by the holder, or has expired. Otherwise the upsert conflicts with the
+	// unique index on the name, as the lease is held by another holder.
+	now := time.Now().UTC()
+	filter := bson.M{
+		"name": name,
+		"$or": bson.A{
+			bson.M{"holder": holder},
+			bson.M{"expires_at": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{
+		"holder":     holder,
+		"expires_at": now.Add(ttl),
+	}}
+
+	_, err = collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("error acquiring lease: %w", err)
+	}
+	return true, nil
+}
+
+func (d *leaseDalImpl) Release(ctx context.Context, name, holder string) (err error) {
+	// Get the lease collection.
+	collection := d.client.Database(mongoDatabase).Collection("leases")
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(leaseDalName, "release_lease", mongoDatabase, "leases")
+	defer func() {
+		observe(err)
+	}()
+
+	_, err = collection.DeleteOne(ctx, bson.M{"name": name, "holder": holder})
+	if err != nil {
+		return fmt.Errorf("error releasing lease: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package dataaccess
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess/monitoring"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	memberSnapshotDalName = "member_snapshot_dal"
+
+	// memberSnapshotsCollection is the collection of the member snapshots.
+	memberSnapshotsCollection = "member_snapshots"
+)
+
+var MemberSnapshotDB MemberSnapshotDal
+
+type MemberSnapshotDal interface {
+	// SaveSnapshots saves the snapshots of the members in the guild, replacing their previous snapshots. The snapshots
+	// do not expire.
+	SaveSnapshots(ctx context.Context, snapshots ...*entities.MemberSnapshot) error
+
+	// UpdateSnapshot saves the snapshot of the member in the guild. The snapshot of a member that has left is kept
+	// instead, as it has not been restored yet.
+	UpdateSnapshot(ctx context.Context, snapshot *entities.MemberSnapshot) error
+
+	// TakeSnapshot gets and deletes the snapshot of the member, so it is only restored once.
+	TakeSnapshot(ctx context.Context, guildID, userID string) (*entities.MemberSnapshot, error)
+
+	// ExpireSnapshot sets when the snapshot of the member that left expires.
+	ExpireSnapshot(ctx context.Context, guildID, userID string, at time.Time) error
+
+	// ExpireMissingSnapshots sets when the snapshots of the members that are not in the guild expire, for the members
+	// that left without it being seen. It returns the number of snapshots that were set to expire.
+	ExpireMissingSnapshots(ctx context.Context, guildID string, userIDs []string, at time.Time) (int64, error)
+
+	// RemoveSnapshotRole removes the role from the snapshot of the member, so it is not restored.
+	RemoveSnapshotRole(ctx context.Context, guildID, userID, roleID string) error
+}
+
+type memberSnapshotDalImpl struct {
+	// l is the logger.
+	l *slog.Logger
+
+	// client is the database.
+	client *mongo.Client
+}
+
+// NewMemberSnapshotDal creates a new member snapshot data access layer.
+func NewMemberSnapshotDal() MemberSnapshotDal {
+	l := slog.Default().With(slog.String(logging.KeyDal, memberSnapshotDalName))
+
+	if MongoDB == nil {
+		l.Warn("MongoDB is nil, this can cause a panic. Proceeding...")
+	}
+
+	return &memberSnapshotDalImpl{
+		l:      l,
+		client: MongoDB,
+	}
+}
+
+func (d *memberSnapshotDalImpl) SaveSnapshots(ctx context.Context, snapshots ...*entities.MemberSnapshot) (err error) {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	// Get the member snapshot collection.
+	collection := d.client.Database(mongoDatabase).Collection(memberSnapshotsCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(memberSnapshotDalName, "save_snapshots", mongoDatabase, memberSnapshotsCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// A member only has one snapshot, so a new snapshot replaces the old one.
+	models := make([]mongo.WriteModel, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"guild_id": snapshot.GuildID, "user_id": snapshot.UserID}).
+			SetReplacement(snapshot).
+			SetUpsert(true),
+		)
+	}
+	if _, err = collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		return fmt.Errorf("error replacing member snapshots: %w", err)
+	}
+	return nil
+}
+
+func (d *memberSnapshotDalImpl) UpdateSnapshot(ctx context.Context, snapshot *entities.MemberSnapshot) (err error) {
+	// Get the member snapshot collection.
+	collection := d.client.Database(mongoDatabase).Collection(memberSnapshotsCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(memberSnapshotDalName, "update_snapshot", mongoDatabase, memberSnapshotsCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// Only the snapshot that does not expire is replaced. If the member has left, the filter does not match and the
+	// insert conflicts with the snapshot that is waiting to be restored.
+	opts := options.Replace().SetUpsert(true)
+	filter := bson.M{"guild_id": snapshot.GuildID, "user_id": snapshot.UserID, "expires_at": nil}
+	if _, err = collection.ReplaceOne(ctx, filter, snapshot, opts); mongo.IsDuplicateKeyError(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error replacing member snapshot: %w", err)
+	}
+	return nil
+}
+
+func (d *memberSnapshotDalImpl) TakeSnapshot(ctx context.Context, guildID, userID string) (_ *entities.MemberSnapshot, err error) {
+	// Get the member snapshot collection.
+	collection := d.client.Database(mongoDatabase).Collection(memberSnapshotsCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(memberSnapshotDalName, "take_snapshot", mongoDatabase, memberSnapshotsCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// Get and delete the member snapshot.
+	snapshot := new(entities.MemberSnapshot)
+	if err = collection.FindOneAndDelete(ctx, bson.M{"guild_id": guildID, "user_id": userID}).Decode(snapshot); err != nil {
+		return nil, fmt.Errorf("error taking member snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+func (d *memberSnapshotDalImpl) ExpireSnapshot(ctx context.Context, guildID, userID string, at time.Time) (err error) {
+	// Get the member snapshot collection.
+	collection := d.client.Database(mongoDatabase).Collection(memberSnapshotsCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(memberSnapshotDalName, "expire_snapshot", mongoDatabase, memberSnapshotsCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// Set the expiry of the member snapshot.
+	_, err = collection.UpdateOne(ctx,
+		bson.M{"guild_id": guildID, "user_id": userID},
+		bson.M{"$set": bson.M{"expires_at": at.UTC()}},
+	)
+	if err != nil {
+		return fmt.Errorf("error expiring member snapshot: %w", err)
+	}
+	return nil
+}
+
+func (d *memberSnapshotDalImpl) ExpireMissingSnapshots(ctx context.Context, guildID string, userIDs []string, at time.Time) (_ int64, err error) {
+	// Get the member snapshot collection.
+	collection := d.client.Database(mongoDatabase).Collection(memberSnapshotsCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(memberSnapshotDalName, "expire_missing_snapshots", mongoDatabase, memberSnapshotsCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// The IDs must be an array, even when there are none.
+	if userIDs == nil {
+		userIDs = []string{}
+	}
+
+	// Set the expiry of the snapshots that do not expire, of the members that are not in the guild.
+	res, err := collection.UpdateMany(ctx, bson.M{
+		"guild_id":   guildID,
+		"user_id":    bson.M{"$nin": userIDs},
+		"expires_at": nil,
+	}, bson.M{"$set": bson.M{"expires_at": at.UTC()}})
+	if err != nil {
+		return 0, fmt.Errorf("error expiring member snapshots: %w", err)
+	}
+
+	return res.ModifiedCount, nil
+}
+
+func (d *memberSnapshotDalImpl) RemoveSnapshotRole(ctx context.Context, guildID, userID, roleID string) (err error) {
+	// Get the member snapshot collection.
+	collection := d.client.Database(mongoDatabase).Collection(memberSnapshotsCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(memberSnapshotDalName, "remove_snapshot_role", mongoDatabase, memberSnapshotsCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// Remove the role from the member snapshot.
+	_, err = collection.UpdateOne(ctx,
+		bson.M{"guild_id": guildID, "user_id": userID},
+		bson.M{"$pull": bson.M{"role_ids": roleID}},
+	)
+	if err != nil {
+		return fmt.Errorf("error removing role from member snapshot: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,398 @@
+package dataaccess
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess/monitoring"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	migratorName = "migrator"
+
+	// migrationsCollection is the collection that records the applied migrations.
+	migrationsCollection = "migrations"
+)
+
+// Migration is a versioned change to the schema or the data of the database. Migrations are applied in order of
+// version and each is only applied once. A migration must be safe to run again, as an instance can stop after the
+// migration is applied but before it is recorded.
+type Migration struct {
+	// Version is the version of the schema after the migration is applied. Versions start at 1.
+	Version int
+
+	// Description describes the migration.
+	Description string
+
+	// Up applies the migration.
+	Up func(ctx context.Context, db *mongo.Database) error
+}
+
+// migrationRecord is the record of an applied migration.
+type migrationRecord struct {
+	// Version is the version of the migration.
+	Version int `bson:"version"`
+
+	// Description is the description of the migration.
+	Description string `bson:"description"`
+
+	// AppliedAt is when the migration was applied.
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+// Migrations are the migrations of the database, in order of version.
+var Migrations = []*Migration{
+	{
+		Version:     1,
+		Description: "create guild indexes",
+		Up: ensureIndexes("guilds", mongo.IndexModel{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetName("id_unique").SetUnique(true),
+		}),
+	},
+	{
+		Version:     2,
+		Description: "create ticket indexes",
+		Up: ensureIndexes("tickets",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "guild_id", Value: 1}, {Key: "channel_id", Value: 1}},
+				Options: options.Index().SetName("guild_id_channel_id_unique").SetUnique(true),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "guild_id", Value: 1}, {Key: "channel_id", Value: 1}, {Key: "deleted", Value: 1}},
+				Options: options.Index().SetName("guild_id_channel_id_deleted"),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "guild_id", Value: 1}, {Key: "created_at", Value: -1}},
+				Options: options.Index().SetName("guild_id_created_at"),
+			},
+		),
+	},
+	{
+		Version:     3,
+		Description: "convert ticket created_at strings to dates",
+		Up:          convertDateStrings("tickets", "created_at"),
+	},
+	{
+		Version:     4,
+		Description: "create lease indexes",
+		Up: ensureIndexes("leases", mongo.IndexModel{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetName("name_unique").SetUnique(true),
+		}),
+	},
+	{
+		Version:     5,
+		Description: "create ticket number index",
+		Up: ensureIndexes("tickets", mongo.IndexModel{
+			Keys:    bson.D{{Key: "guild_id", Value: 1}, {Key: "id", Value: 1}},
+			Options: options.Index().SetName("guild_id_id"),
+		}),
+	},
+	{
+		Version:     6,
+		Description: "create case indexes",
+		Up: ensureIndexes("cases",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "guild_id", Value: 1}, {Key: "id", Value: 1}},
+				Options: options.Index().SetName("guild_id_id_unique").SetUnique(true),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "guild_id", Value: 1}, {Key: "target_id", Value: 1}, {Key: "id", Value: -1}},
+				Options: options.Index().SetName("guild_id_target_id_id"),
+			},
+		),
+	},
+	{
+		Version:     7,
+		Description: "create scheduled action indexes",
+		Up: ensureIndexes("scheduled_actions",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "status", Value: 1}, {Key: "run_at", Value: 1}},
+				Options: options.Index().SetName("status_run_at"),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "guild_id", Value: 1}, {Key: "target_id", Value: 1}, {Key: "type", Value: 1}, {Key: "status", Value: 1}},
+				Options: options.Index().SetName("guild_id_target_id_type_status"),
+			},
+		),
+	},
+	{
+		Version:     8,
+		Description: "create raid indexes",
+		Up: ensureIndexes("raids",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "guild_id", Value: 1}},
+				Options: options.Index().SetName("guild_id_unique").SetUnique(true),
+			},
+		),
+	},
+	{
+		Version:     9,
+		Description: "create role panel indexes",
+		Up: ensureIndexes("role_panels",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "guild_id", Value: 1}, {Key: "id", Value: 1}},
+				Options: options.Index().SetName("guild_id_id_unique").SetUnique(true),
+			},
+		),
+	},
+	{
+		Version:     10,
+		Description: "create verification challenge indexes",
+		Up: ensureIndexes("verification_challenges",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "id", Value: 1}},
+				Options: options.Index().SetName("id_unique").SetUnique(true),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "guild_id", Value: 1}, {Key: "user_id", Value: 1}},
+				Options: options.Index().SetName("guild_id_user_id_unique").SetUnique(true),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "expires_at", Value: 1}},
+				Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(0),
+			},
+		),
+	},
+	{
+		Version:     11,
+		Description: "create member snapshot indexes",
+		// The snapshots of the members in the guild have a null expiry, which the TTL index ignores.
+		Up: ensureIndexes("member_snapshots",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "guild_id", Value: 1}, {Key: "user_id", Value: 1}},
+				Options: options.Index().SetName("guild_id_user_id_unique").SetUnique(true),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "expires_at", Value: 1}},
+				Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(0),
+			},
+		),
+	},
+	{
+		Version:     12,
+		Description: "remove null automod rules",
+		// The rules of a guild are updated by their path, which cannot be created under a null.
+		Up: unsetNulls("guilds", "automod.rules"),
+	},
+}
+
+// convertDateStrings returns a migration that rewrites the RFC3339 strings stored in the field as native dates.
+// Documents that already store a date are not touched.
+func convertDateStrings(collection, field string) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(collection).UpdateMany(ctx,
+			bson.M{field: bson.M{"$type": "string"}},
+			mongo.Pipeline{
+				{{Key: "$set", Value: bson.M{
+					field: bson.M{"$dateFromString": bson.M{
+						"dateString": "$" + field,
+						// Empty or invalid strings are stored as null, which is the zero datetime.
+						"onError": nil,
+						"onNull":  nil,
+					}},
+				}}},
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("error converting %s.%s to dates: %w", collection, field, err)
+		}
+		return nil
+	}
+}
+
+// unsetNulls returns a migration that removes the field from the documents that store it as null.
+func unsetNulls(collection, field string) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(collection).UpdateMany(ctx,
+			bson.M{field: bson.M{"$type": "null"}},
+			bson.M{"$unset": bson.M{field: ""}},
+		)
+		if err != nil {
+			return fmt.Errorf("error removing null %s.%s: %w", collection, field, err)
+		}
+		return nil
+	}
+}
+
+// ensureIndexes returns a migration that creates the indexes on the collection. Creating an index that already
+// exists with the same options does nothing.
+func ensureIndexes(collection string, indexes ...mongo.IndexModel) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		if _, err := db.Collection(collection).Indexes().CreateMany(ctx, indexes); err != nil {
+			return fmt.Errorf("error creating indexes on %s: %w", collection, err)
+		}
+		return nil
+	}
+}
+
+// Migrator applies the migrations to the database.
+type Migrator struct {
+	// l is the logger.
+	l *slog.Logger
+
+	// client is the database.
+	client *mongo.Client
+
+	// migrations are the migrations, in order of version.
+	migrations []*Migration
+}
+
+// NewMigrator creates a new Migrator for the migrations.
+func NewMigrator(migrations ...*Migration) (*Migrator, error) {
+	l := slog.Default().With(slog.String(logging.KeyDal, migratorName))
+
+	if MongoDB == nil {
+		l.Warn("MongoDB is nil, this can cause a panic. Proceeding...")
+	}
+
+	if err := validateMigrations(migrations); err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		l:          l,
+		client:     MongoDB,
+		migrations: migrations,
+	}, nil
+}
+
+// validateMigrations ensures that the migrations are in order of version, and that every version is unique.
+func validateMigrations(migrations []*Migration) error {
+	for i, m := range migrations {
+		switch {
+		case m.Version < 1:
+			return fmt.Errorf("migration %q has invalid version %d", m.Description, m.Version)
+		case m.Up == nil:
+			return fmt.Errorf("migration %d has no up function", m.Version)
+		case i > 0 && m.Version <= migrations[i-1].Version:
+			return fmt.Errorf("migration %d is out of order", m.Version)
+		}
+	}
+	return nil
+}
+
+// Version returns the version of the schema, which is the version of the latest applied migration.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(applied) == 0 {
+		return 0, nil
+	}
+	return applied[len(applied)-1], nil
+}
+
+// Migrate applies the migrations that have not been applied yet, and returns the version of the schema.
+func (m *Migrator) Migrate(ctx context.Context) (int, error) {
+	db := m.client.Database(mongoDatabase)
+
+	// Ensure that each version can only be recorded once, so instances starting at the same time do not record a
+	// migration twice.
+	if err := ensureIndexes(migrationsCollection, mongo.IndexModel{
+		Keys:    bson.D{{Key: "version", Value: 1}},
+		Options: options.Index().SetName("version_unique").SetUnique(true),
+	})(ctx, db); err != nil {
+		return 0, err
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, migration := range pendingMigrations(m.migrations, applied) {
+		l := m.l.With(
+			slog.Int("version", migration.Version),
+			slog.String("description", migration.Description),
+		)
+		l.Info("Applying migration")
+
+		if err := m.apply(ctx, db, migration); err != nil {
+			return 0, fmt.Errorf("error applying migration %d: %w", migration.Version, err)
+		}
+		l.Info("Applied migration")
+	}
+
+	return m.Version(ctx)
+}
+
+// apply applies the migration and records it.
+func (m *Migrator) apply(ctx context.Context, db *mongo.Database, migration *Migration) (err error) {
+	// Record the prometheus metrics when the migration completes.
+	observe := monitoring.ObserveQuery(migratorName, fmt.Sprintf("migration_%d", migration.Version), mongoDatabase, migrationsCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	if err = migration.Up(ctx, db); err != nil {
+		return err
+	}
+
+	_, err = db.Collection(migrationsCollection).InsertOne(ctx, &migrationRecord{
+		Version:     migration.Version,
+		Description: migration.Description,
+		AppliedAt:   time.Now().UTC(),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		// Another instance applied the migration at the same time.
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error recording migration: %w", err)
+	}
+	return nil
+}
+
+// applied returns the versions of the applied migrations, in order.
+func (m *Migrator) applied(ctx context.Context) (_ []int, err error) {
+	collection := m.client.Database(mongoDatabase).Collection(migrationsCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(migratorName, "get_applied_migrations", mongoDatabase, migrationsCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting applied migrations: %w", err)
+	}
+
+	records := make([]*migrationRecord, 0)
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("error decoding applied migrations: %w", err)
+	}
+
+	versions := make([]int, 0, len(records))
+	for _, r := range records {
+		versions = append(versions, r.Version)
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// pendingMigrations returns the migrations that have not been applied, in order of version.
+func pendingMigrations(migrations []*Migration, applied []int) []*Migration {
+	done := make(map[int]bool, len(applied))
+	for _, v := range applied {
+		done[v] = true
+	}
+
+	pending := make([]*Migration, 0)
+	for _, m := range migrations {
+		if !done[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
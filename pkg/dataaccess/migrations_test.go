@@ -0,0 +1,112 @@
+package dataaccess
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func noopMigration(version int) *Migration {
+	return &Migration{
+		Version:     version,
+		Description: "noop",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return nil
+		},
+	}
+}
+
+func TestValidateMigrations(t *testing.T) {
+	tests := []struct {
+		name       string
+		migrations []*Migration
+		wantErr    bool
+	}{
+		{
+			name:       "ordered",
+			migrations: []*Migration{noopMigration(1), noopMigration(2), noopMigration(5)},
+		},
+		{
+			name:       "empty",
+			migrations: nil,
+		},
+		{
+			name:       "out of order",
+			migrations: []*Migration{noopMigration(2), noopMigration(1)},
+			wantErr:    true,
+		},
+		{
+			name:       "duplicate",
+			migrations: []*Migration{noopMigration(1), noopMigration(1)},
+			wantErr:    true,
+		},
+		{
+			name:       "invalid version",
+			migrations: []*Migration{noopMigration(0)},
+			wantErr:    true,
+		},
+		{
+			name:       "no up",
+			migrations: []*Migration{{Version: 1}},
+			wantErr:    true,
+		},
+		{
+			name:       "defined migrations",
+			migrations: Migrations,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMigrations(tt.migrations)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestPendingMigrations(t *testing.T) {
+	migrations := []*Migration{noopMigration(1), noopMigration(2), noopMigration(3)}
+
+	tests := []struct {
+		name    string
+		applied []int
+		want    []int
+	}{
+		{
+			name:    "none applied",
+			applied: nil,
+			want:    []int{1, 2, 3},
+		},
+		{
+			name:    "some applied",
+			applied: []int{1},
+			want:    []int{2, 3},
+		},
+		{
+			name:    "gap applied",
+			applied: []int{1, 3},
+			want:    []int{2},
+		},
+		{
+			name:    "all applied",
+			applied: []int{1, 2, 3, 4},
+			want:    []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]int, 0)
+			for _, m := range pendingMigrations(migrations, tt.applied) {
+				got = append(got, m.Version)
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
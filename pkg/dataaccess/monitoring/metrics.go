@@ -15,12 +15,39 @@ var (
 		[]string{"dal", "query", "database", "collection"},
 	)
 
-	// MongoTotalRequests is the total number of Mongo requests.
+	// MongoTotalRequests is the total number of Mongo requests, by the result of the request.
 	MongoTotalRequests = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "dataaccess_mongo_total_requests",
 			Help: "Total number of Mongo requests",
 		},
-		[]string{"dal", "query", "database", "collection"},
+		[]string{"dal", "query", "database", "collection", "result"},
+	)
+
+	// CacheTotalHits is the total number of cache hits.
+	CacheTotalHits = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dataaccess_cache_total_hits",
+			Help: "Total number of cache hits",
+		},
+		[]string{"dal", "query"},
+	)
+
+	// CacheTotalMisses is the total number of cache misses.
+	CacheTotalMisses = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dataaccess_cache_total_misses",
+			Help: "Total number of cache misses",
+		},
+		[]string{"dal", "query"},
+	)
+
+	// CacheTotalInvalidations is the total number of cache invalidations, by their source.
+	CacheTotalInvalidations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dataaccess_cache_total_invalidations",
+			Help: "Total number of cache invalidations",
+		},
+		[]string{"dal", "source"},
 	)
 )
@@ -0,0 +1,52 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// ResultSuccess is the result of a query that succeeded.
+	ResultSuccess = "success"
+
+	// ResultNotFound is the result of a query that found no documents.
+	ResultNotFound = "not_found"
+
+	// ResultTimeout is the result of a query that ran out of time.
+	ResultTimeout = "timeout"
+
+	// ResultCancelled is the result of a query whose context was cancelled.
+	ResultCancelled = "cancelled"
+
+	// ResultError is the result of a query that failed for any other reason.
+	ResultError = "error"
+)
+
+// Result returns the result label for the error returned by a query.
+func Result(err error) string {
+	switch {
+	case err == nil:
+		return ResultSuccess
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return ResultNotFound
+	case errors.Is(err, context.DeadlineExceeded), mongo.IsTimeout(err):
+		return ResultTimeout
+	case errors.Is(err, context.Canceled):
+		return ResultCancelled
+	default:
+		return ResultError
+	}
+}
+
+// ObserveQuery starts timing a query. The returned function records the latency and the result of the query, and is
+// called with the error returned by the query when it completes.
+func ObserveQuery(dal, query, database, collection string) func(err error) {
+	start := time.Now()
+	return func(err error) {
+		MongoLatency.WithLabelValues(dal, query, database, collection).Observe(time.Since(start).Seconds())
+		MongoTotalRequests.WithLabelValues(dal, query, database, collection, Result(err)).Inc()
+	}
+}
@@ -0,0 +1,51 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestResult(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "success",
+			err:  nil,
+			want: ResultSuccess,
+		},
+		{
+			name: "not found",
+			err:  fmt.Errorf("error getting guild: %w", mongo.ErrNoDocuments),
+			want: ResultNotFound,
+		},
+		{
+			name: "timeout",
+			err:  fmt.Errorf("error getting guild: %w", context.DeadlineExceeded),
+			want: ResultTimeout,
+		},
+		{
+			name: "cancelled",
+			err:  fmt.Errorf("error getting guild: %w", context.Canceled),
+			want: ResultCancelled,
+		},
+		{
+			name: "error",
+			err:  errors.New("boom"),
+			want: ResultError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Result(tt.err))
+		})
+	}
+}
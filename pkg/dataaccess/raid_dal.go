@@ -0,0 +1,91 @@
+package dataaccess
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess/monitoring"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	raidDalName = "raid_dal"
+
+	// raidsCollection is the collection of the raid modes of the guilds.
+	raidsCollection = "raids"
+)
+
+var RaidDB RaidDal
+
+type RaidDal interface {
+	// GetRaid gets the latest raid mode of the guild.
+	GetRaid(ctx context.Context, guildID string) (*entities.Raid, error)
+
+	// SaveRaid saves the raid mode of the guild, replacing the previous one.
+	SaveRaid(ctx context.Context, raid *entities.Raid) error
+}
+
+type raidDalImpl struct {
+	// l is the logger.
+	l *slog.Logger
+
+	// client is the database.
+	client *mongo.Client
+}
+
+// NewRaidDal creates a new raid data access layer.
+func NewRaidDal() RaidDal {
+	l := slog.Default().With(slog.String(logging.KeyDal, raidDalName))
+
+	if MongoDB == nil {
+		l.Warn("MongoDB is nil, this can cause a panic. Proceeding...")
+	}
+
+	return &raidDalImpl{
+		l:      l,
+		client: MongoDB,
+	}
+}
+
+func (d *raidDalImpl) GetRaid(ctx context.Context, guildID string) (_ *entities.Raid, err error) {
+	// Get the raid collection.
+	collection := d.client.Database(mongoDatabase).Collection(raidsCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(raidDalName, "get_raid", mongoDatabase, raidsCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// Get the raid.
+	raid := new(entities.Raid)
+	err = collection.FindOne(ctx, bson.M{"guild_id": guildID}).Decode(raid)
+	if err != nil {
+		return nil, fmt.Errorf("error getting raid: %w", err)
+	}
+
+	return raid, nil
+}
+
+func (d *raidDalImpl) SaveRaid(ctx context.Context, raid *entities.Raid) (err error) {
+	// Get the raid collection.
+	collection := d.client.Database(mongoDatabase).Collection(raidsCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(raidDalName, "save_raid", mongoDatabase, raidsCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// Save the raid.
+	opts := options.Replace().SetUpsert(true)
+	if _, err = collection.ReplaceOne(ctx, bson.M{"guild_id": raid.GuildID}, raid, opts); err != nil {
+		return fmt.Errorf("error replacing raid: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package dataaccess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess/monitoring"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	rolePanelDalName = "role_panel_dal"
+
+	// rolePanelsCollection is the collection of the role panels.
+	rolePanelsCollection = "role_panels"
+
+	// createRolePanelAttempts is how many times a panel is numbered before giving up, when other panels are created in
+	// the guild at the same time.
+	createRolePanelAttempts = 5
+)
+
+var RolePanelDB RolePanelDal
+
+type RolePanelDal interface {
+	// CreateRolePanel saves a new panel, numbering it after the latest panel of the guild. The ID of the panel is set.
+	CreateRolePanel(ctx context.Context, panel *entities.RolePanel) error
+
+	// SaveRolePanel saves a panel that has already been created.
+	SaveRolePanel(ctx context.Context, panel *entities.RolePanel) error
+
+	// GetRolePanel gets a panel by its number.
+	GetRolePanel(ctx context.Context, guildID string, id int) (*entities.RolePanel, error)
+
+	// ListRolePanels lists the panels of the guild, oldest first.
+	ListRolePanels(ctx context.Context, guildID string) ([]*entities.RolePanel, error)
+
+	// DeleteRolePanel deletes a panel by its number.
+	DeleteRolePanel(ctx context.Context, guildID string, id int) error
+}
+
+type rolePanelDalImpl struct {
+	// l is the logger.
+	l *slog.Logger
+
+	// client is the database.
+	client *mongo.Client
+}
+
+// NewRolePanelDal creates a new role panel data access layer.
+func NewRolePanelDal() RolePanelDal {
+	l := slog.Default().With(slog.String(logging.KeyDal, rolePanelDalName))
+
+	if MongoDB == nil {
+		l.Warn("MongoDB is nil, this can cause a panic. Proceeding...")
+	}
+
+	return &rolePanelDalImpl{
+		l:      l,
+		client: MongoDB,
+	}
+}
+
+func (d *rolePanelDalImpl) CreateRolePanel(ctx context.Context, panel *entities.RolePanel) (err error) {
+	// Get the role panel collection.
+	collection := d.client.Database(mongoDatabase).Collection(rolePanelsCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(rolePanelDalName, "create_role_panel", mongoDatabase, rolePanelsCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// Set the options to get the latest panel.
+	opts := options.FindOne()
+	opts.SetSort(bson.M{"id": -1})
+	opts.SetProjection(bson.M{"id": 1})
+
+	// The number is unique in the guild, so the insert fails if another panel took the number first.
+	for attempt := 1; ; attempt++ {
+		latest := new(entities.RolePanel)
+		err = collection.FindOne(ctx, bson.M{"guild_id": panel.GuildID}, opts).Decode(latest)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("error getting latest role panel: %w", err)
+		}
+		panel.ID = latest.ID + 1
+
+		_, err = collection.InsertOne(ctx, panel)
+		if err == nil {
+			return nil
+		}
+		if !mongo.IsDuplicateKeyError(err) || attempt == createRolePanelAttempts {
+			return fmt.Errorf("error inserting role panel: %w", err)
+		}
+
+		d.l.Debug("Role panel number taken, retrying", slog.String("guild_id", panel.GuildID), slog.Int("id", panel.ID))
+	}
+}
+
+func (d *rolePanelDalImpl) SaveRolePanel(ctx context.Context, panel *entities.RolePanel) (err error) {
+	// Get the role panel collection.
+	collection := d.client.Database(mongoDatabase).Collection(rolePanelsCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(rolePanelDalName, "save_role_panel", mongoDatabase, rolePanelsCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// Save the role panel.
+	_, err = collection.ReplaceOne(ctx, bson.M{"guild_id": panel.GuildID, "id": panel.ID}, panel)
+	if err != nil {
+		return fmt.Errorf("error replacing role panel: %w", err)
+	}
+	return nil
+}
+
+func (d *rolePanelDalImpl) GetRolePanel(ctx context.Context, guildID string, id int) (_ *entities.RolePanel, err error) {
+	// Get the role panel collection.
+	collection := d.client.Database(mongoDatabase).Collection(rolePanelsCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(rolePanelDalName, "get_role_panel", mongoDatabase, rolePanelsCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// Get the role panel.
+	panel := new(entities.RolePanel)
+	err = collection.FindOne(ctx, bson.M{"guild_id": guildID, "id": id}).Decode(panel)
+	if err != nil {
+		return nil, fmt.Errorf("error getting role panel: %w", err)
+	}
+
+	return panel, nil
+}
+
+func (d *rolePanelDalImpl) ListRolePanels(ctx context.Context, guildID string) (_ []*entities.RolePanel, err error) {
+	// Get the role panel collection.
+	collection := d.client.Database(mongoDatabase).Collection(rolePanelsCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(rolePanelDalName, "list_role_panels", mongoDatabase, rolePanelsCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// Set the options to list the oldest panels first.
+	opts := options.Find()
+	opts.SetSort(bson.M{"id": 1})
+
+	// List the role panels.
+	cursor, err := collection.Find(ctx, bson.M{"guild_id": guildID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error listing role panels: %w", err)
+	}
+
+	panels := make([]*entities.RolePanel, 0)
+	if err = cursor.All(ctx, &panels); err != nil {
+		return nil, fmt.Errorf("error decoding role panels: %w", err)
+	}
+
+	return panels, nil
+}
+
+func (d *rolePanelDalImpl) DeleteRolePanel(ctx context.Context, guildID string, id int) (err error) {
+	// Get the role panel collection.
+	collection := d.client.Database(mongoDatabase).Collection(rolePanelsCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(rolePanelDalName, "delete_role_panel", mongoDatabase, rolePanelsCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// Delete the role panel.
+	if _, err = collection.DeleteOne(ctx, bson.M{"guild_id": guildID, "id": id}); err != nil {
+		return fmt.Errorf("error deleting role panel: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package dataaccess
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess/monitoring"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	scheduledActionDalName = "scheduled_action_dal"
+
+	// scheduledActionsCollection is the collection of the scheduled actions.
+	scheduledActionsCollection = "scheduled_actions"
+)
+
+var ScheduledActionDB ScheduledActionDal
+
+type ScheduledActionDal interface {
+	// ScheduleAction saves a new action. The ID of the action is set.
+	ScheduleAction(ctx context.Context, action *entities.ScheduledAction) error
+
+	// SaveScheduledAction saves an action that has already been scheduled.
+	SaveScheduledAction(ctx context.Context, action *entities.ScheduledAction) error
+
+	// DueScheduledActions lists the pending actions that are due at the time, the earliest first. If the limit is
+	// zero, every due action is listed.
+	DueScheduledActions(ctx context.Context, at time.Time, limit int64) ([]*entities.ScheduledAction, error)
+
+	// CancelScheduledActions cancels the pending actions of the type against the target, other than the excepted
+	// actions, returning how many were cancelled.
+	CancelScheduledActions(ctx context.Context, guildID string, targetID string, actionType entities.ScheduledActionType, except ...primitive.ObjectID) (int64, error)
+}
+
+type scheduledActionDalImpl struct {
+	// l is the logger.
+	l *slog.Logger
+
+	// client is the database.
+	client *mongo.Client
+}
+
+// NewScheduledActionDal creates a new scheduled action data access layer.
+func NewScheduledActionDal() ScheduledActionDal {
+	l := slog.Default().With(slog.String(logging.KeyDal, scheduledActionDalName))
+
+	if MongoDB == nil {
+		l.Warn("MongoDB is nil, this can cause a panic. Proceeding...")
+	}
+
+	return &scheduledActionDalImpl{
+		l:      l,
+		client: MongoDB,
+	}
+}
+
+func (d *scheduledActionDalImpl) ScheduleAction(ctx context.Context, action *entities.ScheduledAction) (err error) {
+	// Get the scheduled action collection.
+	collection := d.client.Database(mongoDatabase).Collection(scheduledActionsCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(scheduledActionDalName, "schedule_action", mongoDatabase, scheduledActionsCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	if action.ID.IsZero() {
+		action.ID = primitive.NewObjectID()
+	}
+
+	// Save the action.
+	if _, err = collection.InsertOne(ctx, action); err != nil {
+		return fmt.Errorf("error inserting scheduled action: %w", err)
+	}
+	return nil
+}
+
+func (d *scheduledActionDalImpl) SaveScheduledAction(ctx context.Context, action *entities.ScheduledAction) (err error) {
+	// Get the scheduled action collection.
+	collection := d.client.Database(mongoDatabase).Collection(scheduledActionsCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(scheduledActionDalName, "save_scheduled_action", mongoDatabase, scheduledActionsCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// Save the action.
+	if _, err = collection.ReplaceOne(ctx, bson.M{"_id": action.ID}, action); err != nil {
+		return fmt.Errorf("error replacing scheduled action: %w", err)
+	}
+	return nil
+}
+
+func (d *scheduledActionDalImpl) DueScheduledActions(ctx context.Context, at time.Time, limit int64) (_ []*entities.ScheduledAction, err error) {
+	// Get the scheduled action collection.
+	collection := d.client.Database(mongoDatabase).Collection(scheduledActionsCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(scheduledActionDalName, "due_scheduled_actions", mongoDatabase, scheduledActionsCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// Set the options to list the earliest actions first.
+	opts := options.Find()
+	opts.SetSort(bson.M{"run_at": 1})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	// List the actions.
+	cursor, err := collection.Find(ctx, bson.M{
+		"status": entities.ScheduledActionPending,
+		"run_at": bson.M{"$lte": at},
+	}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error listing scheduled actions: %w", err)
+	}
+
+	actions := make([]*entities.ScheduledAction, 0)
+	if err = cursor.All(ctx, &actions); err != nil {
+		return nil, fmt.Errorf("error decoding scheduled actions: %w", err)
+	}
+
+	return actions, nil
+}
+
+func (d *scheduledActionDalImpl) CancelScheduledActions(ctx context.Context, guildID string, targetID string, actionType entities.ScheduledActionType, except ...primitive.ObjectID) (_ int64, err error) {
+	// Get the scheduled action collection.
+	collection := d.client.Database(mongoDatabase).Collection(scheduledActionsCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(scheduledActionDalName, "cancel_scheduled_actions", mongoDatabase, scheduledActionsCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	filter := bson.M{
+		"guild_id":  guildID,
+		"target_id": targetID,
+		"type":      actionType,
+		"status":    entities.ScheduledActionPending,
+	}
+	if len(except) > 0 {
+		filter["_id"] = bson.M{"$nin": except}
+	}
+
+	// Cancel the actions.
+	res, err := collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{
+		"status":       entities.ScheduledActionCancelled,
+		"completed_at": time.Now().UTC(),
+	}})
+	if err != nil {
+		return 0, fmt.Errorf("error cancelling scheduled actions: %w", err)
+	}
+
+	return res.ModifiedCount, nil
+}
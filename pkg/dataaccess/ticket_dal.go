@@ -8,7 +8,6 @@ import (
 	"github.com/Jacobbrewer1/wolf/pkg/dataaccess/monitoring"
 	"github.com/Jacobbrewer1/wolf/pkg/entities"
 	"github.com/Jacobbrewer1/wolf/pkg/logging"
-	"github.com/prometheus/client_golang/prometheus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -27,6 +26,65 @@ type TicketDal interface {
 
 	// GetLatestTicket gets the latest ticket.
 	GetLatestTicket(ctx context.Context, guildID string) (*entities.Ticket, error)
+
+	// GetTicketByID gets a ticket by its number, including deleted tickets.
+	GetTicketByID(ctx context.Context, guildID string, id int) (*entities.Ticket, error)
+
+	// ListTickets lists the tickets of the guild that match the filter, newest first.
+	ListTickets(ctx context.Context, guildID string, filter *TicketFilter) ([]*entities.Ticket, error)
+}
+
+// TicketFilter filters the tickets that are listed.
+type TicketFilter struct {
+	// Status is the status of the tickets. If empty, tickets of every status are listed.
+	Status entities.TicketStatus
+
+	// UserID is the ID of the user that created the tickets. If empty, tickets of every user are listed.
+	UserID string
+
+	// ClaimedBy is the ID of the user that claimed the tickets. If empty, tickets are not filtered by who claimed them.
+	ClaimedBy string
+
+	// Limit is the maximum number of tickets listed. If zero, every ticket is listed.
+	Limit int64
+
+	// Offset is the number of tickets skipped.
+	Offset int64
+}
+
+// query returns the query for the tickets of the guild that match the filter.
+func (f *TicketFilter) query(guildID string) bson.M {
+	query := bson.M{"guild_id": guildID}
+
+	if f.UserID != "" {
+		query["user_id"] = f.UserID
+	}
+
+	if f.ClaimedBy != "" {
+		query["claimed_by"] = f.ClaimedBy
+	}
+
+	// The status is derived from the fields in the same way as Ticket.Status.
+	switch f.Status {
+	case entities.TicketStatusOpen:
+		// Open tickets are not claimed, so this replaces any filter on who claimed them.
+		query["deleted"] = false
+		query["closed_by"] = ""
+		query["claimed_by"] = ""
+	case entities.TicketStatusClaimed:
+		query["deleted"] = false
+		query["closed_by"] = ""
+		if f.ClaimedBy == "" {
+			query["claimed_by"] = bson.M{"$ne": ""}
+		}
+	case entities.TicketStatusClosed:
+		query["deleted"] = false
+		query["closed_by"] = bson.M{"$ne": ""}
+	case entities.TicketStatusDeleted:
+		query["deleted"] = true
+	}
+
+	return query
 }
 
 type ticketDalImpl struct {
@@ -39,7 +97,7 @@ type ticketDalImpl struct {
 
 // NewTicketDal creates a new ticket data access layer.
 func NewTicketDal() TicketDal {
-	l := slog.Default().With(slog.String(logging.KeyDal, guildDalName))
+	l := slog.Default().With(slog.String(logging.KeyDal, ticketDalName))
 
 	if MongoDB == nil {
 		l.Warn("MongoDB is nil, this can cause a panic. Proceeding...")
@@ -51,36 +109,38 @@ func NewTicketDal() TicketDal {
 	}
 }
 
-func (d *ticketDalImpl) SaveTicket(ctx context.Context, ticket *entities.Ticket) error {
+func (d *ticketDalImpl) SaveTicket(ctx context.Context, ticket *entities.Ticket) (err error) {
 	// Get the guild collection.
 	collection := d.client.Database(mongoDatabase).Collection("tickets")
 
-	// Start the prometheus metrics.
-	monitoring.MongoTotalRequests.WithLabelValues(ticketDalName, "save_ticket", mongoDatabase, "tickets").Inc()
-	t := prometheus.NewTimer(monitoring.MongoLatency.WithLabelValues(ticketDalName, "save_ticket", mongoDatabase, "tickets"))
-	defer t.ObserveDuration()
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(ticketDalName, "save_ticket", mongoDatabase, "tickets")
+	defer func() {
+		observe(err)
+	}()
 
 	// Save the guild.
 	opts := options.Update().SetUpsert(true)
-	_, err := collection.UpdateOne(ctx, bson.M{"guild_id": ticket.GuildID, "channel_id": ticket.ChannelID}, bson.M{"$set": ticket}, opts)
+	_, err = collection.UpdateOne(ctx, bson.M{"guild_id": ticket.GuildID, "channel_id": ticket.ChannelID}, bson.M{"$set": ticket}, opts)
 	if err != nil {
 		return fmt.Errorf("error updating guild: %w", err)
 	}
 	return nil
 }
 
-func (d *ticketDalImpl) GetTicket(ctx context.Context, guildID string, channelID string) (*entities.Ticket, error) {
+func (d *ticketDalImpl) GetTicket(ctx context.Context, guildID string, channelID string) (_ *entities.Ticket, err error) {
 	// Get the guild collection.
 	collection := d.client.Database(mongoDatabase).Collection("tickets")
 
-	// Start the prometheus metrics.
-	monitoring.MongoTotalRequests.WithLabelValues(ticketDalName, "get_ticket", mongoDatabase, "tickets").Inc()
-	t := prometheus.NewTimer(monitoring.MongoLatency.WithLabelValues(ticketDalName, "get_ticket", mongoDatabase, "tickets"))
-	defer t.ObserveDuration()
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(ticketDalName, "get_ticket", mongoDatabase, "tickets")
+	defer func() {
+		observe(err)
+	}()
 
 	// Get the ticket.
 	var ticket entities.Ticket
-	err := collection.FindOne(ctx, bson.M{
+	err = collection.FindOne(ctx, bson.M{
 		"guild_id":   guildID,
 		"channel_id": channelID,
 		"deleted":    false,
@@ -92,14 +152,15 @@ func (d *ticketDalImpl) GetTicket(ctx context.Context, guildID string, channelID
 	return &ticket, nil
 }
 
-func (d *ticketDalImpl) GetLatestTicket(ctx context.Context, guildID string) (*entities.Ticket, error) {
+func (d *ticketDalImpl) GetLatestTicket(ctx context.Context, guildID string) (_ *entities.Ticket, err error) {
 	// Get the guild collection.
 	collection := d.client.Database(mongoDatabase).Collection("tickets")
 
-	// Start the prometheus metrics.
-	monitoring.MongoTotalRequests.WithLabelValues(ticketDalName, "get_latest_ticket", mongoDatabase, "tickets").Inc()
-	t := prometheus.NewTimer(monitoring.MongoLatency.WithLabelValues(ticketDalName, "get_latest_ticket", mongoDatabase, "tickets"))
-	defer t.ObserveDuration()
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(ticketDalName, "get_latest_ticket", mongoDatabase, "tickets")
+	defer func() {
+		observe(err)
+	}()
 
 	// Set the options to get the latest ticket.
 	opts := options.FindOne()
@@ -107,10 +168,70 @@ func (d *ticketDalImpl) GetLatestTicket(ctx context.Context, guildID string) (*e
 
 	// Get the ticket.
 	var ticket entities.Ticket
-	err := collection.FindOne(ctx, bson.M{"guild_id": guildID}, opts).Decode(&ticket)
+	err = collection.FindOne(ctx, bson.M{"guild_id": guildID}, opts).Decode(&ticket)
 	if err != nil {
 		return nil, fmt.Errorf("error getting ticket: %w", err)
 	}
 
 	return &ticket, nil
 }
+
+func (d *ticketDalImpl) GetTicketByID(ctx context.Context, guildID string, id int) (_ *entities.Ticket, err error) {
+	// Get the ticket collection.
+	collection := d.client.Database(mongoDatabase).Collection("tickets")
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(ticketDalName, "get_ticket_by_id", mongoDatabase, "tickets")
+	defer func() {
+		observe(err)
+	}()
+
+	// Prefer the latest ticket, in case two tickets were given the same number.
+	opts := options.FindOne()
+	opts.SetSort(bson.M{"created_at": -1})
+
+	// Get the ticket.
+	var ticket entities.Ticket
+	err = collection.FindOne(ctx, bson.M{"guild_id": guildID, "id": id}, opts).Decode(&ticket)
+	if err != nil {
+		return nil, fmt.Errorf("error getting ticket: %w", err)
+	}
+
+	return &ticket, nil
+}
+
+func (d *ticketDalImpl) ListTickets(ctx context.Context, guildID string, filter *TicketFilter) (_ []*entities.Ticket, err error) {
+	// Get the ticket collection.
+	collection := d.client.Database(mongoDatabase).Collection("tickets")
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(ticketDalName, "list_tickets", mongoDatabase, "tickets")
+	defer func() {
+		observe(err)
+	}()
+
+	if filter == nil {
+		filter = new(TicketFilter)
+	}
+
+	// Set the options to list the newest tickets first.
+	opts := options.Find()
+	opts.SetSort(bson.M{"created_at": -1})
+	opts.SetSkip(filter.Offset)
+	if filter.Limit > 0 {
+		opts.SetLimit(filter.Limit)
+	}
+
+	// List the tickets.
+	cursor, err := collection.Find(ctx, filter.query(guildID), opts)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tickets: %w", err)
+	}
+
+	tickets := make([]*entities.Ticket, 0)
+	if err = cursor.All(ctx, &tickets); err != nil {
+		return nil, fmt.Errorf("error decoding tickets: %w", err)
+	}
+
+	return tickets, nil
+}
@@ -0,0 +1,59 @@
+package dataaccess
+
+import (
+	"testing"
+
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestTicketFilter_Query(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter *TicketFilter
+		want   bson.M
+	}{
+		{
+			name:   "empty",
+			filter: &TicketFilter{},
+			want:   bson.M{"guild_id": "guild"},
+		},
+		{
+			name:   "user",
+			filter: &TicketFilter{UserID: "user"},
+			want:   bson.M{"guild_id": "guild", "user_id": "user"},
+		},
+		{
+			name:   "open",
+			filter: &TicketFilter{Status: entities.TicketStatusOpen},
+			want:   bson.M{"guild_id": "guild", "deleted": false, "closed_by": "", "claimed_by": ""},
+		},
+		{
+			name:   "claimed",
+			filter: &TicketFilter{Status: entities.TicketStatusClaimed},
+			want:   bson.M{"guild_id": "guild", "deleted": false, "closed_by": "", "claimed_by": bson.M{"$ne": ""}},
+		},
+		{
+			name:   "claimed by user",
+			filter: &TicketFilter{Status: entities.TicketStatusClaimed, ClaimedBy: "staff"},
+			want:   bson.M{"guild_id": "guild", "deleted": false, "closed_by": "", "claimed_by": "staff"},
+		},
+		{
+			name:   "closed",
+			filter: &TicketFilter{Status: entities.TicketStatusClosed},
+			want:   bson.M{"guild_id": "guild", "deleted": false, "closed_by": bson.M{"$ne": ""}},
+		},
+		{
+			name:   "deleted",
+			filter: &TicketFilter{Status: entities.TicketStatusDeleted},
+			want:   bson.M{"guild_id": "guild", "deleted": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.filter.query("guild"))
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package dataaccess
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Jacobbrewer1/wolf/pkg/dataaccess/monitoring"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	verificationDalName = "verification_dal"
+
+	// verificationChallengesCollection is the collection of the verification challenges.
+	verificationChallengesCollection = "verification_challenges"
+)
+
+var VerificationDB VerificationDal
+
+type VerificationDal interface {
+	// SaveChallenge saves the challenge of the member, replacing any challenge the member was given before.
+	SaveChallenge(ctx context.Context, challenge *entities.VerificationChallenge) error
+
+	// GetChallenge gets a challenge by its ID.
+	GetChallenge(ctx context.Context, id string) (*entities.VerificationChallenge, error)
+
+	// DeleteChallenge deletes the challenge of the member, if there is one.
+	DeleteChallenge(ctx context.Context, guildID, userID string) error
+}
+
+type verificationDalImpl struct {
+	// l is the logger.
+	l *slog.Logger
+
+	// client is the database.
+	client *mongo.Client
+}
+
+// NewVerificationDal creates a new verification data access layer.
+func NewVerificationDal() VerificationDal {
+	l := slog.Default().With(slog.String(logging.KeyDal, verificationDalName))
+
+	if MongoDB == nil {
+		l.Warn("MongoDB is nil, this can cause a panic. Proceeding...")
+	}
+
+	return &verificationDalImpl{
+		l:      l,
+		client: MongoDB,
+	}
+}
+
+func (d *verificationDalImpl) SaveChallenge(ctx context.Context, challenge *entities.VerificationChallenge) (err error) {
+	// Get the verification challenge collection.
+	collection := d.client.Database(mongoDatabase).Collection(verificationChallengesCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(verificationDalName, "save_challenge", mongoDatabase, verificationChallengesCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// A member only has one challenge at a time, so a new challenge replaces the old one.
+	opts := options.Replace().SetUpsert(true)
+	filter := bson.M{"guild_id": challenge.GuildID, "user_id": challenge.UserID}
+	if _, err = collection.ReplaceOne(ctx, filter, challenge, opts); err != nil {
+		return fmt.Errorf("error replacing verification challenge: %w", err)
+	}
+	return nil
+}
+
+func (d *verificationDalImpl) GetChallenge(ctx context.Context, id string) (_ *entities.VerificationChallenge, err error) {
+	// Get the verification challenge collection.
+	collection := d.client.Database(mongoDatabase).Collection(verificationChallengesCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(verificationDalName, "get_challenge", mongoDatabase, verificationChallengesCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// Get the verification challenge.
+	challenge := new(entities.VerificationChallenge)
+	if err = collection.FindOne(ctx, bson.M{"id": id}).Decode(challenge); err != nil {
+		return nil, fmt.Errorf("error getting verification challenge: %w", err)
+	}
+
+	return challenge, nil
+}
+
+func (d *verificationDalImpl) DeleteChallenge(ctx context.Context, guildID, userID string) (err error) {
+	// Get the verification challenge collection.
+	collection := d.client.Database(mongoDatabase).Collection(verificationChallengesCollection)
+
+	// Record the prometheus metrics when the query completes.
+	observe := monitoring.ObserveQuery(verificationDalName, "delete_challenge", mongoDatabase, verificationChallengesCollection)
+	defer func() {
+		observe(err)
+	}()
+
+	// Delete the verification challenge.
+	if _, err = collection.DeleteOne(ctx, bson.M{"guild_id": guildID, "user_id": userID}); err != nil {
+		return fmt.Errorf("error deleting verification challenge: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package entities
+
+import "time"
+
+// AutomodRuleType is a type of automod rule.
+type AutomodRuleType string
+
+const (
+	// AutomodRuleRate is a member sending too many messages within the window.
+	AutomodRuleRate AutomodRuleType = "rate"
+
+	// AutomodRuleDuplicate is a member sending the same message too many times within the window.
+	AutomodRuleDuplicate AutomodRuleType = "duplicate"
+
+	// AutomodRuleMentions is a message mentioning too many users and roles.
+	AutomodRuleMentions AutomodRuleType = "mentions"
+
+	// AutomodRuleInvites is a message containing a Discord invite link.
+	AutomodRuleInvites AutomodRuleType = "invites"
+
+	// AutomodRuleDomains is a message linking to a blocked domain, or any of its subdomains.
+	AutomodRuleDomains AutomodRuleType = "domains"
+
+	// AutomodRuleWords is a message containing a blocked word.
+	AutomodRuleWords AutomodRuleType = "words"
+
+	// AutomodRuleRegex is a message matching a blocked regular expression.
+	AutomodRuleRegex AutomodRuleType = "regex"
+)
+
+// AutomodRuleTypes are all the types of automod rule, in the order they are evaluated.
+var AutomodRuleTypes = []AutomodRuleType{
+	AutomodRuleRate,
+	AutomodRuleDuplicate,
+	AutomodRuleMentions,
+	AutomodRuleInvites,
+	AutomodRuleDomains,
+	AutomodRuleWords,
+	AutomodRuleRegex,
+}
+
+// AutomodAction is an action taken when an automod rule is broken.
+type AutomodAction string
+
+const (
+	// AutomodActionDelete deletes the message that broke the rule.
+	AutomodActionDelete AutomodAction = "delete"
+
+	// AutomodActionWarn warns the member that broke the rule.
+	AutomodActionWarn AutomodAction = "warn"
+
+	// AutomodActionTimeout times out the member that broke the rule.
+	AutomodActionTimeout AutomodAction = "timeout"
+
+	// AutomodActionLog only records that the rule was broken.
+	AutomodActionLog AutomodAction = "log"
+)
+
+// AutomodRule is an automod rule of a guild.
+type AutomodRule struct {
+	// Actions are the actions taken when the rule is broken. Every broken rule is recorded as a case, so a rule
+	// without actions only logs.
+	Actions []AutomodAction `json:"actions" bson:"actions"`
+
+	// Threshold is the number of messages, duplicate messages or mentions that breaks the rule.
+	Threshold int `json:"threshold,omitempty" bson:"threshold,omitempty"`
+
+	// Window is how far back the messages are counted towards the threshold.
+	Window time.Duration `json:"window,omitempty" bson:"window,omitempty"`
+
+	// TimeoutDuration is how long the member is timed out for.
+	TimeoutDuration time.Duration `json:"timeout_duration,omitempty" bson:"timeout_duration,omitempty"`
+
+	// Values are the blocked domains, words or regular expressions.
+	Values []string `json:"values,omitempty" bson:"values,omitempty"`
+
+	// ExemptRoleIDs are the IDs of the roles whose members the rule does not apply to.
+	ExemptRoleIDs []string `json:"exempt_role_ids,omitempty" bson:"exempt_role_ids,omitempty"`
+
+	// ExemptChannelIDs are the IDs of the channels the rule does not apply to.
+	ExemptChannelIDs []string `json:"exempt_channel_ids,omitempty" bson:"exempt_channel_ids,omitempty"`
+}
+
+// HasAction returns true if the action is taken when the rule is broken.
+func (r *AutomodRule) HasAction(action AutomodAction) bool {
+	for _, a := range r.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// AutomodConfig is the automod configuration of a guild.
+type AutomodConfig struct {
+	// Rules are the enabled rules, by their type. The rules are omitted when there are none, as the rules are
+	// updated by their path, which cannot be created under a null.
+	Rules map[AutomodRuleType]*AutomodRule `json:"rules" bson:"rules,omitempty"`
+}
+
+// Rule returns the rule of the type, or nil if it is not enabled.
+func (c *AutomodConfig) Rule(t AutomodRuleType) *AutomodRule {
+	return c.Rules[t]
+}
+
+// SetRule enables the rule of the type. If the rule is nil, the type is disabled.
+func (c *AutomodConfig) SetRule(t AutomodRuleType, rule *AutomodRule) {
+	if rule == nil {
+		delete(c.Rules, t)
+		return
+	}
+
+	if c.Rules == nil {
+		c.Rules = make(map[AutomodRuleType]*AutomodRule)
+	}
+	c.Rules[t] = rule
+}
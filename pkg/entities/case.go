@@ -0,0 +1,85 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/Jacobbrewer1/wolf/pkg/custom"
+)
+
+// CaseType is the moderation action that a case records.
+type CaseType string
+
+const (
+	// CaseTypeWarn is a warning.
+	CaseTypeWarn CaseType = "warn"
+
+	// CaseTypeTimeout is a timeout, which stops the member from talking for the duration.
+	CaseTypeTimeout CaseType = "timeout"
+
+	// CaseTypeKick is a kick.
+	CaseTypeKick CaseType = "kick"
+
+	// CaseTypeBan is a ban.
+	CaseTypeBan CaseType = "ban"
+
+	// CaseTypeUnban is the removal of a ban.
+	CaseTypeUnban CaseType = "unban"
+
+	// CaseTypeSoftban is a ban that is removed straight away, to kick the member and delete their recent messages.
+	CaseTypeSoftban CaseType = "softban"
+
+	// CaseTypeMute is the mute role being given to a member.
+	CaseTypeMute CaseType = "mute"
+
+	// CaseTypeUnmute is the removal of the mute role.
+	CaseTypeUnmute CaseType = "unmute"
+
+	// CaseTypeAutomod is an automod rule being broken, when the rule neither warns nor times out the member.
+	CaseTypeAutomod CaseType = "automod"
+)
+
+// Case is a moderation action taken against a user.
+type Case struct {
+	// ID is the number of the case. Cases are numbered from 1 in each guild.
+	ID int `json:"id" bson:"id"`
+
+	// GuildID is the ID of the guild that the case is in.
+	GuildID string `json:"guild_id" bson:"guild_id"`
+
+	// Type is the action that was taken.
+	Type CaseType `json:"type" bson:"type"`
+
+	// ModeratorID is the ID of the user that took the action.
+	ModeratorID string `json:"moderator_id" bson:"moderator_id"`
+
+	// TargetID is the ID of the user that the action was taken against.
+	TargetID string `json:"target_id" bson:"target_id"`
+
+	// Reason is the reason given for the action.
+	Reason string `json:"reason" bson:"reason"`
+
+	// Duration is how long the action lasts. It is zero for actions that do not expire.
+	Duration time.Duration `json:"duration" bson:"duration"`
+
+	// ExpiresAt is when the action is reversed automatically. It is zero for actions that do not expire.
+	ExpiresAt custom.Datetime `json:"expires_at" bson:"expires_at"`
+
+	// ApplyError is why the action could not be applied, if it could not be. The case is kept, as its number has been
+	// given to the moderator, but the action was never taken.
+	ApplyError string `json:"apply_error,omitempty" bson:"apply_error,omitempty"`
+
+	// ReversedAt is when the action was reversed automatically after it expired. It is zero until then.
+	ReversedAt custom.Datetime `json:"reversed_at" bson:"reversed_at"`
+
+	// ReversalError is why the action could not be reversed automatically, if it could not be.
+	ReversalError string `json:"reversal_error,omitempty" bson:"reversal_error,omitempty"`
+
+	// LogChannelID is the ID of the mod-log channel that the case was posted to.
+	LogChannelID string `json:"log_channel_id" bson:"log_channel_id"`
+
+	// LogMessageID is the ID of the message that the case was posted as in the mod-log channel.
+	LogMessageID string `json:"log_message_id" bson:"log_message_id"`
+
+	// CreatedAt is the time that the case was created.
+	CreatedAt custom.Datetime `json:"created_at" bson:"created_at"`
+}
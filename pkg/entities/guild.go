@@ -7,4 +7,31 @@ type Guild struct {
 
 	// Ticketing is the ticketing configuration.
 	Ticketing TicketingConfig `json:"ticketing" bson:"ticketing"`
+
+	// Moderation is the moderation configuration.
+	Moderation ModerationConfig `json:"moderation" bson:"moderation"`
+
+	// Logging is the server event logging configuration.
+	Logging LoggingConfig `json:"logging" bson:"logging"`
+
+	// Automod is the automod configuration.
+	Automod AutomodConfig `json:"automod" bson:"automod"`
+
+	// Raid is the anti-raid configuration.
+	Raid RaidConfig `json:"raid" bson:"raid"`
+
+	// ReactionRoles is the reaction role configuration.
+	ReactionRoles ReactionRoleConfig `json:"reaction_roles" bson:"reaction_roles"`
+
+	// Welcome is the configuration for the members that join.
+	Welcome WelcomeConfig `json:"welcome" bson:"welcome"`
+
+	// Goodbye is the configuration for the members that leave.
+	Goodbye GoodbyeConfig `json:"goodbye" bson:"goodbye"`
+
+	// Verification is the member verification configuration.
+	Verification VerificationConfig `json:"verification" bson:"verification"`
+
+	// RolePersistence is the configuration for restoring the roles of the members that rejoin.
+	RolePersistence RolePersistenceConfig `json:"role_persistence" bson:"role_persistence"`
 }
@@ -0,0 +1,83 @@
+package entities
+
+// LogType is a type of server event that can be logged.
+type LogType string
+
+const (
+	// LogTypeMessageEdit is a message being edited.
+	LogTypeMessageEdit LogType = "message_edit"
+
+	// LogTypeMessageDelete is a message being deleted.
+	LogTypeMessageDelete LogType = "message_delete"
+
+	// LogTypeMessageBulkDelete is many messages being deleted at once.
+	LogTypeMessageBulkDelete LogType = "message_bulk_delete"
+
+	// LogTypeMemberJoin is a member joining the guild.
+	LogTypeMemberJoin LogType = "member_join"
+
+	// LogTypeMemberLeave is a member leaving the guild, including being kicked.
+	LogTypeMemberLeave LogType = "member_leave"
+
+	// LogTypeMemberBan is a user being banned from the guild, or their ban being removed.
+	LogTypeMemberBan LogType = "member_ban"
+
+	// LogTypeMemberNickname is a member changing their nickname.
+	LogTypeMemberNickname LogType = "member_nickname"
+
+	// LogTypeMemberRoles is a member being given or losing roles.
+	LogTypeMemberRoles LogType = "member_roles"
+
+	// LogTypeChannelCreate is a channel being created.
+	LogTypeChannelCreate LogType = "channel_create"
+
+	// LogTypeChannelDelete is a channel being deleted.
+	LogTypeChannelDelete LogType = "channel_delete"
+
+	// LogTypeInviteCreate is an invite being created.
+	LogTypeInviteCreate LogType = "invite_create"
+
+	// LogTypeVerification is a member passing or failing verification, or being kicked for not being verified.
+	LogTypeVerification LogType = "verification"
+)
+
+// LogTypes are all the types of server event that can be logged.
+var LogTypes = []LogType{
+	LogTypeMessageEdit,
+	LogTypeMessageDelete,
+	LogTypeMessageBulkDelete,
+	LogTypeMemberJoin,
+	LogTypeMemberLeave,
+	LogTypeMemberBan,
+	LogTypeMemberNickname,
+	LogTypeMemberRoles,
+	LogTypeChannelCreate,
+	LogTypeChannelDelete,
+	LogTypeInviteCreate,
+	LogTypeVerification,
+}
+
+// LoggingConfig is the server event logging configuration of a guild.
+type LoggingConfig struct {
+	// Channels are the IDs of the channels that each type of event is logged to. The types without a channel are not
+	// logged.
+	Channels map[LogType]string `json:"channels" bson:"channels"`
+}
+
+// Channel returns the ID of the channel that the type of event is logged to, or an empty string if it is not logged.
+func (c *LoggingConfig) Channel(t LogType) string {
+	return c.Channels[t]
+}
+
+// SetChannel sets the channel that the type of event is logged to. If the channel is empty, the type is not logged.
+func (c *LoggingConfig) SetChannel(t LogType, channelID string) {
+	if channelID == "" {
+		delete(c.Channels, t)
+		return
+	}
+
+	if c.Channels == nil {
+		c.Channels = make(map[LogType]string)
+	}
+	c.Channels[t] = channelID
+}
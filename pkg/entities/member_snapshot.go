@@ -0,0 +1,27 @@
+package entities
+
+import "github.com/Jacobbrewer1/wolf/pkg/custom"
+
+// MemberSnapshot is the roles and nickname of a member, which are restored if the member leaves and rejoins. The
+// snapshot is kept up to date while the member is in the guild, as the roles of a member are not known when they
+// leave.
+type MemberSnapshot struct {
+	// GuildID is the ID of the guild.
+	GuildID string `json:"guild_id" bson:"guild_id"`
+
+	// UserID is the ID of the member.
+	UserID string `json:"user_id" bson:"user_id"`
+
+	// RoleIDs are the IDs of the roles of the member.
+	RoleIDs []string `json:"role_ids" bson:"role_ids"`
+
+	// Nickname is the nickname of the member, if they have one.
+	Nickname string `json:"nickname,omitempty" bson:"nickname,omitempty"`
+
+	// UpdatedAt is when the snapshot was last taken.
+	UpdatedAt custom.Datetime `json:"updated_at" bson:"updated_at"`
+
+	// ExpiresAt is when the snapshot of a member that has left is removed by the database. It is zero while the
+	// member is in the guild, so the snapshot is kept.
+	ExpiresAt custom.Datetime `json:"expires_at" bson:"expires_at"`
+}
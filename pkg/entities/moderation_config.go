@@ -0,0 +1,13 @@
+package entities
+
+// ModerationConfig is the moderation configuration of a guild.
+type ModerationConfig struct {
+	// LogChannelID is the ID of the channel that moderation cases are posted to. If empty, cases are not posted.
+	LogChannelID string `json:"log_channel_id" bson:"log_channel_id"`
+
+	// DMTargets is whether the target of a moderation action is sent a direct message about it.
+	DMTargets bool `json:"dm_targets" bson:"dm_targets"`
+
+	// MuteRoleID is the ID of the role that is given to muted members.
+	MuteRoleID string `json:"mute_role_id" bson:"mute_role_id"`
+}
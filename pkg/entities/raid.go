@@ -0,0 +1,51 @@
+package entities
+
+import "github.com/Jacobbrewer1/wolf/pkg/custom"
+
+// LockedChannel is a channel locked for raid mode, with the permissions it had before it was locked.
+type LockedChannel struct {
+	// ChannelID is the ID of the channel.
+	ChannelID string `json:"channel_id" bson:"channel_id"`
+
+	// HadOverwrite is whether the channel had a permission overwrite for the @everyone role before it was locked.
+	HadOverwrite bool `json:"had_overwrite" bson:"had_overwrite"`
+
+	// Allow are the permissions allowed by the overwrite before the channel was locked.
+	Allow int64 `json:"allow" bson:"allow"`
+
+	// Deny are the permissions denied by the overwrite before the channel was locked.
+	Deny int64 `json:"deny" bson:"deny"`
+}
+
+// Raid is the raid mode of a guild. It is stored, so raid mode is kept and left even if the bot was restarted.
+type Raid struct {
+	// GuildID is the ID of the guild.
+	GuildID string `json:"guild_id" bson:"guild_id"`
+
+	// Active is whether the guild is in raid mode.
+	Active bool `json:"active" bson:"active"`
+
+	// Reason is why raid mode was entered.
+	Reason string `json:"reason" bson:"reason"`
+
+	// StartedBy is the ID of the user that started raid mode. It is empty if it was started automatically.
+	StartedBy string `json:"started_by,omitempty" bson:"started_by,omitempty"`
+
+	// StartedAt is when raid mode was entered.
+	StartedAt custom.Datetime `json:"started_at" bson:"started_at"`
+
+	// EndsAt is when raid mode is left automatically.
+	EndsAt custom.Datetime `json:"ends_at" bson:"ends_at"`
+
+	// EndedAt is when raid mode was left. It is zero while the guild is in raid mode.
+	EndedAt custom.Datetime `json:"ended_at" bson:"ended_at"`
+
+	// VerificationRaised is whether the verification level of the guild was raised for raid mode.
+	VerificationRaised bool `json:"verification_raised" bson:"verification_raised"`
+
+	// PreviousVerificationLevel is the verification level of the guild before it was raised.
+	PreviousVerificationLevel int `json:"previous_verification_level" bson:"previous_verification_level"`
+
+	// LockedChannels are the channels locked for raid mode.
+	LockedChannels []*LockedChannel `json:"locked_channels,omitempty" bson:"locked_channels,omitempty"`
+}
@@ -0,0 +1,48 @@
+package entities
+
+import "time"
+
+// RaidAction is the action taken against the members that join a guild in raid mode.
+type RaidAction string
+
+const (
+	// RaidActionNone takes no action against the members that join.
+	RaidActionNone RaidAction = "none"
+
+	// RaidActionKick kicks the members that join.
+	RaidActionKick RaidAction = "kick"
+
+	// RaidActionQuarantine gives the members that join the quarantine role.
+	RaidActionQuarantine RaidAction = "quarantine"
+)
+
+// RaidConfig is the anti-raid configuration of a guild.
+type RaidConfig struct {
+	// Enabled is whether join floods are detected, and raid mode can be entered.
+	Enabled bool `json:"enabled" bson:"enabled"`
+
+	// JoinThreshold is the number of suspicious members joining within the window that starts raid mode.
+	JoinThreshold int `json:"join_threshold" bson:"join_threshold"`
+
+	// JoinWindow is how far back the joins are counted towards the threshold.
+	JoinWindow time.Duration `json:"join_window" bson:"join_window"`
+
+	// MaxAccountAge is the age under which the account of a member that joins is suspicious. If zero, every member
+	// that joins is suspicious.
+	MaxAccountAge time.Duration `json:"max_account_age" bson:"max_account_age"`
+
+	// Action is the action taken against the members that join in raid mode.
+	Action RaidAction `json:"action" bson:"action"`
+
+	// QuarantineRoleID is the ID of the role given to the members that join in raid mode, when they are quarantined.
+	QuarantineRoleID string `json:"quarantine_role_id,omitempty" bson:"quarantine_role_id,omitempty"`
+
+	// VerificationLevel is the verification level that the guild is raised to in raid mode.
+	VerificationLevel int `json:"verification_level" bson:"verification_level"`
+
+	// LockChannelIDs are the IDs of the channels that members cannot send messages in during raid mode.
+	LockChannelIDs []string `json:"lock_channel_ids,omitempty" bson:"lock_channel_ids,omitempty"`
+
+	// CoolDown is how long raid mode lasts before it is left automatically.
+	CoolDown time.Duration `json:"cool_down" bson:"cool_down"`
+}
@@ -0,0 +1,71 @@
+package entities
+
+// ReactionRoleMode is how the reactions to a reaction role message change the roles of the members.
+type ReactionRoleMode string
+
+const (
+	// ReactionRoleModeNormal gives the role when the member reacts, and takes it when the reaction is removed.
+	ReactionRoleModeNormal ReactionRoleMode = "normal"
+
+	// ReactionRoleModeUnique is the normal mode, except a member can only have one of the roles of the message. Reacting
+	// takes the other roles of the message and removes their reactions.
+	ReactionRoleModeUnique ReactionRoleMode = "unique"
+
+	// ReactionRoleModeVerify only gives the role when the member reacts. Removing the reaction does not take it.
+	ReactionRoleModeVerify ReactionRoleMode = "verify"
+
+	// ReactionRoleModeDrop only takes the role when the member reacts. Removing the reaction does not give it back.
+	ReactionRoleModeDrop ReactionRoleMode = "drop"
+)
+
+// ReactionRole is a role that is given or taken by reacting to a message with the emoji.
+type ReactionRole struct {
+	// Emoji is the emoji of the reaction, in the form used by the API. This is the name and ID of a custom emoji, or
+	// the unicode of a standard emoji.
+	Emoji string `json:"emoji" bson:"emoji"`
+
+	// RoleID is the ID of the role.
+	RoleID string `json:"role_id" bson:"role_id"`
+}
+
+// ReactionRoleMessage is a message whose reactions change the roles of the members.
+type ReactionRoleMessage struct {
+	// ChannelID is the ID of the channel of the message.
+	ChannelID string `json:"channel_id" bson:"channel_id"`
+
+	// MessageID is the ID of the message.
+	MessageID string `json:"message_id" bson:"message_id"`
+
+	// Mode is how the reactions change the roles of the members.
+	Mode ReactionRoleMode `json:"mode" bson:"mode"`
+
+	// Roles are the roles of the message, by their emoji.
+	Roles []*ReactionRole `json:"roles" bson:"roles"`
+}
+
+// ReactionRoleConfig is the reaction role configuration of a guild.
+type ReactionRoleConfig struct {
+	// Messages are the messages whose reactions change the roles of the members.
+	Messages []*ReactionRoleMessage `json:"messages,omitempty" bson:"messages,omitempty"`
+}
+
+// Message returns the reaction role message with the ID, or nil if the message has no reaction roles.
+func (c *ReactionRoleConfig) Message(messageID string) *ReactionRoleMessage {
+	for _, m := range c.Messages {
+		if m.MessageID == messageID {
+			return m
+		}
+	}
+	return nil
+}
+
+// RemoveMessage removes the reaction roles of the message. It returns true if the message had reaction roles.
+func (c *ReactionRoleConfig) RemoveMessage(messageID string) bool {
+	for i, m := range c.Messages {
+		if m.MessageID == messageID {
+			c.Messages = append(c.Messages[:i], c.Messages[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package entities
+
+import "github.com/Jacobbrewer1/wolf/pkg/custom"
+
+// RolePanelStyle is how the roles of a role panel are chosen.
+type RolePanelStyle string
+
+const (
+	// RolePanelStyleButtons is a button for each role, which toggles the role.
+	RolePanelStyleButtons RolePanelStyle = "buttons"
+
+	// RolePanelStyleSelect is a select menu of the roles, where the chosen roles are the roles the member has.
+	RolePanelStyleSelect RolePanelStyle = "select"
+)
+
+// RolePanelRole is a role that can be chosen from a role panel.
+type RolePanelRole struct {
+	// RoleID is the ID of the role.
+	RoleID string `json:"role_id" bson:"role_id"`
+
+	// Label is the label of the button or the option, which is the name of the role when the panel was created.
+	Label string `json:"label" bson:"label"`
+}
+
+// RolePanel is a message that members choose their own roles from. It is stored, so the panel keeps working after the
+// bot is restarted.
+type RolePanel struct {
+	// GuildID is the ID of the guild.
+	GuildID string `json:"guild_id" bson:"guild_id"`
+
+	// ID is the number of the panel, which is unique in the guild.
+	ID int `json:"id" bson:"id"`
+
+	// ChannelID is the ID of the channel the panel is posted in.
+	ChannelID string `json:"channel_id" bson:"channel_id"`
+
+	// MessageID is the ID of the message of the panel.
+	MessageID string `json:"message_id" bson:"message_id"`
+
+	// Title is the title of the panel.
+	Title string `json:"title" bson:"title"`
+
+	// Description is the description of the panel.
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
+
+	// Style is how the roles of the panel are chosen.
+	Style RolePanelStyle `json:"style" bson:"style"`
+
+	// Roles are the roles that can be chosen from the panel.
+	Roles []*RolePanelRole `json:"roles" bson:"roles"`
+
+	// SingleChoice is whether a member can only have one of the roles, so choosing a role removes the others.
+	SingleChoice bool `json:"single_choice" bson:"single_choice"`
+
+	// MaxSelections is the most roles of the panel a member can have. If zero, a member can have every role.
+	MaxSelections int `json:"max_selections,omitempty" bson:"max_selections,omitempty"`
+
+	// RequiredRoleIDs are the roles a member must have to choose roles from the panel.
+	RequiredRoleIDs []string `json:"required_role_ids,omitempty" bson:"required_role_ids,omitempty"`
+
+	// Quiet is whether the member is not sent a confirmation of the roles that were changed.
+	Quiet bool `json:"quiet" bson:"quiet"`
+
+	// CreatedBy is the ID of the user that created the panel.
+	CreatedBy string `json:"created_by" bson:"created_by"`
+
+	// CreatedAt is when the panel was created.
+	CreatedAt custom.Datetime `json:"created_at" bson:"created_at"`
+}
+
+// HasRole returns true if the role can be chosen from the panel.
+func (p *RolePanel) HasRole(roleID string) bool {
+	for _, role := range p.Roles {
+		if role.RoleID == roleID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,22 @@
+package entities
+
+import "time"
+
+// RolePersistenceConfig is the configuration for restoring the roles and nickname of the members that rejoin.
+type RolePersistenceConfig struct {
+	// Enabled is whether the roles of the members that rejoin are restored.
+	Enabled bool `json:"enabled" bson:"enabled"`
+
+	// Nickname is whether the nickname of the members that rejoin is restored.
+	Nickname bool `json:"nickname" bson:"nickname"`
+
+	// AllowedRoleIDs are the IDs of the only roles that are restored. If empty, every role that is not denied is
+	// restored.
+	AllowedRoleIDs []string `json:"allowed_role_ids,omitempty" bson:"allowed_role_ids,omitempty"`
+
+	// DeniedRoleIDs are the IDs of the roles that are never restored, even if they are allowed.
+	DeniedRoleIDs []string `json:"denied_role_ids,omitempty" bson:"denied_role_ids,omitempty"`
+
+	// Retention is how long the roles of the members that leave are kept for.
+	Retention time.Duration `json:"retention" bson:"retention"`
+}
@@ -0,0 +1,87 @@
+package entities
+
+import (
+	"github.com/Jacobbrewer1/wolf/pkg/custom"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ScheduledActionType is the type of a scheduled action.
+type ScheduledActionType string
+
+const (
+	// ScheduledActionUnban removes the ban of the target, when a temporary ban expires.
+	ScheduledActionUnban ScheduledActionType = "unban"
+
+	// ScheduledActionUnmute removes the mute role from the target, when a temporary mute expires.
+	ScheduledActionUnmute ScheduledActionType = "unmute"
+
+	// ScheduledActionDeleteChannel deletes the target channel, such as the channel of a deleted ticket.
+	ScheduledActionDeleteChannel ScheduledActionType = "delete_channel"
+
+	// ScheduledActionEndRaid leaves the raid mode of the target guild, when its cool-down has passed.
+	ScheduledActionEndRaid ScheduledActionType = "end_raid"
+
+	// ScheduledActionAddRole gives the role to the target, such as an auto role that is given after a delay.
+	ScheduledActionAddRole ScheduledActionType = "add_role"
+
+	// ScheduledActionKickUnverified kicks the target if it has not been verified, when its time to be verified has
+	// passed.
+	ScheduledActionKickUnverified ScheduledActionType = "kick_unverified"
+)
+
+// ScheduledActionStatus is the status of a scheduled action.
+type ScheduledActionStatus string
+
+const (
+	// ScheduledActionPending is an action that has not run yet, or failed and will be retried.
+	ScheduledActionPending ScheduledActionStatus = "pending"
+
+	// ScheduledActionDone is an action that has run.
+	ScheduledActionDone ScheduledActionStatus = "done"
+
+	// ScheduledActionFailed is an action that failed and will not be retried.
+	ScheduledActionFailed ScheduledActionStatus = "failed"
+
+	// ScheduledActionCancelled is an action that was cancelled before it ran.
+	ScheduledActionCancelled ScheduledActionStatus = "cancelled"
+)
+
+// ScheduledAction is an action that is run at a later time. The actions are stored, so they are run even if the bot
+// was restarted, or was down when they were due.
+type ScheduledAction struct {
+	// ID is the ID of the action.
+	ID primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+
+	// Type is the type of the action.
+	Type ScheduledActionType `json:"type" bson:"type"`
+
+	// GuildID is the ID of the guild that the action is in.
+	GuildID string `json:"guild_id" bson:"guild_id"`
+
+	// TargetID is the ID of the user or the channel that the action is run against.
+	TargetID string `json:"target_id" bson:"target_id"`
+
+	// RoleID is the ID of the role that the action gives or removes, for actions that change a role.
+	RoleID string `json:"role_id,omitempty" bson:"role_id,omitempty"`
+
+	// CaseID is the number of the case that the action reverses, if it reverses a moderation action.
+	CaseID int `json:"case_id,omitempty" bson:"case_id,omitempty"`
+
+	// RunAt is when the action is due. It is moved back when the action fails and is retried.
+	RunAt custom.Datetime `json:"run_at" bson:"run_at"`
+
+	// Status is the status of the action.
+	Status ScheduledActionStatus `json:"status" bson:"status"`
+
+	// Attempts is the number of times the action has been run.
+	Attempts int `json:"attempts" bson:"attempts"`
+
+	// LastError is the error of the latest attempt that failed.
+	LastError string `json:"last_error,omitempty" bson:"last_error,omitempty"`
+
+	// CreatedAt is when the action was scheduled.
+	CreatedAt custom.Datetime `json:"created_at" bson:"created_at"`
+
+	// CompletedAt is when the action ran, failed for the last time or was cancelled.
+	CompletedAt custom.Datetime `json:"completed_at" bson:"completed_at"`
+}
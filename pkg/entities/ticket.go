@@ -39,8 +39,42 @@ type Ticket struct {
 
 	// CreatedAt is the time that the ticket was created.
 	CreatedAt custom.Datetime `json:"created_at" bson:"created_at"`
+
+	// History are the changes made to the ticket, oldest first.
+	History []*TicketEvent `json:"history" bson:"history"`
 }
 
+// TicketStatus is the status of a ticket.
+type TicketStatus string
+
+const (
+	// TicketStatusOpen is a ticket that is waiting to be claimed.
+	TicketStatusOpen TicketStatus = "open"
+
+	// TicketStatusClaimed is a ticket that has been claimed and is not closed.
+	TicketStatusClaimed TicketStatus = "claimed"
+
+	// TicketStatusClosed is a ticket that has been closed.
+	TicketStatusClosed TicketStatus = "closed"
+
+	// TicketStatusDeleted is a ticket that has been deleted.
+	TicketStatusDeleted TicketStatus = "deleted"
+)
+
 func (t *Ticket) Name() string {
 	return fmt.Sprintf("%d-%s", t.ID, t.Username)
 }
+
+// Status returns the status of the ticket.
+func (t *Ticket) Status() TicketStatus {
+	switch {
+	case t.Deleted:
+		return TicketStatusDeleted
+	case t.ClosedBy != "":
+		return TicketStatusClosed
+	case t.ClaimedBy != "":
+		return TicketStatusClaimed
+	default:
+		return TicketStatusOpen
+	}
+}
@@ -0,0 +1,63 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/Jacobbrewer1/wolf/pkg/custom"
+)
+
+// TicketEventType is the type of change made to a ticket.
+type TicketEventType string
+
+const (
+	// TicketEventCreated is when the ticket is created.
+	TicketEventCreated TicketEventType = "created"
+
+	// TicketEventClaimed is when the ticket is claimed.
+	TicketEventClaimed TicketEventType = "claimed"
+
+	// TicketEventClosed is when the ticket is closed.
+	TicketEventClosed TicketEventType = "closed"
+
+	// TicketEventReopened is when the ticket is reopened.
+	TicketEventReopened TicketEventType = "reopened"
+
+	// TicketEventDeleted is when the ticket is deleted.
+	TicketEventDeleted TicketEventType = "deleted"
+)
+
+// TicketEventSource is where a change to a ticket was made from.
+type TicketEventSource string
+
+const (
+	// TicketEventSourceDiscord is a change made with a command or a button in Discord.
+	TicketEventSourceDiscord TicketEventSource = "discord"
+
+	// TicketEventSourceAPI is a change made with the admin API.
+	TicketEventSourceAPI TicketEventSource = "api"
+)
+
+// TicketEvent is a change made to a ticket.
+type TicketEvent struct {
+	// Type is the type of change.
+	Type TicketEventType `json:"type" bson:"type"`
+
+	// UserID is the ID of the user that made the change. This can be empty for changes made with the admin API.
+	UserID string `json:"user_id" bson:"user_id"`
+
+	// Source is where the change was made from.
+	Source TicketEventSource `json:"source" bson:"source"`
+
+	// At is the time that the change was made.
+	At custom.Datetime `json:"at" bson:"at"`
+}
+
+// AddEvent records a change made to the ticket in its history.
+func (t *Ticket) AddEvent(eventType TicketEventType, userID string, source TicketEventSource) {
+	t.History = append(t.History, &TicketEvent{
+		Type:   eventType,
+		UserID: userID,
+		Source: source,
+		At:     custom.Datetime(time.Now().UTC()),
+	})
+}
@@ -0,0 +1,34 @@
+package entities
+
+import "github.com/Jacobbrewer1/wolf/pkg/custom"
+
+// VerificationChallenge is a captcha that a member has been asked to solve. It is stored, so the answer can be
+// checked when the member submits it and the image can be drawn again.
+type VerificationChallenge struct {
+	// ID is the random ID of the challenge. It cannot be guessed, so it can be used in links to the image.
+	ID string `json:"id" bson:"id"`
+
+	// GuildID is the ID of the guild.
+	GuildID string `json:"guild_id" bson:"guild_id"`
+
+	// UserID is the ID of the member the challenge was given to.
+	UserID string `json:"user_id" bson:"user_id"`
+
+	// Text is the text drawn in the image.
+	Text string `json:"text" bson:"text"`
+
+	// Answer is the answer that passes the challenge.
+	Answer string `json:"answer" bson:"answer"`
+
+	// Seed is the seed of the noise of the image.
+	Seed int64 `json:"seed" bson:"seed"`
+
+	// Attempts is the number of wrong answers that have been submitted.
+	Attempts int `json:"attempts" bson:"attempts"`
+
+	// CreatedAt is when the challenge was given.
+	CreatedAt custom.Datetime `json:"created_at" bson:"created_at"`
+
+	// ExpiresAt is when the challenge can no longer be answered. Expired challenges are removed by the database.
+	ExpiresAt custom.Datetime `json:"expires_at" bson:"expires_at"`
+}
@@ -0,0 +1,56 @@
+package entities
+
+import "time"
+
+// VerificationMode is the challenge that new members pass to be verified.
+type VerificationMode string
+
+const (
+	// VerificationModeButton verifies the members that press the verify button.
+	VerificationModeButton VerificationMode = "button"
+
+	// VerificationModeMath verifies the members that solve a sum drawn as an image.
+	VerificationModeMath VerificationMode = "math"
+
+	// VerificationModeText verifies the members that type the text drawn as an image.
+	VerificationModeText VerificationMode = "text"
+
+	// VerificationModeQuestions verifies the members that answer the questions about the rules.
+	VerificationModeQuestions VerificationMode = "questions"
+)
+
+// VerificationQuestion is a question about the rules that members answer to be verified.
+type VerificationQuestion struct {
+	// Question is the question, which is the label of the answer in the modal.
+	Question string `json:"question" bson:"question"`
+
+	// Answer is the answer to the question. The case and the spaces around the answer are ignored.
+	Answer string `json:"answer" bson:"answer"`
+}
+
+// VerificationConfig is the member verification configuration of a guild.
+type VerificationConfig struct {
+	// Enabled is whether the members that join must be verified.
+	Enabled bool `json:"enabled" bson:"enabled"`
+
+	// Mode is the challenge that the members pass to be verified.
+	Mode VerificationMode `json:"mode" bson:"mode"`
+
+	// ChannelID is the ID of the channel the verification message is posted in.
+	ChannelID string `json:"channel_id" bson:"channel_id"`
+
+	// MessageID is the ID of the verification message, which has the button that starts the challenge.
+	MessageID string `json:"message_id,omitempty" bson:"message_id,omitempty"`
+
+	// UnverifiedRoleID is the ID of the role given to the members that join, until they are verified.
+	UnverifiedRoleID string `json:"unverified_role_id" bson:"unverified_role_id"`
+
+	// SuccessRoleIDs are the IDs of the roles given to the members that are verified.
+	SuccessRoleIDs []string `json:"success_role_ids,omitempty" bson:"success_role_ids,omitempty"`
+
+	// Timeout is how long the members have to be verified before they are kicked. If zero, they are never kicked.
+	Timeout time.Duration `json:"timeout" bson:"timeout"`
+
+	// Questions are the questions that are asked in the questions mode.
+	Questions []*VerificationQuestion `json:"questions,omitempty" bson:"questions,omitempty"`
+}
@@ -0,0 +1,40 @@
+package entities
+
+import "time"
+
+// WelcomeConfig is the configuration of the messages and roles for the members that join a guild.
+type WelcomeConfig struct {
+	// Enabled is whether the welcome message is posted.
+	Enabled bool `json:"enabled" bson:"enabled"`
+
+	// ChannelID is the ID of the channel the welcome message is posted in.
+	ChannelID string `json:"channel_id" bson:"channel_id"`
+
+	// Title is the template of the title of the welcome embed.
+	Title string `json:"title" bson:"title"`
+
+	// Message is the template of the description of the welcome embed.
+	Message string `json:"message" bson:"message"`
+
+	// DMMessage is the template of the direct message sent to the members that join. If empty, no direct message is
+	// sent.
+	DMMessage string `json:"dm_message,omitempty" bson:"dm_message,omitempty"`
+
+	// AutoRoleIDs are the IDs of the roles given to the members that join.
+	AutoRoleIDs []string `json:"auto_role_ids,omitempty" bson:"auto_role_ids,omitempty"`
+
+	// AutoRoleDelay is how long after joining the roles are given. If zero, they are given straight away.
+	AutoRoleDelay time.Duration `json:"auto_role_delay,omitempty" bson:"auto_role_delay,omitempty"`
+}
+
+// GoodbyeConfig is the configuration of the message for the members that leave a guild.
+type GoodbyeConfig struct {
+	// Enabled is whether the goodbye message is posted.
+	Enabled bool `json:"enabled" bson:"enabled"`
+
+	// ChannelID is the ID of the channel the goodbye message is posted in.
+	ChannelID string `json:"channel_id" bson:"channel_id"`
+
+	// Message is the template of the description of the goodbye embed.
+	Message string `json:"message" bson:"message"`
+}
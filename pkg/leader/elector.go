@@ -0,0 +1,139 @@
+package leader
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+)
+
+// releaseTimeout is how long the lease has to be released when the elector stops.
+const releaseTimeout = 5 * time.Second
+
+// Lease is a named lease that is held by a single holder at a time.
+type Lease interface {
+	// Acquire acquires, or renews, the lease for the holder for the ttl. It returns false if the lease is held by
+	// another holder.
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+
+	// Release releases the lease if it is held by the holder.
+	Release(ctx context.Context, name, holder string) error
+}
+
+// Elector elects a single leader between the instances campaigning for the same lease.
+type Elector struct {
+	// l is the logger.
+	l *slog.Logger
+
+	// lease is the lease that is campaigned for.
+	lease Lease
+
+	// name is the name of the lease.
+	name string
+
+	// holder identifies this instance.
+	holder string
+
+	// ttl is how long the lease is held for without being renewed.
+	ttl time.Duration
+
+	// leading is whether this instance is the leader.
+	leading atomic.Bool
+
+	// done is closed when Run returns, after the lease has been released.
+	done chan struct{}
+}
+
+// NewElector creates a new Elector that campaigns for the named lease as the holder.
+func NewElector(l *slog.Logger, lease Lease, name, holder string, ttl time.Duration) *Elector {
+	return &Elector{
+		l:      l.With(slog.String("lease", name), slog.String("holder", holder)),
+		lease:  lease,
+		name:   name,
+		holder: holder,
+		ttl:    ttl,
+		done:   make(chan struct{}),
+	}
+}
+
+// Done returns a channel that is closed when Run returns. The lease has been released by then, so waiting for it before
+// exiting lets another instance take over without waiting for the lease to expire.
+func (e *Elector) Done() <-chan struct{} {
+	return e.done
+}
+
+// IsLeader returns true if this instance is the leader.
+func (e *Elector) IsLeader() bool {
+	return e.leading.Load()
+}
+
+// Run campaigns for the lease until the context is cancelled. When the lease is acquired, fn is called with a context
+// that is cancelled when the lease is lost. The lease is renewed at a third of its ttl, and released when the context
+// is cancelled. Run must only be called once.
+func (e *Elector) Run(ctx context.Context, fn func(ctx context.Context)) {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	var (
+		cancel context.CancelFunc
+		done   chan struct{}
+	)
+
+	stepDown := func() {
+		if cancel == nil {
+			return
+		}
+
+		cancel()
+		<-done
+		cancel = nil
+
+		e.leading.Store(false)
+		e.l.Info("Lost leadership")
+	}
+
+	for {
+		acquired, err := e.lease.Acquire(ctx, e.name, e.holder, e.ttl)
+		if err != nil && ctx.Err() == nil {
+			// The lease may expire before it can be renewed, so leadership is given up.
+			e.l.Error("Error acquiring lease", slog.String(logging.KeyError, err.Error()))
+		}
+
+		switch {
+		case acquired && cancel == nil:
+			e.leading.Store(true)
+			e.l.Info("Acquired leadership")
+
+			var leaderCtx context.Context
+			leaderCtx, cancel = context.WithCancel(ctx)
+			done = make(chan struct{})
+
+			go func() {
+				defer close(done)
+				fn(leaderCtx)
+			}()
+		case !acquired:
+			stepDown()
+		}
+
+		select {
+		case <-ctx.Done():
+			wasLeader := cancel != nil
+			stepDown()
+
+			if wasLeader {
+				releaseCtx, releaseCancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+				if err := e.lease.Release(releaseCtx, e.name, e.holder); err != nil {
+					e.l.Error("Error releasing lease", slog.String(logging.KeyError, err.Error()))
+				}
+				releaseCancel()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
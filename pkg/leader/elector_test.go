@@ -0,0 +1,165 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLease is an in memory lease.
+type fakeLease struct {
+	mut     sync.Mutex
+	holder  string
+	expires time.Time
+	fail    bool
+}
+
+func (f *fakeLease) Acquire(_ context.Context, _, holder string, ttl time.Duration) (bool, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	if f.fail {
+		return false, errors.New("unavailable")
+	}
+
+	now := time.Now()
+	if f.holder != "" && f.holder != holder && now.Before(f.expires) {
+		return false, nil
+	}
+
+	f.holder = holder
+	f.expires = now.Add(ttl)
+	return true, nil
+}
+
+func (f *fakeLease) Release(_ context.Context, _, holder string) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	if f.holder == holder {
+		f.holder = ""
+	}
+	return nil
+}
+
+func (f *fakeLease) setFail(fail bool) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.fail = fail
+}
+
+func (f *fakeLease) currentHolder() string {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	return f.holder
+}
+
+func TestElector_SingleLeader(t *testing.T) {
+	lease := new(fakeLease)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	a := NewElector(slog.Default(), lease, "test", "a", 30*time.Millisecond)
+	b := NewElector(slog.Default(), lease, "test", "b", 30*time.Millisecond)
+
+	leaderA := make(chan context.Context, 1)
+	doneA := make(chan struct{})
+	go func() {
+		defer close(doneA)
+		a.Run(ctx, func(ctx context.Context) {
+			leaderA <- ctx
+			<-ctx.Done()
+		})
+	}()
+
+	// a becomes the leader.
+	leaderCtx := <-leaderA
+	require.True(t, a.IsLeader())
+
+	// b does not become the leader while a holds the lease.
+	bCtx, bCancel := context.WithCancel(context.Background())
+	doneB := make(chan struct{})
+	go func() {
+		defer close(doneB)
+		b.Run(bCtx, func(ctx context.Context) {
+			<-ctx.Done()
+		})
+	}()
+
+	time.Sleep(60 * time.Millisecond)
+	require.False(t, b.IsLeader())
+	require.Equal(t, "a", lease.currentHolder())
+
+	// Stopping a releases the lease and cancels the leader context, so b takes over.
+	cancel()
+	<-doneA
+	require.Error(t, leaderCtx.Err())
+	require.False(t, a.IsLeader())
+
+	require.Eventually(t, b.IsLeader, time.Second, 5*time.Millisecond)
+	require.Equal(t, "b", lease.currentHolder())
+
+	bCancel()
+	<-doneB
+	require.Equal(t, "", lease.currentHolder())
+}
+
+func TestElector_Done(t *testing.T) {
+	lease := new(fakeLease)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	e := NewElector(slog.Default(), lease, "test", "a", time.Hour)
+	go e.Run(ctx, func(ctx context.Context) {
+		<-ctx.Done()
+	})
+
+	require.Eventually(t, e.IsLeader, time.Second, 5*time.Millisecond)
+
+	select {
+	case <-e.Done():
+		t.Fatal("done before the elector was stopped")
+	default:
+	}
+
+	// The lease is released by the time Done is closed, so it does not have to expire.
+	cancel()
+	select {
+	case <-e.Done():
+	case <-time.After(time.Second):
+		t.Fatal("elector did not stop")
+	}
+	require.Equal(t, "", lease.currentHolder())
+}
+
+func TestElector_StepDownOnError(t *testing.T) {
+	lease := new(fakeLease)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	e := NewElector(slog.Default(), lease, "test", "a", 30*time.Millisecond)
+
+	lost := make(chan struct{}, 2)
+	go e.Run(ctx, func(ctx context.Context) {
+		<-ctx.Done()
+		lost <- struct{}{}
+	})
+
+	require.Eventually(t, e.IsLeader, time.Second, 5*time.Millisecond)
+
+	// Leadership is given up when the lease cannot be renewed.
+	lease.setFail(true)
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatal("leadership was not given up")
+	}
+	require.Eventually(t, func() bool { return !e.IsLeader() }, time.Second, 5*time.Millisecond)
+
+	// Leadership is regained once the lease is available.
+	lease.setFail(false)
+	require.Eventually(t, e.IsLeader, time.Second, 5*time.Millisecond)
+}
@@ -1,14 +1,242 @@
 package logging
 
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// EnvLogLevel is the environment variable for the minimum level that is logged.
+	EnvLogLevel = `LOG_LEVEL`
+
+	// EnvLogFormat is the environment variable for the format of the logs.
+	EnvLogFormat = `LOG_FORMAT`
+
+	// EnvLogOutput is the environment variable for where the logs are written. This is either stdout, or the path of
+	// a file that is rotated.
+	EnvLogOutput = `LOG_OUTPUT`
+
+	// EnvLogMaxSize is the environment variable for the size in megabytes at which the log file is rotated.
+	EnvLogMaxSize = `LOG_MAX_SIZE_MB`
+
+	// EnvLogMaxBackups is the environment variable for the number of rotated log files that are kept.
+	EnvLogMaxBackups = `LOG_MAX_BACKUPS`
+
+	// EnvLogLevels is the environment variable for the per logger level overrides, as comma separated name=level
+	// pairs. The name is the value of the KeyDal or KeyComponent attribute of the logger.
+	EnvLogLevels = `LOG_LEVELS`
+
+	// EnvLogSampleBurst is the environment variable for how many identical warnings or errors are logged per sample
+	// window. Zero disables sampling.
+	EnvLogSampleBurst = `LOG_SAMPLE_BURST`
+
+	// EnvLogSampleWindow is the environment variable for the window the sample burst applies to.
+	EnvLogSampleWindow = `LOG_SAMPLE_WINDOW`
+)
+
+const (
+	// OutputStdout writes the logs to stdout.
+	OutputStdout = "stdout"
+
+	// defaultMaxSize is the default size in megabytes at which the log file is rotated.
+	defaultMaxSize = 100
+
+	// defaultMaxBackups is the default number of rotated log files that are kept.
+	defaultMaxBackups = 5
+
+	// defaultSampleWindow is the default window the sample burst applies to.
+	defaultSampleWindow = time.Second
+)
+
 // Config is the configuration for the logging.
 type Config struct {
 	// appName is the name of the application.
 	appName Name
+
+	// levels are the levels of the loggers. They can be changed while the application is running.
+	levels *Levels
+
+	// format is the format of the logs.
+	format Format
+
+	// addSource is whether the source file and line are logged.
+	addSource bool
+
+	// output is where the logs are written. This is either stdout, or the path of a file that is rotated.
+	output string
+
+	// maxSize is the size in megabytes at which the log file is rotated.
+	maxSize int
+
+	// maxBackups is the number of rotated log files that are kept.
+	maxBackups int
+
+	// sampleBurst is how many identical warnings or errors are logged per sample window. Zero disables sampling.
+	sampleBurst int
+
+	// sampleWindow is the window the sample burst applies to.
+	sampleWindow time.Duration
+}
+
+// ConfigOption is a function that configures the logging.
+type ConfigOption func(c *Config)
+
+// WithLevel sets the minimum level that is logged.
+func WithLevel(level slog.Level) ConfigOption {
+	return func(c *Config) {
+		c.levels.SetLevel(level)
+	}
+}
+
+// WithLoggerLevel overrides the minimum level for the logger with the name, which is the value of its KeyDal or
+// KeyComponent attribute.
+func WithLoggerLevel(name string, level slog.Level) ConfigOption {
+	return func(c *Config) {
+		c.levels.SetLoggerLevel(name, level)
+	}
+}
+
+// WithFormat sets the format of the logs.
+func WithFormat(format Format) ConfigOption {
+	return func(c *Config) {
+		c.format = format
+	}
+}
+
+// WithSource sets whether the source file and line are logged.
+func WithSource(addSource bool) ConfigOption {
+	return func(c *Config) {
+		c.addSource = addSource
+	}
 }
 
-// NewConfig creates a new Config.
-func NewConfig(appName Name) *Config {
-	return &Config{
-		appName: appName,
+// WithFileOutput writes the logs to the file at the path. The file is rotated when it reaches maxSize megabytes, and
+// maxBackups rotated files are kept.
+func WithFileOutput(path string, maxSize, maxBackups int) ConfigOption {
+	return func(c *Config) {
+		c.output = path
+		c.maxSize = maxSize
+		c.maxBackups = maxBackups
 	}
 }
+
+// WithSampling limits identical warnings and errors to burst records per window. The number of records that were
+// dropped is added to the next record that is logged.
+func WithSampling(burst int, window time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.sampleBurst = burst
+		c.sampleWindow = window
+	}
+}
+
+// NewConfig creates a new Config. By default, everything from debug level is logged as JSON to stdout.
+func NewConfig(appName Name, opts ...ConfigOption) *Config {
+	c := &Config{
+		appName:      appName,
+		levels:       NewLevels(slog.LevelDebug),
+		format:       FormatJSON,
+		addSource:    true,
+		output:       OutputStdout,
+		maxSize:      defaultMaxSize,
+		maxBackups:   defaultMaxBackups,
+		sampleWindow: defaultSampleWindow,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// NewConfigFromEnv creates a new Config from the environment variables. Any variable that is not set keeps the
+// default of NewConfig.
+func NewConfigFromEnv(appName Name) (*Config, error) {
+	opts := make([]ConfigOption, 0)
+
+	if envLevel := os.Getenv(EnvLogLevel); envLevel != "" {
+		level, err := ParseLevel(envLevel)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvLogLevel, err)
+		}
+		opts = append(opts, WithLevel(level))
+	}
+
+	if envFormat := os.Getenv(EnvLogFormat); envFormat != "" {
+		format, err := ParseFormat(envFormat)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvLogFormat, err)
+		}
+		opts = append(opts, WithFormat(format))
+	}
+
+	if envOutput := os.Getenv(EnvLogOutput); envOutput != "" && envOutput != OutputStdout {
+		maxSize, err := envInt(EnvLogMaxSize, defaultMaxSize)
+		if err != nil {
+			return nil, err
+		}
+
+		maxBackups, err := envInt(EnvLogMaxBackups, defaultMaxBackups)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, WithFileOutput(envOutput, maxSize, maxBackups))
+	}
+
+	if envLevels := os.Getenv(EnvLogLevels); envLevels != "" {
+		for _, pair := range strings.Split(envLevels, ",") {
+			name, str, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || name == "" {
+				return nil, fmt.Errorf("invalid %s: expected name=level, got %q", EnvLogLevels, pair)
+			}
+
+			level, err := ParseLevel(str)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", EnvLogLevels, err)
+			}
+			opts = append(opts, WithLoggerLevel(name, level))
+		}
+	}
+
+	if envBurst := os.Getenv(EnvLogSampleBurst); envBurst != "" {
+		burst, err := envInt(EnvLogSampleBurst, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		window := defaultSampleWindow
+		if envWindow := os.Getenv(EnvLogSampleWindow); envWindow != "" {
+			window, err = time.ParseDuration(envWindow)
+			if err != nil || window <= 0 {
+				return nil, fmt.Errorf("invalid %s: %q", EnvLogSampleWindow, envWindow)
+			}
+		}
+		opts = append(opts, WithSampling(burst, window))
+	}
+
+	return NewConfig(appName, opts...), nil
+}
+
+// envInt returns the non-negative integer in the environment variable, or the default if it is not set.
+func envInt(key string, def int) (int, error) {
+	str := os.Getenv(key)
+	if str == "" {
+		return def, nil
+	}
+
+	v, err := strconv.Atoi(str)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", key, str)
+	}
+	return v, nil
+}
+
+// Levels returns the levels of the loggers, which can be changed while the application is running.
+func (c *Config) Levels() *Levels {
+	return c.levels
+}
@@ -1,7 +1,9 @@
 package logging
 
 import (
+	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -10,21 +12,90 @@ func TestNewConfig(t *testing.T) {
 	tests := []struct {
 		name    string
 		appName Name
+		opts    []ConfigOption
 		want    *Config
 	}{
 		{
 			name:    "TestNewConfig",
 			appName: "TestNewConfig",
 			want: &Config{
-				appName: "TestNewConfig",
+				appName:      "TestNewConfig",
+				levels:       NewLevels(slog.LevelDebug),
+				format:       FormatJSON,
+				addSource:    true,
+				output:       OutputStdout,
+				maxSize:      defaultMaxSize,
+				maxBackups:   defaultMaxBackups,
+				sampleWindow: defaultSampleWindow,
+			},
+		},
+		{
+			name:    "TestNewConfigWithOptions",
+			appName: "TestNewConfig",
+			opts: []ConfigOption{
+				WithLevel(slog.LevelWarn),
+				WithFormat(FormatLogfmt),
+				WithSource(false),
+				WithFileOutput("/var/log/wolf.log", 10, 2),
+				WithSampling(5, time.Minute),
+			},
+			want: &Config{
+				appName:      "TestNewConfig",
+				levels:       NewLevels(slog.LevelWarn),
+				format:       FormatLogfmt,
+				addSource:    false,
+				output:       "/var/log/wolf.log",
+				maxSize:      10,
+				maxBackups:   2,
+				sampleBurst:  5,
+				sampleWindow: time.Minute,
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := NewConfig(tt.appName)
+			got := NewConfig(tt.appName, tt.opts...)
 			require.Equal(t, tt.want, got, "NewConfig() = %v, want %v", got, tt.want)
 		})
 	}
 }
+
+func TestNewConfigFromEnv(t *testing.T) {
+	t.Setenv(EnvLogLevel, "warn")
+	t.Setenv(EnvLogFormat, "TEXT")
+	t.Setenv(EnvLogLevels, "guild_dal=debug, commands=error")
+	t.Setenv(EnvLogSampleBurst, "3")
+	t.Setenv(EnvLogSampleWindow, "10s")
+
+	cfg, err := NewConfigFromEnv("TestNewConfigFromEnv")
+	require.NoError(t, err)
+	require.Equal(t, slog.LevelWarn, cfg.Levels().Level())
+	require.Equal(t, map[string]slog.Level{"guild_dal": slog.LevelDebug, "commands": slog.LevelError}, cfg.Levels().Overrides())
+	require.Equal(t, FormatText, cfg.format)
+	require.Equal(t, OutputStdout, cfg.output)
+	require.Equal(t, 3, cfg.sampleBurst)
+	require.Equal(t, 10*time.Second, cfg.sampleWindow)
+}
+
+func TestNewConfigFromEnv_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+	}{
+		{name: "level", key: EnvLogLevel, value: "loud"},
+		{name: "format", key: EnvLogFormat, value: "xml"},
+		{name: "overrides", key: EnvLogLevels, value: "guild_dal"},
+		{name: "burst", key: EnvLogSampleBurst, value: "-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+
+			_, err := NewConfigFromEnv("TestNewConfigFromEnv")
+			require.Error(t, err)
+		})
+	}
+}
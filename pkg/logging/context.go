@@ -0,0 +1,24 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+// loggerKey is the context key for the logger.
+type loggerKey struct{}
+
+// WithLogger returns a copy of the context that carries the logger.
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger carried by the context, or the default logger if there is none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok && l != nil {
+			return l
+		}
+	}
+	return slog.Default()
+}
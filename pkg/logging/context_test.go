@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromContext(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := slog.New(slog.NewTextHandler(buf, nil)).With(KeyCorrelationID, "abc123")
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want *slog.Logger
+	}{
+		{
+			name: "carried",
+			ctx:  WithLogger(context.Background(), l),
+			want: l,
+		},
+		{
+			name: "default",
+			ctx:  context.Background(),
+			want: slog.Default(),
+		},
+		{
+			name: "nil context",
+			ctx:  nil,
+			want: slog.Default(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Same(t, tt.want, FromContext(tt.ctx))
+		})
+	}
+
+	FromContext(WithLogger(context.Background(), l)).Info("test")
+	require.Contains(t, buf.String(), "correlation_id=abc123")
+}
@@ -0,0 +1,139 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Format is the format of the logs.
+type Format string
+
+const (
+	// FormatJSON logs each record as a JSON object. This is the default.
+	FormatJSON Format = "json"
+
+	// FormatLogfmt logs each record as key=value pairs.
+	FormatLogfmt Format = "logfmt"
+
+	// FormatText logs each record as a human readable line, with the time, level and message first.
+	FormatText Format = "text"
+)
+
+// String returns the string representation of the Format.
+func (f Format) String() string {
+	return string(f)
+}
+
+// ParseFormat parses the name of a format.
+func ParseFormat(str string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(str))); f {
+	case FormatJSON, FormatLogfmt, FormatText:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid format %q", str)
+	}
+}
+
+// newFormatHandler creates the handler that writes the records to w in the format.
+func newFormatHandler(w io.Writer, format Format, opts *slog.HandlerOptions) (slog.Handler, error) {
+	switch format {
+	case FormatJSON, "":
+		return slog.NewJSONHandler(w, opts), nil
+	case FormatLogfmt:
+		return slog.NewTextHandler(w, opts), nil
+	case FormatText:
+		return newTextHandler(w, opts), nil
+	default:
+		return nil, fmt.Errorf("invalid format %q", format)
+	}
+}
+
+// textHandler writes each record as a line with the time, level and message first, followed by the attributes as
+// key=value pairs.
+type textHandler struct {
+	// mut serialises the lines. It is shared by the handlers derived from this one.
+	mut *sync.Mutex
+
+	// w is where the lines are written.
+	w io.Writer
+
+	// buf holds the attributes of the record being handled. It is shared by the handlers derived from this one, and
+	// guarded by mut.
+	buf *bytes.Buffer
+
+	// attrs formats the attributes into buf.
+	attrs slog.Handler
+}
+
+// newTextHandler creates a new textHandler.
+func newTextHandler(w io.Writer, opts *slog.HandlerOptions) *textHandler {
+	buf := new(bytes.Buffer)
+
+	replace := opts.ReplaceAttr
+	attrOpts := *opts
+	attrOpts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		// The time, level and message are written at the start of the line.
+		if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+			return slog.Attr{}
+		}
+		if replace != nil {
+			return replace(groups, a)
+		}
+		return a
+	}
+
+	return &textHandler{
+		mut:   new(sync.Mutex),
+		w:     w,
+		buf:   buf,
+		attrs: slog.NewTextHandler(buf, &attrOpts),
+	}
+}
+
+// Enabled implements the slog.Handler interface.
+func (h *textHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.attrs.Enabled(ctx, level)
+}
+
+// Handle implements the slog.Handler interface.
+func (h *textHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	h.buf.Reset()
+	fmt.Fprintf(h.buf, "%s %-5s %s ", r.Time.UTC().Format(time.RFC3339), r.Level.String(), r.Message)
+
+	// The attributes are appended to the start of the line.
+	if err := h.attrs.Handle(ctx, r); err != nil {
+		return err
+	}
+
+	_, err := h.w.Write(h.buf.Bytes())
+	return err
+}
+
+// WithAttrs implements the slog.Handler interface.
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &textHandler{
+		mut:   h.mut,
+		w:     h.w,
+		buf:   h.buf,
+		attrs: h.attrs.WithAttrs(attrs),
+	}
+}
+
+// WithGroup implements the slog.Handler interface.
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	return &textHandler{
+		mut:   h.mut,
+		w:     h.w,
+		buf:   h.buf,
+		attrs: h.attrs.WithGroup(name),
+	}
+}
@@ -12,4 +12,30 @@ const (
 
 	// KeyDal represents the key for the data access layer.
 	KeyDal = `dal`
+
+	// KeyComponent represents the key for the component of the application, such as the command router.
+	KeyComponent = `component`
+)
+
+const (
+	// KeyCorrelationID represents the key for the correlation ID of a request.
+	KeyCorrelationID = `correlation_id`
+
+	// KeyGuildID represents the key for the guild ID.
+	KeyGuildID = `guild_id`
+
+	// KeyChannelID represents the key for the channel ID.
+	KeyChannelID = `channel_id`
+
+	// KeyUserID represents the key for the user ID.
+	KeyUserID = `user_id`
+
+	// KeyCommand represents the key for the command.
+	KeyCommand = `command`
+
+	// KeyTraceID represents the key for the trace ID of a request.
+	KeyTraceID = `trace_id`
+
+	// KeyRequestID represents the key for the ID of a http request.
+	KeyRequestID = `request_id`
 )
@@ -0,0 +1,150 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Levels are the minimum levels of the loggers. The level of a logger can be overridden by the value of its KeyDal
+// or KeyComponent attribute. Levels are safe to change while the loggers are in use.
+type Levels struct {
+	// base is the level of the loggers that are not overridden.
+	base *slog.LevelVar
+
+	// mut guards the overrides.
+	mut sync.RWMutex
+
+	// overrides are the levels of the loggers that are overridden, keyed by name.
+	overrides map[string]slog.Level
+}
+
+// NewLevels creates new Levels with the base level.
+func NewLevels(level slog.Level) *Levels {
+	base := new(slog.LevelVar)
+	base.Set(level)
+
+	return &Levels{
+		base:      base,
+		overrides: make(map[string]slog.Level),
+	}
+}
+
+// Level returns the level of the loggers that are not overridden.
+func (l *Levels) Level() slog.Level {
+	return l.base.Level()
+}
+
+// SetLevel sets the level of the loggers that are not overridden.
+func (l *Levels) SetLevel(level slog.Level) {
+	l.base.Set(level)
+}
+
+// SetLoggerLevel overrides the level of the named logger.
+func (l *Levels) SetLoggerLevel(name string, level slog.Level) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	l.overrides[name] = level
+}
+
+// ResetLoggerLevel removes the override of the named logger, so it uses the base level again.
+func (l *Levels) ResetLoggerLevel(name string) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	delete(l.overrides, name)
+}
+
+// Overrides returns a copy of the overridden levels, keyed by name.
+func (l *Levels) Overrides() map[string]slog.Level {
+	l.mut.RLock()
+	defer l.mut.RUnlock()
+
+	overrides := make(map[string]slog.Level, len(l.overrides))
+	for name, level := range l.overrides {
+		overrides[name] = level
+	}
+	return overrides
+}
+
+// enabled returns true if the level is logged by the named logger.
+func (l *Levels) enabled(name string, level slog.Level) bool {
+	if name != "" {
+		l.mut.RLock()
+		override, ok := l.overrides[name]
+		l.mut.RUnlock()
+
+		if ok {
+			return level >= override
+		}
+	}
+	return level >= l.base.Level()
+}
+
+// ParseLevel parses the name of a level, such as debug, info, warn or error. Offsets such as warn+2 are accepted.
+func ParseLevel(str string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(str))); err != nil {
+		return 0, fmt.Errorf("invalid level %q", str)
+	}
+	return level, nil
+}
+
+// levelHandler filters the records by the level of the logger.
+type levelHandler struct {
+	// next is the handler the enabled records are passed to.
+	next slog.Handler
+
+	// levels are the levels of the loggers.
+	levels *Levels
+
+	// name is the value of the KeyDal or KeyComponent attribute of the logger, used to look up its override.
+	name string
+}
+
+// newLevelHandler creates a new levelHandler.
+func newLevelHandler(next slog.Handler, levels *Levels) *levelHandler {
+	return &levelHandler{
+		next:   next,
+		levels: levels,
+	}
+}
+
+// Enabled implements the slog.Handler interface.
+func (h *levelHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.levels.enabled(h.name, level)
+}
+
+// Handle implements the slog.Handler interface.
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs implements the slog.Handler interface. The name of the logger is taken from the KeyDal or KeyComponent
+// attribute.
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	name := h.name
+	for _, a := range attrs {
+		if a.Key == KeyDal || a.Key == KeyComponent {
+			name = a.Value.String()
+		}
+	}
+
+	return &levelHandler{
+		next:   h.next.WithAttrs(attrs),
+		levels: h.levels,
+		name:   name,
+	}
+}
+
+// WithGroup implements the slog.Handler interface.
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{
+		next:   h.next.WithGroup(name),
+		levels: h.levels,
+		name:   h.name,
+	}
+}
@@ -8,7 +8,11 @@ import (
 	"strings"
 )
 
-// CommonLogger constructs a logging with default options.
+// minHandlerLevel is the level of the format handlers. It is below every level, as the records are filtered by the
+// levels of the loggers before they reach the format handler.
+const minHandlerLevel = slog.Level(-100)
+
+// CommonLogger constructs a logging with the options of the config.
 func CommonLogger(cfg *Config) (*slog.Logger, error) {
 	// Check config.
 	if cfg == nil {
@@ -18,26 +22,49 @@ func CommonLogger(cfg *Config) (*slog.Logger, error) {
 	if cfg.appName == "" {
 		return nil, errors.New("app name is empty")
 	}
-	writer := os.Stdout
-	return CommonLoggerWithOptions(cfg, writer, slog.LevelDebug, true)
+
+	var writer io.Writer = os.Stdout
+	if cfg.output != "" && cfg.output != OutputStdout {
+		file, err := NewRotatingFile(cfg.output, cfg.maxSize, cfg.maxBackups)
+		if err != nil {
+			return nil, err
+		}
+		writer = file
+	}
+
+	return newLogger(cfg, writer)
 }
 
 // CommonLoggerWithOptions constructs a logging with custom options.
 func CommonLoggerWithOptions(cfg *Config, w io.Writer, minLevel slog.Level, logToJson bool) (*slog.Logger, error) {
+	cfg.levels.SetLevel(minLevel)
+
+	cfg.format = FormatLogfmt
+	if logToJson {
+		cfg.format = FormatJSON
+	}
+
+	return newLogger(cfg, w)
+}
+
+// newLogger constructs a logging that writes to w, and sets it as the default.
+func newLogger(cfg *Config, w io.Writer) (*slog.Logger, error) {
 	opts := slog.HandlerOptions{
-		AddSource:   true,
-		Level:       minLevel,
+		AddSource:   cfg.addSource,
+		Level:       minHandlerLevel,
 		ReplaceAttr: replaceAttrs,
 	}
 
-	var logger *slog.Logger
-	if logToJson {
-		logger = slog.New(slog.NewJSONHandler(w, &opts))
-	} else {
-		logger = slog.New(slog.NewTextHandler(w, &opts))
+	handler, err := newFormatHandler(w, cfg.format, &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.sampleBurst > 0 {
+		handler = newSamplingHandler(handler, cfg.sampleBurst, cfg.sampleWindow)
 	}
 
-	logger = logger.With(
+	logger := slog.New(newLevelHandler(handler, cfg.levels)).With(
 		KeyAppName, cfg.appName,
 	)
 
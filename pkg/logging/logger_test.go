@@ -1,7 +1,11 @@
 package logging
 
 import (
+	"bytes"
 	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -14,10 +18,8 @@ func TestCommonLogger(t *testing.T) {
 		wantErr error
 	}{
 		{
-			name: "TestCommonLogger",
-			cfg: &Config{
-				appName: "TestCommonLogger",
-			},
+			name:    "TestCommonLogger",
+			cfg:     NewConfig("TestCommonLogger"),
 			wantErr: nil,
 		},
 		{
@@ -26,24 +28,18 @@ func TestCommonLogger(t *testing.T) {
 			wantErr: errors.New("logging config is nil"),
 		},
 		{
-			name: "TestCommonLogger",
-			cfg: &Config{
-				appName: "",
-			},
+			name:    "TestCommonLogger",
+			cfg:     NewConfig(""),
 			wantErr: errors.New("app name is empty"),
 		},
 		{
-			name: "TestCommonLogger",
-			cfg: &Config{
-				appName: "TestCommonLogger",
-			},
+			name:    "TestCommonLogger",
+			cfg:     NewConfig("TestCommonLogger"),
 			wantErr: nil,
 		},
 		{
-			name: "TestCommonLogger",
-			cfg: &Config{
-				appName: "TestCommonLogger",
-			},
+			name:    "TestCommonLogger",
+			cfg:     NewConfig("TestCommonLogger"),
 			wantErr: nil,
 		},
 	}
@@ -55,3 +51,57 @@ func TestCommonLogger(t *testing.T) {
 		})
 	}
 }
+
+func TestCommonLogger_Levels(t *testing.T) {
+	prev := slog.Default()
+	t.Cleanup(func() {
+		slog.SetDefault(prev)
+	})
+
+	cfg := NewConfig("TestCommonLogger",
+		WithLevel(slog.LevelInfo),
+		WithLoggerLevel("guild_dal", slog.LevelError),
+		WithSource(false),
+	)
+
+	buf := new(bytes.Buffer)
+	l, err := CommonLoggerWithOptions(cfg, buf, slog.LevelInfo, false)
+	require.NoError(t, err)
+
+	dal := l.With(slog.String(KeyDal, "guild_dal"))
+
+	l.Debug("hidden")
+	l.Info("shown")
+	dal.Warn("hidden by override")
+	dal.Error("shown by override")
+
+	// The level can be changed while the loggers are in use.
+	cfg.Levels().SetLevel(slog.LevelDebug)
+	cfg.Levels().ResetLoggerLevel("guild_dal")
+	l.Debug("shown after change")
+	dal.Info("shown after reset")
+
+	out := buf.String()
+	require.NotContains(t, out, "hidden")
+	require.Contains(t, out, `msg=shown `)
+	require.Contains(t, out, `msg="shown by override"`)
+	require.Contains(t, out, `msg="shown after change"`)
+	require.Contains(t, out, `msg="shown after reset"`)
+}
+
+func TestCommonLogger_File(t *testing.T) {
+	prev := slog.Default()
+	t.Cleanup(func() {
+		slog.SetDefault(prev)
+	})
+
+	path := filepath.Join(t.TempDir(), "logs", "wolf.log")
+	l, err := CommonLogger(NewConfig("TestCommonLogger", WithFileOutput(path, 1, 1), WithFormat(FormatText)))
+	require.NoError(t, err)
+
+	l.Info("written to file", slog.String("key", "value"))
+
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Regexp(t, `^\S+ INFO  written to file .*key=value\n$`, string(got))
+}
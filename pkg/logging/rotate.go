@@ -0,0 +1,135 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// megabyte is the number of bytes in a megabyte.
+const megabyte = 1024 * 1024
+
+// RotatingFile is a log file that is rotated when it reaches its maximum size. The rotated files are named after
+// the file with a numeric suffix, where .1 is the most recent.
+type RotatingFile struct {
+	// mut serialises the writes.
+	mut sync.Mutex
+
+	// path is the path of the file.
+	path string
+
+	// maxSize is the size in bytes at which the file is rotated.
+	maxSize int64
+
+	// maxBackups is the number of rotated files that are kept.
+	maxBackups int
+
+	// file is the open file.
+	file *os.File
+
+	// size is the size of the open file.
+	size int64
+}
+
+// NewRotatingFile opens the file at the path for appending, creating it and its directory if needed. The file is
+// rotated when it reaches maxSize megabytes, and maxBackups rotated files are kept.
+func NewRotatingFile(path string, maxSize, maxBackups int) (*RotatingFile, error) {
+	if maxSize <= 0 {
+		return nil, errors.New("max size must be positive")
+	}
+
+	f := &RotatingFile{
+		path:       path,
+		maxSize:    int64(maxSize) * megabyte,
+		maxBackups: maxBackups,
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("error creating log directory: %w", err)
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write implements the io.Writer interface. The file is rotated first if the write would take it over its maximum
+// size.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close implements the io.Closer interface.
+func (f *RotatingFile) Close() error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	return f.file.Close()
+}
+
+// open opens the file for appending.
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("error opening log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("error getting log file info: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// rotate shifts the rotated files along by one, removing the oldest, moves the file to .1 and opens a new file.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("error closing log file: %w", err)
+	}
+
+	if f.maxBackups == 0 {
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error removing log file: %w", err)
+		}
+		return f.open()
+	}
+
+	if err := os.Remove(f.backupPath(f.maxBackups)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error removing oldest log file: %w", err)
+	}
+
+	for i := f.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(f.backupPath(i), f.backupPath(i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error rotating log file: %w", err)
+		}
+	}
+
+	if err := os.Rename(f.path, f.backupPath(1)); err != nil {
+		return fmt.Errorf("error rotating log file: %w", err)
+	}
+	return f.open()
+}
+
+// backupPath returns the path of the nth rotated file.
+func (f *RotatingFile) backupPath(n int) string {
+	return fmt.Sprintf("%s.%d", f.path, n)
+}
@@ -0,0 +1,37 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wolf.log")
+
+	f, err := NewRotatingFile(path, 1, 2)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, f.Close())
+	})
+
+	chunk := bytes.Repeat([]byte("a"), megabyte/2+1)
+	for _, b := range []byte("abcd") {
+		chunk[0] = b
+		_, err := f.Write(chunk)
+		require.NoError(t, err)
+	}
+
+	// Each chunk is over half the maximum size, so every write after the first rotates the file.
+	for name, want := range map[string]byte{path: 'd', path + ".1": 'c', path + ".2": 'b'} {
+		got, err := os.ReadFile(name)
+		require.NoError(t, err)
+		require.Equal(t, want, got[0], name)
+	}
+
+	_, err = os.Stat(path + ".3")
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
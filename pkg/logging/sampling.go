@@ -0,0 +1,161 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// KeySampleDropped represents the key for the number of identical records that were dropped by sampling since the
+	// record was last logged.
+	KeySampleDropped = `sample_dropped`
+
+	// maxSampleKeys is the number of distinct records the sampler tracks before the expired windows are removed.
+	maxSampleKeys = 1000
+)
+
+// sampleKey identifies identical records.
+type sampleKey struct {
+	// level is the level of the record.
+	level slog.Level
+
+	// message is the message of the record.
+	message string
+}
+
+// sampleWindow counts the records for a key within a window.
+type sampleWindow struct {
+	// start is when the window started.
+	start time.Time
+
+	// count is the number of records logged in the window.
+	count int
+
+	// dropped is the number of records dropped since a record was last logged.
+	dropped int
+}
+
+// sampler limits identical records to a burst per window.
+type sampler struct {
+	// mut guards the windows.
+	mut sync.Mutex
+
+	// burst is how many identical records are logged per window.
+	burst int
+
+	// window is the window the burst applies to.
+	window time.Duration
+
+	// windows are the windows of the records, keyed by the level and message.
+	windows map[sampleKey]*sampleWindow
+
+	// now returns the current time. It is replaced in the tests.
+	now func() time.Time
+}
+
+// newSampler creates a new sampler.
+func newSampler(burst int, window time.Duration) *sampler {
+	return &sampler{
+		burst:   burst,
+		window:  window,
+		windows: make(map[sampleKey]*sampleWindow),
+		now:     time.Now,
+	}
+}
+
+// sample returns whether the record is logged and, if so, how many identical records were dropped before it.
+func (s *sampler) sample(key sampleKey) (bool, int) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	now := s.now()
+	w, ok := s.windows[key]
+	if !ok {
+		if len(s.windows) >= maxSampleKeys {
+			s.removeExpired(now)
+		}
+
+		w = &sampleWindow{start: now}
+		s.windows[key] = w
+	} else if now.Sub(w.start) >= s.window {
+		w.start = now
+		w.count = 0
+	}
+
+	if w.count >= s.burst {
+		w.dropped++
+		return false, 0
+	}
+
+	w.count++
+	dropped := w.dropped
+	w.dropped = 0
+	return true, dropped
+}
+
+// removeExpired removes the windows that have expired and have no dropped records to report.
+func (s *sampler) removeExpired(now time.Time) {
+	for key, w := range s.windows {
+		if now.Sub(w.start) >= s.window && w.dropped == 0 {
+			delete(s.windows, key)
+		}
+	}
+}
+
+// samplingHandler drops repetitive warnings and errors. Records below the warning level are not sampled.
+type samplingHandler struct {
+	// next is the handler the sampled records are passed to.
+	next slog.Handler
+
+	// sampler decides which records are logged. It is shared by the handlers derived from this one.
+	sampler *sampler
+}
+
+// newSamplingHandler creates a new samplingHandler.
+func newSamplingHandler(next slog.Handler, burst int, window time.Duration) *samplingHandler {
+	return &samplingHandler{
+		next:    next,
+		sampler: newSampler(burst, window),
+	}
+}
+
+// Enabled implements the slog.Handler interface.
+func (h *samplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements the slog.Handler interface.
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelWarn {
+		return h.next.Handle(ctx, r)
+	}
+
+	ok, dropped := h.sampler.sample(sampleKey{level: r.Level, message: r.Message})
+	if !ok {
+		return nil
+	}
+
+	if dropped > 0 {
+		r = r.Clone()
+		r.AddAttrs(slog.Int(KeySampleDropped, dropped))
+	}
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs implements the slog.Handler interface.
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{
+		next:    h.next.WithAttrs(attrs),
+		sampler: h.sampler,
+	}
+}
+
+// WithGroup implements the slog.Handler interface.
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{
+		next:    h.next.WithGroup(name),
+		sampler: h.sampler,
+	}
+}
@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h := newSamplingHandler(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}), 2, time.Minute)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.sampler.now = func() time.Time {
+		return now
+	}
+
+	l := slog.New(h)
+	for i := 0; i < 5; i++ {
+		l.Error("repeated")
+		l.Info("not sampled")
+	}
+	l.Error("different")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 8)
+	require.Equal(t, 2, strings.Count(buf.String(), "msg=repeated"))
+	require.Equal(t, 5, strings.Count(buf.String(), `msg="not sampled"`))
+	require.NotContains(t, buf.String(), KeySampleDropped)
+
+	// The next record after the window reports the records that were dropped.
+	now = now.Add(time.Minute)
+	buf.Reset()
+	l.Error("repeated")
+	require.Contains(t, buf.String(), KeySampleDropped+"=3")
+}
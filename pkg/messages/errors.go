@@ -2,5 +2,8 @@ package messages
 
 const (
 	ErrUserErrorProcessing = "There was an error processing your request."
+	ErrUserErrorReference  = "There was an error processing your request. If this keeps happening, please share the reference `%s` with the server staff."
+	ErrUserNoPermission    = "You do not have permission to use this command."
+	ErrUserGuildOnly       = "This command can only be used in a server."
 	ErrInternalServerError = "There was an internal server error."
 )
@@ -0,0 +1,144 @@
+package reactionrole
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+)
+
+// MaxRolesPerMessage is the most reaction roles a message can have. This is the most distinct reactions a message can
+// have.
+const MaxRolesPerMessage = 20
+
+// variationSelector is the character that asks for the emoji presentation of a standard emoji. Discord does not always
+// keep it, so it is ignored when emojis are compared.
+const variationSelector = "\uFE0F"
+
+// maxEmojiLength is the most characters a standard emoji is made of, allowing for the longest joined sequences.
+const maxEmojiLength = 16
+
+// customEmojiPattern matches a custom emoji as it is written in a message, such as <:wolf:123> or <a:wolf:123>.
+var customEmojiPattern = regexp.MustCompile(`^<a?:(\w+):(\d+)>$`)
+
+// ErrInvalidEmoji is returned when an emoji cannot be parsed.
+var ErrInvalidEmoji = errors.New("invalid emoji")
+
+// Change is the roles to give to and take from a member, and the reactions of the member to remove.
+type Change struct {
+	// Add are the roles to give to the member.
+	Add []string
+
+	// Remove are the roles to take from the member.
+	Remove []string
+
+	// RemoveReactions are the emojis, in the form used by the API, whose reactions by the member are removed.
+	RemoveReactions []string
+}
+
+// ParseEmoji returns the emoji, as written in a message, in the form used by the API.
+func ParseEmoji(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if match := customEmojiPattern.FindStringSubmatch(s); match != nil {
+		return match[1] + ":" + match[2], nil
+	}
+
+	// A standard emoji is a short sequence of characters without spaces or markup.
+	if s == "" || strings.ContainsAny(s, " <>:") || len([]rune(s)) > maxEmojiLength {
+		return "", ErrInvalidEmoji
+	}
+	return s, nil
+}
+
+// Key returns the key that the emoji, in the form used by the API, is compared by. Custom emojis are compared by their
+// ID, so they keep working when they are renamed.
+func Key(emoji string) string {
+	if i := strings.LastIndex(emoji, ":"); i >= 0 {
+		return emoji[i+1:]
+	}
+	return strings.ReplaceAll(emoji, variationSelector, "")
+}
+
+// Find returns the reaction role of the message for the emoji, in the form used by the API, or nil if there is none.
+func Find(msg *entities.ReactionRoleMessage, emoji string) *entities.ReactionRole {
+	key := Key(emoji)
+	for _, role := range msg.Roles {
+		if Key(role.Emoji) == key {
+			return role
+		}
+	}
+	return nil
+}
+
+// React returns the change for the member, with the roles, reacting to the message with the emoji. It returns nil if
+// the emoji has no role.
+func React(msg *entities.ReactionRoleMessage, emoji string, memberRoles []string) *Change {
+	role := Find(msg, emoji)
+	if role == nil {
+		return nil
+	}
+
+	switch msg.Mode {
+	case entities.ReactionRoleModeDrop:
+		return &Change{Remove: []string{role.RoleID}}
+	case entities.ReactionRoleModeUnique:
+		change := &Change{Add: []string{role.RoleID}}
+		for _, other := range msg.Roles {
+			if other.RoleID != role.RoleID && slices.Contains(memberRoles, other.RoleID) {
+				change.Remove = append(change.Remove, other.RoleID)
+				change.RemoveReactions = append(change.RemoveReactions, other.Emoji)
+			}
+		}
+		return change
+	default:
+		return &Change{Add: []string{role.RoleID}}
+	}
+}
+
+// Unreact returns the change for the member removing their reaction to the message with the emoji. It returns nil if
+// the emoji has no role, or the mode of the message does not change the roles when a reaction is removed.
+func Unreact(msg *entities.ReactionRoleMessage, emoji string) *Change {
+	role := Find(msg, emoji)
+	if role == nil {
+		return nil
+	}
+
+	switch msg.Mode {
+	case entities.ReactionRoleModeVerify, entities.ReactionRoleModeDrop:
+		return nil
+	default:
+		return &Change{Remove: []string{role.RoleID}}
+	}
+}
+
+// Reconcile returns the change for the member, with the roles, that reacted to the message with the emoji while the
+// roles were not being changed. Only the changes that are missing are returned, and a member of a unique message that
+// already has one of its roles is left alone, as it is not known which reaction came last. It returns nil if there is
+// nothing to change.
+func Reconcile(msg *entities.ReactionRoleMessage, emoji string, memberRoles []string) *Change {
+	role := Find(msg, emoji)
+	if role == nil {
+		return nil
+	}
+
+	switch msg.Mode {
+	case entities.ReactionRoleModeDrop:
+		if slices.Contains(memberRoles, role.RoleID) {
+			return &Change{Remove: []string{role.RoleID}}
+		}
+	case entities.ReactionRoleModeUnique:
+		for _, other := range msg.Roles {
+			if slices.Contains(memberRoles, other.RoleID) {
+				return nil
+			}
+		}
+		return &Change{Add: []string{role.RoleID}}
+	default:
+		if !slices.Contains(memberRoles, role.RoleID) {
+			return &Change{Add: []string{role.RoleID}}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package reactionrole
+
+import (
+	"testing"
+
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/stretchr/testify/require"
+)
+
+// message returns a message in the mode with the red, green and blue roles.
+func message(mode entities.ReactionRoleMode) *entities.ReactionRoleMessage {
+	return &entities.ReactionRoleMessage{
+		MessageID: "message",
+		Mode:      mode,
+		Roles: []*entities.ReactionRole{
+			{Emoji: "\u2764\uFE0F", RoleID: "red"},
+			{Emoji: "green:123", RoleID: "green"},
+			{Emoji: "\U0001F535", RoleID: "blue"},
+		},
+	}
+}
+
+func TestParseEmoji(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "standard", input: " \U0001F535 ", want: "\U0001F535"},
+		{name: "custom", input: "<:wolf:123456>", want: "wolf:123456"},
+		{name: "animated", input: "<a:wolf:123456>", want: "wolf:123456"},
+		{name: "empty", input: " ", wantErr: true},
+		{name: "text", input: "not an emoji", wantErr: true},
+		{name: "markup", input: "<@123>", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEmoji(tt.input)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidEmoji)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	msg := message(entities.ReactionRoleModeNormal)
+
+	// Standard emojis are found with or without the variation selector, and custom emojis by their ID.
+	require.Equal(t, "red", Find(msg, "\u2764").RoleID)
+	require.Equal(t, "green", Find(msg, "renamed:123").RoleID)
+	require.Nil(t, Find(msg, "\U0001F7E2"))
+}
+
+func TestReact(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        entities.ReactionRoleMode
+		emoji       string
+		memberRoles []string
+		want        *Change
+	}{
+		{
+			name:  "normal",
+			mode:  entities.ReactionRoleModeNormal,
+			emoji: "\U0001F535",
+			want:  &Change{Add: []string{"blue"}},
+		},
+		{
+			name:        "unique",
+			mode:        entities.ReactionRoleModeUnique,
+			emoji:       "\U0001F535",
+			memberRoles: []string{"member", "red", "green"},
+			want: &Change{
+				Add:             []string{"blue"},
+				Remove:          []string{"red", "green"},
+				RemoveReactions: []string{"\u2764\uFE0F", "green:123"},
+			},
+		},
+		{
+			name:  "verify",
+			mode:  entities.ReactionRoleModeVerify,
+			emoji: "green:123",
+			want:  &Change{Add: []string{"green"}},
+		},
+		{
+			name:  "drop",
+			mode:  entities.ReactionRoleModeDrop,
+			emoji: "green:123",
+			want:  &Change{Remove: []string{"green"}},
+		},
+		{
+			name:  "unknown emoji",
+			mode:  entities.ReactionRoleModeNormal,
+			emoji: "\U0001F7E2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, React(message(tt.mode), tt.emoji, tt.memberRoles))
+		})
+	}
+}
+
+func TestUnreact(t *testing.T) {
+	tests := []struct {
+		name string
+		mode entities.ReactionRoleMode
+		want *Change
+	}{
+		{name: "normal", mode: entities.ReactionRoleModeNormal, want: &Change{Remove: []string{"blue"}}},
+		{name: "unique", mode: entities.ReactionRoleModeUnique, want: &Change{Remove: []string{"blue"}}},
+		{name: "verify", mode: entities.ReactionRoleModeVerify},
+		{name: "drop", mode: entities.ReactionRoleModeDrop},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Unreact(message(tt.mode), "\U0001F535"))
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        entities.ReactionRoleMode
+		memberRoles []string
+		want        *Change
+	}{
+		{
+			name: "normal missing role",
+			mode: entities.ReactionRoleModeNormal,
+			want: &Change{Add: []string{"blue"}},
+		},
+		{
+			name:        "normal has role",
+			mode:        entities.ReactionRoleModeNormal,
+			memberRoles: []string{"blue"},
+		},
+		{
+			name: "unique without a role",
+			mode: entities.ReactionRoleModeUnique,
+			want: &Change{Add: []string{"blue"}},
+		},
+		{
+			name:        "unique with another role",
+			mode:        entities.ReactionRoleModeUnique,
+			memberRoles: []string{"red"},
+		},
+		{
+			name:        "drop has role",
+			mode:        entities.ReactionRoleModeDrop,
+			memberRoles: []string{"blue"},
+			want:        &Change{Remove: []string{"blue"}},
+		},
+		{
+			name: "drop without role",
+			mode: entities.ReactionRoleModeDrop,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Reconcile(message(tt.mode), "\U0001F535", tt.memberRoles))
+		})
+	}
+}
@@ -1,5 +1,12 @@
 package request
 
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
 // ContentType represents the content type of the request.
 type ContentType string
 
@@ -55,3 +62,68 @@ func getContentType(contentType string) ContentType {
 		return ContentTypeJSON
 	}
 }
+
+// acceptedType is a media type in the Accept header of a request.
+type acceptedType struct {
+	// mediaType is the media type, without its parameters.
+	mediaType string
+
+	// quality is the preference for the media type, from 0 to 1.
+	quality float64
+}
+
+// AcceptedContentType returns the offered content type that is preferred by the Accept header of the request. The
+// first offered content type is returned if the header is empty or accepts anything, and JSON if none of the
+// offered types are accepted.
+func AcceptedContentType(r *http.Request, offered ...ContentType) ContentType {
+	if len(offered) == 0 {
+		return ContentTypeJSON
+	}
+
+	header := r.Header.Get("Accept")
+	if header == "" {
+		return offered[0]
+	}
+
+	accepted := make([]acceptedType, 0)
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && key == "q" {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		accepted = append(accepted, acceptedType{
+			mediaType: strings.ToLower(strings.TrimSpace(mediaType)),
+			quality:   quality,
+		})
+	}
+
+	// The most preferred types are tried first, keeping the order of the header for equal preferences.
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].quality > accepted[j].quality
+	})
+
+	for _, a := range accepted {
+		if a.quality <= 0 {
+			continue
+		}
+
+		if a.mediaType == "*/*" {
+			return offered[0]
+		}
+
+		// getContentType falls back to JSON, so the media type must match exactly.
+		if ct := getContentType(a.mediaType); ct.String() == a.mediaType && ct.IsIn(offered...) {
+			return ct
+		}
+	}
+
+	return ContentTypeJSON
+}
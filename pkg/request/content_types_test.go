@@ -1,6 +1,8 @@
 package request
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -56,3 +58,57 @@ func Test_getContentType(t *testing.T) {
 		})
 	}
 }
+
+func TestAcceptedContentType(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   ContentType
+	}{
+		{
+			name:   "empty",
+			accept: "",
+			want:   ContentTypeJSON,
+		},
+		{
+			name:   "xml",
+			accept: "application/xml",
+			want:   ContentTypeXML,
+		},
+		{
+			name:   "any",
+			accept: "*/*",
+			want:   ContentTypeJSON,
+		},
+		{
+			name:   "quality",
+			accept: "application/json;q=0.5, application/xml",
+			want:   ContentTypeXML,
+		},
+		{
+			name:   "not offered",
+			accept: "text/html, application/xml;q=0.1",
+			want:   ContentTypeXML,
+		},
+		{
+			name:   "nothing offered",
+			accept: "image/png",
+			want:   ContentTypeJSON,
+		},
+		{
+			name:   "rejected",
+			accept: "application/xml;q=0",
+			want:   ContentTypeJSON,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			require.Equal(t, tt.want, AcceptedContentType(r, ContentTypeJSON, ContentTypeXML))
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package request
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// MaxBodySize is the maximum size of a request body that is decoded.
+const MaxBodySize = 1 << 20
+
+var (
+	// ErrEmptyBody is returned when the request has no body to decode.
+	ErrEmptyBody = errors.New("request body is empty")
+
+	// ErrUnsupportedMediaType is returned when the content type of the request body cannot be decoded.
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+)
+
+// Decode decodes the body of the request into v. The body must be JSON or XML, as given by the Content-Type header,
+// and at most MaxBodySize bytes. Unknown JSON fields are rejected.
+func Decode(w http.ResponseWriter, r *http.Request, v any) error {
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return ErrEmptyBody
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return fmt.Errorf("%w: %q", ErrUnsupportedMediaType, r.Header.Get("Content-Type"))
+	}
+
+	body := http.MaxBytesReader(w, r.Body, MaxBodySize)
+
+	switch ContentType(mediaType) {
+	case ContentTypeJSON:
+		decoder := json.NewDecoder(body)
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(v)
+	case ContentTypeXML:
+		err = xml.NewDecoder(body).Decode(v)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedMediaType, mediaType)
+	}
+
+	if errors.Is(err, io.EOF) {
+		return ErrEmptyBody
+	} else if err != nil {
+		return fmt.Errorf("error decoding request body: %w", err)
+	}
+	return nil
+}
+
+// RespondDecodeError writes the error returned by Decode to the response.
+func RespondDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	maxBytesErr := new(http.MaxBytesError)
+	switch {
+	case errors.Is(err, ErrEmptyBody):
+		RespondError(w, r, http.StatusBadRequest, "A request body is required")
+	case errors.Is(err, ErrUnsupportedMediaType):
+		w.Header().Set("Accept", ContentTypeJSON.String()+", "+ContentTypeXML.String())
+		RespondError(w, r, http.StatusUnsupportedMediaType, "The request body must be JSON or XML")
+	case errors.As(err, &maxBytesErr):
+		RespondError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("The request body must not be larger than %d bytes", maxBytesErr.Limit))
+	default:
+		cause := err
+		if unwrapped := errors.Unwrap(err); unwrapped != nil {
+			cause = unwrapped
+		}
+		RespondError(w, r, http.StatusBadRequest, "Invalid request body", cause.Error())
+	}
+}
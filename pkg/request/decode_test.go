@@ -0,0 +1,90 @@
+package request
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        *testValue
+		status      int
+	}{
+		{
+			name:        "json",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"name":"wolf"}`,
+			want:        &testValue{Name: "wolf"},
+		},
+		{
+			name:        "xml",
+			contentType: "application/xml",
+			body:        `<testValue><name>wolf</name></testValue>`,
+			want:        &testValue{Name: "wolf"},
+		},
+		{
+			name:        "empty",
+			contentType: "application/json",
+			body:        ``,
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "missing content type",
+			contentType: "",
+			body:        `{"name":"wolf"}`,
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "unsupported content type",
+			contentType: "text/plain",
+			body:        `wolf`,
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "unknown field",
+			contentType: "application/json",
+			body:        `{"nme":"wolf"}`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "too large",
+			contentType: "application/json",
+			body:        `{"name":"` + strings.Repeat("a", MaxBodySize) + `"}`,
+			status:      http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+
+			got := new(testValue)
+			err := Decode(w, r, got)
+			if tt.status == 0 {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, got)
+				return
+			}
+
+			require.Error(t, err)
+			RespondDecodeError(w, r, err)
+			require.Equal(t, tt.status, w.Code)
+
+			resp := new(ErrorResponse)
+			require.NoError(t, json.NewDecoder(w.Body).Decode(resp))
+			require.Equal(t, ErrorCode(tt.status), resp.Code)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package request
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Jacobbrewer1/wolf/pkg/messages"
+)
+
+// ErrorResponse is the body of every error response.
+type ErrorResponse struct {
+	XMLName xml.Name `json:"-" xml:"error"`
+
+	// Code identifies the kind of error, such as not_found. It is derived from the status of the response.
+	Code string `json:"code" xml:"code"`
+
+	// Message describes the error.
+	Message string `json:"message" xml:"message"`
+
+	// Details are the specific problems with the request, such as each invalid field.
+	Details []string `json:"details,omitempty" xml:"detail,omitempty"`
+
+	// RequestID is the ID of the request, so the error can be found in the logs.
+	RequestID string `json:"request_id,omitempty" xml:"request_id,omitempty"`
+}
+
+// String returns the error as a line of plain text.
+func (e *ErrorResponse) String() string {
+	str := fmt.Sprintf("%s: %s", e.Code, e.Message)
+	if len(e.Details) > 0 {
+		str += " (" + strings.Join(e.Details, "; ") + ")"
+	}
+	if e.RequestID != "" {
+		str += " [request " + e.RequestID + "]"
+	}
+	return str
+}
+
+// NewErrorResponse creates the error response for the status.
+func NewErrorResponse(r *http.Request, status int, message string, details ...string) *ErrorResponse {
+	return &ErrorResponse{
+		Code:      ErrorCode(status),
+		Message:   message,
+		Details:   details,
+		RequestID: RequestID(r),
+	}
+}
+
+// ErrorCode returns the code of the errors with the status, which is its status text in snake case. For example, the
+// code of a 404 is not_found.
+func ErrorCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+
+	text = strings.ToLower(text)
+	text = strings.ReplaceAll(text, "-", "_")
+	text = strings.ReplaceAll(text, "'", "")
+	return strings.ReplaceAll(text, " ", "_")
+}
+
+// RespondError writes the error to the response with the status, in the format preferred by the request.
+func RespondError(w http.ResponseWriter, r *http.Request, status int, message string, details ...string) {
+	Respond(w, r, status, NewErrorResponse(r, status, message, details...))
+}
+
+// RespondInternalError writes a generic internal server error to the response, so the details of the error are not
+// leaked. The error should be logged by the caller.
+func RespondInternalError(w http.ResponseWriter, r *http.Request) {
+	RespondError(w, r, http.StatusInternalServerError, messages.ErrInternalServerError)
+}
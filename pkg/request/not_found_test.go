@@ -10,26 +10,59 @@ import (
 
 func TestNotFoundHandler(t *testing.T) {
 	tests := []struct {
-		name   string
-		w      *httptest.ResponseRecorder
-		r      *http.Request
-		status int
-		want   string
+		name        string
+		handler     http.HandlerFunc
+		accept      string
+		status      int
+		contentType string
+		want        string
 	}{
 		{
-			name:   "NotFound",
-			w:      httptest.NewRecorder(),
-			r:      httptest.NewRequest(http.MethodGet, "/", nil),
-			status: http.StatusNotFound,
-			want:   "{\"message\":\"Not found\"}\n",
+			name:        "NotFound",
+			handler:     NotFoundHandler(),
+			status:      http.StatusNotFound,
+			contentType: "application/json",
+			want:        "{\"code\":\"not_found\",\"message\":\"Not found\",\"request_id\":\"abc\"}\n",
+		},
+		{
+			name:        "NotFound XML",
+			handler:     NotFoundHandler(),
+			accept:      "application/xml",
+			status:      http.StatusNotFound,
+			contentType: "application/xml",
+			want:        "<error><code>not_found</code><message>Not found</message><request_id>abc</request_id></error>",
+		},
+		{
+			name:        "NotFound text",
+			handler:     NotFoundHandler(),
+			accept:      "text/plain",
+			status:      http.StatusNotFound,
+			contentType: "text/plain",
+			want:        "not_found: Not found [request abc]\n",
+		},
+		{
+			name:        "MethodNotAllowed",
+			handler:     MethodNotAllowedHandler(),
+			status:      http.StatusMethodNotAllowed,
+			contentType: "application/json",
+			want:        "{\"code\":\"method_not_allowed\",\"message\":\"Method not allowed\",\"request_id\":\"abc\"}\n",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			NotFoundHandler().ServeHTTP(tt.w, tt.r)
-			require.Equal(t, tt.status, tt.w.Code)
-			require.Equal(t, tt.want, tt.w.Body.String())
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(HeaderRequestID, "abc")
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+
+			tt.handler.ServeHTTP(w, r)
+			require.Equal(t, tt.status, w.Code)
+			require.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			require.Equal(t, "abc", w.Header().Get(HeaderRequestID))
+			require.Equal(t, tt.want, w.Body.String())
 		})
 	}
 }
@@ -1,32 +1,200 @@
 package request
 
 import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
 	"golang.org/x/time/rate"
 )
 
+const (
+	// DefaultRate is the number of requests per second allowed for each key.
+	DefaultRate = 10
+
+	// DefaultBurst is the number of requests allowed at once for each key.
+	DefaultBurst = 5
+
+	// DefaultIdleTimeout is how long the limiter of a key is kept after its last request.
+	DefaultIdleTimeout = 10 * time.Minute
+)
+
 type RateLimiter interface {
 	// Allow returns true if the request is allowed.
 	Allow(key string) bool
+
+	// RetryAfter returns how long until a request with the key is allowed.
+	RetryAfter(key string) time.Duration
+}
+
+// RateLimiterOption configures a RateLimiter.
+type RateLimiterOption func(r *rateLimiterImpl)
+
+// WithRate sets the number of requests per second allowed for each key.
+func WithRate(limit float64) RateLimiterOption {
+	return func(r *rateLimiterImpl) {
+		r.rate = rate.Limit(limit)
+	}
+}
+
+// WithBurst sets the number of requests allowed at once for each key.
+func WithBurst(burst int) RateLimiterOption {
+	return func(r *rateLimiterImpl) {
+		r.burst = burst
+	}
+}
+
+// WithIdleTimeout sets how long the limiter of a key is kept after its last request.
+func WithIdleTimeout(timeout time.Duration) RateLimiterOption {
+	return func(r *rateLimiterImpl) {
+		r.idleTimeout = timeout
+	}
+}
+
+// keyLimiter is the limiter of a key.
+type keyLimiter struct {
+	// limiter is the token bucket of the key.
+	limiter *rate.Limiter
+
+	// lastSeen is the time of the last request with the key.
+	lastSeen time.Time
 }
 
 type rateLimiterImpl struct {
-	// limiter is the limiter.
-	limiters map[string]*rate.Limiter
+	mut sync.Mutex
+
+	// limiters are the limiters, by key.
+	limiters map[string]*keyLimiter
+
+	// rate is the number of requests per second allowed for each key.
+	rate rate.Limit
+
+	// burst is the number of requests allowed at once for each key.
+	burst int
+
+	// idleTimeout is how long the limiter of a key is kept after its last request.
+	idleTimeout time.Duration
+
+	// lastEviction is when the idle limiters were last evicted.
+	lastEviction time.Time
+
+	// now returns the current time.
+	now func() time.Time
 }
 
-func NewRateLimiter() RateLimiter {
-	return &rateLimiterImpl{
-		limiters: make(map[string]*rate.Limiter),
+// NewRateLimiter creates a RateLimiter that limits each key separately. The limiters of the keys that have been idle
+// for longer than the idle timeout are evicted, so the memory used is bounded by the number of active keys.
+func NewRateLimiter(opts ...RateLimiterOption) RateLimiter {
+	r := &rateLimiterImpl{
+		limiters:    make(map[string]*keyLimiter),
+		rate:        DefaultRate,
+		burst:       DefaultBurst,
+		idleTimeout: DefaultIdleTimeout,
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(r)
 	}
+
+	r.lastEviction = r.now()
+	return r
 }
 
 func (r *rateLimiterImpl) Allow(key string) bool {
-	// Rate limits the request.
-	limiter, ok := r.limiters[key]
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	now := r.now()
+	return r.limiter(key, now).AllowN(now, 1)
+}
+
+func (r *rateLimiterImpl) RetryAfter(key string) time.Duration {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	now := r.now()
+	limiter := r.limiter(key, now)
+
+	// Reserve a token to see how long it takes to be available, without using it.
+	reservation := limiter.ReserveN(now, 1)
+	defer reservation.CancelAt(now)
+
+	if !reservation.OK() {
+		// The burst is zero, so no request is ever allowed.
+		return time.Duration(math.MaxInt64)
+	}
+	return reservation.DelayFrom(now)
+}
+
+// limiter returns the limiter of the key, creating it if needed. The mutex must be held.
+func (r *rateLimiterImpl) limiter(key string, now time.Time) *rate.Limiter {
+	r.evictIdle(now)
+
+	l, ok := r.limiters[key]
 	if !ok {
-		limiter = rate.NewLimiter(10, 5)
-		r.limiters[key] = limiter
+		l = &keyLimiter{
+			limiter: rate.NewLimiter(r.rate, r.burst),
+		}
+		r.limiters[key] = l
 	}
 
-	return limiter.Allow()
+	l.lastSeen = now
+	return l.limiter
+}
+
+// evictIdle removes the limiters of the keys that have been idle for longer than the idle timeout. The limiters are
+// checked at most once per idle timeout. The mutex must be held.
+func (r *rateLimiterImpl) evictIdle(now time.Time) {
+	if now.Sub(r.lastEviction) < r.idleTimeout {
+		return
+	}
+	r.lastEviction = now
+
+	for key, l := range r.limiters {
+		if now.Sub(l.lastSeen) >= r.idleTimeout {
+			delete(r.limiters, key)
+		}
+	}
+}
+
+// RateLimit returns a middleware that limits the requests of each client IP with the limiter. The rejected requests
+// get a 429 with a Retry-After header.
+func RateLimit(limiter RateLimiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := ClientIP(r)
+			if limiter.Allow(ip) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			r = WithRequestID(w, r)
+			RespondTooManyRequests(w, r, limiter.RetryAfter(ip))
+		})
+	}
+}
+
+// RespondTooManyRequests writes a 429 to the response, with the Retry-After header rounded up to the next second.
+func RespondTooManyRequests(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	RespondError(w, r, http.StatusTooManyRequests, "Too many requests")
+}
+
+// ClientIP returns the IP address of the client that made the request. The address of the connection is used, as the
+// forwarding headers can be set by the client.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
 }
@@ -0,0 +1,101 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRateLimiter creates a rate limiter with a clock controlled by the test.
+func newTestRateLimiter(now *time.Time, opts ...RateLimiterOption) *rateLimiterImpl {
+	r := NewRateLimiter(opts...).(*rateLimiterImpl)
+	r.now = func() time.Time { return *now }
+	r.lastEviction = *now
+	return r
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := newTestRateLimiter(&now, WithRate(1), WithBurst(2))
+
+	require.True(t, r.Allow("a"))
+	require.True(t, r.Allow("a"))
+	require.False(t, r.Allow("a"), "the burst is used up")
+	require.True(t, r.Allow("b"), "the keys are limited separately")
+
+	require.Equal(t, time.Second, r.RetryAfter("a"))
+	require.False(t, r.Allow("a"), "asking when to retry does not use a token")
+
+	now = now.Add(time.Second)
+	require.True(t, r.Allow("a"))
+	require.False(t, r.Allow("a"))
+}
+
+func TestRateLimiter_EvictIdle(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := newTestRateLimiter(&now, WithIdleTimeout(time.Minute))
+
+	r.Allow("a")
+	now = now.Add(30 * time.Second)
+	r.Allow("b")
+	require.Len(t, r.limiters, 2)
+
+	now = now.Add(40 * time.Second)
+	r.Allow("c")
+	require.Len(t, r.limiters, 2, "a has been idle for longer than the timeout")
+	require.NotContains(t, r.limiters, "a")
+}
+
+func TestRateLimiter_Concurrent(t *testing.T) {
+	r := NewRateLimiter(WithRate(0), WithBurst(100))
+
+	var (
+		wg      sync.WaitGroup
+		mut     sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if r.Allow("a") {
+				mut.Lock()
+				allowed++
+				mut.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, 100, allowed)
+}
+
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newTestRateLimiter(&now, WithRate(0.5), WithBurst(1))
+
+	handler := RateLimit(limiter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	do := func(remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	require.Equal(t, http.StatusNoContent, do("10.0.0.1:1234").Code)
+
+	w := do("10.0.0.1:5678")
+	require.Equal(t, http.StatusTooManyRequests, w.Code, "the port is not part of the key")
+	require.Equal(t, "2", w.Header().Get("Retry-After"))
+	require.Contains(t, w.Body.String(), `"code":"too_many_requests"`)
+
+	require.Equal(t, http.StatusNoContent, do("10.0.0.2:1234").Code)
+}
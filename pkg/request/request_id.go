@@ -0,0 +1,67 @@
+package request
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	// HeaderRequestID is the header that carries the ID of a request.
+	HeaderRequestID = "X-Request-Id"
+
+	// maxRequestIDLength is the maximum length of a request ID given by a client.
+	maxRequestIDLength = 64
+)
+
+// requestIDKey is the context key for the request ID.
+type requestIDKey struct{}
+
+// WithRequestID returns the request with an ID in its context, and sets the ID on the response. The ID given by the
+// client in the X-Request-Id header is used if it is safe to log, otherwise a new ID is generated.
+func WithRequestID(w http.ResponseWriter, r *http.Request) *http.Request {
+	if id := RequestID(r); id != "" {
+		return r
+	}
+
+	id := r.Header.Get(HeaderRequestID)
+	if !validRequestID(id) {
+		id = newRequestID()
+	}
+
+	w.Header().Set(HeaderRequestID, id)
+	return r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+}
+
+// RequestID returns the ID of the request, or an empty string if it has none.
+func RequestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID returns true if the ID is not empty, and only has letters, digits, dashes and underscores.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random ID.
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		// This should never happen, but the ID is only used for correlation so fall back to a fixed value.
+		return "0000000000000000"
+	}
+	return hex.EncodeToString(b)
+}
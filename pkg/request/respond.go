@@ -0,0 +1,62 @@
+package request
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+)
+
+// Respond writes the value to the response with the status, in the format preferred by the Accept header of the
+// request. JSON is preferred, then XML, then plain text. Plain text is only offered if the value can be written as
+// text, which is the case for strings and for values that implement fmt.Stringer or encoding.TextMarshaler.
+func Respond(w http.ResponseWriter, r *http.Request, status int, v any) {
+	text, isText := textOf(v)
+
+	offered := []ContentType{ContentTypeJSON, ContentTypeXML}
+	if isText {
+		offered = append(offered, ContentTypeText)
+	}
+
+	contentType := AcceptedContentType(r, offered...)
+
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("Content-Type", contentType.String())
+	w.WriteHeader(status)
+
+	var err error
+	switch contentType {
+	case ContentTypeXML:
+		err = xml.NewEncoder(w).Encode(v)
+	case ContentTypeText:
+		_, err = io.WriteString(w, text+"\n")
+	default:
+		err = json.NewEncoder(w).Encode(v)
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error encoding response", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// textOf returns the value as text, and false if it cannot be written as text.
+func textOf(v any) (string, bool) {
+	switch t := v.(type) {
+	case string:
+		return t, true
+	case fmt.Stringer:
+		return t.String(), true
+	case encoding.TextMarshaler:
+		b, err := t.MarshalText()
+		if err != nil {
+			return "", false
+		}
+		return string(b), true
+	default:
+		return "", false
+	}
+}
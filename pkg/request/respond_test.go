@@ -0,0 +1,140 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testValue is a value that can only be encoded as JSON or XML.
+type testValue struct {
+	Name string `json:"name" xml:"name"`
+}
+
+func TestRespond(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       any
+		accept      string
+		contentType string
+		want        string
+	}{
+		{
+			name:        "default",
+			value:       &testValue{Name: "wolf"},
+			contentType: "application/json",
+			want:        "{\"name\":\"wolf\"}\n",
+		},
+		{
+			name:        "xml",
+			value:       &testValue{Name: "wolf"},
+			accept:      "application/xml",
+			contentType: "application/xml",
+			want:        "<testValue><name>wolf</name></testValue>",
+		},
+		{
+			name:        "text",
+			value:       "wolf",
+			accept:      "text/plain",
+			contentType: "text/plain",
+			want:        "wolf\n",
+		},
+		{
+			name:        "text not offered",
+			value:       &testValue{Name: "wolf"},
+			accept:      "text/plain, application/xml;q=0.5",
+			contentType: "application/xml",
+			want:        "<testValue><name>wolf</name></testValue>",
+		},
+		{
+			name:        "nothing accepted",
+			value:       &testValue{Name: "wolf"},
+			accept:      "image/png",
+			contentType: "application/json",
+			want:        "{\"name\":\"wolf\"}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+
+			Respond(w, r, http.StatusCreated, tt.value)
+			require.Equal(t, http.StatusCreated, w.Code)
+			require.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			require.Equal(t, "Accept", w.Header().Get("Vary"))
+			require.Equal(t, tt.want, w.Body.String())
+		})
+	}
+}
+
+func TestRespond_ClientWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := NewClientWriter(rec)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "text/plain")
+
+	Respond(w, r, http.StatusTeapot, "wolf")
+	require.Equal(t, http.StatusTeapot, w.StatusCode())
+	require.Equal(t, "text/plain", rec.Header().Get("Content-Type"), "the client writer must not override the content type")
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{status: http.StatusBadRequest, want: "bad_request"},
+		{status: http.StatusNotFound, want: "not_found"},
+		{status: http.StatusRequestEntityTooLarge, want: "request_entity_too_large"},
+		{status: http.StatusTeapot, want: "im_a_teapot"},
+		{status: http.StatusMultiStatus, want: "multi_status"},
+		{status: 599, want: "error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			require.Equal(t, tt.want, ErrorCode(tt.status))
+		})
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{name: "given", header: "abc-123_DEF", keep: true},
+		{name: "missing", header: ""},
+		{name: "unsafe", header: "abc\ndef"},
+		{name: "too long", header: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(HeaderRequestID, tt.header)
+
+			r = WithRequestID(w, r)
+			id := RequestID(r)
+			require.NotEmpty(t, id)
+			require.Equal(t, id, w.Header().Get(HeaderRequestID))
+			if tt.keep {
+				require.Equal(t, tt.header, id)
+			} else {
+				require.NotEqual(t, tt.header, id)
+			}
+
+			// The ID is kept once it is set.
+			require.Equal(t, id, RequestID(WithRequestID(httptest.NewRecorder(), r)))
+		})
+	}
+}
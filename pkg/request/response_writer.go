@@ -21,10 +21,10 @@ func NewClientWriter(w http.ResponseWriter) *ClientWriter {
 	}
 }
 
-// Write writes the data to the connection as part of an HTTP reply.
+// Write writes the data to the connection as part of an HTTP reply. The content type defaults to JSON if the handler
+// has not set one.
 func (c *ClientWriter) Write(p []byte) (bytes int, err error) {
 	if !c.isHeaderWritten {
-		c.SetJsonContentType()
 		c.WriteHeader(http.StatusOK)
 	}
 	bytes, err = c.ResponseWriter.Write(p)
@@ -0,0 +1,43 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientWriter_ContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		want        string
+	}{
+		{
+			name: "default",
+			want: "application/json",
+		},
+		{
+			name:        "set by handler",
+			contentType: "text/html; charset=utf-8",
+			want:        "text/html; charset=utf-8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			w := NewClientWriter(rec)
+			if tt.contentType != "" {
+				w.Header().Set("Content-Type", tt.contentType)
+			}
+
+			_, err := w.Write([]byte("body"))
+			require.NoError(t, err)
+			require.Equal(t, tt.want, rec.Header().Get("Content-Type"))
+			require.Equal(t, http.StatusOK, w.StatusCode())
+			require.EqualValues(t, 4, w.BytesWritten())
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package rolepanel
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+)
+
+const (
+	// MaxRoles is the most roles a panel can have. This is the most options a select menu can have, and the most
+	// buttons a message can have.
+	MaxRoles = 25
+
+	// ButtonsPerRow is the most buttons a row of a message can have.
+	ButtonsPerRow = 5
+)
+
+var (
+	// ErrMissingRequiredRoles is returned when the member does not have the roles required to use the panel.
+	ErrMissingRequiredRoles = errors.New("member does not have the required roles")
+
+	// ErrTooManyRoles is returned when the change would give the member more roles than the panel allows.
+	ErrTooManyRoles = errors.New("member would have too many roles")
+
+	// ErrUnknownRole is returned when a role is chosen that is not on the panel.
+	ErrUnknownRole = errors.New("role is not on the panel")
+)
+
+// Change is the roles to give to and take from a member.
+type Change struct {
+	// Add are the roles to give to the member.
+	Add []string
+
+	// Remove are the roles to take from the member.
+	Remove []string
+}
+
+// Empty returns true if the change does not give or take any roles.
+func (c *Change) Empty() bool {
+	return len(c.Add) == 0 && len(c.Remove) == 0
+}
+
+// Limit returns the most roles of the panel a member can have.
+func Limit(panel *entities.RolePanel) int {
+	switch {
+	case panel.SingleChoice:
+		return 1
+	case panel.MaxSelections > 0 && panel.MaxSelections < len(panel.Roles):
+		return panel.MaxSelections
+	default:
+		return len(panel.Roles)
+	}
+}
+
+// Toggle returns the change for the member, with the roles, pressing the button of the role. The role is taken if the
+// member has it, otherwise it is given. Choosing a role of a single choice panel takes the other roles of the panel.
+func Toggle(panel *entities.RolePanel, memberRoles []string, roleID string) (*Change, error) {
+	if !panel.HasRole(roleID) {
+		return nil, ErrUnknownRole
+	}
+	if !hasAll(memberRoles, panel.RequiredRoleIDs) {
+		return nil, ErrMissingRequiredRoles
+	}
+
+	held := heldRoles(panel, memberRoles)
+	if slices.Contains(held, roleID) {
+		return &Change{Remove: []string{roleID}}, nil
+	}
+
+	change := &Change{Add: []string{roleID}}
+	if panel.SingleChoice {
+		change.Remove = held
+		return change, nil
+	}
+
+	if len(held)+1 > Limit(panel) {
+		return nil, ErrTooManyRoles
+	}
+	return change, nil
+}
+
+// Select returns the change for the member, with the roles, choosing the roles from the select menu of the panel. The
+// member ends up with exactly the chosen roles of the panel.
+func Select(panel *entities.RolePanel, memberRoles []string, chosen []string) (*Change, error) {
+	for _, roleID := range chosen {
+		if !panel.HasRole(roleID) {
+			return nil, ErrUnknownRole
+		}
+	}
+	if !hasAll(memberRoles, panel.RequiredRoleIDs) {
+		return nil, ErrMissingRequiredRoles
+	}
+	if len(chosen) > Limit(panel) {
+		return nil, ErrTooManyRoles
+	}
+
+	change := new(Change)
+	for _, roleID := range chosen {
+		if !slices.Contains(memberRoles, roleID) && !slices.Contains(change.Add, roleID) {
+			change.Add = append(change.Add, roleID)
+		}
+	}
+	for _, roleID := range heldRoles(panel, memberRoles) {
+		if !slices.Contains(chosen, roleID) {
+			change.Remove = append(change.Remove, roleID)
+		}
+	}
+	return change, nil
+}
+
+// heldRoles returns the roles of the panel that the member has, in the order of the panel.
+func heldRoles(panel *entities.RolePanel, memberRoles []string) []string {
+	var held []string
+	for _, role := range panel.Roles {
+		if slices.Contains(memberRoles, role.RoleID) {
+			held = append(held, role.RoleID)
+		}
+	}
+	return held
+}
+
+// hasAll returns true if the member, with the roles, has every one of the required roles.
+func hasAll(memberRoles, required []string) bool {
+	for _, roleID := range required {
+		if !slices.Contains(memberRoles, roleID) {
+			return false
+		}
+	}
+	return true
+}
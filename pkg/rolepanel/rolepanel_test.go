@@ -0,0 +1,176 @@
+package rolepanel
+
+import (
+	"testing"
+
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/stretchr/testify/require"
+)
+
+// panel returns a panel of the red, green and blue roles.
+func panel(modify func(p *entities.RolePanel)) *entities.RolePanel {
+	p := &entities.RolePanel{
+		Roles: []*entities.RolePanelRole{
+			{RoleID: "red", Label: "Red"},
+			{RoleID: "green", Label: "Green"},
+			{RoleID: "blue", Label: "Blue"},
+		},
+	}
+	if modify != nil {
+		modify(p)
+	}
+	return p
+}
+
+func TestToggle(t *testing.T) {
+	tests := []struct {
+		name        string
+		panel       *entities.RolePanel
+		memberRoles []string
+		roleID      string
+		want        *Change
+		wantErr     error
+	}{
+		{
+			name:   "add",
+			panel:  panel(nil),
+			roleID: "red",
+			want:   &Change{Add: []string{"red"}},
+		},
+		{
+			name:        "remove",
+			panel:       panel(nil),
+			memberRoles: []string{"member", "red"},
+			roleID:      "red",
+			want:        &Change{Remove: []string{"red"}},
+		},
+		{
+			name:        "single choice",
+			panel:       panel(func(p *entities.RolePanel) { p.SingleChoice = true }),
+			memberRoles: []string{"blue", "member"},
+			roleID:      "red",
+			want:        &Change{Add: []string{"red"}, Remove: []string{"blue"}},
+		},
+		{
+			name:        "max selections",
+			panel:       panel(func(p *entities.RolePanel) { p.MaxSelections = 2 }),
+			memberRoles: []string{"blue", "green"},
+			roleID:      "red",
+			wantErr:     ErrTooManyRoles,
+		},
+		{
+			name:        "remove at max selections",
+			panel:       panel(func(p *entities.RolePanel) { p.MaxSelections = 2 }),
+			memberRoles: []string{"blue", "green"},
+			roleID:      "blue",
+			want:        &Change{Remove: []string{"blue"}},
+		},
+		{
+			name:        "required roles",
+			panel:       panel(func(p *entities.RolePanel) { p.RequiredRoleIDs = []string{"member", "verified"} }),
+			memberRoles: []string{"member"},
+			roleID:      "red",
+			wantErr:     ErrMissingRequiredRoles,
+		},
+		{
+			name:        "has required roles",
+			panel:       panel(func(p *entities.RolePanel) { p.RequiredRoleIDs = []string{"member", "verified"} }),
+			memberRoles: []string{"verified", "member"},
+			roleID:      "red",
+			want:        &Change{Add: []string{"red"}},
+		},
+		{
+			name:    "unknown role",
+			panel:   panel(nil),
+			roleID:  "admin",
+			wantErr: ErrUnknownRole,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Toggle(tt.panel, tt.memberRoles, tt.roleID)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name        string
+		panel       *entities.RolePanel
+		memberRoles []string
+		chosen      []string
+		want        *Change
+		wantErr     error
+	}{
+		{
+			name:        "replace",
+			panel:       panel(nil),
+			memberRoles: []string{"member", "red", "green"},
+			chosen:      []string{"green", "blue"},
+			want:        &Change{Add: []string{"blue"}, Remove: []string{"red"}},
+		},
+		{
+			name:        "choose none",
+			panel:       panel(nil),
+			memberRoles: []string{"red", "blue"},
+			want:        &Change{Remove: []string{"red", "blue"}},
+		},
+		{
+			name:        "no change",
+			panel:       panel(nil),
+			memberRoles: []string{"red"},
+			chosen:      []string{"red"},
+			want:        &Change{},
+		},
+		{
+			name:    "single choice",
+			panel:   panel(func(p *entities.RolePanel) { p.SingleChoice = true }),
+			chosen:  []string{"red", "blue"},
+			wantErr: ErrTooManyRoles,
+		},
+		{
+			name:    "max selections",
+			panel:   panel(func(p *entities.RolePanel) { p.MaxSelections = 2 }),
+			chosen:  []string{"red", "green", "blue"},
+			wantErr: ErrTooManyRoles,
+		},
+		{
+			name:    "required roles",
+			panel:   panel(func(p *entities.RolePanel) { p.RequiredRoleIDs = []string{"member"} }),
+			chosen:  []string{"red"},
+			wantErr: ErrMissingRequiredRoles,
+		},
+		{
+			name:    "unknown role",
+			panel:   panel(nil),
+			chosen:  []string{"admin"},
+			wantErr: ErrUnknownRole,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Select(tt.panel, tt.memberRoles, tt.chosen)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLimit(t *testing.T) {
+	require.Equal(t, 3, Limit(panel(nil)))
+	require.Equal(t, 1, Limit(panel(func(p *entities.RolePanel) { p.SingleChoice = true })))
+	require.Equal(t, 2, Limit(panel(func(p *entities.RolePanel) { p.MaxSelections = 2 })))
+	require.Equal(t, 3, Limit(panel(func(p *entities.RolePanel) { p.MaxSelections = 10 })))
+}
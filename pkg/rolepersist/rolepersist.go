@@ -0,0 +1,56 @@
+package rolepersist
+
+import (
+	"slices"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+)
+
+// Restorable returns the roles of the snapshot that are restored to the member that rejoined, in the order of the
+// snapshot. The roles must still exist and be below the highest role of the bot, and must not be managed by an
+// integration, be denied or, if there is an allow list, be missing from it. Roles with the administrator permission
+// are never restored. The roles the member already has are left out.
+func Restorable(cfg *entities.RolePersistenceConfig, guild *discordgo.Guild, botPosition int, snapshotRoles, memberRoles []string) []string {
+	var restore []string
+	for _, id := range snapshotRoles {
+		role := findRole(guild.Roles, id)
+
+		switch {
+		case role == nil || role.ID == guild.ID || role.Managed:
+		case role.Position >= botPosition:
+		case role.Permissions&discordgo.PermissionAdministrator != 0:
+		case slices.Contains(cfg.DeniedRoleIDs, id):
+		case len(cfg.AllowedRoleIDs) > 0 && !slices.Contains(cfg.AllowedRoleIDs, id):
+		case slices.Contains(memberRoles, id) || slices.Contains(restore, id):
+		default:
+			restore = append(restore, id)
+		}
+	}
+	return restore
+}
+
+// Changed returns true if the roles or the nickname of the member have changed, so the snapshot of the member must
+// be taken again. A member that was not known before has always changed.
+func Changed(before, after *discordgo.Member) bool {
+	if before == nil || before.Nick != after.Nick || len(before.Roles) != len(after.Roles) {
+		return true
+	}
+
+	for _, id := range after.Roles {
+		if !slices.Contains(before.Roles, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// findRole returns the role with the ID, or nil if there is not one.
+func findRole(roles []*discordgo.Role, id string) *discordgo.Role {
+	for _, role := range roles {
+		if role.ID == id {
+			return role
+		}
+	}
+	return nil
+}
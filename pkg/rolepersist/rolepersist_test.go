@@ -0,0 +1,137 @@
+package rolepersist
+
+import (
+	"testing"
+
+	"github.com/Jacobbrewer1/discordgo"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/stretchr/testify/require"
+)
+
+// guild returns a guild with the everyone, red, green, blue, admin, integration and high roles. The bot can give the
+// roles below position 5.
+func guild() *discordgo.Guild {
+	return &discordgo.Guild{
+		ID: "guild",
+		Roles: []*discordgo.Role{
+			{ID: "guild", Position: 0},
+			{ID: "red", Position: 1},
+			{ID: "green", Position: 2},
+			{ID: "blue", Position: 3},
+			{ID: "admin", Position: 4, Permissions: discordgo.PermissionAdministrator},
+			{ID: "integration", Position: 4, Managed: true},
+			{ID: "high", Position: 6},
+		},
+	}
+}
+
+func TestRestorable(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           *entities.RolePersistenceConfig
+		snapshotRoles []string
+		memberRoles   []string
+		want          []string
+	}{
+		{
+			name:          "all",
+			cfg:           new(entities.RolePersistenceConfig),
+			snapshotRoles: []string{"red", "green", "blue"},
+			want:          []string{"red", "green", "blue"},
+		},
+		{
+			name:          "unassignable",
+			cfg:           new(entities.RolePersistenceConfig),
+			snapshotRoles: []string{"guild", "deleted", "integration", "high", "red"},
+			want:          []string{"red"},
+		},
+		{
+			name:          "administrator",
+			cfg:           &entities.RolePersistenceConfig{AllowedRoleIDs: []string{"admin", "red"}},
+			snapshotRoles: []string{"admin", "red"},
+			want:          []string{"red"},
+		},
+		{
+			name:          "denied",
+			cfg:           &entities.RolePersistenceConfig{DeniedRoleIDs: []string{"green"}},
+			snapshotRoles: []string{"red", "green", "blue"},
+			want:          []string{"red", "blue"},
+		},
+		{
+			name:          "allowed",
+			cfg:           &entities.RolePersistenceConfig{AllowedRoleIDs: []string{"red", "blue"}},
+			snapshotRoles: []string{"red", "green", "blue"},
+			want:          []string{"red", "blue"},
+		},
+		{
+			name: "denied and allowed",
+			cfg: &entities.RolePersistenceConfig{
+				AllowedRoleIDs: []string{"red", "blue"},
+				DeniedRoleIDs:  []string{"blue"},
+			},
+			snapshotRoles: []string{"red", "green", "blue"},
+			want:          []string{"red"},
+		},
+		{
+			name:          "member has role",
+			cfg:           new(entities.RolePersistenceConfig),
+			snapshotRoles: []string{"red", "green", "red"},
+			memberRoles:   []string{"green"},
+			want:          []string{"red"},
+		},
+		{
+			name: "empty",
+			cfg:  new(entities.RolePersistenceConfig),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Restorable(tt.cfg, guild(), 5, tt.snapshotRoles, tt.memberRoles))
+		})
+	}
+}
+
+func TestChanged(t *testing.T) {
+	tests := []struct {
+		name   string
+		before *discordgo.Member
+		after  *discordgo.Member
+		want   bool
+	}{
+		{
+			name:  "unknown",
+			after: &discordgo.Member{Roles: []string{"red"}},
+			want:  true,
+		},
+		{
+			name:   "same",
+			before: &discordgo.Member{Nick: "wolf", Roles: []string{"red", "blue"}},
+			after:  &discordgo.Member{Nick: "wolf", Roles: []string{"blue", "red"}},
+		},
+		{
+			name:   "nickname",
+			before: &discordgo.Member{Nick: "wolf", Roles: []string{"red"}},
+			after:  &discordgo.Member{Roles: []string{"red"}},
+			want:   true,
+		},
+		{
+			name:   "role added",
+			before: &discordgo.Member{Roles: []string{"red"}},
+			after:  &discordgo.Member{Roles: []string{"red", "blue"}},
+			want:   true,
+		},
+		{
+			name:   "role swapped",
+			before: &discordgo.Member{Roles: []string{"red"}},
+			after:  &discordgo.Member{Roles: []string{"blue"}},
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Changed(tt.before, tt.after))
+		})
+	}
+}
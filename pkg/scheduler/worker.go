@@ -0,0 +1,265 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/Jacobbrewer1/wolf/pkg/custom"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/Jacobbrewer1/wolf/pkg/logging"
+)
+
+const (
+	// DefaultInterval is how often the store is checked for due actions.
+	DefaultInterval = 10 * time.Second
+
+	// DefaultBatchSize is the maximum number of due actions fetched at once.
+	DefaultBatchSize = 50
+
+	// DefaultMaxAttempts is the number of times an action is run before it is given up on.
+	DefaultMaxAttempts = 5
+
+	// DefaultRetryDelay is how long a failed action waits before it is retried. The delay doubles with each attempt.
+	DefaultRetryDelay = time.Minute
+)
+
+// Store stores the scheduled actions.
+type Store interface {
+	// DueScheduledActions lists the pending actions that are due at the time, the earliest first. If the limit is
+	// zero, every due action is listed.
+	DueScheduledActions(ctx context.Context, at time.Time, limit int64) ([]*entities.ScheduledAction, error)
+
+	// SaveScheduledAction saves an action that has already been scheduled.
+	SaveScheduledAction(ctx context.Context, action *entities.ScheduledAction) error
+}
+
+// Handler runs a scheduled action. An action is run at least once, so a handler must be safe to run again for the
+// same action.
+type Handler func(ctx context.Context, action *entities.ScheduledAction) error
+
+// Observer is called with the outcome of every action after it is run and saved.
+type Observer func(ctx context.Context, action *entities.ScheduledAction)
+
+// permanentError is an error that is not retried.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks the error of a handler as permanent, so the action fails without being retried.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// WorkerOption configures a Worker.
+type WorkerOption func(w *Worker)
+
+// WithInterval sets how often the store is checked for due actions.
+func WithInterval(interval time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.interval = interval
+	}
+}
+
+// WithBatchSize sets the maximum number of due actions fetched at once.
+func WithBatchSize(size int64) WorkerOption {
+	return func(w *Worker) {
+		w.batchSize = size
+	}
+}
+
+// WithMaxAttempts sets the number of times an action is run before it is given up on.
+func WithMaxAttempts(attempts int) WorkerOption {
+	return func(w *Worker) {
+		w.maxAttempts = attempts
+	}
+}
+
+// WithRetryDelay sets how long a failed action waits before it is retried. The delay doubles with each attempt.
+func WithRetryDelay(delay time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.retryDelay = delay
+	}
+}
+
+// WithObserver sets the observer that is called with the outcome of every action after it is run and saved.
+func WithObserver(observer Observer) WorkerOption {
+	return func(w *Worker) {
+		w.observer = observer
+	}
+}
+
+// Worker runs the scheduled actions when they are due. Only a single worker should run against a store at a time.
+type Worker struct {
+	// l is the logger.
+	l *slog.Logger
+
+	// store stores the actions.
+	store Store
+
+	// handlers are the handlers of the actions, by type.
+	handlers map[entities.ScheduledActionType]Handler
+
+	// interval is how often the store is checked for due actions.
+	interval time.Duration
+
+	// batchSize is the maximum number of due actions fetched at once.
+	batchSize int64
+
+	// maxAttempts is the number of times an action is run before it is given up on.
+	maxAttempts int
+
+	// retryDelay is how long a failed action waits before its first retry.
+	retryDelay time.Duration
+
+	// observer is called with the outcome of every action after it is run and saved.
+	observer Observer
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+// NewWorker creates a new Worker for the actions in the store.
+func NewWorker(l *slog.Logger, store Store, opts ...WorkerOption) *Worker {
+	w := &Worker{
+		l:           l,
+		store:       store,
+		handlers:    make(map[entities.ScheduledActionType]Handler),
+		interval:    DefaultInterval,
+		batchSize:   DefaultBatchSize,
+		maxAttempts: DefaultMaxAttempts,
+		retryDelay:  DefaultRetryDelay,
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// Handle sets the handler of the actions of the type.
+func (w *Worker) Handle(actionType entities.ScheduledActionType, handler Handler) {
+	w.handlers[actionType] = handler
+}
+
+// Run runs the due actions until the context is cancelled. The actions that became due while no worker was running
+// are run straight away.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.RunDue(ctx); err != nil && ctx.Err() == nil {
+			w.l.Error("Error running scheduled actions", slog.String(logging.KeyError, err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue runs the actions that are due, returning how many were run.
+func (w *Worker) RunDue(ctx context.Context) (int, error) {
+	total := 0
+	for ctx.Err() == nil {
+		actions, err := w.store.DueScheduledActions(ctx, w.now().UTC(), w.batchSize)
+		if err != nil {
+			return total, fmt.Errorf("error getting due actions: %w", err)
+		}
+
+		for _, action := range actions {
+			if err := w.runAction(ctx, action); err != nil {
+				return total, err
+			}
+			total++
+		}
+
+		// Every action in the batch has been run or rescheduled, so a full batch means there may be more due.
+		if w.batchSize <= 0 || int64(len(actions)) < w.batchSize {
+			break
+		}
+	}
+	return total, nil
+}
+
+// runAction runs the action and saves the outcome.
+func (w *Worker) runAction(ctx context.Context, action *entities.ScheduledAction) error {
+	l := w.l.With(
+		slog.String("action_id", action.ID.Hex()),
+		slog.String("type", string(action.Type)),
+		slog.String("guild_id", action.GuildID),
+	)
+
+	action.Attempts++
+	err := w.handle(ctx, action)
+	now := w.now().UTC()
+
+	switch {
+	case err == nil:
+		action.Status = entities.ScheduledActionDone
+		action.LastError = ""
+		action.CompletedAt = custom.Datetime(now)
+		l.Debug("Scheduled action run")
+	case errors.As(err, new(*permanentError)) || action.Attempts >= w.maxAttempts:
+		action.Status = entities.ScheduledActionFailed
+		action.LastError = err.Error()
+		action.CompletedAt = custom.Datetime(now)
+		l.Error("Scheduled action failed", slog.Int("attempts", action.Attempts), slog.String(logging.KeyError, err.Error()))
+	default:
+		action.LastError = err.Error()
+		action.RunAt = custom.Datetime(now.Add(w.retryDelay << (action.Attempts - 1)))
+		l.Warn("Scheduled action failed, retrying",
+			slog.Int("attempts", action.Attempts),
+			slog.Time("retry_at", time.Time(action.RunAt)),
+			slog.String(logging.KeyError, err.Error()),
+		)
+	}
+
+	// The outcome is saved even if the context was cancelled while the action ran.
+	ctx = context.WithoutCancel(ctx)
+	if err := w.store.SaveScheduledAction(ctx, action); err != nil {
+		return fmt.Errorf("error saving scheduled action: %w", err)
+	}
+
+	if w.observer != nil {
+		w.observer(ctx, action)
+	}
+	return nil
+}
+
+// handle runs the handler of the action. A panic in the handler is returned as an error, so it does not stop the
+// worker.
+func (w *Worker) handle(ctx context.Context, action *entities.ScheduledAction) (err error) {
+	handler, ok := w.handlers[action.Type]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for scheduled action type %q", action.Type))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			w.l.Error("Panic running scheduled action", slog.String(logging.KeyStack, string(debug.Stack())))
+			err = fmt.Errorf("panic running scheduled action: %v", r)
+		}
+	}()
+
+	return handler(ctx, action)
+}
@@ -0,0 +1,264 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/wolf/pkg/custom"
+	"github.com/Jacobbrewer1/wolf/pkg/entities"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeStore is an in memory store of scheduled actions.
+type fakeStore struct {
+	mut     sync.Mutex
+	actions map[primitive.ObjectID]*entities.ScheduledAction
+	failGet bool
+}
+
+func newFakeStore(actions ...*entities.ScheduledAction) *fakeStore {
+	s := &fakeStore{actions: make(map[primitive.ObjectID]*entities.ScheduledAction)}
+	for _, action := range actions {
+		action.ID = primitive.NewObjectID()
+		action.Status = entities.ScheduledActionPending
+		s.actions[action.ID] = action
+	}
+	return s
+}
+
+func (s *fakeStore) DueScheduledActions(_ context.Context, at time.Time, limit int64) ([]*entities.ScheduledAction, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.failGet {
+		return nil, errors.New("unavailable")
+	}
+
+	due := make([]*entities.ScheduledAction, 0)
+	for _, action := range s.actions {
+		if action.Status == entities.ScheduledActionPending && !time.Time(action.RunAt).After(at) {
+			cp := *action
+			due = append(due, &cp)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return time.Time(due[i].RunAt).Before(time.Time(due[j].RunAt))
+	})
+
+	if limit > 0 && int64(len(due)) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (s *fakeStore) SaveScheduledAction(_ context.Context, action *entities.ScheduledAction) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	cp := *action
+	s.actions[action.ID] = &cp
+	return nil
+}
+
+func (s *fakeStore) get(id primitive.ObjectID) *entities.ScheduledAction {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	cp := *s.actions[id]
+	return &cp
+}
+
+func newTestWorker(store Store, now time.Time, opts ...WorkerOption) *Worker {
+	w := NewWorker(slog.Default(), store, opts...)
+	w.now = func() time.Time {
+		return now
+	}
+	return w
+}
+
+func TestWorker_RunDue(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	due := &entities.ScheduledAction{Type: entities.ScheduledActionUnban, TargetID: "due", RunAt: custom.Datetime(now.Add(-time.Minute))}
+	missed := &entities.ScheduledAction{Type: entities.ScheduledActionUnban, TargetID: "missed", RunAt: custom.Datetime(now.Add(-48 * time.Hour))}
+	future := &entities.ScheduledAction{Type: entities.ScheduledActionUnban, TargetID: "future", RunAt: custom.Datetime(now.Add(time.Minute))}
+	store := newFakeStore(due, missed, future)
+
+	var ran []string
+	w := newTestWorker(store, now)
+	w.Handle(entities.ScheduledActionUnban, func(_ context.Context, action *entities.ScheduledAction) error {
+		ran = append(ran, action.TargetID)
+		return nil
+	})
+
+	n, err := w.RunDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []string{"missed", "due"}, ran)
+
+	for _, action := range []*entities.ScheduledAction{due, missed} {
+		got := store.get(action.ID)
+		require.Equal(t, entities.ScheduledActionDone, got.Status)
+		require.Equal(t, 1, got.Attempts)
+		require.Equal(t, now, time.Time(got.CompletedAt))
+	}
+	require.Equal(t, entities.ScheduledActionPending, store.get(future.ID).Status)
+}
+
+func TestWorker_RunDue_Batches(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	actions := make([]*entities.ScheduledAction, 0, 5)
+	for i := 0; i < 5; i++ {
+		actions = append(actions, &entities.ScheduledAction{
+			Type:  entities.ScheduledActionDeleteChannel,
+			RunAt: custom.Datetime(now.Add(-time.Duration(i) * time.Minute)),
+		})
+	}
+	store := newFakeStore(actions...)
+
+	w := newTestWorker(store, now, WithBatchSize(2))
+	w.Handle(entities.ScheduledActionDeleteChannel, func(context.Context, *entities.ScheduledAction) error {
+		return nil
+	})
+
+	n, err := w.RunDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+}
+
+func TestWorker_RunDue_Retry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	action := &entities.ScheduledAction{Type: entities.ScheduledActionUnmute, RunAt: custom.Datetime(now)}
+	store := newFakeStore(action)
+
+	w := NewWorker(slog.Default(), store, WithMaxAttempts(3), WithRetryDelay(time.Minute))
+	w.now = func() time.Time {
+		return now
+	}
+	w.Handle(entities.ScheduledActionUnmute, func(context.Context, *entities.ScheduledAction) error {
+		return errors.New("discord unavailable")
+	})
+
+	// The delay doubles with each attempt, until the action is given up on.
+	for attempt, wantDelay := range []time.Duration{time.Minute, 2 * time.Minute} {
+		_, err := w.RunDue(context.Background())
+		require.NoError(t, err)
+
+		got := store.get(action.ID)
+		require.Equal(t, entities.ScheduledActionPending, got.Status)
+		require.Equal(t, attempt+1, got.Attempts)
+		require.Equal(t, "discord unavailable", got.LastError)
+		require.Equal(t, now.Add(wantDelay), time.Time(got.RunAt))
+
+		now = time.Time(got.RunAt)
+	}
+
+	_, err := w.RunDue(context.Background())
+	require.NoError(t, err)
+
+	got := store.get(action.ID)
+	require.Equal(t, entities.ScheduledActionFailed, got.Status)
+	require.Equal(t, 3, got.Attempts)
+}
+
+func TestWorker_RunDue_Failures(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler Handler
+		wantErr string
+	}{
+		{
+			name: "permanent",
+			handler: func(context.Context, *entities.ScheduledAction) error {
+				return Permanent(errors.New("unknown role"))
+			},
+			wantErr: "unknown role",
+		},
+		{
+			name: "panic",
+			handler: func(context.Context, *entities.ScheduledAction) error {
+				panic("boom")
+			},
+			wantErr: "panic running scheduled action: boom",
+		},
+		{
+			name:    "no handler",
+			wantErr: `no handler for scheduled action type "unban"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+			action := &entities.ScheduledAction{Type: entities.ScheduledActionUnban, RunAt: custom.Datetime(now)}
+			store := newFakeStore(action)
+
+			var observed *entities.ScheduledAction
+			w := newTestWorker(store, now, WithMaxAttempts(1), WithObserver(func(_ context.Context, action *entities.ScheduledAction) {
+				observed = action
+			}))
+			if tt.handler != nil {
+				w.Handle(entities.ScheduledActionUnban, tt.handler)
+			}
+
+			n, err := w.RunDue(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, 1, n)
+
+			got := store.get(action.ID)
+			require.Equal(t, entities.ScheduledActionFailed, got.Status)
+			require.Equal(t, tt.wantErr, got.LastError)
+			require.Equal(t, entities.ScheduledActionFailed, observed.Status)
+		})
+	}
+}
+
+func TestWorker_RunDue_StoreError(t *testing.T) {
+	store := newFakeStore()
+	store.failGet = true
+
+	_, err := newTestWorker(store, time.Now()).RunDue(context.Background())
+	require.ErrorContains(t, err, "unavailable")
+}
+
+func TestWorker_Run(t *testing.T) {
+	action := &entities.ScheduledAction{Type: entities.ScheduledActionUnban, RunAt: custom.Datetime(time.Now().Add(-time.Hour))}
+	store := newFakeStore(action)
+
+	ran := make(chan struct{})
+	w := NewWorker(slog.Default(), store, WithInterval(time.Hour))
+	w.Handle(entities.ScheduledActionUnban, func(context.Context, *entities.ScheduledAction) error {
+		close(ran)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.Run(ctx)
+	}()
+
+	// The missed action is run straight away, without waiting for the interval.
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("missed action was not run")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not stop")
+	}
+}
@@ -0,0 +1,206 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultMaxTemplateLength is the longest template that is accepted by default.
+	DefaultMaxTemplateLength = 2000
+
+	// DefaultMaxOutputLength is the longest output that is rendered by default. This is the longest description of an
+	// embed.
+	DefaultMaxOutputLength = 4096
+)
+
+var (
+	// ErrTemplateTooLong is returned when a template is longer than the engine accepts.
+	ErrTemplateTooLong = errors.New("template is too long")
+
+	// ErrUnclosedPlaceholder is returned when a placeholder is opened but not closed.
+	ErrUnclosedPlaceholder = errors.New("unclosed placeholder")
+
+	// ErrUnknownPlaceholder is returned when a template uses a placeholder the engine does not have.
+	ErrUnknownPlaceholder = errors.New("unknown placeholder")
+)
+
+// Placeholder is a value that can be used in the templates, written as {name}.
+type Placeholder struct {
+	// Name is the name of the placeholder.
+	Name string
+
+	// Description describes the value of the placeholder, for the users writing the templates.
+	Description string
+}
+
+// Vars are the values of the placeholders, by their name.
+type Vars map[string]string
+
+// EngineOption configures an Engine.
+type EngineOption func(e *Engine)
+
+// WithMaxTemplateLength sets the longest template that is accepted. A length below 1 is ignored, as no template would
+// be accepted.
+func WithMaxTemplateLength(n int) EngineOption {
+	return func(e *Engine) {
+		if n > 0 {
+			e.maxTemplateLength = n
+		}
+	}
+}
+
+// WithMaxOutputLength sets the longest output that is rendered. Longer output is cut short. A length below 1 is
+// ignored, as there would be no room for the output.
+func WithMaxOutputLength(n int) EngineOption {
+	return func(e *Engine) {
+		if n > 0 {
+			e.maxOutputLength = n
+		}
+	}
+}
+
+// Engine renders the templates written by users. It is sandboxed: the templates can only substitute the placeholders
+// of the engine, the values are never expanded themselves, and the length of the templates and the output is limited.
+// Literal braces are written as {{ and }}. It is safe for concurrent use.
+type Engine struct {
+	// placeholders are the placeholders that can be used, by their name.
+	placeholders map[string]*Placeholder
+
+	// maxTemplateLength is the longest template that is accepted, in characters.
+	maxTemplateLength int
+
+	// maxOutputLength is the longest output that is rendered, in characters.
+	maxOutputLength int
+}
+
+// NewEngine creates a new Engine with the placeholders.
+func NewEngine(placeholders []*Placeholder, opts ...EngineOption) *Engine {
+	e := &Engine{
+		placeholders:      make(map[string]*Placeholder, len(placeholders)),
+		maxTemplateLength: DefaultMaxTemplateLength,
+		maxOutputLength:   DefaultMaxOutputLength,
+	}
+	for _, p := range placeholders {
+		e.placeholders[p.Name] = p
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Placeholders returns the placeholders of the engine, sorted by name.
+func (e *Engine) Placeholders() []*Placeholder {
+	placeholders := make([]*Placeholder, 0, len(e.placeholders))
+	for _, p := range e.placeholders {
+		placeholders = append(placeholders, p)
+	}
+	sort.Slice(placeholders, func(i, j int) bool {
+		return placeholders[i].Name < placeholders[j].Name
+	})
+	return placeholders
+}
+
+// Validate returns an error if the template cannot be rendered.
+func (e *Engine) Validate(text string) error {
+	_, err := e.render(text, nil)
+	return err
+}
+
+// Render renders the template with the values of the placeholders. A placeholder without a value renders as empty.
+func (e *Engine) Render(text string, vars Vars) (string, error) {
+	return e.render(text, vars)
+}
+
+// render renders the template, or only checks it if the values are nil.
+func (e *Engine) render(text string, vars Vars) (string, error) {
+	if len([]rune(text)) > e.maxTemplateLength {
+		return "", fmt.Errorf("%w: it can be at most %d characters", ErrTemplateTooLong, e.maxTemplateLength)
+	}
+
+	out := new(output)
+	out.max = e.maxOutputLength
+
+	for i := 0; i < len(text); {
+		switch {
+		case strings.HasPrefix(text[i:], "{{"):
+			out.write("{")
+			i += 2
+		case strings.HasPrefix(text[i:], "}}"):
+			out.write("}")
+			i += 2
+		case text[i] == '{':
+			end := strings.IndexAny(text[i+1:], "{}")
+			if end < 0 || text[i+1+end] != '}' {
+				return "", fmt.Errorf("%w at character %d", ErrUnclosedPlaceholder, i+1)
+			}
+
+			name := strings.TrimSpace(text[i+1 : i+1+end])
+			if _, ok := e.placeholders[name]; !ok {
+				return "", fmt.Errorf("%w {%s}", ErrUnknownPlaceholder, name)
+			}
+
+			// The value is written as it is, so placeholders in the values are never expanded.
+			out.write(vars[name])
+			i += end + 2
+		case text[i] == '}':
+			// A lone closing brace has nothing to close, so it is written as it is.
+			out.write("}")
+			i++
+		default:
+			end := strings.IndexAny(text[i:], "{}")
+			if end < 0 {
+				end = len(text) - i
+			}
+			out.write(text[i : i+end])
+			i += end
+		}
+	}
+
+	return out.String(), nil
+}
+
+// output is the rendered output, which is cut short at the maximum length.
+type output struct {
+	// b is the rendered output.
+	b strings.Builder
+
+	// length is the length of the output, in characters.
+	length int
+
+	// max is the longest output, in characters.
+	max int
+
+	// full is whether the output has been cut short.
+	full bool
+}
+
+// write writes the text to the output, unless the output is full.
+func (o *output) write(text string) {
+	if o.full {
+		return
+	}
+
+	for _, r := range text {
+		if o.length == o.max {
+			o.full = true
+			return
+		}
+		o.b.WriteRune(r)
+		o.length++
+	}
+}
+
+// String returns the rendered output. Output that was cut short ends with an ellipsis.
+func (o *output) String() string {
+	if !o.full {
+		return o.b.String()
+	}
+
+	runes := []rune(o.b.String())
+	return string(runes[:len(runes)-1]) + "…"
+}
@@ -0,0 +1,129 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// placeholders are the placeholders of the test engine.
+var placeholders = []*Placeholder{
+	{Name: "user", Description: "The user"},
+	{Name: "server", Description: "The server"},
+}
+
+func TestEngine_Render(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		vars    Vars
+		want    string
+		wantErr error
+	}{
+		{
+			name: "placeholders",
+			text: "Welcome {user} to {server}!",
+			vars: Vars{"user": "<@1>", "server": "Wolf"},
+			want: "Welcome <@1> to Wolf!",
+		},
+		{
+			name: "spaces in placeholder",
+			text: "Hi { user }",
+			vars: Vars{"user": "<@1>"},
+			want: "Hi <@1>",
+		},
+		{
+			name: "missing value",
+			text: "Hi {user}.",
+			want: "Hi .",
+		},
+		{
+			name: "escaped braces",
+			text: "{{user}} is written as {{user}}, a lone } is kept",
+			want: "{user} is written as {user}, a lone } is kept",
+		},
+		{
+			name: "values are not expanded",
+			text: "Hi {user}",
+			vars: Vars{"user": "{server}", "server": "Wolf"},
+			want: "Hi {server}",
+		},
+		{
+			name: "unicode",
+			text: "\U0001F44B {user} ✨",
+			vars: Vars{"user": "Zoë"},
+			want: "\U0001F44B Zoë ✨",
+		},
+		{
+			name:    "unknown placeholder",
+			text:    "Hi {password}",
+			wantErr: ErrUnknownPlaceholder,
+		},
+		{
+			name:    "unclosed placeholder",
+			text:    "Hi {user",
+			wantErr: ErrUnclosedPlaceholder,
+		},
+		{
+			name:    "nested placeholder",
+			text:    "Hi {us{server}er}",
+			wantErr: ErrUnclosedPlaceholder,
+		},
+	}
+
+	e := NewEngine(placeholders)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := e.Render(tt.text, tt.vars)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.ErrorIs(t, e.Validate(tt.text), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, e.Validate(tt.text))
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEngine_Limits(t *testing.T) {
+	e := NewEngine(placeholders, WithMaxTemplateLength(20), WithMaxOutputLength(10))
+
+	require.ErrorIs(t, e.Validate(strings.Repeat("a", 21)), ErrTemplateTooLong)
+
+	// The output is cut short, even when the values are long.
+	got, err := e.Render("{user}{user}", Vars{"user": "ééééééé"})
+	require.NoError(t, err)
+	require.Equal(t, "ééééééééé…", got)
+
+	got, err = e.Render("{user}", Vars{"user": "short"})
+	require.NoError(t, err)
+	require.Equal(t, "short", got)
+}
+
+func TestEngine_InvalidLimits(t *testing.T) {
+	// The limits below 1 are ignored, so the defaults are used.
+	e := NewEngine(placeholders, WithMaxTemplateLength(0), WithMaxOutputLength(0))
+
+	require.NoError(t, e.Validate("{user}"))
+
+	got, err := e.Render("{user}", Vars{"user": "short"})
+	require.NoError(t, err)
+	require.Equal(t, "short", got)
+
+	got, err = e.Render("{user}", Vars{"user": strings.Repeat("a", DefaultMaxOutputLength+1)})
+	require.NoError(t, err)
+	require.Equal(t, strings.Repeat("a", DefaultMaxOutputLength-1)+"…", got)
+
+	e = NewEngine(placeholders, WithMaxOutputLength(-1))
+	got, err = e.Render("{user}", Vars{"user": "short"})
+	require.NoError(t, err)
+	require.Equal(t, "short", got)
+}
+
+func TestEngine_Placeholders(t *testing.T) {
+	e := NewEngine([]*Placeholder{{Name: "b"}, {Name: "a"}})
+	require.Equal(t, []*Placeholder{{Name: "a"}, {Name: "b"}}, e.Placeholders())
+}
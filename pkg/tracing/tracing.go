@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the name of the tracer that creates the spans of the application.
+const TracerName = "github.com/Jacobbrewer1/wolf"
+
+const (
+	// ExporterNone disables tracing.
+	ExporterNone = "none"
+
+	// ExporterStdout writes the spans to stdout.
+	ExporterStdout = "stdout"
+
+	// ExporterOTLP exports the spans with OTLP over HTTP. The endpoint is configured with the standard
+	// OTEL_EXPORTER_OTLP_ENDPOINT environment variables.
+	ExporterOTLP = "otlp"
+)
+
+// Tracer returns the tracer that creates the spans of the application.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// NewProvider creates a tracer provider that exports the spans with the exporter, and sets it as the global tracer
+// provider. The spans written by the stdout exporter are written to w. The provider must be shut down to flush the
+// spans that have not been exported yet.
+func NewProvider(ctx context.Context, serviceName, exporter string, w io.Writer) (*sdktrace.TracerProvider, error) {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+	}
+
+	switch exporter {
+	case ExporterNone, "":
+		// Spans are still created, so the trace IDs are logged, but they are not exported.
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(w))
+		if err != nil {
+			return nil, fmt.Errorf("error creating stdout exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	case ExporterOTLP:
+		exp, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error creating OTLP exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	default:
+		return nil, fmt.Errorf("unknown exporter %q", exporter)
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return tp, nil
+}
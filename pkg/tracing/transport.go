@@ -0,0 +1,86 @@
+package tracing
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// transport creates a client span for each request.
+type transport struct {
+	// base is the transport that makes the requests.
+	base http.RoundTripper
+}
+
+// NewTransport wraps the transport so a client span is created for each request, as a child of the span in the
+// context of the request. IDs and tokens in the path are replaced, so the span names stay bounded and no secrets are
+// recorded.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &transport{
+		base: base,
+	}
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	route := routeTemplate(req.URL.Path)
+
+	ctx, span := Tracer().Start(req.Context(), req.Method+" "+route,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.URLPath(route),
+			semconv.ServerAddress(req.URL.Hostname()),
+		),
+	)
+	defer span.End()
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
+
+// routeTemplate replaces the IDs and the interaction and webhook tokens in the path with placeholders.
+func routeTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		switch {
+		case isSnowflake(seg):
+			segments[i] = "{id}"
+		case i >= 2 && (segments[i-2] == "interactions" || segments[i-2] == "webhooks") && seg != "":
+			// The segment after the ID of an interaction or a webhook is its token.
+			segments[i] = "{token}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// isSnowflake returns true if the path segment is a Discord ID.
+func isSnowflake(seg string) bool {
+	if seg == "" {
+		return false
+	}
+
+	for _, r := range seg {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
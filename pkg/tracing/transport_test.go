@@ -0,0 +1,109 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// roundTripFunc is a http.RoundTripper implemented by a function.
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip implements the http.RoundTripper interface.
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// newRecorder sets a tracer provider that records the spans as the global tracer provider.
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+	})
+	return recorder
+}
+
+func TestRouteTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{
+			name: "channel message",
+			path: "/api/v9/channels/123456789012345678/messages",
+			want: "/api/v9/channels/{id}/messages",
+		},
+		{
+			name: "interaction callback",
+			path: "/api/v9/interactions/123456789012345678/aW50ZXJhY3Rpb24tdG9rZW4/callback",
+			want: "/api/v9/interactions/{id}/{token}/callback",
+		},
+		{
+			name: "webhook message",
+			path: "/api/v9/webhooks/123456789012345678/aW50ZXJhY3Rpb24tdG9rZW4/messages/@original",
+			want: "/api/v9/webhooks/{id}/{token}/messages/@original",
+		},
+		{
+			name: "no ids",
+			path: "/api/v9/gateway/bot",
+			want: "/api/v9/gateway/bot",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, routeTemplate(tt.path))
+		})
+	}
+}
+
+func TestTransport_RoundTrip(t *testing.T) {
+	recorder := newRecorder(t)
+
+	ctx, parent := Tracer().Start(context.Background(), "parent")
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: http.NoBody, Request: req}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "https://discord.com/api/v9/channels/123456789012345678", nil).WithContext(ctx)
+	resp, err := NewTransport(base).RoundTrip(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	require.Equal(t, "GET /api/v9/channels/{id}", spans[0].Name())
+	require.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+}
+
+func TestTransport_RoundTripError(t *testing.T) {
+	recorder := newRecorder(t)
+
+	base := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "https://discord.com/api/v9/channels/1/messages", nil)
+	_, err := NewTransport(base).RoundTrip(req)
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+	require.Len(t, spans[0].Events(), 1)
+}